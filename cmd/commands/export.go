@@ -0,0 +1,240 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+type exportRow interface {
+	csvRecord() []string
+}
+
+type newsRow struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Slug    string `json:"slug"`
+	Status  string `json:"status"`
+	Content string `json:"content"`
+	// Author is the email of the author, empty for news without one
+	Author    string    `json:"author"`
+	Topics    []string  `json:"topics"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+var newsColumns = []string{"id", "title", "slug", "status", "content", "author", "topics", "created_at", "updated_at"}
+
+func (n newsRow) csvRecord() []string {
+	return []string{
+		n.ID, n.Title, n.Slug, n.Status, n.Content, n.Author,
+		strings.Join(n.Topics, listSeparator),
+		n.CreatedAt.Format(time.RFC3339), n.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+type topicRow struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+var topicColumns = []string{"id", "name", "slug", "created_at", "updated_at"}
+
+func (t topicRow) csvRecord() []string {
+	return []string{t.ID, t.Name, t.Slug, t.CreatedAt.Format(time.RFC3339), t.UpdatedAt.Format(time.RFC3339)}
+}
+
+// rowWriter writes rows one by one so exports never hold the table in memory
+type rowWriter struct {
+	csv  *csv.Writer
+	json *json.Encoder
+}
+
+func newRowWriter(w io.Writer, format string, columns []string) (*rowWriter, error) {
+	if format == formatJSONL {
+		return &rowWriter{json: json.NewEncoder(w)}, nil
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return nil, err
+	}
+	return &rowWriter{csv: cw}, nil
+}
+
+func (w *rowWriter) Write(row exportRow) error {
+	if w.json != nil {
+		return w.json.Encode(row)
+	}
+	return w.csv.Write(row.csvRecord())
+}
+
+func (w *rowWriter) Flush() error {
+	if w.csv == nil {
+		return nil
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+func runExport(db *sql.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New("export target is required (news or topics)")
+	}
+	target := args[0]
+
+	fs := flag.NewFlagSet("export "+target, flag.ContinueOnError)
+	format := fs.String("format", formatCSV, "output format: csv or jsonl")
+	status := fs.String("status", "", "only export news with this status")
+	out := fs.String("out", "-", "output file, - for stdout")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	f, err := detectFormat(*format, "")
+	if err != nil {
+		return err
+	}
+
+	w, err := openOutput(*out)
+	if err != nil {
+		return fmt.Errorf("open output: %w", err)
+	}
+	defer w.Close()
+
+	ctx := context.Background()
+	var count int
+	switch target {
+	case "news":
+		count, err = exportNews(ctx, db, w, f, *status)
+	case "topics":
+		count, err = exportTopics(ctx, db, w, f)
+	default:
+		return errors.New("unknown export target: " + target)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "export %s: %d rows written\n", target, count)
+	return nil
+}
+
+func exportNews(ctx context.Context, db *sql.DB, w io.Writer, format, status string) (int, error) {
+	query := `
+		SELECT
+			n.id,
+			n.title,
+			n.slug,
+			n.status,
+			n.content,
+			COALESCE(u.email, '') AS author,
+			n.created_at,
+			n.updated_at,
+			COALESCE((
+				SELECT string_agg(t.slug, '|' ORDER BY t.slug)
+				FROM news_topic nt
+				JOIN topik t ON t.id = nt.topic_id
+				WHERE nt.news_id = n.id AND t.deleted_at IS NULL
+			), '') AS topics
+		FROM news n
+		LEFT JOIN users u ON u.id = n.author_id
+		WHERE n.deleted_at IS NULL`
+
+	var args []any
+	if status != "" {
+		query += " AND n.status = $1"
+		args = append(args, status)
+	}
+	query += " ORDER BY n.created_at, n.id"
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	rw, err := newRowWriter(w, format, newsColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for rows.Next() {
+		var row newsRow
+		var topics string
+		err := rows.Scan(
+			&row.ID,
+			&row.Title,
+			&row.Slug,
+			&row.Status,
+			&row.Content,
+			&row.Author,
+			&row.CreatedAt,
+			&row.UpdatedAt,
+			&topics,
+		)
+		if err != nil {
+			return count, err
+		}
+		row.Topics = []string{}
+		if topics != "" {
+			row.Topics = strings.Split(topics, listSeparator)
+		}
+		if err := rw.Write(row); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+
+	return count, rw.Flush()
+}
+
+func exportTopics(ctx context.Context, db *sql.DB, w io.Writer, format string) (int, error) {
+	query := `
+		SELECT id, name, slug, created_at, updated_at
+		FROM topik
+		WHERE deleted_at IS NULL
+		ORDER BY name, id`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	rw, err := newRowWriter(w, format, topicColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for rows.Next() {
+		var row topicRow
+		if err := rows.Scan(&row.ID, &row.Name, &row.Slug, &row.CreatedAt, &row.UpdatedAt); err != nil {
+			return count, err
+		}
+		if err := rw.Write(row); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+
+	return count, rw.Flush()
+}
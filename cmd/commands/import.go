@@ -0,0 +1,476 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/utils"
+	"github.com/google/uuid"
+)
+
+// importBatchSize is the number of lines saved per bulk request. Every line
+// is saved on its own, a failing line is reported and the others are kept.
+const importBatchSize = 500
+
+// newsSaver and topicSaver are the services the imported lines are saved
+// through, so they get the outbox events, the author and the audit log of
+// the API
+type newsSaver interface {
+	BulkSaveNews(ctx context.Context, req *domain.BulkNewsRequest) (*domain.BulkResult, error)
+}
+
+type topicSaver interface {
+	BulkSaveTopics(ctx context.Context, req *domain.BulkTopicRequest) (*domain.BulkResult, error)
+}
+
+// importServices save the imported lines
+type importServices struct {
+	news   newsSaver
+	topics topicSaver
+}
+
+// importLookup finds the rows updated by the imported lines and the topics
+// of the news
+type importLookup interface {
+	// find returns the id of the row with id when one is given, or else of
+	// the row with slug, and whether it exists. The given id is returned when
+	// neither exists, the row is created with it.
+	find(ctx context.Context, line int, table, id, slug string) (string, bool, error)
+	// topic returns the id of the topic with the name or slug ref
+	topic(ctx context.Context, ref string) (string, bool, error)
+	// author returns the user with email
+	author(ctx context.Context, email string) (*auth.Principal, error)
+}
+
+// importSummary counts what happened to every line of an import file
+type importSummary struct {
+	Inserted      int
+	Updated       int
+	Skipped       int
+	TopicsCreated int
+	Errors        []error
+}
+
+func (s *importSummary) skip(err error) {
+	s.Skipped++
+	s.Errors = append(s.Errors, err)
+}
+
+func (s *importSummary) print(w io.Writer, target string, dryRun bool) {
+	fmt.Fprintf(w, "import %s: %d inserted, %d updated, %d skipped", target, s.Inserted, s.Updated, s.Skipped)
+	if s.TopicsCreated > 0 {
+		fmt.Fprintf(w, ", %d topics created", s.TopicsCreated)
+	}
+	if dryRun {
+		fmt.Fprint(w, " (dry run, nothing was written)")
+	}
+	fmt.Fprintln(w)
+	for _, err := range s.Errors {
+		fmt.Fprintf(w, "  %v\n", err)
+	}
+}
+
+func runImport(db *sql.DB, services importServices, args []string) error {
+	if len(args) == 0 {
+		return errors.New("import target is required (news or topics)")
+	}
+	target := args[0]
+
+	fs := flag.NewFlagSet("import "+target, flag.ContinueOnError)
+	file := fs.String("file", "", "file to import, - for stdin")
+	format := fs.String("format", "", "input format: csv or jsonl (detected from the file extension by default)")
+	dryRun := fs.Bool("dry-run", false, "validate and count rows without writing anything")
+	createTopics := fs.Bool("create-topics", false, "create topics referenced by news that do not exist yet")
+	author := fs.String("author", "", "email of the user recorded as the author of the imported news")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("--file is required")
+	}
+	if target != "news" && target != "topics" {
+		return errors.New("unknown import target: " + target)
+	}
+
+	f, err := detectFormat(*format, *file)
+	if err != nil {
+		return err
+	}
+
+	in, err := openInput(*file)
+	if err != nil {
+		return fmt.Errorf("open input: %w", err)
+	}
+	defer in.Close()
+
+	reader, err := newRecordReader(in, f)
+	if err != nil {
+		return err
+	}
+
+	imp := newImporter(services, sqlLookup{db: db}, *dryRun, *createTopics)
+	ctx := context.Background()
+	if *author != "" {
+		principal, err := imp.lookup.author(ctx, *author)
+		if err != nil {
+			return err
+		}
+		ctx = auth.WithPrincipal(ctx, principal)
+	}
+
+	err = imp.run(ctx, target, reader)
+	imp.summary.print(os.Stdout, target, *dryRun)
+	return err
+}
+
+// importer saves the records of an import file in batches. The lines failing
+// are reported in the summary, an error is only returned when the import
+// cannot go on, the batches saved before it are kept.
+type importer struct {
+	services     importServices
+	lookup       importLookup
+	dryRun       bool
+	createTopics bool
+	summary      *importSummary
+	// topicIDs caches the resolved topics by lower cased reference
+	topicIDs map[string]string
+	// authorIDs caches the user ids of the authors by lower cased email
+	authorIDs map[string]string
+
+	news   []domain.BulkNewsItem
+	topics []domain.BulkTopicItem
+	// lines are the lines of the pending items
+	lines []int
+	// slugs are the slugs of the pending items, a line repeating one waits
+	// for the previous one to be saved so it updates it
+	slugs map[string]bool
+}
+
+func newImporter(services importServices, lookup importLookup, dryRun, createTopics bool) *importer {
+	return &importer{
+		services:     services,
+		lookup:       lookup,
+		dryRun:       dryRun,
+		createTopics: createTopics,
+		summary:      &importSummary{},
+		topicIDs:     make(map[string]string),
+		authorIDs:    make(map[string]string),
+		slugs:        make(map[string]bool),
+	}
+}
+
+func (imp *importer) run(ctx context.Context, target string, reader recordReader) error {
+	for {
+		rec, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var lineErr *lineError
+		if errors.As(err, &lineErr) {
+			imp.summary.skip(lineErr)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if target == "news" {
+			err = imp.addNews(ctx, rec)
+		} else {
+			err = imp.addTopic(ctx, rec)
+		}
+		if errors.As(err, &lineErr) {
+			imp.summary.skip(lineErr)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", rec.Line, err)
+		}
+	}
+	return imp.flush(ctx)
+}
+
+func (imp *importer) addNews(ctx context.Context, rec *record) error {
+	title := rec.get("title")
+	content := rec.get("content")
+	status := rec.get("status")
+	if status == "" {
+		status = "draft"
+	}
+	if title == "" || content == "" {
+		return &lineError{Line: rec.Line, Err: errors.New("title and content are required")}
+	}
+
+	// The slug column of exports is not imported, news are stored with the
+	// slug of their title and matched on it
+	slug := utils.Slugify(title)
+	if err := imp.waitFor(ctx, slug); err != nil {
+		return err
+	}
+
+	id, found, err := imp.lookup.find(ctx, rec.Line, "news", rec.get("id"), slug)
+	if err != nil {
+		return err
+	}
+
+	topics := make([]domain.NewsTopicNew, 0)
+	for _, ref := range rec.list("topics") {
+		topicID, err := imp.resolveTopic(ctx, rec.Line, ref)
+		if err != nil {
+			return err
+		}
+		topics = append(topics, domain.NewsTopicNew{TopicId: topicID})
+	}
+	authorID, err := imp.resolveAuthor(ctx, rec.Line, rec.get("author"))
+	if err != nil {
+		return err
+	}
+	if imp.dryRun {
+		imp.count(slug, found)
+		return nil
+	}
+
+	// News without an author column get the one of --author
+	item := domain.BulkNewsItem{ID: id, Title: title, Status: status, Content: content, Topics: topics, AuthorID: authorID}
+	item.Insert = !found && id != ""
+	imp.news = append(imp.news, item)
+	return imp.added(ctx, rec.Line, slug)
+}
+
+func (imp *importer) addTopic(ctx context.Context, rec *record) error {
+	name := rec.get("name")
+	if name == "" {
+		return &lineError{Line: rec.Line, Err: errors.New("name is required")}
+	}
+	slug := utils.Slugify(name)
+	if err := imp.waitFor(ctx, slug); err != nil {
+		return err
+	}
+
+	id, found, err := imp.lookup.find(ctx, rec.Line, "topik", rec.get("id"), slug)
+	if err != nil {
+		return err
+	}
+
+	if imp.dryRun {
+		imp.count(slug, found)
+		return nil
+	}
+
+	item := domain.BulkTopicItem{ID: id, Name: name, Insert: !found && id != ""}
+	imp.topics = append(imp.topics, item)
+	return imp.added(ctx, rec.Line, slug)
+}
+
+// waitFor saves the pending items when one of them has slug, so the line
+// repeating it updates the saved row instead of creating another one
+func (imp *importer) waitFor(ctx context.Context, slug string) error {
+	if imp.dryRun || !imp.slugs[slug] {
+		return nil
+	}
+	return imp.flush(ctx)
+}
+
+// count counts the line of a dry run, a line repeating the slug of a
+// previous one would update its row
+func (imp *importer) count(slug string, found bool) {
+	if found || imp.slugs[slug] {
+		imp.summary.Updated++
+		return
+	}
+	imp.summary.Inserted++
+	imp.slugs[slug] = true
+}
+
+// added records the line of the item just appended and saves the batch once
+// it is full
+func (imp *importer) added(ctx context.Context, line int, slug string) error {
+	imp.lines = append(imp.lines, line)
+	imp.slugs[slug] = true
+	if len(imp.lines) < importBatchSize {
+		return nil
+	}
+	return imp.flush(ctx)
+}
+
+// flush saves the pending items and reports the failing ones on their line
+func (imp *importer) flush(ctx context.Context) error {
+	if len(imp.lines) == 0 {
+		return nil
+	}
+
+	var result *domain.BulkResult
+	var err error
+	if len(imp.news) > 0 {
+		result, err = imp.services.news.BulkSaveNews(ctx, &domain.BulkNewsRequest{Items: imp.news})
+	} else {
+		result, err = imp.services.topics.BulkSaveTopics(ctx, &domain.BulkTopicRequest{Items: imp.topics})
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, item := range result.Items {
+		switch {
+		case item.Error != "":
+			imp.summary.skip(&lineError{Line: imp.lines[item.Index], Err: errors.New(item.Error)})
+		case item.Action == domain.BulkActionCreated:
+			imp.summary.Inserted++
+		case item.Action == domain.BulkActionUpdated:
+			imp.summary.Updated++
+		}
+	}
+
+	imp.news, imp.topics, imp.lines = imp.news[:0], imp.topics[:0], imp.lines[:0]
+	clear(imp.slugs)
+	return nil
+}
+
+var errUnknownTopic = errors.New("unknown topic")
+
+// resolveTopic returns the id of the topic with the name or slug ref, and
+// creates it when it does not exist and --create-topics is set. A topic
+// which cannot be created fails the line of the news only.
+func (imp *importer) resolveTopic(ctx context.Context, line int, ref string) (string, error) {
+	key := strings.ToLower(ref)
+	if id, ok := imp.topicIDs[key]; ok {
+		return id, nil
+	}
+
+	id, found, err := imp.lookup.topic(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		if !imp.createTopics {
+			return "", &lineError{Line: line, Err: fmt.Errorf("%w %q", errUnknownTopic, ref)}
+		}
+		if id, err = imp.createTopic(ctx, line, ref); err != nil {
+			return "", err
+		}
+		imp.summary.TopicsCreated++
+	}
+
+	imp.topicIDs[key] = id
+	return id, nil
+}
+
+// resolveAuthor returns the user id of the author with email, an unknown
+// author fails the line. It returns an empty id when email is empty.
+func (imp *importer) resolveAuthor(ctx context.Context, line int, email string) (string, error) {
+	if email == "" {
+		return "", nil
+	}
+	key := strings.ToLower(email)
+	if id, ok := imp.authorIDs[key]; ok {
+		return id, nil
+	}
+
+	principal, err := imp.lookup.author(ctx, email)
+	if errors.Is(err, domain.ErrNotFound) {
+		return "", &lineError{Line: line, Err: err}
+	}
+	if err != nil {
+		return "", err
+	}
+	imp.authorIDs[key] = principal.UserID
+	return principal.UserID, nil
+}
+
+func (imp *importer) createTopic(ctx context.Context, line int, name string) (string, error) {
+	if imp.dryRun {
+		// Stands for the topic until the end of the dry run
+		return uuid.NewString(), nil
+	}
+	result, err := imp.services.topics.BulkSaveTopics(ctx, &domain.BulkTopicRequest{
+		Items: []domain.BulkTopicItem{{Name: name}},
+	})
+	if err != nil {
+		return "", err
+	}
+	if item := result.Items[0]; item.Error != "" {
+		return "", &lineError{Line: line, Err: fmt.Errorf("topic %q: %s", name, item.Error)}
+	}
+	return result.Items[0].ID, nil
+}
+
+// sqlLookup looks the rows up in the database
+type sqlLookup struct {
+	db *sql.DB
+}
+
+// find looks a row up by id when one is given and by slug when there is no
+// row with that id. The given id is kept for a row matching neither, so
+// imports into another database keep the ids of the export.
+func (l sqlLookup) find(ctx context.Context, line int, table, id, slug string) (string, bool, error) {
+	if id != "" {
+		if _, err := uuid.Parse(id); err != nil {
+			return "", false, &lineError{Line: line, Err: fmt.Errorf("invalid id %q", id)}
+		}
+
+		var deleted bool
+		err := l.db.QueryRowContext(ctx, `SELECT deleted_at IS NOT NULL FROM `+table+` WHERE id = $1`, id).Scan(&deleted)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+		case err != nil:
+			return "", false, err
+		case deleted:
+			return "", false, &lineError{Line: line, Err: fmt.Errorf("%s was deleted", id)}
+		default:
+			return id, true, nil
+		}
+	}
+
+	var existing string
+	err := l.db.QueryRowContext(ctx, `
+		SELECT id FROM `+table+`
+		WHERE slug = $1 AND deleted_at IS NULL
+		ORDER BY created_at
+		LIMIT 1`, slug).Scan(&existing)
+	if errors.Is(err, sql.ErrNoRows) {
+		return id, false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return existing, true, nil
+}
+
+func (l sqlLookup) topic(ctx context.Context, ref string) (string, bool, error) {
+	var id string
+	err := l.db.QueryRowContext(ctx, `
+		SELECT id FROM topik
+		WHERE deleted_at IS NULL
+			AND (slug = $1 OR slug = $2 OR lower(name) = $1)
+		ORDER BY created_at
+		LIMIT 1`, strings.ToLower(ref), utils.Slugify(ref)).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return id, true, nil
+}
+
+func (l sqlLookup) author(ctx context.Context, email string) (*auth.Principal, error) {
+	p := &auth.Principal{}
+	err := l.db.QueryRowContext(ctx, `
+		SELECT id, name, email, role FROM users
+		WHERE lower(email) = lower($1) AND deleted_at IS NULL`, email).Scan(&p.UserID, &p.Name, &p.Email, &p.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("author %s: %w", email, domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
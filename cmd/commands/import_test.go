@@ -0,0 +1,222 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLookup knows the rows by slug, the topics by lower cased name and the
+// authors by email
+type fakeLookup struct {
+	rows    map[string]string
+	topics  map[string]string
+	authors map[string]string
+}
+
+func (l *fakeLookup) find(_ context.Context, _ int, _, id, slug string) (string, bool, error) {
+	if existing, ok := l.rows[slug]; ok {
+		return existing, true, nil
+	}
+	return id, false, nil
+}
+
+func (l *fakeLookup) topic(_ context.Context, ref string) (string, bool, error) {
+	id, ok := l.topics[strings.ToLower(ref)]
+	return id, ok, nil
+}
+
+func (l *fakeLookup) author(_ context.Context, email string) (*auth.Principal, error) {
+	id, ok := l.authors[email]
+	if !ok {
+		return nil, fmt.Errorf("author %s: %w", email, domain.ErrNotFound)
+	}
+	return &auth.Principal{UserID: id, Email: email}, nil
+}
+
+// fakeSaver saves every item except the ones titled or named "fail", the
+// saved items are added to the lookup like the database would
+type fakeSaver struct {
+	lookup   *fakeLookup
+	requests int
+	saved    []string
+	// ids and authors are the ids and author ids of the saved items
+	ids     []string
+	authors []string
+}
+
+func (s *fakeSaver) save(names []string, ids []string, inserts []bool) *domain.BulkResult {
+	s.requests++
+	result := &domain.BulkResult{Items: make([]domain.BulkItemResult, len(names))}
+	for i, name := range names {
+		item := &result.Items[i]
+		item.Index = i
+		switch {
+		case name == "fail":
+			item.Error = domain.ErrConflict.Error()
+		case inserts[i]:
+			item.ID, item.Action = ids[i], domain.BulkActionCreated
+		case ids[i] != "":
+			item.ID, item.Action = ids[i], domain.BulkActionUpdated
+		default:
+			item.ID, item.Action = fmt.Sprintf("id-%s", name), domain.BulkActionCreated
+		}
+		if item.Error == "" {
+			s.saved = append(s.saved, name)
+			s.ids = append(s.ids, item.ID)
+			s.lookup.rows[utils.Slugify(name)] = item.ID
+		}
+	}
+	return result
+}
+
+func (s *fakeSaver) BulkSaveNews(_ context.Context, req *domain.BulkNewsRequest) (*domain.BulkResult, error) {
+	names, ids, inserts := make([]string, len(req.Items)), make([]string, len(req.Items)), make([]bool, len(req.Items))
+	for i, item := range req.Items {
+		names[i], ids[i], inserts[i] = item.Title, item.ID, item.Insert
+		s.authors = append(s.authors, item.AuthorID)
+	}
+	return s.save(names, ids, inserts), nil
+}
+
+func (s *fakeSaver) BulkSaveTopics(_ context.Context, req *domain.BulkTopicRequest) (*domain.BulkResult, error) {
+	names, ids, inserts := make([]string, len(req.Items)), make([]string, len(req.Items)), make([]bool, len(req.Items))
+	for i, item := range req.Items {
+		names[i], ids[i], inserts[i] = item.Name, item.ID, item.Insert
+	}
+	return s.save(names, ids, inserts), nil
+}
+
+func newTestImporter(dryRun, createTopics bool) (*importer, *fakeSaver) {
+	lookup := &fakeLookup{
+		rows:    map[string]string{"pemilu": "id-pemilu"},
+		topics:  map[string]string{"politik": "topic-politik"},
+		authors: map[string]string{"alice@example.com": "user-alice"},
+	}
+	saver := &fakeSaver{lookup: lookup}
+	return newImporter(importServices{news: saver, topics: saver}, lookup, dryRun, createTopics), saver
+}
+
+func runTestImport(t *testing.T, imp *importer, target, input string) {
+	t.Helper()
+	reader, err := newRecordReader(strings.NewReader(input), formatCSV)
+	require.NoError(t, err)
+	require.NoError(t, imp.run(context.Background(), target, reader))
+}
+
+func errorMessages(errs []error) []string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return messages
+}
+
+func TestImporter_News(t *testing.T) {
+	imp, saver := newTestImporter(false, false)
+
+	runTestImport(t, imp, "news", strings.Join([]string{
+		"title,content,topics",
+		"Banjir,isi,politik",
+		"Pemilu,isi,Politik",
+		",isi,",
+		"Gempa,isi,olahraga",
+		"fail,isi,",
+		`"broken,isi,`,
+	}, "\n"))
+
+	assert.Equal(t, 1, imp.summary.Inserted)
+	assert.Equal(t, 1, imp.summary.Updated)
+	assert.Equal(t, 4, imp.summary.Skipped)
+	assert.Equal(t, []string{
+		"line 4: title and content are required",
+		`line 5: unknown topic "olahraga"`,
+		`line 7: extraneous or missing " in quoted-field`,
+		"line 6: " + domain.ErrConflict.Error(),
+	}, errorMessages(imp.summary.Errors), "the saving errors are reported on the line of the item")
+	assert.Equal(t, []string{"Banjir", "Pemilu"}, saver.saved)
+	assert.Equal(t, 1, saver.requests, "the lines are saved in one batch")
+}
+
+func TestImporter_ReimportIgnoresSlug(t *testing.T) {
+	imp, saver := newTestImporter(false, false)
+	input := "title,slug,content\nBanjir Jakarta,custom-slug,isi\n"
+
+	runTestImport(t, imp, "news", input)
+	again := newImporter(importServices{news: saver, topics: saver}, imp.lookup, false, false)
+	runTestImport(t, again, "news", input)
+
+	assert.Equal(t, 1, imp.summary.Inserted)
+	assert.Equal(t, 0, again.summary.Inserted, "the line matches the news of its title")
+	assert.Equal(t, 1, again.summary.Updated)
+	assert.Equal(t, []string{"Banjir Jakarta", "Banjir Jakarta"}, saver.saved)
+}
+
+func TestImporter_KeepsIDs(t *testing.T) {
+	imp, saver := newTestImporter(false, false)
+
+	runTestImport(t, imp, "news", strings.Join([]string{
+		"id,title,content",
+		"6f1c0a52-6f0e-4f4b-9a57-1c2a1f0e9d11,Banjir,isi",
+		"0c3a5a1e-21a8-4b9b-8d0e-8d3c1f6b7e22,Pemilu,isi",
+	}, "\n"))
+
+	assert.Equal(t, 1, imp.summary.Inserted)
+	assert.Equal(t, 1, imp.summary.Updated)
+	assert.Equal(t, []string{"6f1c0a52-6f0e-4f4b-9a57-1c2a1f0e9d11", "id-pemilu"}, saver.ids,
+		"a missing news is created with its id, an existing one is matched on its slug")
+}
+
+func TestImporter_Authors(t *testing.T) {
+	imp, saver := newTestImporter(false, false)
+
+	runTestImport(t, imp, "news", strings.Join([]string{
+		"title,content,author",
+		"Banjir,isi,alice@example.com",
+		"Gempa,isi,",
+		"Badai,isi,bob@example.com",
+	}, "\n"))
+
+	assert.Equal(t, 2, imp.summary.Inserted)
+	assert.Equal(t, []string{"line 4: author bob@example.com: " + domain.ErrNotFound.Error()}, errorMessages(imp.summary.Errors))
+	assert.Equal(t, []string{"user-alice", ""}, saver.authors, "news without an author get the one of --author")
+}
+
+func TestImporter_CreateTopics(t *testing.T) {
+	imp, saver := newTestImporter(false, true)
+
+	runTestImport(t, imp, "news", "title,content,topics\nBanjir,isi,Cuaca|fail\nBadai,isi,cuaca\n")
+
+	assert.Equal(t, 1, imp.summary.TopicsCreated, "created topics are cached")
+	assert.Equal(t, 1, imp.summary.Inserted)
+	assert.Equal(t, []string{`line 2: topic "fail": ` + domain.ErrConflict.Error()}, errorMessages(imp.summary.Errors))
+	assert.Equal(t, []string{"Cuaca", "Badai"}, saver.saved)
+}
+
+func TestImporter_RepeatedSlug(t *testing.T) {
+	imp, saver := newTestImporter(false, false)
+
+	runTestImport(t, imp, "topics", "name\nCuaca\nOlahraga\ncuaca\n")
+
+	assert.Equal(t, 2, imp.summary.Inserted)
+	assert.Equal(t, 1, imp.summary.Updated, "the repeated line updates the row of the first one")
+	assert.Equal(t, 2, saver.requests)
+}
+
+func TestImporter_DryRun(t *testing.T) {
+	imp, saver := newTestImporter(true, true)
+
+	runTestImport(t, imp, "news", "title,content,topics\nBanjir,isi,Cuaca\nPemilu,isi,\nBanjir,isi,cuaca\n")
+
+	assert.Equal(t, 1, imp.summary.Inserted)
+	assert.Equal(t, 2, imp.summary.Updated)
+	assert.Equal(t, 1, imp.summary.TopicsCreated)
+	assert.Zero(t, saver.requests, "nothing is written")
+}
@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/utils"
+	"github.com/google/uuid"
+)
+
+// runPartner manages the partner clients allowed to send signed requests.
+// The secret is only printed once, when the client is created.
+func runPartner(db *sql.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New("partner action is required (create or revoke)")
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("partner "+action, flag.ContinueOnError)
+	name := fs.String("name", "", "name of the partner client to create")
+	id := fs.String("id", "", "id of the partner client to revoke")
+	scopeList := fs.String("scopes", "", "comma separated scopes granted to the partner client to create")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch action {
+	case "create":
+		if *name == "" {
+			return errors.New("--name is required")
+		}
+		scopes, err := parseScopes(*scopeList)
+		if err != nil {
+			return err
+		}
+		secret, err := utils.GenerateToken(32)
+		if err != nil {
+			return err
+		}
+
+		var clientID string
+		err = db.QueryRowContext(ctx, `
+			INSERT INTO partner_clients (name, secret, scopes, created_at, updated_at)
+			VALUES ($1, $2, $3, NOW(), NOW())
+			RETURNING id`, *name, secret, scopes).Scan(&clientID)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "client id: %s\nscopes:    %s\nsecret:    %s\n", clientID, strings.Join(scopes, ","), secret)
+	case "revoke":
+		if _, err := uuid.Parse(*id); err != nil {
+			return errors.New("--id must be a valid client id")
+		}
+		res, err := db.ExecContext(ctx, `
+			UPDATE partner_clients
+			SET revoked_at = NOW(), updated_at = NOW()
+			WHERE id = $1 AND revoked_at IS NULL`, *id)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return errors.New("partner client not found or already revoked")
+		}
+		fmt.Fprintf(os.Stdout, "partner client %s revoked\n", *id)
+	default:
+		return errors.New("unknown partner action: " + action)
+	}
+	return nil
+}
+
+// parseScopes splits a comma separated scope list, partner clients can be
+// granted the same scopes as API keys
+func parseScopes(list string) ([]string, error) {
+	var scopes []string
+	for _, scope := range strings.Split(list, ",") {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+		if !slices.Contains(domain.APIKeyScopes, scope) {
+			return nil, fmt.Errorf("unknown scope %q", scope)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, errors.New("--scopes needs at least one scope")
+	}
+	return scopes, nil
+}
@@ -5,15 +5,18 @@ import (
 	"fmt"
 
 	"github.com/edwinjordan/ZOGTest-Golang.git/database"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/repository/postgres"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service"
 )
 
-func Execute(command string, args []string) error {
+// Execute runs command against the database at databaseURL
+func Execute(databaseURL, command string, args []string) error {
 	subcommand := ""
 	if len(args) > 0 {
 		subcommand = args[0]
 	}
 
-	db, err := database.SetupSQLDatabase()
+	db, err := database.SetupSQLDatabase(databaseURL)
 	if err != nil {
 		return fmt.Errorf("failed to connect to DB: %w", err)
 	}
@@ -33,6 +36,29 @@ func Execute(command string, args []string) error {
 		if err := runSeeder(db, target); err != nil {
 			return fmt.Errorf("seeding failed: %w", err)
 		}
+	case "export":
+		if err := runExport(db, args); err != nil {
+			return fmt.Errorf("export failed: %w", err)
+		}
+	case "import":
+		pool, err := database.SetupPgxPool(database.PoolConfig{URL: databaseURL})
+		if err != nil {
+			return fmt.Errorf("failed to connect to DB: %w", err)
+		}
+		defer pool.Close()
+
+		auditor := service.WithAuditor(service.NewAuditService(postgres.NewAuditRepository(pool), 0))
+		services := importServices{
+			news:   service.NewNewsService(postgres.NewNewsRepository(pool), auditor),
+			topics: service.NewTopicService(postgres.NewTopicRepository(pool), auditor),
+		}
+		if err := runImport(db, services, args); err != nil {
+			return fmt.Errorf("import failed: %w", err)
+		}
+	case "partner":
+		if err := runPartner(db, args); err != nil {
+			return fmt.Errorf("partner failed: %w", err)
+		}
 	default:
 		return errors.New("unknown command: " + command)
 	}
@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/database"
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/repository/postgres"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service"
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDatabase connects to the migrated database of DATABASE_URL and returns
+// the services of the import, the test is skipped when it is not set
+func testDatabase(t *testing.T) (*sql.DB, importServices) {
+	t.Helper()
+	_ = godotenv.Load("../../.env")
+	url := os.Getenv("DATABASE_URL")
+	if url == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+
+	db, err := database.SetupSQLDatabase(url)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	pool, err := database.SetupPgxPool(database.PoolConfig{URL: url})
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	return db, importServices{
+		news:   service.NewNewsService(postgres.NewNewsRepository(pool)),
+		topics: service.NewTopicService(postgres.NewTopicRepository(pool)),
+	}
+}
+
+// exportedNews exports the news of status as JSON Lines, by id
+func exportedNews(t *testing.T, db *sql.DB, status string) (*bytes.Buffer, map[string]newsRow) {
+	t.Helper()
+	var buf bytes.Buffer
+	_, err := exportNews(context.Background(), db, &buf, formatJSONL, status)
+	require.NoError(t, err)
+
+	rows := make(map[string]newsRow)
+	decoder := json.NewDecoder(bytes.NewReader(buf.Bytes()))
+	for decoder.More() {
+		var row newsRow
+		require.NoError(t, decoder.Decode(&row))
+		rows[row.ID] = row
+	}
+	return &buf, rows
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	db, services := testDatabase(t)
+	ctx := context.Background()
+	status := "roundtrip-" + uuid.NewString()[:8]
+	topic := "Roundtrip " + uuid.NewString()
+
+	// Creates a news through an import, with its topic
+	imp := newImporter(services, sqlLookup{db: db}, false, true)
+	reader, err := newRecordReader(bytes.NewBufferString(
+		"title,content,status,topics\nRoundtrip "+status+",isi,"+status+","+topic+"\n"), formatCSV)
+	require.NoError(t, err)
+	require.NoError(t, imp.run(ctx, "news", reader))
+	require.Equal(t, 1, imp.summary.Inserted, imp.summary.Errors)
+	require.Equal(t, 1, imp.summary.TopicsCreated)
+
+	exported, before := exportedNews(t, db, status)
+	require.Len(t, before, 1)
+	t.Cleanup(func() {
+		for id := range before {
+			_, _ = db.Exec(`DELETE FROM news WHERE id = $1`, id)
+		}
+		_, _ = db.Exec(`DELETE FROM topik WHERE name = $1`, topic)
+	})
+
+	// Importing the export again updates the same news
+	imp = newImporter(services, sqlLookup{db: db}, false, false)
+	reader, err = newRecordReader(exported, formatJSONL)
+	require.NoError(t, err)
+	require.NoError(t, imp.run(ctx, "news", reader))
+
+	assert.Empty(t, imp.summary.Errors)
+	assert.Equal(t, 1, imp.summary.Updated)
+	assert.Zero(t, imp.summary.Inserted)
+	_, after := exportedNews(t, db, status)
+	for id, row := range before {
+		require.Contains(t, after, id)
+		assert.Equal(t, row.Title, after[id].Title)
+		assert.Equal(t, row.Content, after[id].Content)
+		assert.Equal(t, row.Topics, after[id].Topics)
+	}
+
+	var events int
+	require.NoError(t, db.QueryRow(`
+		SELECT count(*) FROM outbox
+		WHERE aggregate_id = $1 AND event_type IN ($2, $3)`,
+		exportedID(before), domain.EventNewsCreated, domain.EventNewsUpdated).Scan(&events))
+	assert.Equal(t, 2, events, "the import writes the events of the API")
+}
+
+func exportedID(rows map[string]newsRow) string {
+	for id := range rows {
+		return id
+	}
+	return ""
+}
+
+// An export imported into a database missing its news, like another one,
+// creates them with their ids and authors
+func TestExportImportKeepsIDs(t *testing.T) {
+	db, services := testDatabase(t)
+	ctx := context.Background()
+	status := "roundtrip-" + uuid.NewString()[:8]
+	email := status + "@example.com"
+
+	var authorID string
+	require.NoError(t, db.QueryRow(`
+		INSERT INTO users (name, email, password) VALUES ('Roundtrip', $1, '')
+		RETURNING id`, email).Scan(&authorID))
+	imp := newImporter(services, sqlLookup{db: db}, false, false)
+	reader, err := newRecordReader(bytes.NewBufferString(
+		"title,content,status,author\nRoundtrip "+status+",isi,"+status+","+email+"\n"), formatCSV)
+	require.NoError(t, err)
+	require.NoError(t, imp.run(ctx, "news", reader))
+	require.Equal(t, 1, imp.summary.Inserted, imp.summary.Errors)
+
+	exported, before := exportedNews(t, db, status)
+	require.Len(t, before, 1)
+	id := exportedID(before)
+	assert.Equal(t, email, before[id].Author)
+	t.Cleanup(func() {
+		_, _ = db.Exec(`DELETE FROM news WHERE id = $1`, id)
+		_, _ = db.Exec(`DELETE FROM users WHERE id = $1`, authorID)
+	})
+	_, err = db.Exec(`DELETE FROM news WHERE id = $1`, id)
+	require.NoError(t, err)
+
+	imp = newImporter(services, sqlLookup{db: db}, false, false)
+	reader, err = newRecordReader(exported, formatJSONL)
+	require.NoError(t, err)
+	require.NoError(t, imp.run(ctx, "news", reader))
+
+	assert.Empty(t, imp.summary.Errors)
+	assert.Equal(t, 1, imp.summary.Inserted)
+	_, after := exportedNews(t, db, status)
+	require.Contains(t, after, id, "the news keeps its id")
+	assert.Equal(t, email, after[id].Author)
+}
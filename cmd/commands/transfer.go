@@ -0,0 +1,197 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	formatCSV   = "csv"
+	formatJSONL = "jsonl"
+
+	// listSeparator joins multi valued fields such as topics in CSV files
+	listSeparator = "|"
+)
+
+// record is one row of an import file, keyed by column name
+type record struct {
+	Line   int
+	Fields map[string]string
+}
+
+func (r *record) get(key string) string {
+	return strings.TrimSpace(r.Fields[key])
+}
+
+func (r *record) list(key string) []string {
+	var values []string
+	for _, v := range strings.Split(r.Fields[key], listSeparator) {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// lineError reports a problem with a single line of an import file, the
+// import skips that line and carries on with the next one
+type lineError struct {
+	Line int
+	Err  error
+}
+
+func (e *lineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *lineError) Unwrap() error {
+	return e.Err
+}
+
+// recordReader streams records one at a time and returns io.EOF when done
+type recordReader interface {
+	Next() (*record, error)
+}
+
+func newRecordReader(r io.Reader, format string) (recordReader, error) {
+	switch format {
+	case formatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		header, err := cr.Read()
+		if err != nil {
+			return nil, fmt.Errorf("read csv header: %w", err)
+		}
+		for i := range header {
+			header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+		}
+		return &csvRecordReader{reader: cr, header: header}, nil
+	case formatJSONL:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		return &jsonlRecordReader{scanner: scanner}, nil
+	default:
+		return nil, errors.New("unsupported format: " + format)
+	}
+}
+
+type csvRecordReader struct {
+	reader *csv.Reader
+	header []string
+}
+
+func (c *csvRecordReader) Next() (*record, error) {
+	values, err := c.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &lineError{Line: parseErr.Line, Err: parseErr.Err}
+		}
+		return nil, err
+	}
+
+	line, _ := c.reader.FieldPos(0)
+	rec := &record{Line: line, Fields: make(map[string]string, len(c.header))}
+	for i, key := range c.header {
+		if i < len(values) {
+			rec.Fields[key] = values[i]
+		}
+	}
+	return rec, nil
+}
+
+type jsonlRecordReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (j *jsonlRecordReader) Next() (*record, error) {
+	for j.scanner.Scan() {
+		j.line++
+		raw := strings.TrimSpace(j.scanner.Text())
+		if raw == "" {
+			continue
+		}
+
+		var values map[string]any
+		if err := json.Unmarshal([]byte(raw), &values); err != nil {
+			return nil, &lineError{Line: j.line, Err: err}
+		}
+
+		rec := &record{Line: j.line, Fields: make(map[string]string, len(values))}
+		for key, value := range values {
+			rec.Fields[strings.ToLower(key)] = stringifyJSONValue(value)
+		}
+		return rec, nil
+	}
+	if err := j.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func stringifyJSONValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, stringifyJSONValue(item))
+		}
+		return strings.Join(parts, listSeparator)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// detectFormat falls back to the file extension when no format was given
+func detectFormat(format, path string) (string, error) {
+	if format != "" {
+		format = strings.ToLower(format)
+	} else {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			format = formatCSV
+		case ".jsonl", ".ndjson":
+			format = formatJSONL
+		default:
+			return "", errors.New("cannot detect format, pass --format csv|jsonl")
+		}
+	}
+	if format != formatCSV && format != formatJSONL {
+		return "", errors.New("unsupported format: " + format)
+	}
+	return format, nil
+}
+
+// openInput opens path for reading, "-" reads from stdin
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(path)
+}
+
+// openOutput creates path for writing, "-" or an empty path writes to stdout
+func openOutput(path string) (io.WriteCloser, error) {
+	if path == "" || path == "-" {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	return os.Create(path)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readAll reads every record of r, the line errors included
+func readAll(t *testing.T, r recordReader) ([]*record, []error) {
+	t.Helper()
+	var records []*record
+	var lineErrors []error
+	for {
+		rec, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return records, lineErrors
+		}
+		var lineErr *lineError
+		if errors.As(err, &lineErr) {
+			lineErrors = append(lineErrors, lineErr)
+			continue
+		}
+		require.NoError(t, err)
+		records = append(records, rec)
+	}
+}
+
+func TestRowWriterRoundTrip(t *testing.T) {
+	created := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+	rows := []newsRow{
+		{
+			ID: "6f1c0a52-6f0e-4f4b-9a57-1c2a1f0e9d11", Title: "Banjir, \"Jakarta\"", Slug: "banjir-jakarta",
+			Status: "published", Content: "line one\nline two", Author: "alice@example.com", Topics: []string{"cuaca", "jakarta"},
+			CreatedAt: created, UpdatedAt: created,
+		},
+		{ID: "0c3a5a1e-21a8-4b9b-8d0e-8d3c1f6b7e22", Title: "Pemilu", Slug: "pemilu", Status: "draft", Content: "isi", Topics: []string{}},
+	}
+
+	for _, format := range []string{formatCSV, formatJSONL} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := newRowWriter(&buf, format, newsColumns)
+			require.NoError(t, err)
+			for _, row := range rows {
+				require.NoError(t, w.Write(row))
+			}
+			require.NoError(t, w.Flush())
+
+			reader, err := newRecordReader(&buf, format)
+			require.NoError(t, err)
+			records, lineErrors := readAll(t, reader)
+
+			require.Empty(t, lineErrors)
+			require.Len(t, records, 2)
+			assert.Equal(t, rows[0].ID, records[0].get("id"))
+			assert.Equal(t, rows[0].Title, records[0].get("title"))
+			assert.Equal(t, rows[0].Content, records[0].get("content"))
+			assert.Equal(t, rows[0].Author, records[0].get("author"))
+			assert.Equal(t, rows[0].Topics, records[0].list("topics"))
+			assert.Equal(t, created.Format(time.RFC3339), records[0].get("created_at"))
+			assert.Empty(t, records[1].list("topics"))
+		})
+	}
+}
+
+func TestRecordReader_LineErrors(t *testing.T) {
+	reader, err := newRecordReader(strings.NewReader(`{"title":"a"}`+"\n\n"+`{"title":`+"\n"+`{"TITLE":"b","topics":["x","y"]}`), formatJSONL)
+	require.NoError(t, err)
+
+	records, lineErrors := readAll(t, reader)
+
+	require.Len(t, records, 2)
+	assert.Equal(t, 1, records[0].Line)
+	assert.Equal(t, 4, records[1].Line, "blank lines are counted")
+	assert.Equal(t, "b", records[1].get("title"), "keys are lower cased")
+	assert.Equal(t, []string{"x", "y"}, records[1].list("topics"))
+	require.Len(t, lineErrors, 1)
+	assert.Contains(t, lineErrors[0].Error(), "line 3:")
+}
+
+func TestDetectFormat(t *testing.T) {
+	for _, tc := range []struct {
+		format, path, want string
+	}{
+		{"", "news.csv", formatCSV},
+		{"", "news.NDJSON", formatJSONL},
+		{"JSONL", "news.csv", formatJSONL},
+	} {
+		got, err := detectFormat(tc.format, tc.path)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got)
+	}
+
+	_, err := detectFormat("", "news.txt")
+	assert.Error(t, err)
+	_, err = detectFormat("xml", "")
+	assert.Error(t, err)
+}
@@ -20,10 +20,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	cfg, err := config.Load(nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	command := os.Args[1]
 	args := os.Args[2:]
 
-	err := commands.Execute(command, args)
+	err = commands.Execute(cfg.Database.URL, command, args)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
@@ -1,10 +1,26 @@
 package config
 
 import (
+	"cmp"
 	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/edwinjordan/ZOGTest-Golang.git/database"
 	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/oidc"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/ratelimit"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/redact"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service"
+	"github.com/edwinjordan/ZOGTest-Golang.git/utils"
 	"github.com/joho/godotenv"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func LoadEnv() {
@@ -13,3 +29,457 @@ func LoadEnv() {
 
 	}
 }
+
+// Config is the configuration of the application, see Load for where it
+// comes from. Every field has a toml key inside its table and an
+// environment variable.
+type Config struct {
+	App         AppConfig         `toml:"app"`
+	Server      ServerConfig      `toml:"server"`
+	Database    DatabaseConfig    `toml:"database"`
+	Log         LoggingConfig     `toml:"log"`
+	Telemetry   TelemetryConfig   `toml:"telemetry"`
+	Redact      RedactConfig      `toml:"redact"`
+	BodyCapture BodyCaptureConfig `toml:"body_capture"`
+	RateLimit   RateLimitConfig   `toml:"rate_limit"`
+	Audit       AuditConfig       `toml:"audit"`
+	Outbox      OutboxConfig      `toml:"outbox"`
+	Webhook     WebhookConfig     `toml:"webhook"`
+	SMTP        SMTPConfig        `toml:"smtp"`
+	Password    PasswordConfig    `toml:"password"`
+	Auth        AuthConfig        `toml:"auth"`
+	OIDC        OIDCConfig        `toml:"oidc"`
+}
+
+type AppConfig struct {
+	Name string `toml:"name" env:"SERVICE_NAME"`
+	// Environment is one of local, development, testing, staging and
+	// production, it picks the log format and the tracing sampler
+	Environment string `toml:"environment" env:"APP_ENVIRONMENT"`
+}
+
+type ServerConfig struct {
+	Host string `toml:"host" env:"APP_HOST"`
+	Port int    `toml:"port" env:"APP_PORT"`
+	// RequestTimeout bounds the handling of a request
+	RequestTimeout time.Duration `toml:"request_timeout" env:"API_TIMEOUT"`
+	// RouteTimeouts override RequestTimeout, the first matching route
+	// applies. They are only set in the file, as [[server.route_timeouts]].
+	RouteTimeouts []RouteTimeoutConfig `toml:"route_timeouts"`
+	// ShutdownTimeout bounds the graceful shutdown
+	ShutdownTimeout time.Duration `toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	// ShutdownDrainDelay is how long /readyz fails before the shutdown, so
+	// load balancers stop sending requests first
+	ShutdownDrainDelay time.Duration `toml:"shutdown_drain_delay" env:"SHUTDOWN_DRAIN_DELAY"`
+	CORSAllowOrigins   []string      `toml:"cors_allow_origins" env:"CORS_ALLOW_ORIGINS"`
+	// TrustedProxies are the addresses or CIDR ranges of the reverse proxies
+	// whose X-Forwarded-For header gives the client IP. Without any, the
+	// client IP is the address of the connection and the header is ignored.
+	TrustedProxies []string `toml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+}
+
+// IPExtractor returns how the client IP of the rate limits, the login
+// throttle, the sessions and the audit log is read. Headers sent by the
+// clients themselves are never trusted.
+func (c ServerConfig) IPExtractor() echo.IPExtractor {
+	if len(c.TrustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, proxy := range c.TrustedProxies {
+		if ipRange, err := parseIPRange(proxy); err == nil {
+			options = append(options, echo.TrustIPRange(ipRange))
+		}
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// parseIPRange parses a CIDR range, or a single address
+func parseIPRange(s string) (*net.IPNet, error) {
+	if _, ipRange, err := net.ParseCIDR(s); err == nil {
+		return ipRange, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("%q is not an IP address or CIDR range", s)
+	}
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// Timeouts returns the timeouts of the timeout middleware
+func (c ServerConfig) Timeouts() middleware.TimeoutConfig {
+	timeouts := middleware.TimeoutConfig{Default: c.RequestTimeout}
+	for _, route := range c.RouteTimeouts {
+		timeouts.Routes = append(timeouts.Routes, middleware.TimeoutRoute(route))
+	}
+	return timeouts
+}
+
+type RouteTimeoutConfig struct {
+	// Method is any method when empty
+	Method string `toml:"method"`
+	// Path is an Echo route like /api/v1/news/bulk, or a prefix ending with *
+	Path string `toml:"path"`
+	// Timeout of 0 disables the timeout, e.g. for streaming responses
+	Timeout time.Duration `toml:"timeout"`
+}
+
+type DatabaseConfig struct {
+	URL string `toml:"url" env:"DATABASE_URL"`
+	// ReplicaURL is a read replica serving the lists and gets of news and
+	// topics, they stay on the primary when empty
+	ReplicaURL string `toml:"replica_url" env:"DATABASE_REPLICA_URL"`
+	// Pool settings, zero keeps the pgx default
+	MaxConns        int32         `toml:"max_conns" env:"DB_MAX_CONNS"`
+	MinConns        int32         `toml:"min_conns" env:"DB_MIN_CONNS"`
+	MaxConnLifetime time.Duration `toml:"max_conn_lifetime" env:"DB_MAX_CONN_LIFETIME"`
+	MaxConnIdleTime time.Duration `toml:"max_conn_idle_time" env:"DB_MAX_CONN_IDLE_TIME"`
+}
+
+// Pool returns the settings of the pool of the database at url
+func (c DatabaseConfig) Pool(name, url string) database.PoolConfig {
+	return database.PoolConfig{
+		Name:            name,
+		URL:             url,
+		MaxConns:        c.MaxConns,
+		MinConns:        c.MinConns,
+		MaxConnLifetime: c.MaxConnLifetime,
+		MaxConnIdleTime: c.MaxConnIdleTime,
+	}
+}
+
+type LoggingConfig struct {
+	// Level is DEBUG, INFO, WARN or ERROR, empty picks one per environment
+	Level string `toml:"level" env:"LOG_LEVEL"`
+	// Levels gives the rest, service and repository packages a level of
+	// their own, "component=level" pairs
+	Levels map[string]string `toml:"levels" env:"LOG_LEVELS"`
+	// SampleInitial records of a message below WARN are logged every second,
+	// then one of every SampleThereafter. Zero, the default, disables the
+	// sampling.
+	SampleInitial    int `toml:"sample_initial" env:"LOG_SAMPLE_INITIAL"`
+	SampleThereafter int `toml:"sample_thereafter" env:"LOG_SAMPLE_THEREAFTER"`
+}
+
+// Sampling returns the sampling of the logs
+func (c *LoggingConfig) Sampling() logging.SamplingConfig {
+	return logging.SamplingConfig{
+		Tick:       time.Second,
+		Initial:    c.SampleInitial,
+		Thereafter: c.SampleThereafter,
+	}
+}
+
+type TelemetryConfig struct {
+	Enabled      bool   `toml:"enabled" env:"ENABLE_INSTRUMENTATION"`
+	OTLPEndpoint string `toml:"otlp_endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	// SampleRate is the share of traces kept in production
+	SampleRate float64 `toml:"sample_rate" env:"TRACING_SAMPLE_RATE"`
+	// RedactAttributes are redacted from the spans only, on top of the
+	// keys of RedactConfig, an attribute is redacted when its key contains
+	// one of them
+	RedactAttributes []string `toml:"redact_attributes" env:"TRACING_REDACT_ATTRIBUTES"`
+}
+
+// RedactConfig keeps credentials and personal data out of the logs and the
+// spans, on top of redact.DefaultKeys and redact.DefaultPatterns
+type RedactConfig struct {
+	// Keys redact the attributes whose keys contain one of them
+	Keys []string `toml:"keys" env:"REDACT_KEYS"`
+	// Patterns are regular expressions redacted from every value, only their
+	// capture group when they have one. They are only set in the file, as
+	// they may contain commas.
+	Patterns []string `toml:"patterns"`
+}
+
+// Rules returns the redaction rules, with the extra keys of one of the
+// outputs
+func (c RedactConfig) Rules(extraKeys ...string) *redact.Rules {
+	// Validate has checked the patterns
+	rules, _ := redact.New(
+		slices.Concat(redact.DefaultKeys, c.Keys, extraKeys),
+		slices.Concat(redact.DefaultPatterns, c.Patterns),
+	)
+	return rules
+}
+
+// BodyCaptureConfig logs the bodies of a share of the requests of some
+// routes, to debug integrations
+type BodyCaptureConfig struct {
+	// MaxBytes bounds the captured part of each body
+	MaxBytes int `toml:"max_bytes" env:"BODY_CAPTURE_MAX_BYTES"`
+	// RedactFields are redacted from the bodies on top of the keys of
+	// RedactConfig, a field is redacted when its name contains one of them
+	RedactFields []string `toml:"redact_fields" env:"BODY_CAPTURE_REDACT_FIELDS"`
+	// Routes have their bodies captured, the first matching route applies.
+	// They are only set in the file, as [[body_capture.routes]] tables.
+	Routes []BodyCaptureRouteConfig `toml:"routes"`
+}
+
+// Capture returns the configuration of the body capture middleware, the
+// bodies are redacted with the rules of redact
+func (c BodyCaptureConfig) Capture(redact RedactConfig) middleware.BodyCaptureConfig {
+	capture := middleware.BodyCaptureConfig{
+		MaxBytes: c.MaxBytes,
+		Rules:    redact.Rules(c.RedactFields...),
+	}
+	for _, route := range c.Routes {
+		capture.Routes = append(capture.Routes, middleware.BodyCaptureRoute(route))
+	}
+	return capture
+}
+
+type BodyCaptureRouteConfig struct {
+	// Method is any method when empty
+	Method string `toml:"method"`
+	// Path is an Echo route like /api/v1/partner/news, or a prefix ending
+	// with *
+	Path string `toml:"path"`
+	// SampleRate is the share of the requests captured, between 0 and 1
+	SampleRate float64 `toml:"sample_rate"`
+}
+
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `toml:"requests_per_second" env:"RATE_LIMIT_REQUESTS_PER_SECOND"`
+	Burst             int     `toml:"burst" env:"RATE_LIMIT_BURST"`
+	// Key counts the requests by "principal" or "ip"
+	Key string `toml:"key" env:"RATE_LIMIT_KEY"`
+	// IPRequestsPerSecond and IPBurst limit every request by client IP
+	// before the authentication, health checks and rejected credentials
+	// included
+	IPRequestsPerSecond float64 `toml:"ip_requests_per_second" env:"RATE_LIMIT_IP_REQUESTS_PER_SECOND"`
+	IPBurst             int     `toml:"ip_burst" env:"RATE_LIMIT_IP_BURST"`
+	// Backend keeps the counters in "memory", or shares them between
+	// replicas in "postgres" or "redis"
+	Backend  string `toml:"backend" env:"RATE_LIMIT_BACKEND"`
+	RedisURL string `toml:"redis_url" env:"RATE_LIMIT_REDIS_URL"`
+	// Routes have their own limits, the first matching route applies. They
+	// are only set in the file, as [[rate_limit.routes]] tables.
+	Routes []RateLimitRouteConfig `toml:"routes"`
+}
+
+// IPPolicies returns the policies of the rate limit middleware running
+// before the authentication: the routes keyed by ip, and the IP limit for
+// the others.
+func (c RateLimitConfig) IPPolicies() middleware.RateLimitConfig {
+	policies := middleware.RateLimitConfig{
+		Default: middleware.RateLimitPolicy{
+			Name:  "ip",
+			Limit: ratelimit.Limit{Rate: c.IPRequestsPerSecond, Burst: c.IPBurst},
+			Key:   middleware.RateLimitKeyIP,
+		},
+	}
+	for _, route := range c.Routes {
+		if cmp.Or(route.Key, c.Key) == "ip" {
+			policies.Routes = append(policies.Routes, c.route(route))
+		}
+	}
+	return policies
+}
+
+// Policies returns the policies of the rate limit middleware running after
+// the authentication, the routes without a key use Key. The routes keyed
+// by ip are left to IPPolicies.
+func (c RateLimitConfig) Policies() middleware.RateLimitConfig {
+	policies := middleware.RateLimitConfig{
+		Default: middleware.RateLimitPolicy{
+			Name:  "default",
+			Limit: ratelimit.Limit{Rate: c.RequestsPerSecond, Burst: c.Burst},
+			Key:   middleware.RateLimitKeys[c.Key],
+		},
+	}
+	for _, route := range c.Routes {
+		limited := c.route(route)
+		if cmp.Or(route.Key, c.Key) == "ip" {
+			limited.Policy.Key = nil
+		}
+		policies.Routes = append(policies.Routes, limited)
+	}
+	return policies
+}
+
+func (c RateLimitConfig) route(route RateLimitRouteConfig) middleware.RateLimitRoute {
+	return middleware.RateLimitRoute{
+		Method: route.Method,
+		Path:   route.Path,
+		Policy: middleware.RateLimitPolicy{
+			Name:  strings.TrimSpace(route.Method + " " + route.Path),
+			Limit: ratelimit.Limit{Rate: route.RequestsPerSecond, Burst: route.Burst},
+			Key:   middleware.RateLimitKeys[cmp.Or(route.Key, c.Key)],
+		},
+	}
+}
+
+type RateLimitRouteConfig struct {
+	// Method is any method when empty
+	Method string `toml:"method"`
+	// Path is an Echo route like /api/v1/news/:id, or a prefix ending with *
+	Path              string  `toml:"path"`
+	RequestsPerSecond float64 `toml:"requests_per_second"`
+	Burst             int     `toml:"burst"`
+	Key               string  `toml:"key"`
+}
+
+type AuditConfig struct {
+	// RetentionDays is how long audit events are kept, 0 keeps them forever
+	RetentionDays int `toml:"retention_days" env:"AUDIT_RETENTION_DAYS"`
+}
+
+type OutboxConfig struct {
+	// WebhookURL receives every domain event as JSON when set
+	WebhookURL string `toml:"webhook_url" env:"OUTBOX_WEBHOOK_URL"`
+}
+
+type WebhookConfig struct {
+	// AllowedNetworks are the addresses or CIDR ranges of the internal
+	// network webhooks may be delivered to, e.g. for receivers next to the
+	// service. Other private, loopback and link-local addresses are refused.
+	AllowedNetworks []string `toml:"allowed_networks" env:"WEBHOOK_ALLOWED_NETWORKS"`
+}
+
+// Dispatch returns the delivery configuration of the webhooks
+func (c WebhookConfig) Dispatch() service.WebhookDispatchConfig {
+	config := service.DefaultWebhookDispatchConfig
+	for _, network := range c.AllowedNetworks {
+		if ipRange, err := parseIPRange(network); err == nil {
+			config.AllowedNetworks = append(config.AllowedNetworks, ipRange)
+		}
+	}
+	return config
+}
+
+// SMTPConfig sends the emails of the application, they are dropped when
+// Host is empty
+type SMTPConfig struct {
+	Host     string `toml:"host" env:"SMTP_HOST"`
+	Port     int    `toml:"port" env:"SMTP_PORT"`
+	Username string `toml:"username" env:"SMTP_USERNAME"`
+	Password string `toml:"password" env:"SMTP_PASSWORD"`
+	From     string `toml:"from" env:"SMTP_FROM"`
+}
+
+type PasswordConfig struct {
+	// Hasher is argon2id or bcrypt, hashes of the other one are upgraded on
+	// the next login
+	Hasher            string        `toml:"hasher" env:"PASSWORD_HASHER"`
+	BcryptCost        int           `toml:"bcrypt_cost" env:"BCRYPT_COST"`
+	Argon2MemoryKiB   uint32        `toml:"argon2_memory_kib" env:"ARGON2_MEMORY_KIB"`
+	Argon2Iterations  uint32        `toml:"argon2_iterations" env:"ARGON2_ITERATIONS"`
+	Argon2Parallelism uint8         `toml:"argon2_parallelism" env:"ARGON2_PARALLELISM"`
+	MinLength         int           `toml:"min_length" env:"PASSWORD_MIN_LENGTH"`
+	MinClasses        int           `toml:"min_classes" env:"PASSWORD_MIN_CLASSES"`
+	Banned            []string      `toml:"banned" env:"PASSWORD_BANNED"`
+	ResetURL          string        `toml:"reset_url" env:"PASSWORD_RESET_URL"`
+	ResetTTL          time.Duration `toml:"reset_ttl" env:"PASSWORD_RESET_TTL"`
+}
+
+type AuthConfig struct {
+	VerificationSecret         string        `toml:"verification_secret" env:"EMAIL_VERIFICATION_SECRET"`
+	VerificationURL            string        `toml:"verification_url" env:"EMAIL_VERIFICATION_URL"`
+	VerificationTTL            time.Duration `toml:"verification_ttl" env:"EMAIL_VERIFICATION_TTL"`
+	VerificationResendCooldown time.Duration `toml:"verification_resend_cooldown" env:"EMAIL_VERIFICATION_RESEND_COOLDOWN"`
+	LoginMaxFailures           int           `toml:"login_max_failures" env:"LOGIN_MAX_FAILURES"`
+	LoginMaxIPFailures         int           `toml:"login_max_ip_failures" env:"LOGIN_MAX_IP_FAILURES"`
+	LoginLockout               time.Duration `toml:"login_lockout" env:"LOGIN_LOCKOUT"`
+	TOTPIssuer                 string        `toml:"totp_issuer" env:"TOTP_ISSUER"`
+	SessionTTL                 time.Duration `toml:"session_ttl" env:"SESSION_TTL"`
+}
+
+// OIDCConfig enables the login through an OpenID provider when Issuer is set
+type OIDCConfig struct {
+	Issuer       string   `toml:"issuer" env:"OIDC_ISSUER"`
+	ClientID     string   `toml:"client_id" env:"OIDC_CLIENT_ID"`
+	ClientSecret string   `toml:"client_secret" env:"OIDC_CLIENT_SECRET"`
+	RedirectURL  string   `toml:"redirect_url" env:"OIDC_REDIRECT_URL"`
+	Scopes       []string `toml:"scopes" env:"OIDC_SCOPES"`
+	GroupsClaim  string   `toml:"groups_claim" env:"OIDC_GROUPS_CLAIM"`
+	// GroupRoles maps groups of the provider to roles, "group=role" pairs
+	// in the environment
+	GroupRoles  map[string]string `toml:"group_roles" env:"OIDC_GROUP_ROLES"`
+	DefaultRole string            `toml:"default_role" env:"OIDC_DEFAULT_ROLE"`
+	StateSecret string            `toml:"state_secret" env:"OIDC_STATE_SECRET"`
+}
+
+// Default returns the configuration used for everything the file, the
+// environment and the flags leave out.
+func Default() *Config {
+	return &Config{
+		App: AppConfig{
+			Name:        "zogtest-golang-api",
+			Environment: "local",
+		},
+		Server: ServerConfig{
+			Host:           "0.0.0.0",
+			Port:           8000,
+			RequestTimeout: 30 * time.Second,
+			// Bulk changes run a statement per item in one transaction
+			RouteTimeouts: []RouteTimeoutConfig{
+				{Path: "/api/v1/news/bulk", Timeout: 2 * time.Minute},
+				{Path: "/api/v1/topics/bulk", Timeout: 2 * time.Minute},
+			},
+			ShutdownTimeout:    10 * time.Second,
+			ShutdownDrainDelay: 5 * time.Second,
+			CORSAllowOrigins:   []string{"*"},
+		},
+		Telemetry: TelemetryConfig{
+			OTLPEndpoint: "localhost:4317",
+			SampleRate:   0.7,
+		},
+		BodyCapture: BodyCaptureConfig{
+			MaxBytes: middleware.DefaultBodyCaptureConfig.MaxBytes,
+		},
+		RateLimit: RateLimitConfig{
+			RequestsPerSecond:   10,
+			Burst:               20,
+			Key:                 "principal",
+			IPRequestsPerSecond: 50,
+			IPBurst:             100,
+			Backend:             "memory",
+			// Guessing passwords and flooding mailboxes is slowed down by IP
+			Routes: []RateLimitRouteConfig{
+				{Method: http.MethodPost, Path: "/api/v1/auth/login", RequestsPerSecond: 0.1, Burst: 5, Key: "ip"},
+				{Method: http.MethodPost, Path: "/api/v1/auth/login/2fa", RequestsPerSecond: 0.1, Burst: 5, Key: "ip"},
+				{Method: http.MethodPost, Path: "/api/v1/auth/password/forgot", RequestsPerSecond: 0.1, Burst: 5, Key: "ip"},
+			},
+		},
+		Audit: AuditConfig{
+			RetentionDays: 365,
+		},
+		SMTP: SMTPConfig{
+			Port: 587,
+		},
+		Password: PasswordConfig{
+			Hasher:            "argon2id",
+			BcryptCost:        bcrypt.DefaultCost,
+			Argon2MemoryKiB:   utils.DefaultArgon2idParams.Memory,
+			Argon2Iterations:  utils.DefaultArgon2idParams.Iterations,
+			Argon2Parallelism: utils.DefaultArgon2idParams.Parallelism,
+			MinLength:         service.DefaultPasswordPolicy.MinLength,
+			MinClasses:        service.DefaultPasswordPolicy.MinClasses,
+			ResetURL:          service.DefaultPasswordResetConfig.URL,
+			ResetTTL:          service.DefaultPasswordResetConfig.TTL,
+		},
+		Auth: AuthConfig{
+			VerificationURL:            service.DefaultEmailVerificationConfig.URL,
+			VerificationTTL:            service.DefaultEmailVerificationConfig.TTL,
+			VerificationResendCooldown: service.DefaultEmailVerificationConfig.ResendCooldown,
+			LoginMaxFailures:           service.DefaultLoginThrottleConfig.MaxAccountFailures,
+			LoginMaxIPFailures:         service.DefaultLoginThrottleConfig.MaxIPFailures,
+			LoginLockout:               service.DefaultLoginThrottleConfig.Lockout,
+			TOTPIssuer:                 service.DefaultTwoFactorConfig.Issuer,
+			SessionTTL:                 service.DefaultSessionConfig.TTL,
+		},
+		OIDC: OIDCConfig{
+			Scopes:      slices.Clone(oidc.DefaultConfig.Scopes),
+			GroupsClaim: oidc.DefaultConfig.GroupsClaim,
+		},
+	}
+}
@@ -0,0 +1,341 @@
+package config_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/config"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	t.Chdir(t.TempDir())
+	file := writeFile(t, "config.toml", `
+[server]
+host = "127.0.0.1"
+port = 9000
+request_timeout = "45s"
+
+[[server.route_timeouts]]
+method = "GET"
+path = "/api/v1/news*"
+timeout = "5m"
+
+[database]
+url = "postgres://file"
+max_conns = 40
+
+[rate_limit]
+burst = 50
+`)
+	t.Setenv("APP_PORT", "9100")
+	t.Setenv("DB_MAX_CONNS", "60")
+
+	cfg, err := config.Load([]string{"-config", file, "-port", "9200"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", cfg.Server.Host, "file over default")
+	assert.Equal(t, 45*time.Second, cfg.Server.RequestTimeout, "file over default")
+	timeouts := cfg.Server.Timeouts()
+	assert.Equal(t, 45*time.Second, timeouts.Default)
+	require.Len(t, timeouts.Routes, 1, "the file replaces the default route timeouts")
+	assert.Equal(t, 5*time.Minute, timeouts.Routes[0].Timeout)
+	assert.Equal(t, int32(60), cfg.Database.MaxConns, "env over file")
+	assert.Equal(t, 9200, cfg.Server.Port, "flag over env")
+	assert.Equal(t, 50, cfg.RateLimit.Burst)
+	assert.Equal(t, 10.0, cfg.RateLimit.RequestsPerSecond, "default")
+	assert.Len(t, cfg.RateLimit.Routes, 3, "default routes")
+	assert.Zero(t, cfg.Log.Sampling().Initial, "the log sampling is opt-in")
+}
+
+func TestLoad_EnvTypes(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("DATABASE_URL", "postgres://env")
+	t.Setenv("DB_MAX_CONN_LIFETIME", "3600")
+	t.Setenv("DB_MAX_CONN_IDLE_TIME", "10m")
+	t.Setenv("ENABLE_INSTRUMENTATION", "true")
+	t.Setenv("TRACING_SAMPLE_RATE", "0.25")
+	t.Setenv("CORS_ALLOW_ORIGINS", "https://a.example,https://b.example")
+	t.Setenv("OIDC_ISSUER", "https://login.example")
+	t.Setenv("OIDC_CLIENT_ID", "newsroom")
+	t.Setenv("OIDC_REDIRECT_URL", "http://localhost:8000/api/v1/auth/oidc/callback")
+	t.Setenv("OIDC_SCOPES", "email profile")
+	t.Setenv("OIDC_GROUP_ROLES", "newsroom-admins=admin,newsroom=user")
+	t.Setenv("LOG_LEVELS", "repository=warn,service=DEBUG")
+
+	cfg, err := config.Load(nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://env", cfg.Database.URL)
+	assert.Equal(t, time.Hour, cfg.Database.MaxConnLifetime, "bare numbers are seconds")
+	assert.Equal(t, 10*time.Minute, cfg.Database.MaxConnIdleTime)
+	assert.True(t, cfg.Telemetry.Enabled)
+	assert.Equal(t, 0.25, cfg.Telemetry.SampleRate)
+	assert.Equal(t, []string{"https://a.example", "https://b.example"}, cfg.Server.CORSAllowOrigins)
+	assert.Equal(t, []string{"email", "profile"}, cfg.OIDC.Scopes)
+	assert.Equal(t, map[string]string{"newsroom-admins": "admin", "newsroom": "user"}, cfg.OIDC.GroupRoles)
+	assert.Equal(t, map[string]string{"repository": "warn", "service": "DEBUG"}, cfg.Log.Levels)
+}
+
+func TestLoad_SecretFiles(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("DATABASE_URL_FILE", writeFile(t, "database_url", "postgres://secret\n"))
+
+	t.Run("Reads the variable from the file", func(t *testing.T) {
+		cfg, err := config.Load(nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, "postgres://secret", cfg.Database.URL)
+	})
+
+	t.Run("Refuses the variable and its file together", func(t *testing.T) {
+		t.Setenv("DATABASE_URL", "postgres://env")
+
+		_, err := config.Load(nil)
+
+		assert.ErrorContains(t, err, "DATABASE_URL and DATABASE_URL_FILE are both set")
+	})
+
+	t.Run("Reports a missing file", func(t *testing.T) {
+		t.Setenv("SMTP_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+
+		_, err := config.Load(nil)
+
+		assert.ErrorContains(t, err, "SMTP_PASSWORD_FILE")
+	})
+}
+
+func TestLoad_Files(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("DATABASE_URL", "postgres://env")
+
+	t.Run("Runs without the default file", func(t *testing.T) {
+		_, err := config.Load(nil)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("Requires an explicit file", func(t *testing.T) {
+		t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.toml"))
+
+		_, err := config.Load(nil)
+
+		assert.ErrorContains(t, err, "missing.toml")
+	})
+
+	t.Run("Refuses unknown keys", func(t *testing.T) {
+		file := writeFile(t, "config.toml", "[server]\nmode = \"debug\"\n")
+
+		_, err := config.Load([]string{"-config", file})
+
+		assert.ErrorContains(t, err, "unknown keys server.mode")
+	})
+}
+
+func TestLoad_Validation(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("APP_ENVIRONMENT", "prod")
+	t.Setenv("TRACING_SAMPLE_RATE", "1.5")
+	t.Setenv("PASSWORD_HASHER", "md5")
+	t.Setenv("OIDC_ISSUER", "https://login.example")
+	t.Setenv("OIDC_GROUP_ROLES", "newsroom=owner")
+	t.Setenv("LOG_LEVELS", "handlers=DEBUG,service=TRACE")
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8,proxy.internal")
+
+	_, err := config.Load(nil)
+
+	require.Error(t, err)
+	for _, want := range []string{
+		`APP_ENVIRONMENT: "prod" is not one of`,
+		"DATABASE_URL: must be set",
+		"TRACING_SAMPLE_RATE: 1.5 is not between 0 and 1",
+		`PASSWORD_HASHER: "md5" is not argon2id or bcrypt`,
+		"OIDC_CLIENT_ID: must be set with OIDC_ISSUER",
+		`OIDC_GROUP_ROLES: unknown role "owner" for group "newsroom"`,
+		`LOG_LEVELS: unknown component "handlers"`,
+		`LOG_LEVELS: "TRACE" of service is not one of`,
+		`TRUSTED_PROXIES: "proxy.internal" is not an IP address or CIDR range`,
+	} {
+		assert.ErrorContains(t, err, want)
+	}
+}
+
+func TestServerConfig_IPExtractor(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.1.2.3:1234"
+	req.Header.Set(echo.HeaderXForwardedFor, "203.0.113.7, 198.51.100.9")
+	req.Header.Set(echo.HeaderXRealIP, "203.0.113.8")
+
+	direct := config.ServerConfig{}.IPExtractor()
+	assert.Equal(t, "10.1.2.3", direct(req), "the headers are ignored without trusted proxies")
+
+	proxied := config.ServerConfig{TrustedProxies: []string{"10.0.0.0/8"}}.IPExtractor()
+	assert.Equal(t, "198.51.100.9", proxied(req), "the address the trusted proxy saw")
+
+	single := config.ServerConfig{TrustedProxies: []string{"10.9.9.9"}}.IPExtractor()
+	assert.Equal(t, "10.1.2.3", single(req), "the connection is not from the trusted proxy")
+}
+
+func TestLoad_RateLimitRoutes(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("DATABASE_URL", "postgres://env")
+	file := writeFile(t, "config.toml", `
+[rate_limit]
+key = "ip"
+
+[[rate_limit.routes]]
+method = "POST"
+path = "/api/v1/news"
+requests_per_second = 0.5
+burst = 2
+
+[[rate_limit.routes]]
+path = "/api/v1/audit*"
+requests_per_second = 1
+burst = 1
+key = "principal"
+`)
+
+	cfg, err := config.Load([]string{"-config", file})
+
+	require.NoError(t, err)
+	require.Len(t, cfg.RateLimit.Routes, 2, "the file replaces the default routes")
+	policies := cfg.RateLimit.Policies()
+	assert.Equal(t, "default", policies.Default.Name)
+	assert.Equal(t, 20, policies.Default.Limit.Burst)
+	require.Len(t, policies.Routes, 2)
+	assert.Equal(t, "POST /api/v1/news", policies.Routes[0].Policy.Name)
+	assert.Nil(t, policies.Routes[0].Policy.Key, "the route falls back to the ip key, limited before the authentication")
+	assert.Equal(t, "/api/v1/audit*", policies.Routes[1].Policy.Name)
+	assert.NotNil(t, policies.Routes[1].Policy.Key)
+
+	ipPolicies := cfg.RateLimit.IPPolicies()
+	assert.Equal(t, "ip", ipPolicies.Default.Name)
+	assert.Equal(t, 100, ipPolicies.Default.Limit.Burst)
+	require.Len(t, ipPolicies.Routes, 1)
+	assert.Equal(t, "POST /api/v1/news", ipPolicies.Routes[0].Policy.Name)
+	assert.Equal(t, 0.5, ipPolicies.Routes[0].Policy.Limit.Rate)
+	assert.NotNil(t, ipPolicies.Routes[0].Policy.Key)
+}
+
+func TestLoad_RateLimitValidation(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("DATABASE_URL", "postgres://env")
+	t.Setenv("RATE_LIMIT_BACKEND", "redis")
+	t.Setenv("RATE_LIMIT_KEY", "session")
+	t.Setenv("RATE_LIMIT_IP_BURST", "0")
+	file := writeFile(t, "config.toml", `
+[[rate_limit.routes]]
+path = "api/v1/news"
+requests_per_second = 0
+burst = 1
+`)
+
+	_, err := config.Load([]string{"-config", file})
+
+	require.Error(t, err)
+	for _, want := range []string{
+		"RATE_LIMIT_REDIS_URL: must be set with RATE_LIMIT_BACKEND=redis",
+		`RATE_LIMIT_KEY: "session" is not principal or ip`,
+		"RATE_LIMIT_IP_BURST: must be positive",
+		`rate_limit.routes[0]: path "api/v1/news" does not start with /`,
+		"rate_limit.routes[0]: requests_per_second must be positive",
+	} {
+		assert.ErrorContains(t, err, want)
+	}
+}
+
+func TestLoad_ParseErrors(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("DATABASE_URL", "postgres://env")
+	t.Setenv("APP_PORT", "eighty")
+	t.Setenv("API_TIMEOUT", "soon")
+
+	_, err := config.Load(nil)
+
+	assert.ErrorContains(t, err, `APP_PORT: "eighty" is not an integer`)
+	assert.ErrorContains(t, err, `API_TIMEOUT: "soon" is not a duration`)
+}
+
+func TestLoad_Redact(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("DATABASE_URL", "postgres://env")
+	t.Setenv("REDACT_KEYS", "phone,address")
+	file := writeFile(t, "config.toml", `
+[redact]
+patterns = ['\b\d{16}\b', 'nik=(\d+)']
+`)
+
+	cfg, err := config.Load([]string{"-config", file})
+
+	require.NoError(t, err)
+	rules := cfg.Redact.Rules("customer")
+	assert.True(t, rules.Key("user.phone"))
+	assert.True(t, rules.Key("customer.name"), "extra keys")
+	assert.True(t, rules.Key("password"), "default keys")
+	redacted, ok := rules.String("card 4111111111111111 nik=3201 of alice@example.com")
+	assert.True(t, ok)
+	assert.Equal(t, "card REDACTED nik=REDACTED of REDACTED", redacted)
+
+	t.Run("Refuses invalid patterns", func(t *testing.T) {
+		file := writeFile(t, "config.toml", `
+[redact]
+patterns = ['(unclosed']
+`)
+
+		_, err := config.Load([]string{"-config", file})
+
+		assert.ErrorContains(t, err, "redact.patterns[0]: error parsing regexp")
+	})
+}
+
+func TestLoad_BodyCapture(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("DATABASE_URL", "postgres://env")
+	t.Setenv("BODY_CAPTURE_REDACT_FIELDS", "nik")
+	file := writeFile(t, "config.toml", `
+[body_capture]
+max_bytes = 1024
+
+[[body_capture.routes]]
+method = "POST"
+path = "/api/v1/partner/*"
+sample_rate = 0.1
+`)
+
+	cfg, err := config.Load([]string{"-config", file})
+
+	require.NoError(t, err)
+	capture := cfg.BodyCapture.Capture(cfg.Redact)
+	assert.Equal(t, 1024, capture.MaxBytes)
+	assert.Equal(t, []middleware.BodyCaptureRoute{{Method: "POST", Path: "/api/v1/partner/*", SampleRate: 0.1}}, capture.Routes)
+	assert.Equal(t, `{"nik":"REDACTED","password":"REDACTED"}`,
+		capture.Rules.Body("application/json", []byte(`{"nik":"3201","password":"hunter2"}`)))
+
+	t.Run("Refuses invalid routes", func(t *testing.T) {
+		file := writeFile(t, "config.toml", `
+[[body_capture.routes]]
+path = "api/v1/partner"
+sample_rate = 2
+`)
+
+		_, err := config.Load([]string{"-config", file})
+
+		assert.ErrorContains(t, err, `body_capture.routes[0]: path "api/v1/partner" does not start with /`)
+		assert.ErrorContains(t, err, "body_capture.routes[0]: sample_rate 2 is not between 0 and 1")
+	})
+}
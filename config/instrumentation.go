@@ -4,15 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"os"
-	"strconv"
 	"time"
 
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/health"
 	"github.com/edwinjordan/ZOGTest-Golang.git/internal/metrics"
 	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/tracing"
 	"github.com/labstack/echo-contrib/echoprometheus"
 	"github.com/labstack/echo/v4"
-	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
@@ -20,6 +19,7 @@ import (
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
@@ -28,15 +28,10 @@ func ApplyInstrumentation(
 	ctx context.Context,
 	e *echo.Echo,
 	appMetrics *metrics.Metrics,
+	cfg *Config,
+	checks *health.Health,
 ) (func(context.Context) error, error) {
-	enableInstrumentationStr := os.Getenv("ENABLE_INSTRUMENTATION")
-	enableInstrumentation, err := strconv.ParseBool(enableInstrumentationStr)
-	if err != nil {
-		enableInstrumentation = false
-		fmt.Printf("Warning: ENABLE_INSTRUMENTATION environment variable '%s' could not be parsed as boolean. Defaulting to false. Error: %v\n", enableInstrumentationStr, err)
-	}
-
-	if !enableInstrumentation {
+	if !cfg.Telemetry.Enabled {
 		slog.Info("Instrumentation is disabled by ENABLE_INSTRUMENTATION environment variable")
 		return func(context.Context) error { return nil }, nil
 	}
@@ -44,19 +39,16 @@ func ApplyInstrumentation(
 	slog.Info("Instrumentation is enabled")
 
 	// Apply Prometheus middleware and metrics endpoint.
-	err = initMetrics(e, appMetrics)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize metrics: %w", err)
-	}
+	initMetrics(e, appMetrics)
 
 	// Initialize the OpenTelemetry tracer provider.
-	tp, shutdownTracer, err := initTracer(ctx)
+	tp, shutdownTracer, err := initTracer(ctx, cfg, checks)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize tracer: %w", err)
 	}
 
 	// Initialize the OpenTelemetry metrics provider.
-	mp, shutdownMetrics, err := initOTelMetrics(ctx)
+	mp, shutdownMetrics, err := initOTelMetrics(ctx, cfg, checks)
 	if err != nil {
 		shutdownTracer(ctx) // Clean up tracer if metrics fail
 		return nil, fmt.Errorf("failed to initialize OTel metrics: %w", err)
@@ -87,35 +79,29 @@ func ApplyInstrumentation(
 	return shutdown, nil
 }
 
-func initMetrics(e *echo.Echo, appMetrics *metrics.Metrics) error {
-	serviceName := os.Getenv("SERVICE_NAME")
+// initMetrics serves the registry of appMetrics, with the HTTP metrics of
+// every request, on /metrics
+func initMetrics(e *echo.Echo, appMetrics *metrics.Metrics) {
 	// @see: https://echo.labstack.com/docs/middleware/prometheus#custom-configuration
-	e.Use(echoprometheus.NewMiddleware(serviceName))
-	e.GET("/metrics", echoprometheus.NewHandler())
-
-	// Register all custom metrics from the appMetrics struct
-	if err := prometheus.Register(appMetrics.UserRepoCalls); err != nil {
-		return fmt.Errorf("failed to register UserRepoCalls metric: %w", err)
-	}
-	// Register other metrics here if you add them to your metrics.Metrics struct
-	// if err := prometheus.Register(appMetrics.OtherMetric); err != nil {
-	// 	return fmt.Errorf("failed to register OtherMetric: %w", err)
-	// }
+	e.Use(echoprometheus.NewMiddlewareWithConfig(echoprometheus.MiddlewareConfig{
+		Namespace:  metrics.Namespace,
+		Subsystem:  "http",
+		Registerer: appMetrics.Registry,
+	}))
+	e.GET("/metrics", echoprometheus.NewHandlerWithConfig(echoprometheus.HandlerConfig{
+		Gatherer: appMetrics.Registry,
+	}))
 
 	slog.Info("Prometheus metrics initialized and registered")
-	return nil
 }
 
 // initTracer initializes an OTel tracer provider. In non-production
 // environments, it uses a no-op provider. It returns a shutdown function
 // and an error.
-func initTracer(ctx context.Context) (*sdktrace.TracerProvider, func(context.Context) error, error) {
-	env := os.Getenv("APP_ENVIRONMENT")
-	serviceName := os.Getenv("SERVICE_NAME")
-	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
-	if endpoint == "" {
-		endpoint = "localhost:4317"
-	}
+func initTracer(ctx context.Context, cfg *Config, checks *health.Health) (*sdktrace.TracerProvider, func(context.Context) error, error) {
+	env := cfg.App.Environment
+	serviceName := cfg.App.Name
+	endpoint := cfg.Telemetry.OTLPEndpoint
 
 	var sampler sdktrace.Sampler
 	if env != "production" {
@@ -124,14 +110,7 @@ func initTracer(ctx context.Context) (*sdktrace.TracerProvider, func(context.Con
 		slog.Info("Tracing sampler: AlwaysSample (non-prod)")
 	} else {
 		// production: probabilistic sampling
-		rate := 0.7 // Default: 70%
-		if s := os.Getenv("TRACING_SAMPLE_RATE"); s != "" {
-			if f, err := strconv.ParseFloat(s, 64); err == nil && f >= 0 && f <= 1 {
-				rate = f
-			} else {
-				fmt.Printf("WARN: invalid TRACING_SAMPLE_RATE='%s', using %0.2f\n", s, rate)
-			}
-		}
+		rate := cfg.Telemetry.SampleRate
 
 		// use ParentBased so child spans follow the root decision
 		sampler = sdktrace.ParentBased(sdktrace.TraceIDRatioBased(rate))
@@ -149,6 +128,10 @@ func initTracer(ctx context.Context) (*sdktrace.TracerProvider, func(context.Con
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
 	}
+	// Readiness reports failing exports, without failing since the API works
+	// without traces
+	traceStatus := health.NewExportStatus("otlp_traces")
+	checks.RegisterOptional(traceStatus)
 
 	res, err := resource.New(
 		ctx,
@@ -166,7 +149,11 @@ func initTracer(ctx context.Context) (*sdktrace.TracerProvider, func(context.Con
 
 	tp := sdktrace.NewTracerProvider(
 		sdktrace.WithSampler(sampler),
-		sdktrace.WithBatcher(exporter),
+		// Sensitive attributes are redacted before the spans are batched
+		sdktrace.WithSpanProcessor(tracing.NewRedactingProcessor(
+			sdktrace.NewBatchSpanProcessor(trackedSpanExporter{SpanExporter: exporter, status: traceStatus}),
+			cfg.Redact.Rules(cfg.Telemetry.RedactAttributes...),
+		)),
 		sdktrace.WithResource(res),
 	)
 	otel.SetTracerProvider(tp)
@@ -186,13 +173,10 @@ func initTracer(ctx context.Context) (*sdktrace.TracerProvider, func(context.Con
 }
 
 // initOTelMetrics initializes OpenTelemetry metrics provider with OTLP exporter
-func initOTelMetrics(ctx context.Context) (*metric.MeterProvider, func(context.Context) error, error) {
-	env := os.Getenv("APP_ENVIRONMENT")
-	serviceName := os.Getenv("SERVICE_NAME")
-	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
-	if endpoint == "" {
-		endpoint = "localhost:4317"
-	}
+func initOTelMetrics(ctx context.Context, cfg *Config, checks *health.Health) (*metric.MeterProvider, func(context.Context) error, error) {
+	env := cfg.App.Environment
+	serviceName := cfg.App.Name
+	endpoint := cfg.Telemetry.OTLPEndpoint
 
 	// Create OTLP metric exporter
 	metricExporter, err := otlpmetricgrpc.New(
@@ -204,6 +188,8 @@ func initOTelMetrics(ctx context.Context) (*metric.MeterProvider, func(context.C
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
 	}
+	metricStatus := health.NewExportStatus("otlp_metrics")
+	checks.RegisterOptional(metricStatus)
 
 	// Create resource
 	res, err := resource.New(
@@ -225,7 +211,7 @@ func initOTelMetrics(ctx context.Context) (*metric.MeterProvider, func(context.C
 		metric.WithResource(res),
 		metric.WithReader(
 			metric.NewPeriodicReader(
-				metricExporter,
+				trackedMetricExporter{Exporter: metricExporter, status: metricStatus},
 				metric.WithInterval(10*time.Second), // Export metrics every 10 seconds
 			),
 		),
@@ -246,3 +232,28 @@ func initOTelMetrics(ctx context.Context) (*metric.MeterProvider, func(context.C
 
 	return mp, shutdown, nil
 }
+
+// trackedSpanExporter records the outcome of every export for the readiness
+type trackedSpanExporter struct {
+	sdktrace.SpanExporter
+	status *health.ExportStatus
+}
+
+func (e trackedSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	e.status.Record(err)
+	return err
+}
+
+// trackedMetricExporter records the outcome of every export for the
+// readiness
+type trackedMetricExporter struct {
+	metric.Exporter
+	status *health.ExportStatus
+}
+
+func (e trackedMetricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	err := e.Exporter.Export(ctx, rm)
+	e.status.Record(err)
+	return err
+}
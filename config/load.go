@@ -0,0 +1,237 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// DefaultFile is read when neither -config nor CONFIG_FILE name a file, it
+// may be missing
+const DefaultFile = "config.toml"
+
+// Load builds the configuration of the application. Every value comes from,
+// by increasing precedence: Default, the TOML file, the environment and the
+// command line flags in args. Secrets can be read from a file named by the
+// variable with a _FILE suffix, e.g. DATABASE_URL_FILE. The result is
+// validated, so a nil error means the configuration is usable.
+func Load(args []string) (*Config, error) {
+	flags := flag.NewFlagSet("zogtest", flag.ContinueOnError)
+	file := flags.String("config", "", "TOML configuration file (default $CONFIG_FILE or "+DefaultFile+")")
+	host := flags.String("host", "", "address to listen on, overrides APP_HOST")
+	port := flags.Int("port", 0, "port to listen on, overrides APP_PORT")
+	environment := flags.String("env", "", "environment of the application, overrides APP_ENVIRONMENT")
+	logLevel := flags.String("log-level", "", "DEBUG, INFO, WARN or ERROR, overrides LOG_LEVEL")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+
+	path, required := *file, true
+	if path == "" {
+		path, required = os.Getenv("CONFIG_FILE"), true
+	}
+	if path == "" {
+		path, required = DefaultFile, false
+	}
+	if err := cfg.loadFile(path, required); err != nil {
+		return nil, err
+	}
+
+	if err := applyEnv(reflect.ValueOf(cfg).Elem()); err != nil {
+		return nil, err
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "host":
+			cfg.Server.Host = *host
+		case "port":
+			cfg.Server.Port = *port
+		case "env":
+			cfg.App.Environment = *environment
+		case "log-level":
+			cfg.Log.Level = *logLevel
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, nil
+}
+
+// loadFile decodes the TOML file at path over cfg. Unknown keys are refused
+// so typos and leftovers of older layouts do not go unnoticed.
+func (cfg *Config) loadFile(path string, required bool) error {
+	// Tables of an array are decoded over the existing elements, the routes
+	// of the file must replace the default ones instead
+	defaultTimeouts, defaultRoutes := cfg.Server.RouteTimeouts, cfg.RateLimit.Routes
+	cfg.Server.RouteTimeouts, cfg.RateLimit.Routes = nil, nil
+	meta, err := toml.DecodeFile(path, cfg)
+	if !meta.IsDefined("server", "route_timeouts") {
+		cfg.Server.RouteTimeouts = defaultTimeouts
+	}
+	if !meta.IsDefined("rate_limit", "routes") {
+		cfg.RateLimit.Routes = defaultRoutes
+	}
+	if err != nil {
+		if !required && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("read config file: %w", err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return fmt.Errorf("config file %s: unknown keys %s", path, strings.Join(keys, ", "))
+	}
+	return nil
+}
+
+// applyEnv sets the fields of v with an env tag from the environment,
+// recursing into the nested structs. Empty variables are ignored.
+func applyEnv(v reflect.Value) error {
+	var errs []error
+	for i := 0; i < v.NumField(); i++ {
+		field, structField := v.Field(i), v.Type().Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		name := structField.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		value, ok, err := lookupEnv(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := setField(field, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// lookupEnv returns the value of the variable name, or the content of the
+// file named by name_FILE, which keeps secrets out of the environment
+func lookupEnv(name string) (string, bool, error) {
+	value := os.Getenv(name)
+	file := os.Getenv(name + "_FILE")
+	switch {
+	case value != "" && file != "":
+		return "", false, fmt.Errorf("%s and %s_FILE are both set, use one of them", name, name)
+	case file != "":
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return "", false, fmt.Errorf("%s_FILE: %w", name, err)
+		}
+		return strings.TrimRight(string(content), "\r\n"), true, nil
+	}
+	return value, value != "", nil
+}
+
+func setField(field reflect.Value, value string) error {
+	switch field.Interface().(type) {
+	case time.Duration:
+		d, err := parseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	case []string:
+		field.Set(reflect.ValueOf(splitList(value)))
+		return nil
+	case map[string]string:
+		m, err := parseMap(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(m))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not an integer of %d bits", value, field.Type().Bits())
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a positive integer of %d bits", value, field.Type().Bits())
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// parseDuration accepts Go durations like 30s, and bare integers as seconds
+func parseDuration(value string) (time.Duration, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a duration like 30s or 15m", value)
+	}
+	return d, nil
+}
+
+// splitList splits a list separated by commas or spaces
+func splitList(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
+
+// parseMap parses comma separated key=value pairs
+func parseMap(value string) (map[string]string, error) {
+	m := map[string]string{}
+	for _, pair := range splitList(value) {
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%q is not a key=value pair", pair)
+		}
+		m[key] = val
+	}
+	return m, nil
+}
@@ -3,8 +3,9 @@ package config
 import (
 	"log/slog"
 	"os"
-	"strings"
 
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/redact"
 	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
 
 	"github.com/lmittmann/tint"
@@ -14,90 +15,98 @@ type LogConfig struct {
 	Level       slog.Level
 	Environment string
 	Handler     slog.Handler
+	// Levels can be changed while the application runs, see Reload
+	Levels *logging.Levels
 }
 
 // GetLogLevel converts string log level to slog.Level
 func GetLogLevel(level string) slog.Level {
-	switch strings.ToUpper(level) {
-	case "DEBUG":
-		return slog.LevelDebug
-	case "INFO":
-		return slog.LevelInfo
-	case "WARN", "WARNING":
-		return slog.LevelWarn
-	case "ERROR":
-		return slog.LevelError
-	default:
+	parsed, err := logging.ParseLevel(level)
+	if err != nil {
 		return slog.LevelInfo // default to INFO
 	}
+	return parsed
 }
 
-// NewLogConfig creates a new logging configuration based on environment
-func NewLogConfig() *LogConfig {
-	env := os.Getenv("APP_ENVIRONMENT")
-	if env == "" {
-		env = "local"
+// logLevel returns logLevel, or the default level of the environment when
+// it is empty
+func logLevel(env, logLevel string) string {
+	if logLevel != "" {
+		return logLevel
 	}
-
-	logLevel := os.Getenv("LOG_LEVEL")
-	if logLevel == "" {
-		// Set default log levels per environment
-		switch env {
-		case "local", "development":
-			logLevel = "DEBUG"
-		case "testing":
-			logLevel = "INFO"
-		case "staging":
-			logLevel = "WARN"
-		case "production":
-			logLevel = "ERROR"
-		default:
-			logLevel = "INFO"
-		}
+	// Set default log levels per environment
+	switch env {
+	case "local", "development":
+		return "DEBUG"
+	case "testing":
+		return "INFO"
+	case "staging":
+		return "WARN"
+	case "production":
+		return "ERROR"
+	default:
+		return "INFO"
 	}
+}
 
-	level := GetLogLevel(logLevel)
-	handler := createHandler(env, level)
+// NewLogConfig creates a new logging configuration based on environment,
+// an empty cfg.Level picks the default level of the environment. Every
+// record is redacted with rules.
+func NewLogConfig(env string, cfg LoggingConfig, rules *redact.Rules) *LogConfig {
+	level := logLevel(env, cfg.Level)
+	levels := logging.NewLevels(GetLogLevel(level))
+	// Validate has checked the levels of the components
+	_ = levels.Apply(level, cfg.Levels)
+	handler := logging.NewHandler(createHandler(env, rules), levels, cfg.Sampling())
 
 	return &LogConfig{
-		Level:       level,
+		Level:       levels.Level(""),
 		Environment: env,
 		Handler:     handler,
+		Levels:      levels,
 	}
 }
 
-// createHandler creates appropriate log handler based on environment
-func createHandler(env string, level slog.Level) slog.Handler {
+// Reload applies the log levels of cfg, the other settings are kept
+func (c *LogConfig) Reload(cfg *Config) error {
+	return c.Levels.Apply(logLevel(cfg.App.Environment, cfg.Log.Level), cfg.Log.Levels)
+}
+
+// createHandler creates appropriate log handler based on environment,
+// redacting the records with rules. It logs every level, logging.Handler
+// filters the records.
+func createHandler(env string, rules *redact.Rules) slog.Handler {
 	w := os.Stdout
 	opts := &slog.HandlerOptions{
-		Level: level,
+		Level: slog.LevelDebug,
 	}
 
 	switch env {
 	case "local", "development":
 		// Use colored output for local development
-		return tint.NewHandler(w, &tint.Options{
-			Level:       level,
+		return logging.NewRedactingHandler(tint.NewHandler(w, &tint.Options{
+			Level:       slog.LevelDebug,
 			ReplaceAttr: middleware.ColorizeLogging,
-		})
+		}), rules)
 	case "production":
 		// Use JSON handler for production (structured logging)
-		return slog.NewJSONHandler(w, opts)
+		return logging.NewRedactingHandler(slog.NewJSONHandler(w, opts), rules)
 	default:
 		// Use text handler for other environments
-		return slog.NewTextHandler(w, opts)
+		return logging.NewRedactingHandler(slog.NewTextHandler(w, opts), rules)
 	}
 }
 
 // SetupLogging initializes the global logger with the configured handler
-func SetupLogging() *LogConfig {
-	config := NewLogConfig()
+func SetupLogging(cfg *Config) *LogConfig {
+	config := NewLogConfig(cfg.App.Environment, cfg.Log, cfg.Redact.Rules())
 	logger := slog.New(config.Handler)
 	slog.SetDefault(logger)
 
 	slog.Info("Logging configured",
 		slog.String("environment", config.Environment),
 		slog.String("level", config.Level.String()),
+		slog.Any("components", config.Levels.Get().Components),
 	)
 
 	return config
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Environments lists the values of App.Environment
+var Environments = []string{"local", "development", "testing", "staging", "production"}
+
+var logLevels = []string{"", "DEBUG", "INFO", "WARN", "WARNING", "ERROR"}
+
+var rateLimitBackends = []string{"memory", "postgres", "redis"}
+
+// Validate reports every invalid value at once, each one named by its
+// environment variable.
+func (cfg *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, name, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: "+format, append([]any{name}, args...)...))
+		}
+	}
+
+	check(cfg.App.Name != "", "SERVICE_NAME", "must be set")
+	check(slices.Contains(Environments, cfg.App.Environment), "APP_ENVIRONMENT",
+		"%q is not one of %s", cfg.App.Environment, strings.Join(Environments, ", "))
+
+	check(cfg.Server.Port > 0 && cfg.Server.Port <= 65535, "APP_PORT", "%d is not a port", cfg.Server.Port)
+	check(cfg.Server.RequestTimeout > 0, "API_TIMEOUT", "must be positive")
+	for i, route := range cfg.Server.RouteTimeouts {
+		name := fmt.Sprintf("server.route_timeouts[%d]", i)
+		check(strings.HasPrefix(route.Path, "/"), name, "path %q does not start with /", route.Path)
+		check(route.Timeout >= 0, name, "timeout must not be negative")
+	}
+	check(cfg.Server.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT", "must be positive")
+	check(cfg.Server.ShutdownDrainDelay >= 0, "SHUTDOWN_DRAIN_DELAY", "must not be negative")
+	check(len(cfg.Server.CORSAllowOrigins) > 0, "CORS_ALLOW_ORIGINS", "must list at least one origin")
+	for _, proxy := range cfg.Server.TrustedProxies {
+		_, err := parseIPRange(proxy)
+		check(err == nil, "TRUSTED_PROXIES", "%v", err)
+	}
+
+	check(cfg.Database.URL != "", "DATABASE_URL", "must be set")
+	check(cfg.Database.MaxConns >= 0, "DB_MAX_CONNS", "must not be negative")
+	check(cfg.Database.MinConns >= 0, "DB_MIN_CONNS", "must not be negative")
+	check(cfg.Database.MaxConns == 0 || cfg.Database.MinConns <= cfg.Database.MaxConns, "DB_MIN_CONNS",
+		"%d is above DB_MAX_CONNS %d", cfg.Database.MinConns, cfg.Database.MaxConns)
+	check(cfg.Database.MaxConnLifetime >= 0, "DB_MAX_CONN_LIFETIME", "must not be negative")
+	check(cfg.Database.MaxConnIdleTime >= 0, "DB_MAX_CONN_IDLE_TIME", "must not be negative")
+
+	check(slices.Contains(logLevels, strings.ToUpper(cfg.Log.Level)), "LOG_LEVEL",
+		"%q is not one of DEBUG, INFO, WARN, ERROR", cfg.Log.Level)
+	for _, component := range slices.Sorted(maps.Keys(cfg.Log.Levels)) {
+		level := cfg.Log.Levels[component]
+		check(slices.Contains(logging.Components, component), "LOG_LEVELS",
+			"unknown component %q, not one of %s", component, strings.Join(logging.Components, ", "))
+		check(level != "" && slices.Contains(logLevels, strings.ToUpper(level)), "LOG_LEVELS",
+			"%q of %s is not one of DEBUG, INFO, WARN, ERROR", level, component)
+	}
+	check(cfg.Log.SampleInitial >= 0, "LOG_SAMPLE_INITIAL", "must not be negative")
+	check(cfg.Log.SampleThereafter >= 0, "LOG_SAMPLE_THEREAFTER", "must not be negative")
+
+	check(cfg.Telemetry.SampleRate >= 0 && cfg.Telemetry.SampleRate <= 1, "TRACING_SAMPLE_RATE",
+		"%g is not between 0 and 1", cfg.Telemetry.SampleRate)
+	check(!cfg.Telemetry.Enabled || cfg.Telemetry.OTLPEndpoint != "", "OTEL_EXPORTER_OTLP_ENDPOINT",
+		"must be set with ENABLE_INSTRUMENTATION")
+	for i, pattern := range cfg.Redact.Patterns {
+		_, err := regexp.Compile(pattern)
+		check(err == nil, fmt.Sprintf("redact.patterns[%d]", i), "%v", err)
+	}
+	check(cfg.BodyCapture.MaxBytes > 0, "BODY_CAPTURE_MAX_BYTES", "must be positive")
+	for i, route := range cfg.BodyCapture.Routes {
+		name := fmt.Sprintf("body_capture.routes[%d]", i)
+		check(strings.HasPrefix(route.Path, "/"), name, "path %q does not start with /", route.Path)
+		check(route.SampleRate >= 0 && route.SampleRate <= 1, name,
+			"sample_rate %g is not between 0 and 1", route.SampleRate)
+	}
+
+	check(cfg.RateLimit.RequestsPerSecond > 0, "RATE_LIMIT_REQUESTS_PER_SECOND", "must be positive")
+	check(cfg.RateLimit.Burst > 0, "RATE_LIMIT_BURST", "must be positive")
+	check(cfg.RateLimit.IPRequestsPerSecond > 0, "RATE_LIMIT_IP_REQUESTS_PER_SECOND", "must be positive")
+	check(cfg.RateLimit.IPBurst > 0, "RATE_LIMIT_IP_BURST", "must be positive")
+	check(middleware.RateLimitKeys[cfg.RateLimit.Key] != nil, "RATE_LIMIT_KEY",
+		"%q is not principal or ip", cfg.RateLimit.Key)
+	check(slices.Contains(rateLimitBackends, cfg.RateLimit.Backend), "RATE_LIMIT_BACKEND",
+		"%q is not one of %s", cfg.RateLimit.Backend, strings.Join(rateLimitBackends, ", "))
+	check(cfg.RateLimit.Backend != "redis" || cfg.RateLimit.RedisURL != "", "RATE_LIMIT_REDIS_URL",
+		"must be set with RATE_LIMIT_BACKEND=redis")
+	for i, route := range cfg.RateLimit.Routes {
+		name := fmt.Sprintf("rate_limit.routes[%d]", i)
+		check(strings.HasPrefix(route.Path, "/"), name, "path %q does not start with /", route.Path)
+		check(route.RequestsPerSecond > 0, name, "requests_per_second must be positive")
+		check(route.Burst > 0, name, "burst must be positive")
+		check(route.Key == "" || middleware.RateLimitKeys[route.Key] != nil, name,
+			"key %q is not principal or ip", route.Key)
+	}
+
+	check(cfg.Audit.RetentionDays >= 0, "AUDIT_RETENTION_DAYS", "must not be negative")
+	for _, network := range cfg.Webhook.AllowedNetworks {
+		_, err := parseIPRange(network)
+		check(err == nil, "WEBHOOK_ALLOWED_NETWORKS", "%v", err)
+	}
+
+	if cfg.SMTP.Host != "" {
+		check(cfg.SMTP.Port > 0 && cfg.SMTP.Port <= 65535, "SMTP_PORT", "%d is not a port", cfg.SMTP.Port)
+		check(cfg.SMTP.From != "", "SMTP_FROM", "must be set with SMTP_HOST")
+	}
+
+	switch cfg.Password.Hasher {
+	case "bcrypt":
+		check(cfg.Password.BcryptCost >= bcrypt.MinCost && cfg.Password.BcryptCost <= bcrypt.MaxCost, "BCRYPT_COST",
+			"%d is not between %d and %d", cfg.Password.BcryptCost, bcrypt.MinCost, bcrypt.MaxCost)
+	case "argon2id":
+		check(cfg.Password.Argon2MemoryKiB >= 8*1024, "ARGON2_MEMORY_KIB", "must be at least 8192")
+		check(cfg.Password.Argon2Iterations > 0, "ARGON2_ITERATIONS", "must be positive")
+		check(cfg.Password.Argon2Parallelism > 0, "ARGON2_PARALLELISM", "must be positive")
+	default:
+		check(false, "PASSWORD_HASHER", "%q is not argon2id or bcrypt", cfg.Password.Hasher)
+	}
+	check(cfg.Password.MinLength > 0, "PASSWORD_MIN_LENGTH", "must be positive")
+	check(cfg.Password.MinClasses >= 0 && cfg.Password.MinClasses <= 4, "PASSWORD_MIN_CLASSES",
+		"%d is not between 0 and 4", cfg.Password.MinClasses)
+	check(cfg.Password.ResetTTL > 0, "PASSWORD_RESET_TTL", "must be positive")
+
+	check(cfg.Auth.VerificationTTL > 0, "EMAIL_VERIFICATION_TTL", "must be positive")
+	check(cfg.Auth.VerificationResendCooldown >= 0, "EMAIL_VERIFICATION_RESEND_COOLDOWN", "must not be negative")
+	check(cfg.Auth.LoginMaxFailures > 0, "LOGIN_MAX_FAILURES", "must be positive")
+	check(cfg.Auth.LoginMaxIPFailures > 0, "LOGIN_MAX_IP_FAILURES", "must be positive")
+	check(cfg.Auth.LoginLockout > 0, "LOGIN_LOCKOUT", "must be positive")
+	check(cfg.Auth.TOTPIssuer != "", "TOTP_ISSUER", "must be set")
+	check(cfg.Auth.SessionTTL > 0, "SESSION_TTL", "must be positive")
+
+	if cfg.OIDC.Issuer != "" {
+		check(cfg.OIDC.ClientID != "", "OIDC_CLIENT_ID", "must be set with OIDC_ISSUER")
+		check(cfg.OIDC.RedirectURL != "", "OIDC_REDIRECT_URL", "must be set with OIDC_ISSUER")
+		for _, group := range slices.Sorted(maps.Keys(cfg.OIDC.GroupRoles)) {
+			role := cfg.OIDC.GroupRoles[group]
+			check(slices.Contains(domain.Roles, role), "OIDC_GROUP_ROLES", "unknown role %q for group %q", role, group)
+		}
+		check(cfg.OIDC.DefaultRole == "" || slices.Contains(domain.Roles, cfg.OIDC.DefaultRole), "OIDC_DEFAULT_ROLE",
+			"unknown role %q", cfg.OIDC.DefaultRole)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,32 @@
+// Package dbroute picks the database pool of a query: reads go to the read
+// replica unless the context asks for the primary.
+package dbroute
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type primaryKey struct{}
+
+// WithPrimary sends the reads done with ctx to the primary, for callers
+// that must see their own writes before the replica catches up
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// ReadsFromPrimary reports whether WithPrimary was applied to ctx
+func ReadsFromPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+// Reader returns the pool the reads done with ctx go to: the replica,
+// unless there is none or ctx asks for the primary
+func Reader(ctx context.Context, primary, replica *pgxpool.Pool) *pgxpool.Pool {
+	if replica == nil || ReadsFromPrimary(ctx) {
+		return primary
+	}
+	return replica
+}
@@ -0,0 +1,19 @@
+package dbroute_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/database/dbroute"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReader(t *testing.T) {
+	primary, replica := &pgxpool.Pool{}, &pgxpool.Pool{}
+	ctx := context.Background()
+
+	assert.Same(t, replica, dbroute.Reader(ctx, primary, replica), "reads go to the replica")
+	assert.Same(t, primary, dbroute.Reader(ctx, primary, nil), "without replica reads go to the primary")
+	assert.Same(t, primary, dbroute.Reader(dbroute.WithPrimary(ctx), primary, replica), "read your writes")
+}
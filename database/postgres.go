@@ -4,50 +4,96 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"os"
+	"log/slog"
+	"time"
 
 	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
 
 	"github.com/exaring/otelpgx"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/multitracer"
 	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/jackc/pgx/v5/stdlib"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-func SetupPgxPool() (*pgxpool.Pool, error) {
-	dbURL := os.Getenv("DATABASE_URL")
-	if dbURL == "" {
-		return nil, fmt.Errorf("DATABASE_URL environment variable not set")
+// PoolConfig configures a connection pool, zero values keep the pgx
+// defaults
+type PoolConfig struct {
+	// Name tells the pools apart in traces, metrics and logs
+	Name            string
+	URL             string
+	MaxConns        int32
+	MinConns        int32
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+	// Recorder records the count and latency of the queries by repository
+	// method and table when set
+	Recorder QueryRecorder
+}
+
+func SetupPgxPool(cfg PoolConfig) (*pgxpool.Pool, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("database URL is not set")
 	}
+	if cfg.Name == "" {
+		cfg.Name = "primary"
+	}
+	poolName := attribute.String("db.client.connection.pool.name", cfg.Name)
 
-	config, err := pgxpool.ParseConfig(dbURL)
+	config, err := pgxpool.ParseConfig(cfg.URL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse database URL: %w", err)
 	}
+	if cfg.MaxConns > 0 {
+		config.MaxConns = cfg.MaxConns
+	}
+	if cfg.MinConns > 0 {
+		config.MinConns = cfg.MinConns
+	}
+	if cfg.MaxConnLifetime > 0 {
+		config.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		config.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+	// The pool copies the connection config, so the tracer must be set
+	// before it is created
+	var tracer pgx.QueryTracer = otelpgx.NewTracer(otelpgx.WithAttributes(poolName))
+	if cfg.Recorder != nil {
+		tracer = multitracer.New(tracer, newQueryMetricsTracer(cfg.Name, cfg.Recorder))
+	}
+	config.ConnConfig.Tracer = tracer
 
 	dbPool, err := pgxpool.NewWithConfig(context.Background(), config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create connection pool: %w", err)
 	}
-	config.ConnConfig.Tracer = otelpgx.NewTracer()
 
-	if err := otelpgx.RecordStats(dbPool); err != nil {
+	if err := otelpgx.RecordStats(dbPool, otelpgx.WithStatsAttributes(poolName)); err != nil {
+		dbPool.Close()
 		return nil, fmt.Errorf("unable to record database stats: %w", err)
 	}
 
 	ctx := context.Background()
 	if err := dbPool.Ping(ctx); err != nil {
+		dbPool.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	logging.LogInfo(ctx, "Connected to PostgreSQL database")
+	logging.LogInfo(ctx, "Connected to PostgreSQL database",
+		slog.String("pool", cfg.Name),
+		slog.String("host", config.ConnConfig.Host),
+		slog.Int("max_conns", int(config.MaxConns)),
+		slog.Int("min_conns", int(config.MinConns)),
+	)
 
 	return dbPool, nil
 }
 
-func SetupSQLDatabase() (*sql.DB, error) {
-	dbURL := os.Getenv("DATABASE_URL")
+func SetupSQLDatabase(dbURL string) (*sql.DB, error) {
 	if dbURL == "" {
-		return nil, fmt.Errorf("DATABASE_URL environment variable not set")
+		return nil, fmt.Errorf("database URL is not set")
 	}
 
 	db, err := sql.Open("pgx", dbURL)
@@ -0,0 +1,172 @@
+package database
+
+import (
+	"context"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// QueryRecorder records the queries of a pool, method is the function which
+// ran the query, like postgres.NewsRepository.GetNews
+type QueryRecorder interface {
+	RecordDBQuery(ctx context.Context, pool, method, operation, table string, duration time.Duration, success bool)
+}
+
+// queryMetricsTracer times every query, batch and copy of a pool for the
+// recorder. The repository method is found on the stack and the operation
+// and table are read from the SQL, so every repository is covered without
+// instrumenting it.
+type queryMetricsTracer struct {
+	pool       string
+	recorder   QueryRecorder
+	statements sync.Map // SQL to statement
+}
+
+func newQueryMetricsTracer(pool string, recorder QueryRecorder) *queryMetricsTracer {
+	return &queryMetricsTracer{pool: pool, recorder: recorder}
+}
+
+type queryMetricsKey struct{}
+
+// queryStart is kept in the context between the start and the end of a
+// query, and between the queries of a batch
+type queryStart struct {
+	method string
+	sql    string
+	start  time.Time
+}
+
+func (t *queryMetricsTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryMetricsKey{}, &queryStart{method: caller(), sql: data.SQL, start: time.Now()})
+}
+
+func (t *queryMetricsTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	if start, ok := ctx.Value(queryMetricsKey{}).(*queryStart); ok {
+		t.record(ctx, start.method, t.statement(start.sql), time.Since(start.start), data.Err)
+	}
+}
+
+func (t *queryMetricsTracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceBatchStartData) context.Context {
+	return context.WithValue(ctx, queryMetricsKey{}, &queryStart{method: caller(), start: time.Now()})
+}
+
+// TraceBatchQuery times each query of a batch from the end of the previous
+// one, the queries are pipelined
+func (t *queryMetricsTracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	if start, ok := ctx.Value(queryMetricsKey{}).(*queryStart); ok {
+		now := time.Now()
+		t.record(ctx, start.method, t.statement(data.SQL), now.Sub(start.start), data.Err)
+		start.start = now
+	}
+}
+
+func (t *queryMetricsTracer) TraceBatchEnd(context.Context, *pgx.Conn, pgx.TraceBatchEndData) {}
+
+func (t *queryMetricsTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	return context.WithValue(ctx, queryMetricsKey{}, &queryStart{
+		method: caller(),
+		sql:    strings.Join(data.TableName, "."),
+		start:  time.Now(),
+	})
+}
+
+func (t *queryMetricsTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	if start, ok := ctx.Value(queryMetricsKey{}).(*queryStart); ok {
+		t.record(ctx, start.method, statement{operation: "COPY", table: start.sql}, time.Since(start.start), data.Err)
+	}
+}
+
+func (t *queryMetricsTracer) record(ctx context.Context, method string, stmt statement, duration time.Duration, err error) {
+	t.recorder.RecordDBQuery(ctx, t.pool, method, stmt.operation, stmt.table, duration, err == nil)
+}
+
+// statement returns the parsed SQL, the queries of the repositories are
+// constants so they are parsed once
+func (t *queryMetricsTracer) statement(sql string) statement {
+	if stmt, ok := t.statements.Load(sql); ok {
+		return stmt.(statement)
+	}
+	stmt := parseStatement(sql)
+	t.statements.Store(sql, stmt)
+	return stmt
+}
+
+type statement struct {
+	operation string
+	table     string
+}
+
+var (
+	statementKeywords = regexp.MustCompile(`(?i)\(|\)|\b(?:select|insert|update|delete|merge|with)\b`)
+	statementTable    = regexp.MustCompile(`(?i)\b(?:from|into|update|join)\s+("?[a-z_][a-z0-9_."]*)`)
+)
+
+// parseStatement finds the operation and the first table of the main
+// statement of sql, after the common table expressions. They are "unknown"
+// when not found.
+func parseStatement(sql string) statement {
+	stmt := statement{operation: "unknown", table: "unknown"}
+	depth := 0
+	for _, match := range statementKeywords.FindAllStringIndex(sql, -1) {
+		keyword := strings.ToUpper(sql[match[0]:match[1]])
+		switch {
+		case keyword == "(":
+			depth++
+		case keyword == ")":
+			depth--
+		case depth == 0 && keyword != "WITH":
+			stmt.operation = keyword
+			if table := statementTable.FindStringSubmatch(sql[match[0]:]); table != nil {
+				stmt.table = strings.ReplaceAll(table[1], `"`, "")
+			}
+			return stmt
+		}
+	}
+	return stmt
+}
+
+// callerSkipped are the packages between a query and the function running it
+var callerSkipped = []string{
+	"github.com/jackc/pgx/",
+	"github.com/exaring/otelpgx",
+	"github.com/edwinjordan/ZOGTest-Golang.git/database.(*queryMetricsTracer)",
+	"github.com/edwinjordan/ZOGTest-Golang.git/database.caller",
+	"runtime.",
+}
+
+// caller names the function running the query, e.g.
+// postgres.NewsRepository.GetNews for
+// github.com/.../internal/repository/postgres.(*NewsRepository).GetNews
+func caller() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		skipped := false
+		for _, prefix := range callerSkipped {
+			if strings.HasPrefix(frame.Function, prefix) {
+				skipped = true
+				break
+			}
+		}
+		if !skipped && frame.Function != "" {
+			return functionName(frame.Function)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+var closureSuffix = regexp.MustCompile(`(\.func\d+|\.gowrap\d+|\.\d+)+$`)
+
+func functionName(function string) string {
+	name := function[strings.LastIndex(function, "/")+1:]
+	name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+	return closureSuffix.ReplaceAllString(name, "")
+}
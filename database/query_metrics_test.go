@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStatement(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want statement
+	}{
+		{`SELECT id, title FROM news WHERE id = $1`, statement{"SELECT", "news"}},
+		{`
+			INSERT INTO users (name, email) VALUES ($1, $2)
+			ON CONFLICT (email) DO UPDATE SET name = EXCLUDED.name`, statement{"INSERT", "users"}},
+		{`update sessions set last_seen_at = NOW()`, statement{"UPDATE", "sessions"}},
+		{`DELETE FROM rate_limits WHERE tat < $1`, statement{"DELETE", "rate_limits"}},
+		{`
+			WITH updated AS (
+				UPDATE news_topics SET topic_id = $2 WHERE topic_id = $1 RETURNING news_id
+			)
+			SELECT COUNT(*) FROM updated`, statement{"SELECT", "updated"}},
+		{`SELECT COALESCE(MAX(version_id), 0) FROM public."goose_db_version"`, statement{"SELECT", "public.goose_db_version"}},
+		{`;`, statement{"unknown", "unknown"}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, parseStatement(tt.sql), tt.sql)
+	}
+}
+
+func TestFunctionName(t *testing.T) {
+	assert.Equal(t, "postgres.NewsRepository.GetNews",
+		functionName("github.com/edwinjordan/ZOGTest-Golang.git/internal/repository/postgres.(*NewsRepository).GetNews"))
+	assert.Equal(t, "postgres.TopicRepository.BulkSaveTopics",
+		functionName("github.com/edwinjordan/ZOGTest-Golang.git/internal/repository/postgres.(*TopicRepository).BulkSaveTopics.func1.2"))
+	assert.Equal(t, "health.Migrations", functionName("github.com/edwinjordan/ZOGTest-Golang.git/internal/health.Migrations.func1"))
+}
+
+type recordedQuery struct {
+	pool, method, operation, table string
+	success                        bool
+}
+
+type fakeQueryRecorder struct {
+	queries []recordedQuery
+}
+
+func (r *fakeQueryRecorder) RecordDBQuery(_ context.Context, pool, method, operation, table string, _ time.Duration, success bool) {
+	r.queries = append(r.queries, recordedQuery{pool, method, operation, table, success})
+}
+
+// runQuery stands for a repository method
+func runQuery(tracer *queryMetricsTracer, sql string, err error) {
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: sql})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: err})
+}
+
+func TestQueryMetricsTracer(t *testing.T) {
+	recorder := &fakeQueryRecorder{}
+	tracer := newQueryMetricsTracer("replica", recorder)
+
+	runQuery(tracer, `SELECT id FROM topics`, nil)
+	runQuery(tracer, `DELETE FROM topics WHERE id = $1`, pgx.ErrTxClosed)
+	ctx := tracer.TraceCopyFromStart(context.Background(), nil, pgx.TraceCopyFromStartData{TableName: pgx.Identifier{"outbox"}})
+	tracer.TraceCopyFromEnd(ctx, nil, pgx.TraceCopyFromEndData{})
+
+	require.Len(t, recorder.queries, 3)
+	assert.Equal(t, recordedQuery{"replica", "database.runQuery", "SELECT", "topics", true}, recorder.queries[0])
+	assert.Equal(t, recordedQuery{"replica", "database.runQuery", "DELETE", "topics", false}, recorder.queries[1])
+	assert.Equal(t, "COPY", recorder.queries[2].operation)
+	assert.Equal(t, "outbox", recorder.queries[2].table)
+}
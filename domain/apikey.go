@@ -0,0 +1,82 @@
+package domain
+
+import "time"
+
+const (
+	ScopeNewsRead    = "news:read"
+	ScopeNewsWrite   = "news:write"
+	ScopeTopicsRead  = "topics:read"
+	ScopeTopicsWrite = "topics:write"
+)
+
+// APIKeyScopes lists every scope an API key can be granted
+var APIKeyScopes = []string{ScopeNewsRead, ScopeNewsWrite, ScopeTopicsRead, ScopeTopicsWrite}
+
+// PublicScopes are the scopes of the routes anonymous callers may use, the
+// public read API
+var PublicScopes = []string{ScopeNewsRead, ScopeTopicsRead}
+
+// ScopeRoles lists the roles a key owner needs to hold for the key to use a
+// scope, scopes missing from it need no role
+var ScopeRoles = map[string][]string{
+	ScopeNewsWrite:   {RoleEditor, RoleAdmin},
+	ScopeTopicsWrite: {RoleEditor, RoleAdmin},
+}
+
+// APIKeyPrefix starts every API key, it tells keys apart from other bearer tokens
+const APIKeyPrefix = "zog_"
+
+const (
+	// DefaultAPIKeyLifetime applies to keys created without an expiry
+	DefaultAPIKeyLifetime = 90 * 24 * time.Hour
+	// DefaultAPIKeyOverlap is how long a rotated key keeps working
+	DefaultAPIKeyOverlap = 24 * time.Hour
+	// MaxAPIKeyOverlap bounds the overlap window asked for on rotation
+	MaxAPIKeyOverlap = 30 * 24 * time.Hour
+)
+
+type APIKey struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	OwnerID string `json:"owner_id"`
+	// Prefix is the start of the key, enough to recognise it in listings
+	Prefix string   `json:"prefix"`
+	Scopes []string `json:"scopes"`
+	// Key is the plain API key, it is only returned when the key is minted
+	Key          string     `json:"key,omitempty"`
+	RotatedFrom  string     `json:"rotated_from,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+	RequestCount int64      `json:"request_count"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	// Hash is the SHA-256 of the key, the only form of the key that is stored
+	Hash string `json:"-"`
+	// OwnerRole is the current role of the owner, only set when the key is
+	// looked up to authenticate a request
+	OwnerRole string `json:"-"`
+}
+
+// Active reports whether the key is neither revoked nor expired at the given time
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" validate:"required"`
+	Scopes []string `json:"scopes" validate:"required"`
+	// ExpiresAt defaults to DefaultAPIKeyLifetime from now
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type RotateAPIKeyRequest struct {
+	// OverlapSeconds is how long the old key keeps working, DefaultAPIKeyOverlap when zero
+	OverlapSeconds int `json:"overlap_seconds"`
+}
+
+// APIKeyUsage is the usage of a key accumulated since the last flush
+type APIKeyUsage struct {
+	KeyID    string
+	Requests int64
+	LastUsed time.Time
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+	AuditActionMerge  = "merge"
+	AuditActionRotate = "rotate"
+	AuditActionRevoke = "revoke"
+)
+
+const (
+	AuditEntityNews            = "news"
+	AuditEntityTopic           = "topic"
+	AuditEntityUser            = "user"
+	AuditEntityWebhook         = "webhook"
+	AuditEntityAPIKey          = "api_key"
+	AuditEntityTwoFactorPolicy = "two_factor_policy"
+)
+
+// AuditEvent is one recorded change. Before and After only hold the fields
+// that changed, creations have no Before and deletions no After.
+type AuditEvent struct {
+	ID      string `json:"id"`
+	ActorID string `json:"actor_id,omitempty"`
+	// ActorClientID is the partner client making the change, partners have
+	// no user account
+	ActorClientID string `json:"actor_client_id,omitempty"`
+	// AuthMethod tells how the actor authenticated, see the auth.Method
+	// constants
+	AuthMethod string          `json:"auth_method,omitempty"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After      json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	RequestID  string          `json:"request_id,omitempty"`
+	ClientIP   string          `json:"client_ip,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type AuditFilter struct {
+	EntityType string `json:"entity" query:"entity"`
+	EntityID   string `json:"id" query:"id"`
+	ActorID    string `json:"actor" query:"actor"`
+	ClientID   string `json:"client" query:"client"`
+	Action     string `json:"action" query:"action"`
+	PageRequest
+}
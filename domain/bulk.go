@@ -0,0 +1,81 @@
+package domain
+
+// MaxBulkItems is the upper bound of entries accepted by a single bulk request
+const MaxBulkItems = 5000
+
+const (
+	BulkActionCreated = "created"
+	BulkActionUpdated = "updated"
+	BulkActionDeleted = "deleted"
+)
+
+// BulkNewsItem is one entry of a bulk news request. Entries with an ID update
+// the existing news, entries without one create a new news.
+type BulkNewsItem struct {
+	ID      string         `json:"id"`
+	Title   string         `json:"title"`
+	Status  string         `json:"status"`
+	Content string         `json:"content"`
+	Topics  []NewsTopicNew `json:"topics"`
+	// AuthorID is filled from the authenticated caller, never from the payload
+	AuthorID string `json:"-"`
+	// Insert creates the news with ID instead of updating it, imports keep
+	// the ids of an export with it. It is never read from the payload.
+	Insert bool `json:"-"`
+}
+
+type BulkNewsRequest struct {
+	AllOrNothing bool           `json:"all_or_nothing"`
+	Items        []BulkNewsItem `json:"items" validate:"required"`
+}
+
+// BulkTopicItem is one entry of a bulk topic request. Entries with an ID update
+// the existing topic, entries without one create a new topic.
+type BulkTopicItem struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Insert creates the topic with ID instead of updating it, imports keep
+	// the ids of an export with it. It is never read from the payload.
+	Insert bool `json:"-"`
+}
+
+type BulkTopicRequest struct {
+	AllOrNothing bool            `json:"all_or_nothing"`
+	Items        []BulkTopicItem `json:"items" validate:"required"`
+}
+
+type BulkDeleteRequest struct {
+	AllOrNothing bool     `json:"all_or_nothing"`
+	IDs          []string `json:"ids" validate:"required"`
+}
+
+// BulkItemResult reports the outcome of a single bulk entry, Index points
+// back to the position of the entry in the request.
+type BulkItemResult struct {
+	Index  int    `json:"index"`
+	ID     string `json:"id,omitempty"`
+	Action string `json:"action,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Before is the entry as it was before an update or a delete, for the
+	// audit log
+	Before any `json:"-"`
+}
+
+type BulkResult struct {
+	AllOrNothing bool             `json:"all_or_nothing"`
+	Succeeded    int              `json:"succeeded"`
+	Failed       int              `json:"failed"`
+	Items        []BulkItemResult `json:"items"`
+}
+
+// Tally counts the succeeded and failed entries of the result
+func (r *BulkResult) Tally() {
+	r.Succeeded, r.Failed = 0, 0
+	for _, item := range r.Items {
+		if item.Error != "" {
+			r.Failed++
+		} else {
+			r.Succeeded++
+		}
+	}
+}
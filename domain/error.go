@@ -13,4 +13,18 @@ var (
 	ErrBadParamInput = errors.New("given Param is not valid")
 	// ErrUserNotFound
 	ErrUserNotFound = errors.New("user not found")
+	// ErrInvalidCredentials will throw if the given email or password is wrong
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrUnverifiedAccount will throw if a pending user tries to log in
+	ErrUnverifiedAccount = errors.New("email address not verified")
+	// ErrAccountLocked will throw if too many logins failed for an account or client IP
+	ErrAccountLocked = errors.New("too many failed logins")
+	// ErrTwoFactorRequired will throw if a user with two-factor authentication uses a password only login
+	ErrTwoFactorRequired = errors.New("two-factor authentication required")
+	// ErrInvalidToken will throw if a one-time token is unknown, used or expired
+	ErrInvalidToken = errors.New("invalid or expired token")
+	// ErrForbidden will throw if the caller is not allowed to perform the action
+	ErrForbidden = errors.New("forbidden")
+	// ErrBulkAborted will throw if an all-or-nothing bulk operation was rolled back
+	ErrBulkAborted = errors.New("bulk operation aborted")
 )
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventNewsCreated   = "news.created"
+	EventNewsUpdated   = "news.updated"
+	EventNewsPublished = "news.published"
+	EventNewsDeleted   = "news.deleted"
+	EventTopicCreated  = "topic.created"
+	EventTopicUpdated  = "topic.updated"
+	EventTopicDeleted  = "topic.deleted"
+	EventTopicMerged   = "topic.merged"
+)
+
+// EventTypes lists every domain event type
+var EventTypes = []string{
+	EventNewsCreated,
+	EventNewsUpdated,
+	EventNewsPublished,
+	EventNewsDeleted,
+	EventTopicCreated,
+	EventTopicUpdated,
+	EventTopicDeleted,
+	EventTopicMerged,
+}
+
+// Event is a change that downstream systems may react to. Events are written
+// to the outbox together with the change and delivered at least once, the ID
+// stays the same across redeliveries so consumers can drop duplicates.
+type Event struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	Payload       json.RawMessage `json:"payload" swaggertype:"object"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+}
+
+// NewEvent builds an event of the given type carrying payload as JSON
+func NewEvent(eventType, aggregateType, aggregateID string, payload any) (Event, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		ID:            uuid.NewString(),
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       raw,
+		OccurredAt:    time.Now().UTC(),
+	}, nil
+}
+
+// OutboxMessage is an event waiting in the outbox, Attempts counts the
+// deliveries tried so far including the current one. DeliveredSinks names
+// the sinks which accepted the event on a previous attempt.
+type OutboxMessage struct {
+	Event
+	Attempts       int
+	DeliveredSinks []string
+}
+
+type NewsEventPayload struct {
+	ID       string   `json:"id"`
+	Title    string   `json:"title,omitempty"`
+	Slug     string   `json:"slug,omitempty"`
+	Status   string   `json:"status,omitempty"`
+	AuthorID string   `json:"author_id,omitempty"`
+	TopicIDs []string `json:"topic_ids,omitempty"`
+}
+
+type TopicEventPayload struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	Slug string `json:"slug,omitempty"`
+}
+
+type TopicMergedPayload struct {
+	SourceID  string `json:"source_id"`
+	TargetID  string `json:"target_id"`
+	MovedNews int64  `json:"moved_news"`
+}
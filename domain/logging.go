@@ -0,0 +1,16 @@
+package domain
+
+// LogLevels are the levels of the logs, Components lists the components
+// with a level of their own, the others log at Level
+type LogLevels struct {
+	Level      string            `json:"level"`
+	Components map[string]string `json:"components"`
+}
+
+// UpdateLogLevelRequest sets the level of a component (rest, service or
+// repository), or the default level when Component is empty. An empty Level
+// makes the component follow the default level again.
+type UpdateLogLevelRequest struct {
+	Component string `json:"component"`
+	Level     string `json:"level"`
+}
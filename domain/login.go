@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoginThrottle counts the recent failed logins of one account or client IP
+type LoginThrottle struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+// Locked reports whether logins are refused at the given time
+func (t *LoginThrottle) Locked(now time.Time) bool {
+	return t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
+
+// AccountLockedError is returned while an account or client IP is locked
+// out after too many failed logins, it matches ErrAccountLocked.
+type AccountLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *AccountLockedError) Error() string {
+	return fmt.Sprintf("%v, retry in %s", ErrAccountLocked, e.RetryAfter.Round(time.Second))
+}
+
+func (e *AccountLockedError) Unwrap() error {
+	return ErrAccountLocked
+}
+
+type UnlockUserRequest struct {
+	// IP optionally lifts the lockout of a client IP as well
+	IP string `json:"ip"`
+}
@@ -2,16 +2,30 @@ package domain
 
 import "time"
 
+const (
+	NewsStatusDraft     = "draft"
+	NewsStatusPublished = "published"
+)
+
 type News struct {
-	ID        string          `json:"id"`
-	Title     string          `json:"title"`
-	Slug      string          `json:"slug"`
-	Status    string          `json:"status"`
-	Content   string          `json:"content"`
-	CreatedAt time.Time       `json:"created_at"`
-	UpdatedAt time.Time       `json:"updated_at"`
-	Topics    []NewsTopic     `json:"topics"`
-	TopicList []NewsTopicList `json:"topics_list"`
+	ID          string          `json:"id"`
+	Title       string          `json:"title"`
+	Slug        string          `json:"slug"`
+	Status      string          `json:"status"`
+	Content     string          `json:"content"`
+	Author      *AuthorSummary  `json:"author"`
+	CoAuthors   []AuthorSummary `json:"co_authors"`
+	CoAuthorIDs []string        `json:"co_author_ids,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Topics      []NewsTopic     `json:"topics"`
+	TopicList   []NewsTopicList `json:"topics_list"`
+}
+
+// AuthorSummary is the public part of a user embedded in news
+type AuthorSummary struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
 }
 
 type NewsUpdate struct {
@@ -41,11 +55,14 @@ type NewsTopic struct {
 }
 
 type CreateNewsRequest struct {
-	Title   string      `json:"title" validate:"required"`
-	Slug    string      `json:"slug"`
-	Status  string      `json:"status" validate:"required"`
-	Content string      `json:"content" validate:"required"`
-	Topic   []NewsTopic `json:"topics"`
+	Title       string      `json:"title" validate:"required"`
+	Slug        string      `json:"slug"`
+	Status      string      `json:"status" validate:"required"`
+	Content     string      `json:"content" validate:"required"`
+	Topic       []NewsTopic `json:"topics"`
+	CoAuthorIDs []string    `json:"co_author_ids"`
+	// AuthorID is filled from the authenticated caller, never from the payload
+	AuthorID string `json:"-"`
 	//Password string `json:"password" validate:"required,password"`
 }
 type CreateNewsTopicRequest struct {
@@ -57,13 +74,16 @@ type NewsTopicNew struct {
 	TopicId string `json:"topic_id"`
 }
 type UpdateNewsRequest struct {
-	Title   string         `json:"title" validate:"required"`
-	Slug    string         `json:"slug"`
-	Status  string         `json:"status" validate:"required"`
-	Content string         `json:"content" validate:"required"`
-	Topic   []NewsTopicNew `json:"topics"`
+	Title       string         `json:"title" validate:"required"`
+	Slug        string         `json:"slug"`
+	Status      string         `json:"status" validate:"required"`
+	Content     string         `json:"content" validate:"required"`
+	Topic       []NewsTopicNew `json:"topics"`
+	CoAuthorIDs []string       `json:"co_author_ids"`
 }
 
 type NewsFilter struct {
 	Search string `json:"search" query:"search"`
+	// AuthorID limits the list to news written or co-written by the user
+	AuthorID string `json:"author_id" query:"author_id"`
 }
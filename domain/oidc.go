@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+// ExternalIdentity is a user as asserted by an OpenID provider, Role is
+// the local role their groups map to
+type ExternalIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Role          string
+}
+
+// OIDCAuthorization starts a login at the OpenID provider. State goes into
+// a cookie and must come back with the callback.
+type OIDCAuthorization struct {
+	URL       string
+	State     string
+	ExpiresAt time.Time
+}
+
+// OIDCCallbackRequest is what the provider redirects back with, next to the
+// state cookie of the login
+type OIDCCallbackRequest struct {
+	Code        string `query:"code"`
+	State       string `query:"state"`
+	Error       string `query:"error"`
+	StateCookie string `json:"-"`
+}
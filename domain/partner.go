@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// PartnerClient is an external system pushing content through signed requests
+// instead of a user account. Secret is the shared HMAC key of the client,
+// Scopes restrict what it may do like the scopes of an API key.
+type PartnerClient struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
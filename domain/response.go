@@ -21,3 +21,47 @@ type ResponseMultipleData[Data any] struct {
 }
 
 type Empty struct{}
+
+const (
+	DefaultPerPage = 50
+	MaxPerPage     = 200
+)
+
+// PageRequest is the pagination requested through the page and per_page
+// query parameters
+type PageRequest struct {
+	Page    int `json:"page" query:"page"`
+	PerPage int `json:"per_page" query:"per_page"`
+}
+
+// Normalize clamps the pagination to sane bounds
+func (p *PageRequest) Normalize() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.PerPage < 1 {
+		p.PerPage = DefaultPerPage
+	}
+	if p.PerPage > MaxPerPage {
+		p.PerPage = MaxPerPage
+	}
+}
+
+// Offset is the number of rows skipped before the requested page
+func (p *PageRequest) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+type Pagination struct {
+	Page    int `json:"page"`
+	PerPage int `json:"per_page"`
+	Total   int `json:"total"`
+}
+
+type ResponsePaginatedData[Data any] struct {
+	Code    int        `json:"code"`    // number
+	Status  string     `json:"status"`  // string
+	Data    []Data     `json:"data"`    // list of data
+	Meta    Pagination `json:"meta"`    // pagination
+	Message string     `json:"message"` // string
+}
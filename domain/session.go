@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+// SessionTokenPrefix starts every session token, it tells them apart from
+// API keys in the Authorization header
+const SessionTokenPrefix = "zogs_"
+
+// Session is a login on one device. IP and UserAgent are those of the
+// login, LastSeenAt and LastSeenIP those of the latest request.
+type Session struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	LastSeenIP string     `json:"last_seen_ip"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// Current marks the session the request was made with
+	Current bool `json:"current"`
+	// TokenHash is the only form of the token that is stored
+	TokenHash string `json:"-"`
+}
+
+// RevokedSessions reports how many sessions a revocation ended
+type RevokedSessions struct {
+	Revoked int64 `json:"revoked"`
+}
+
+// SessionCredentials pairs a live session with its user for authentication
+type SessionCredentials struct {
+	Session           Session
+	User              User
+	TwoFactorEnabled  bool
+	TwoFactorRequired bool
+}
+
+// SessionToken is returned once when a session starts, send it as
+// "Authorization: Bearer <token>"
+type SessionToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
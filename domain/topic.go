@@ -26,3 +26,7 @@ type UpdateTopicRequest struct {
 type TopicFilter struct {
 	Search string `json:"search" query:"search"`
 }
+
+type MergeTopicRequest struct {
+	TargetID string `json:"target_id" validate:"required"`
+}
@@ -0,0 +1,71 @@
+package domain
+
+import "time"
+
+// TwoFactor is the second factor state of a user
+type TwoFactor struct {
+	User User
+	// Secret is the TOTP secret, set as soon as setup starts
+	Secret string
+	// EnabledAt is nil until the user confirmed the secret with a code
+	EnabledAt *time.Time
+	// LastStep is the TOTP time step of the last accepted code
+	LastStep int64
+}
+
+// Enabled reports whether logins of the user need a second factor
+func (t *TwoFactor) Enabled() bool {
+	return t.EnabledAt != nil
+}
+
+// TwoFactorPolicy tells whether the users of a role must use two-factor
+// authentication
+type TwoFactorPolicy struct {
+	Role      string    `json:"role"`
+	Required  bool      `json:"required"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type UpdateTwoFactorPolicyRequest struct {
+	Required bool `json:"required"`
+}
+
+// TwoFactorSetup is handed to the user to enroll an authenticator app
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	// URI is the otpauth URI, usually rendered as a QR code
+	URI string `json:"uri"`
+}
+
+type VerifyTwoFactorRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// RecoveryCodes are shown once when two-factor authentication is enabled,
+// each of them replaces a TOTP code for a single login
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	// Code is a TOTP code or one of the recovery codes
+	Code string `json:"code" validate:"required"`
+}
+
+// LoginResult holds either a session, when the login is complete, or a
+// challenge to answer with a second factor
+type LoginResult struct {
+	Session   *SessionToken   `json:"session,omitempty"`
+	Challenge *LoginChallenge `json:"challenge,omitempty"`
+}
+
+type LoginChallenge struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -4,12 +4,49 @@ import (
 	"time"
 )
 
+const (
+	RoleUser = "user"
+	// RoleEditor is held by the staff publishing the news
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// Roles lists every role a user can hold, from the least to the most
+// privileged
+var Roles = []string{RoleUser, RoleEditor, RoleAdmin}
+
+const (
+	// UserStatusPending users have not verified their email and cannot log in
+	UserStatusPending = "pending"
+	UserStatusActive  = "active"
+)
+
 type User struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	Status string `json:"status"`
+	// EmailVerifiedAt is nil until the user follows the verification link
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// Active reports whether the user verified their email
+func (u *User) Active() bool {
+	return u.Status == UserStatusActive
+}
+
+// UserCredentials pairs a user with its password hash for authentication
+type UserCredentials struct {
+	User         User
+	PasswordHash string
+	// TwoFactorEnabled users must answer a second factor challenge
+	TwoFactorEnabled bool
+	// TwoFactorRequired is set when the role of the user requires two-factor
+	// authentication
+	TwoFactorRequired bool
 }
 
 type CreateUserRequest struct {
@@ -23,6 +60,24 @@ type UpdateUserRequest struct {
 	Email string `json:"email" validate:"required,email"`
 }
 
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
 type UserFilter struct {
 	Search string `json:"search" query:"search"`
 }
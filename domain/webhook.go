@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	// WebhookDeliveryDead is the dead-letter state of deliveries that ran out
+	// of attempts, they are only retried through a manual redelivery
+	WebhookDeliveryDead = "dead"
+)
+
+// WebhookAllEvents subscribes a webhook to every event type
+const WebhookAllEvents = "*"
+
+type Webhook struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	// Secret signs the deliveries, it is only returned when the webhook is created
+	Secret    string    `json:"secret,omitempty"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" validate:"required,url"`
+	EventTypes []string `json:"event_types" validate:"required"`
+	// Secret is generated when left empty
+	Secret string `json:"secret"`
+}
+
+// WebhookDelivery is one event sent, or to be sent, to one webhook
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	WebhookID      string          `json:"webhook_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	// URL and Secret of the webhook, only loaded for sending
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+type WebhookDeliveryFilter struct {
+	Status string `json:"status" query:"status"`
+	PageRequest
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+type contextKey struct{}
+
+const (
+	MethodBasic     = "basic"
+	MethodSignature = "signature"
+	MethodAPIKey    = "api_key"
+	MethodSession   = "session"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	UserID string
+	// ClientID identifies partner systems, which have no user account
+	ClientID string
+	// KeyID is the API key the caller authenticated with
+	KeyID string
+	Name  string
+	Email string
+	Role  string
+	// Scopes restrict what API key and partner callers may do, other callers
+	// have none
+	Scopes []string
+	// Method tells how the caller authenticated, see the Method constants
+	Method string
+	// SessionID is the session of callers authenticated with a session token
+	SessionID string
+	// TwoFactorSetupRequired is set for users whose role requires two-factor
+	// authentication but who did not set it up yet
+	TwoFactorSetupRequired bool
+}
+
+// WithPrincipal stores the authenticated caller in the context
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// HasRole reports whether the caller holds one of the given roles
+func (p *Principal) HasRole(roles ...string) bool {
+	if p == nil {
+		return false
+	}
+	for _, role := range roles {
+		if p.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope reports whether an API key or partner caller was granted the
+// scope. Callers authenticated any other way are not restricted by scopes.
+func (p *Principal) HasScope(scope string) bool {
+	if p == nil {
+		return false
+	}
+	if p.Method != MethodAPIKey && p.Method != MethodSignature {
+		return true
+	}
+	return slices.Contains(p.Scopes, scope)
+}
+
+// FromContext returns the authenticated caller or nil for anonymous requests
+func FromContext(ctx context.Context) *Principal {
+	if p, ok := ctx.Value(contextKey{}).(*Principal); ok {
+		return p
+	}
+	return nil
+}
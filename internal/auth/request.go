@@ -0,0 +1,24 @@
+package auth
+
+import "context"
+
+type requestKey struct{}
+
+// Request describes the HTTP request a call is made for, the services record
+// it on sessions and audit events and throttle logins by client IP
+type Request struct {
+	ID        string
+	ClientIP  string
+	UserAgent string
+}
+
+// WithRequest stores the request metadata in the context
+func WithRequest(ctx context.Context, r Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, r)
+}
+
+// RequestFromContext returns the request metadata, empty outside of a request
+func RequestFromContext(ctx context.Context) Request {
+	r, _ := ctx.Value(requestKey{}).(Request)
+	return r
+}
@@ -0,0 +1,122 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+)
+
+// The sinks receive every event at least once, see service.EventSink: an
+// event is not delivered again to a sink which accepted it, unless the relay
+// stops before recording it.
+
+// LogSink writes every event to the application log
+type LogSink struct{}
+
+func NewLogSink() *LogSink {
+	return &LogSink{}
+}
+
+func (s *LogSink) Name() string {
+	return "log"
+}
+
+func (s *LogSink) Deliver(ctx context.Context, event domain.Event) error {
+	logging.LogBusinessEvent(ctx, event.Type, event.AggregateType, event.AggregateID,
+		slog.String("event_id", event.ID),
+		slog.Time("occurred_at", event.OccurredAt),
+	)
+	return nil
+}
+
+// HTTPSink posts every event as JSON to a single URL, any non 2xx answer is
+// a failed delivery.
+type HTTPSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewHTTPSink(url string, timeout time.Duration) *HTTPSink {
+	return &HTTPSink{URL: url, Client: &http.Client{Timeout: timeout}}
+}
+
+func (s *HTTPSink) Name() string {
+	return "http"
+}
+
+func (s *HTTPSink) Deliver(ctx context.Context, event domain.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", event.ID)
+	req.Header.Set("X-Event-Type", event.Type)
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Handler reacts to an event delivered in process
+type Handler func(ctx context.Context, event domain.Event) error
+
+// AllEvents subscribes a handler to every event type
+const AllEvents = "*"
+
+// Bus hands events to the in-process subscribers of their type
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe registers handler for eventType, or for every event with AllEvents
+func (b *Bus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+func (b *Bus) Name() string {
+	return "bus"
+}
+
+// Deliver runs every matching handler, the delivery fails if any of them does
+func (b *Bus) Deliver(ctx context.Context, event domain.Event) error {
+	b.mu.RLock()
+	handlers := append(append([]Handler(nil), b.handlers[event.Type]...), b.handlers[AllEvents]...)
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Postgres pings the database of pool
+func Postgres(name string, pool *pgxpool.Pool) HealthChecker {
+	return CheckerFunc(name, pool.Ping)
+}
+
+// Migrations checks that the goose migrations applied to the database of
+// pool reached expected, the version the binary was built with
+func Migrations(pool *pgxpool.Pool, expected int64) HealthChecker {
+	return CheckerFunc("migrations", func(ctx context.Context) error {
+		var version int64
+		err := pool.QueryRow(ctx, `SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied`).Scan(&version)
+		if err != nil {
+			return fmt.Errorf("read migration version: %w", err)
+		}
+		if version < expected {
+			return fmt.Errorf("database is at version %d, migrations up to %d are pending", version, expected)
+		}
+		return nil
+	})
+}
+
+// ExportStatus remembers the outcome of the last export of a telemetry
+// exporter, the exporters record into it
+type ExportStatus struct {
+	name string
+	mu   sync.Mutex
+	err  error
+	at   time.Time
+}
+
+func NewExportStatus(name string) *ExportStatus {
+	return &ExportStatus{name: name}
+}
+
+// Record stores the outcome of an export
+func (s *ExportStatus) Record(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err, s.at = err, time.Now()
+}
+
+func (s *ExportStatus) Name() string {
+	return s.name
+}
+
+// Check fails while the last export failed, nothing exported yet counts as
+// healthy
+func (s *ExportStatus) Check(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return fmt.Errorf("last export at %s failed: %w", s.at.Format(time.RFC3339), s.err)
+	}
+	return nil
+}
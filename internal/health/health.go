@@ -0,0 +1,164 @@
+// Package health runs the checks behind the liveness and readiness
+// endpoints.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp = "up"
+	// StatusDegraded means an optional dependency is down, the application
+	// still serves requests
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+// ErrShuttingDown fails the readiness once the shutdown started
+var ErrShuttingDown = errors.New("shutting down")
+
+// HealthChecker checks a dependency of the application
+type HealthChecker interface {
+	// Name identifies the check in the report
+	Name() string
+	// Check returns nil when the dependency is usable
+	Check(ctx context.Context) error
+}
+
+type checkerFunc struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+func (c checkerFunc) Name() string                    { return c.name }
+func (c checkerFunc) Check(ctx context.Context) error { return c.check(ctx) }
+
+// CheckerFunc turns check into a HealthChecker called name
+func CheckerFunc(name string, check func(ctx context.Context) error) HealthChecker {
+	return checkerFunc{name: name, check: check}
+}
+
+type CheckResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Optional checks only degrade the status of the report
+	Optional  bool    `json:"optional,omitempty"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks,omitempty"`
+}
+
+// Ready reports whether the report allows serving traffic
+func (r *Report) Ready() bool {
+	return r.Status != StatusDown
+}
+
+type registered struct {
+	checker  HealthChecker
+	optional bool
+}
+
+// Health runs the registered checks. It is safe for concurrent use.
+type Health struct {
+	// Timeout bounds each check
+	timeout      time.Duration
+	mu           sync.RWMutex
+	checkers     []registered
+	shuttingDown atomic.Bool
+}
+
+func New(timeout time.Duration) *Health {
+	return &Health{timeout: timeout}
+}
+
+// Register adds a check the readiness depends on
+func (h *Health) Register(c HealthChecker) {
+	h.register(c, false)
+}
+
+// RegisterOptional adds a check which only degrades the readiness, for
+// dependencies the application can serve requests without
+func (h *Health) RegisterOptional(c HealthChecker) {
+	h.register(c, true)
+}
+
+func (h *Health) register(c HealthChecker, optional bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checkers = append(h.checkers, registered{checker: c, optional: optional})
+}
+
+// ShutDown fails the readiness from now on, so load balancers stop sending
+// requests before the server stops accepting them
+func (h *Health) ShutDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Live reports that the process is running, without checking dependencies
+func (h *Health) Live(ctx context.Context) *Report {
+	return &Report{Status: StatusUp}
+}
+
+// Ready runs every check concurrently and reports the dependencies
+func (h *Health) Ready(ctx context.Context) *Report {
+	h.mu.RLock()
+	checkers := append([]registered(nil), h.checkers...)
+	h.mu.RUnlock()
+
+	report := &Report{Status: StatusUp, Checks: make([]CheckResult, len(checkers))}
+	var wg sync.WaitGroup
+	for i, c := range checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = h.run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		switch {
+		case result.Status == StatusUp:
+		case !result.Optional:
+			report.Status = StatusDown
+		case report.Status == StatusUp:
+			report.Status = StatusDegraded
+		}
+	}
+
+	if h.shuttingDown.Load() {
+		report.Status = StatusDown
+		report.Checks = append(report.Checks, CheckResult{Name: "shutdown", Status: StatusDown, Error: ErrShuttingDown.Error()})
+	}
+	return report
+}
+
+func (h *Health) run(ctx context.Context, c registered) CheckResult {
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := c.checker.Check(ctx)
+	result := CheckResult{
+		Name:      c.checker.Name(),
+		Status:    StatusUp,
+		Optional:  c.optional,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
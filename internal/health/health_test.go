@@ -0,0 +1,91 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	up   = health.CheckerFunc("up", func(context.Context) error { return nil })
+	down = health.CheckerFunc("down", func(context.Context) error { return errors.New("connection refused") })
+	slow = health.CheckerFunc("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+)
+
+func TestHealth_Ready(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		required []health.HealthChecker
+		optional []health.HealthChecker
+		status   string
+	}{
+		{"Up without checks", nil, nil, health.StatusUp},
+		{"Up", []health.HealthChecker{up}, []health.HealthChecker{up}, health.StatusUp},
+		{"Degraded by an optional check", []health.HealthChecker{up}, []health.HealthChecker{down}, health.StatusDegraded},
+		{"Down by a required check", []health.HealthChecker{down}, []health.HealthChecker{down}, health.StatusDown},
+		{"Down by a timeout", []health.HealthChecker{slow}, nil, health.StatusDown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := health.New(10 * time.Millisecond)
+			for _, c := range tt.required {
+				h.Register(c)
+			}
+			for _, c := range tt.optional {
+				h.RegisterOptional(c)
+			}
+
+			report := h.Ready(ctx)
+
+			assert.Equal(t, tt.status, report.Status)
+			assert.Equal(t, tt.status != health.StatusDown, report.Ready())
+			assert.Len(t, report.Checks, len(tt.required)+len(tt.optional))
+		})
+	}
+}
+
+func TestHealth_ReportsEachCheck(t *testing.T) {
+	h := health.New(time.Second)
+	h.Register(up)
+	h.RegisterOptional(down)
+
+	report := h.Ready(context.Background())
+
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, health.CheckResult{Name: "up", Status: health.StatusUp, LatencyMS: report.Checks[0].LatencyMS}, report.Checks[0])
+	assert.Equal(t, "down", report.Checks[1].Name)
+	assert.True(t, report.Checks[1].Optional)
+	assert.Equal(t, "connection refused", report.Checks[1].Error)
+}
+
+func TestHealth_ShutDown(t *testing.T) {
+	h := health.New(time.Second)
+	h.Register(up)
+
+	h.ShutDown()
+
+	assert.False(t, h.Ready(context.Background()).Ready(), "readiness fails while draining")
+	assert.True(t, h.Live(context.Background()).Ready(), "the process is still alive")
+}
+
+func TestExportStatus(t *testing.T) {
+	ctx := context.Background()
+	status := health.NewExportStatus("otlp_traces")
+	assert.NoError(t, status.Check(ctx), "nothing exported yet")
+
+	status.Record(errors.New("collector unavailable"))
+	assert.ErrorContains(t, status.Check(ctx), "collector unavailable")
+
+	status.Record(nil)
+	assert.NoError(t, status.Check(ctx), "recovers with the next export")
+}
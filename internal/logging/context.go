@@ -3,15 +3,16 @@ package logging
 import (
 	"context"
 	"log/slog"
+	"runtime"
+	"time"
 
-	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
 )
 
 // TopicInfo holds Topic context information for logging
 type TopicInfo struct {
 	ID        string `json:"Topic_id,omitempty"`
 	Topicname string `json:"Topicname,omitempty"`
-	Email     string `json:"email,omitempty"`
 	Role      string `json:"role,omitempty"`
 }
 
@@ -32,16 +33,31 @@ func GetTopicInfo(ctx context.Context) *TopicInfo {
 	return nil
 }
 
-// NewContextualLogger creates a logger with request ID and Topic context
+// NewContextualLogger creates a logger with request ID, caller and Topic context
 func NewContextualLogger(ctx context.Context) *slog.Logger {
 	logger := slog.Default()
 
 	// Add request ID if available
-	requestID := middleware.GetRequestID(ctx)
+	requestID := auth.RequestFromContext(ctx).ID
 	if requestID != "" {
 		logger = logger.With(slog.String("request_id", requestID))
 	}
 
+	// Add the authenticated user or partner client if available
+	if principal := auth.FromContext(ctx); principal != nil {
+		attrs := []any{slog.String("auth_method", principal.Method)}
+		if principal.UserID != "" {
+			attrs = append(attrs, slog.String("user_id", principal.UserID))
+		}
+		if principal.KeyID != "" {
+			attrs = append(attrs, slog.String("api_key_id", principal.KeyID))
+		}
+		if principal.ClientID != "" {
+			attrs = append(attrs, slog.String("client_id", principal.ClientID), slog.String("client_name", principal.Name))
+		}
+		logger = logger.With(attrs...)
+	}
+
 	// Add Topic information if available
 	TopicInfo := GetTopicInfo(ctx)
 	if TopicInfo != nil {
@@ -52,9 +68,6 @@ func NewContextualLogger(ctx context.Context) *slog.Logger {
 		if TopicInfo.Topicname != "" {
 			attrs = append(attrs, slog.String("Topicname", TopicInfo.Topicname))
 		}
-		if TopicInfo.Email != "" {
-			attrs = append(attrs, slog.String("Topic_email", TopicInfo.Email))
-		}
 		if TopicInfo.Role != "" {
 			attrs = append(attrs, slog.String("Topic_role", TopicInfo.Role))
 		}
@@ -66,6 +79,20 @@ func NewContextualLogger(ctx context.Context) *slog.Logger {
 	return logger
 }
 
+// log logs with the caller of the logging function as the source of the
+// record, which the level of its component applies to
+func log(ctx context.Context, logger *slog.Logger, level slog.Level, message string, args ...any) {
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	// Skips runtime.Callers, log and the logging function
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), level, message, pcs[0])
+	r.Add(args...)
+	_ = logger.Handler().Handle(ctx, r)
+}
+
 // LoggerWithFields creates a logger with additional fields
 func LoggerWithFields(ctx context.Context, fields ...any) *slog.Logger {
 	logger := NewContextualLogger(ctx)
@@ -80,12 +107,18 @@ func LogSecurityEvent(ctx context.Context, event string, details ...any) {
 	logger := NewContextualLogger(ctx)
 	args := []any{slog.String("security_event", event)}
 	args = append(args, details...)
-	logger.Warn("Security Event", args...)
+	log(ctx, logger, slog.LevelWarn, "Security Event", args...)
 }
 
+// LogAuthAttempt logs failed attempts as warnings, so the sampling never
+// drops them while someone is guessing passwords
 func LogAuthAttempt(ctx context.Context, Topicname string, success bool, reason string) {
 	logger := NewContextualLogger(ctx)
-	logger.Info("Authentication Attempt",
+	level := slog.LevelInfo
+	if !success {
+		level = slog.LevelWarn
+	}
+	log(ctx, logger, level, "Authentication Attempt",
 		slog.String("Topicname", Topicname),
 		slog.Bool("success", success),
 		slog.String("reason", reason),
@@ -94,7 +127,7 @@ func LogAuthAttempt(ctx context.Context, Topicname string, success bool, reason
 
 func LogDataAccess(ctx context.Context, resource string, action string, result string) {
 	logger := NewContextualLogger(ctx)
-	logger.Info("Data Access",
+	log(ctx, logger, slog.LevelInfo, "Data Access",
 		slog.String("resource", resource),
 		slog.String("action", action),
 		slog.String("result", result),
@@ -109,7 +142,7 @@ func LogPerformance(ctx context.Context, operation string, duration int64, metad
 		slog.Int64("duration_ms", duration),
 	}
 	args = append(args, metadata...)
-	logger.Info("Performance Metric", args...)
+	log(ctx, logger, slog.LevelInfo, "Performance Metric", args...)
 }
 
 // Business logic logging functions
@@ -121,25 +154,25 @@ func LogBusinessEvent(ctx context.Context, event string, entityType string, enti
 		slog.String("entity_id", entityID),
 	}
 	args = append(args, details...)
-	logger.Info("Business Event", args...)
+	log(ctx, logger, slog.LevelInfo, "Business Event", args...)
 }
 
 // General info logging with context
 func LogInfo(ctx context.Context, message string, details ...any) {
 	logger := NewContextualLogger(ctx)
-	logger.Info(message, details...)
+	log(ctx, logger, slog.LevelInfo, message, details...)
 }
 
 // General warn logging with context
 func LogWarn(ctx context.Context, message string, details ...any) {
 	logger := NewContextualLogger(ctx)
-	logger.Info(message, details...)
+	log(ctx, logger, slog.LevelWarn, message, details...)
 }
 
 // Error message logging with context (when you have a message but no error object)
 func LogErrorMessage(ctx context.Context, message string, details ...any) {
 	logger := NewContextualLogger(ctx)
-	logger.Error(message, details...)
+	log(ctx, logger, slog.LevelError, message, details...)
 }
 
 // Error logging with context
@@ -150,7 +183,7 @@ func LogError(ctx context.Context, err error, operation string, details ...any)
 		slog.String("operation", operation),
 	}
 	args = append(args, details...)
-	logger.Error("Operation Failed", args...)
+	log(ctx, logger, slog.LevelError, "Operation Failed", args...)
 }
 
 func LogErrorWithStackTrace(ctx context.Context, err error, operation string, stackTrace string, details ...any) {
@@ -161,5 +194,5 @@ func LogErrorWithStackTrace(ctx context.Context, err error, operation string, st
 		slog.String("stack_trace", stackTrace),
 	}
 	args = append(args, details...)
-	logger.Error("Operation Failed with Stack Trace", args...)
+	log(ctx, logger, slog.LevelError, "Operation Failed with Stack Trace", args...)
 }
@@ -0,0 +1,140 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const modulePath = "github.com/edwinjordan/ZOGTest-Golang.git/"
+
+// componentPackages are the packages of each component, with their
+// subpackages
+var componentPackages = map[string][]string{
+	ComponentREST:       {modulePath + "internal/rest"},
+	ComponentService:    {modulePath + "service"},
+	ComponentRepository: {modulePath + "internal/repository", modulePath + "database"},
+}
+
+// SamplingConfig keeps the logs below WARN from flooding the output: every
+// Tick, the first Initial records of a message are logged, and then one of
+// every Thereafter.
+type SamplingConfig struct {
+	Tick       time.Duration
+	Initial    int
+	Thereafter int
+}
+
+// Handler filters the records of next by the level of the component which
+// logged them, samples them and adds the IDs of the trace and the span of
+// their context, which the traces in Jaeger can be searched by.
+type Handler struct {
+	next    slog.Handler
+	levels  *Levels
+	sampler *sampler
+}
+
+// NewHandler wraps next, which should accept every level. Sampling is
+// disabled when sampling.Initial is zero.
+func NewHandler(next slog.Handler, levels *Levels, sampling SamplingConfig) *Handler {
+	h := &Handler{next: next, levels: levels}
+	if sampling.Initial > 0 && sampling.Tick > 0 {
+		h.sampler = &sampler{config: sampling}
+	}
+	return h
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.levels.minimum() && h.next.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < h.levels.Level(component(r.PC)) {
+		return nil
+	}
+	if r.Level < slog.LevelWarn && h.sampler != nil && !h.sampler.keep(r.Level, r.Message, r.Time) {
+		return nil
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		r = r.Clone()
+		r.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{next: h.next.WithAttrs(attrs), levels: h.levels, sampler: h.sampler}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name), levels: h.levels, sampler: h.sampler}
+}
+
+// components caches the component of the functions logging, by program
+// counter
+var components sync.Map
+
+// component returns the component of the function at pc, or "" when it is
+// part of none
+func component(pc uintptr) string {
+	if pc == 0 {
+		return ""
+	}
+	if c, ok := components.Load(pc); ok {
+		return c.(string)
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	c := ""
+	for name, packages := range componentPackages {
+		for _, pkg := range packages {
+			if rest, ok := strings.CutPrefix(frame.Function, pkg); ok && rest != "" && (rest[0] == '.' || rest[0] == '/') {
+				c = name
+			}
+		}
+	}
+	components.Store(pc, c)
+	return c
+}
+
+// sampler counts the records of each level and message during a tick
+type sampler struct {
+	config   SamplingConfig
+	counters sync.Map // samplingKey to *samplingCounter
+}
+
+type samplingKey struct {
+	level   slog.Level
+	message string
+}
+
+type samplingCounter struct {
+	tick  atomic.Int64
+	count atomic.Int64
+}
+
+func (s *sampler) keep(level slog.Level, message string, t time.Time) bool {
+	value, ok := s.counters.Load(samplingKey{level, message})
+	if !ok {
+		value, _ = s.counters.LoadOrStore(samplingKey{level, message}, new(samplingCounter))
+	}
+	counter := value.(*samplingCounter)
+
+	tick := t.UnixNano() / int64(s.config.Tick)
+	if last := counter.tick.Load(); last != tick && counter.tick.CompareAndSwap(last, tick) {
+		counter.count.Store(0)
+	}
+	n := counter.count.Add(1)
+	if n <= int64(s.config.Initial) {
+		return true
+	}
+	return s.config.Thereafter > 0 && (n-int64(s.config.Initial))%int64(s.config.Thereafter) == 0
+}
@@ -0,0 +1,137 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTestLogger logs JSON records to the returned buffer
+func newTestLogger(t *testing.T, levels *Levels, sampling SamplingConfig) (*slog.Logger, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	base := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug, AddSource: true})
+	return slog.New(NewHandler(base, levels, sampling)), &buf
+}
+
+// setDefault makes logger the default one for the test, the helpers log
+// with it
+func setDefault(t *testing.T, logger *slog.Logger) {
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(previous) })
+}
+
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for line := range strings.Lines(buf.String()) {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		out = append(out, record)
+	}
+	return out
+}
+
+func TestHandler_ComponentLevels(t *testing.T) {
+	// The records logged by this package count as the service component
+	saved := componentPackages
+	componentPackages = map[string][]string{ComponentService: {modulePath + "internal/logging"}}
+	components.Clear()
+	t.Cleanup(func() {
+		componentPackages = saved
+		components.Clear()
+	})
+
+	levels := NewLevels(slog.LevelWarn)
+	logger, buf := newTestLogger(t, levels, SamplingConfig{})
+	setDefault(t, logger)
+
+	logger.Debug("hidden")
+	require.NoError(t, levels.Set(ComponentService, "DEBUG"))
+	logger.Debug("shown")
+	LogInfo(context.Background(), "shown by a helper")
+	require.NoError(t, levels.Set(ComponentService, ""))
+	logger.Info("hidden again")
+
+	logged := records(t, buf)
+	require.Len(t, logged, 2)
+	assert.Equal(t, "shown", logged[0]["msg"])
+	assert.Equal(t, "shown by a helper", logged[1]["msg"])
+	assert.Equal(t, modulePath+"internal/logging.TestHandler_ComponentLevels",
+		logged[1]["source"].(map[string]any)["function"], "the helpers log with the source of their caller")
+}
+
+func TestHandler_Sampling(t *testing.T) {
+	logger, buf := newTestLogger(t, NewLevels(slog.LevelInfo), SamplingConfig{Tick: time.Hour, Initial: 2, Thereafter: 3})
+
+	for i := range 8 {
+		logger.Info("HTTP Request", slog.Int("i", i))
+		logger.Warn("Slow request", slog.Int("i", i))
+	}
+	logger.Info("Other message")
+
+	var info, warn []float64
+	for _, record := range records(t, buf) {
+		switch record["msg"] {
+		case "HTTP Request":
+			info = append(info, record["i"].(float64))
+		case "Slow request":
+			warn = append(warn, record["i"].(float64))
+		}
+	}
+	assert.Equal(t, []float64{0, 1, 4, 7}, info, "the first 2 records, then one of every 3")
+	assert.Len(t, warn, 8, "warnings are not sampled")
+	assert.Contains(t, buf.String(), "Other message", "messages are sampled apart")
+}
+
+func TestLogAuthAttempt_FailuresNotSampled(t *testing.T) {
+	logger, buf := newTestLogger(t, NewLevels(slog.LevelInfo), SamplingConfig{Tick: time.Hour, Initial: 1, Thereafter: 100})
+	setDefault(t, logger)
+
+	for range 5 {
+		LogAuthAttempt(context.Background(), "jane@example.com", false, "invalid credentials")
+	}
+
+	logged := records(t, buf)
+	require.Len(t, logged, 5, "every failure is kept")
+	assert.Equal(t, "WARN", logged[0]["level"])
+}
+
+func TestHandler_TraceIDs(t *testing.T) {
+	logger, buf := newTestLogger(t, NewLevels(slog.LevelInfo), SamplingConfig{})
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+
+	logger.InfoContext(ctx, "traced")
+	logger.Info("untraced")
+
+	logged := records(t, buf)
+	require.Len(t, logged, 2)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", logged[0]["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", logged[0]["span_id"])
+	assert.NotContains(t, logged[1], "trace_id")
+}
+
+func TestLogWarn(t *testing.T) {
+	logger, buf := newTestLogger(t, NewLevels(slog.LevelInfo), SamplingConfig{})
+	setDefault(t, logger)
+
+	LogWarn(context.Background(), "Disk almost full")
+
+	logged := records(t, buf)
+	require.Len(t, logged, 1)
+	assert.Equal(t, "WARN", logged[0]["level"])
+}
@@ -0,0 +1,161 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+)
+
+// The components whose logs can have a level of their own, the logs of the
+// other packages are logged at the default level
+const (
+	ComponentREST       = "rest"
+	ComponentService    = "service"
+	ComponentRepository = "repository"
+)
+
+var Components = []string{ComponentREST, ComponentService, ComponentRepository}
+
+// ParseLevel parses DEBUG, INFO, WARN or ERROR, regardless of the case
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToUpper(level) {
+	case "DEBUG":
+		return slog.LevelDebug, nil
+	case "INFO":
+		return slog.LevelInfo, nil
+	case "WARN", "WARNING":
+		return slog.LevelWarn, nil
+	case "ERROR":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("%w: %q is not one of DEBUG, INFO, WARN, ERROR", domain.ErrBadParamInput, level)
+}
+
+// Levels holds the levels of the logs, which can be changed while the
+// application runs. A component follows the default level until it is
+// given a level of its own.
+type Levels struct {
+	level      slog.LevelVar
+	components map[string]*slog.LevelVar
+
+	// mu orders the changes, the levels are read without it
+	mu sync.Mutex
+	// overridden are the components with a level of their own
+	overridden map[string]bool
+}
+
+func NewLevels(level slog.Level) *Levels {
+	l := &Levels{
+		components: make(map[string]*slog.LevelVar, len(Components)),
+		overridden: map[string]bool{},
+	}
+	l.level.Set(level)
+	for _, component := range Components {
+		l.components[component] = new(slog.LevelVar)
+		l.components[component].Set(level)
+	}
+	return l
+}
+
+// Level returns the level of the logs of component, the default level for
+// the components without a level of their own
+func (l *Levels) Level(component string) slog.Level {
+	if level, ok := l.components[component]; ok {
+		return level.Level()
+	}
+	return l.level.Level()
+}
+
+// minimum returns the lowest level of the components
+func (l *Levels) minimum() slog.Level {
+	minimum := l.level.Level()
+	for _, level := range l.components {
+		minimum = min(minimum, level.Level())
+	}
+	return minimum
+}
+
+// Set sets the level of component, or the default level when component is
+// empty. An empty level makes the component follow the default level again.
+func (l *Levels) Set(component, level string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.set(component, level)
+}
+
+// Apply replaces every level: the default one and the levels of the
+// components, the components missing from components follow the default
+// level. Nothing changes when one of the levels is invalid.
+func (l *Levels) Apply(level string, components map[string]string) error {
+	if _, err := ParseLevel(level); err != nil {
+		return err
+	}
+	for component, level := range components {
+		if _, ok := l.components[component]; !ok {
+			return unknownComponent(component)
+		}
+		if _, err := ParseLevel(level); err != nil {
+			return fmt.Errorf("%s: %w", component, err)
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, component := range Components {
+		l.set(component, components[component])
+	}
+	return l.set("", level)
+}
+
+func (l *Levels) set(component, level string) error {
+	if component != "" {
+		if _, ok := l.components[component]; !ok {
+			return unknownComponent(component)
+		}
+	}
+	if component != "" && level == "" {
+		delete(l.overridden, component)
+		l.components[component].Set(l.level.Level())
+		return nil
+	}
+
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	if component != "" {
+		l.overridden[component] = true
+		l.components[component].Set(parsed)
+		return nil
+	}
+	l.level.Set(parsed)
+	for component, componentLevel := range l.components {
+		if !l.overridden[component] {
+			componentLevel.Set(parsed)
+		}
+	}
+	return nil
+}
+
+// Get returns the levels, the components listed are the ones with a level
+// of their own
+func (l *Levels) Get() domain.LogLevels {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	levels := domain.LogLevels{
+		Level:      l.level.Level().String(),
+		Components: map[string]string{},
+	}
+	for component := range l.overridden {
+		levels.Components[component] = l.components[component].Level().String()
+	}
+	return levels
+}
+
+func unknownComponent(component string) error {
+	return fmt.Errorf("%w: unknown component %q, not one of %s", domain.ErrBadParamInput,
+		component, strings.Join(Components, ", "))
+}
@@ -0,0 +1,46 @@
+package logging_test
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevels(t *testing.T) {
+	levels := logging.NewLevels(slog.LevelInfo)
+
+	require.NoError(t, levels.Set(logging.ComponentRepository, "warn"))
+	require.NoError(t, levels.Set("", "DEBUG"))
+
+	assert.Equal(t, slog.LevelDebug, levels.Level(logging.ComponentService), "components follow the default level")
+	assert.Equal(t, slog.LevelWarn, levels.Level(logging.ComponentRepository))
+	assert.Equal(t, slog.LevelDebug, levels.Level(""))
+	assert.Equal(t, domain.LogLevels{Level: "DEBUG", Components: map[string]string{"repository": "WARN"}}, levels.Get())
+
+	require.NoError(t, levels.Set(logging.ComponentRepository, ""))
+	assert.Equal(t, slog.LevelDebug, levels.Level(logging.ComponentRepository), "back to the default level")
+
+	assert.ErrorIs(t, levels.Set("handlers", "DEBUG"), domain.ErrBadParamInput)
+	assert.ErrorIs(t, levels.Set("", "TRACE"), domain.ErrBadParamInput)
+	assert.ErrorIs(t, levels.Set("", ""), domain.ErrBadParamInput)
+}
+
+func TestLevels_Apply(t *testing.T) {
+	levels := logging.NewLevels(slog.LevelInfo)
+	require.NoError(t, levels.Set(logging.ComponentREST, "DEBUG"))
+
+	require.NoError(t, levels.Apply("ERROR", map[string]string{logging.ComponentService: "INFO"}))
+
+	assert.Equal(t, domain.LogLevels{Level: "ERROR", Components: map[string]string{"service": "INFO"}}, levels.Get(),
+		"the levels set before are replaced")
+	assert.Equal(t, slog.LevelError, levels.Level(logging.ComponentREST))
+
+	err := levels.Apply("DEBUG", map[string]string{logging.ComponentService: "LOUD"})
+
+	assert.ErrorIs(t, err, domain.ErrBadParamInput)
+	assert.Equal(t, slog.LevelError, levels.Level(""), "nothing changes when a level is invalid")
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/redact"
+)
+
+// RedactingHandler redacts the attributes of the records of next whose keys
+// are sensitive, and the emails, tokens and passwords found in the messages,
+// the string attributes and the errors.
+type RedactingHandler struct {
+	next  slog.Handler
+	rules *redact.Rules
+}
+
+// NewRedactingHandler wraps next with rules
+func NewRedactingHandler(next slog.Handler, rules *redact.Rules) *RedactingHandler {
+	return &RedactingHandler{next: next, rules: rules}
+}
+
+func (h *RedactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *RedactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	message, _ := h.rules.String(r.Message)
+	redacted := slog.NewRecord(r.Time, r.Level, message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.redact(a))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *RedactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.redact(a)
+	}
+	return &RedactingHandler{next: h.next.WithAttrs(redacted), rules: h.rules}
+}
+
+func (h *RedactingHandler) WithGroup(name string) slog.Handler {
+	return &RedactingHandler{next: h.next.WithGroup(name), rules: h.rules}
+}
+
+// redact returns a with its value redacted, the attributes of groups are
+// redacted one by one
+func (h *RedactingHandler) redact(a slog.Attr) slog.Attr {
+	if h.rules.Key(a.Key) {
+		return slog.String(a.Key, redact.Redacted)
+	}
+	value := a.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			redacted[i] = h.redact(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+	case slog.KindString:
+		if s, ok := h.rules.String(value.String()); ok {
+			return slog.String(a.Key, s)
+		}
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok {
+			if s, ok := h.rules.String(err.Error()); ok {
+				return slog.String(a.Key, s)
+			}
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: value}
+}
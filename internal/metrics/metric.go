@@ -1,27 +1,103 @@
 package metrics
 
 import (
-	"fmt"
-	"os"
+	"context"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 )
 
+// Namespace prefixes the names of every Prometheus metric of the
+// application. Names are fixed, instances are told apart by the labels the
+// scraper adds.
+const Namespace = "zogtest"
+
+// Metrics holds the Prometheus metrics of the application, registered on
+// their own registry rather than the global one
 type Metrics struct {
-	UserRepoCalls *prometheus.CounterVec
-	// Add other metrics here, e.g., ProductRepoCalls, ApiLatency, etc.
+	Registry *prometheus.Registry
+
+	DBQueriesTotal  *prometheus.CounterVec
+	DBQueryDuration *prometheus.HistogramVec
+	OperationsTotal *prometheus.CounterVec
 }
 
 func NewMetrics() *Metrics {
-	serviceName := os.Getenv("SERVICE_NAME")
-	return &Metrics{
-		UserRepoCalls: prometheus.NewCounterVec(
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		DBQueriesTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: "db",
+				Name:      "queries_total",
+				Help:      "Total number of database queries by repository method and table.",
+			},
+			[]string{"pool", "method", "operation", "table", "status"},
+		),
+		DBQueryDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: Namespace,
+				Subsystem: "db",
+				Name:      "query_duration_seconds",
+				Help:      "Duration of the database queries by repository method and table.",
+				Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+			},
+			[]string{"pool", "method", "operation", "table"},
+		),
+		OperationsTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
-				Name: fmt.Sprintf("%s_user_repository_calls_total", serviceName),
-				Help: "Total number of calls to the user repository.",
+				Namespace: Namespace,
+				Subsystem: "business",
+				Name:      "operations_total",
+				Help:      "Total number of business operations by entity.",
 			},
-			[]string{"method", "status"}, // Labels for the counter
+			[]string{"entity", "operation", "status"},
 		),
-		// Initialize other metrics here...
+	}
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.DBQueriesTotal,
+		m.DBQueryDuration,
+		m.OperationsTotal,
+	)
+	return m
+}
+
+func status(success bool) string {
+	if success {
+		return "success"
+	}
+	return "error"
+}
+
+// Recorder records the database queries and business operations on the
+// Prometheus metrics and, when set, on the OpenTelemetry ones
+type Recorder struct {
+	Prometheus *Metrics
+	OTel       *OTelMetrics
+}
+
+// RecordDBQuery records a query of the repository method on the table of
+// the pool
+func (r *Recorder) RecordDBQuery(ctx context.Context, pool, method, operation, table string, duration time.Duration, success bool) {
+	if r.Prometheus != nil {
+		r.Prometheus.DBQueriesTotal.WithLabelValues(pool, method, operation, table, status(success)).Inc()
+		r.Prometheus.DBQueryDuration.WithLabelValues(pool, method, operation, table).Observe(duration.Seconds())
+	}
+	if r.OTel != nil {
+		r.OTel.RecordDBQuery(ctx, pool, method, operation, table, float64(duration)/float64(time.Millisecond), success)
+	}
+}
+
+// RecordOperation records a business operation on an entity, one of the
+// domain.AuditEntity values
+func (r *Recorder) RecordOperation(ctx context.Context, entity, operation string, success bool) {
+	if r.Prometheus != nil {
+		r.Prometheus.OperationsTotal.WithLabelValues(entity, operation, status(success)).Inc()
+	}
+	if r.OTel != nil {
+		r.OTel.RecordOperation(ctx, entity, operation, success)
 	}
 }
@@ -0,0 +1,35 @@
+package metrics_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+	m := metrics.NewMetrics()
+	recorder := &metrics.Recorder{Prometheus: m}
+
+	recorder.RecordDBQuery(ctx, "primary", "postgres.NewsRepository.GetNews", "SELECT", "news", 3*time.Millisecond, true)
+	recorder.RecordDBQuery(ctx, "primary", "postgres.NewsRepository.GetNews", "SELECT", "news", time.Second, false)
+	recorder.RecordOperation(ctx, "news", "create", true)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.DBQueriesTotal.WithLabelValues(
+		"primary", "postgres.NewsRepository.GetNews", "SELECT", "news", "error")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.DBQueriesTotal))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.OperationsTotal.WithLabelValues("news", "create", "success")))
+
+	err := testutil.GatherAndCompare(m.Registry, strings.NewReader(`
+# HELP zogtest_business_operations_total Total number of business operations by entity.
+# TYPE zogtest_business_operations_total counter
+zogtest_business_operations_total{entity="news",operation="create",status="success"} 1
+`), "zogtest_business_operations_total")
+	require.NoError(t, err, "the names do not depend on the service name")
+}
@@ -142,11 +142,14 @@ func (m *OTelMetrics) RecordHTTPRequest(ctx context.Context, method, path string
 	m.HTTPRequestDuration.Record(ctx, duration, metric.WithAttributes(attrs...))
 }
 
-// RecordDBQuery records a database query metric
-func (m *OTelMetrics) RecordDBQuery(ctx context.Context, operation, table string, duration float64, success bool) {
+// RecordDBQuery records a database query metric, method is the repository
+// method running the query
+func (m *OTelMetrics) RecordDBQuery(ctx context.Context, pool, method, operation, table string, duration float64, success bool) {
 	attrs := []attribute.KeyValue{
-		attribute.String("db.operation", operation),
-		attribute.String("db.table", table),
+		attribute.String("db.client.connection.pool.name", pool),
+		attribute.String("code.function", method),
+		attribute.String("db.operation.name", operation),
+		attribute.String("db.collection.name", table),
 		attribute.Bool("success", success),
 	}
 
@@ -154,6 +157,19 @@ func (m *OTelMetrics) RecordDBQuery(ctx context.Context, operation, table string
 	m.DBQueryDuration.Record(ctx, duration, metric.WithAttributes(attrs...))
 }
 
+// RecordOperation records a business operation on an entity, the entities
+// without a counter are ignored
+func (m *OTelMetrics) RecordOperation(ctx context.Context, entity, operation string, success bool) {
+	switch entity {
+	case "user":
+		m.RecordUserOperation(ctx, operation, success)
+	case "topic":
+		m.RecordTopicOperation(ctx, operation, success)
+	case "news":
+		m.RecordNewsOperation(ctx, operation, success)
+	}
+}
+
 // RecordUserOperation records a user operation metric
 func (m *OTelMetrics) RecordUserOperation(ctx context.Context, operation string, success bool) {
 	attrs := []attribute.KeyValue{
@@ -0,0 +1,90 @@
+// Package netguard keeps the requests sent to URLs given by users, like the
+// webhook deliveries, away from the internal network. Only public addresses
+// are reached, unless their network is explicitly allowed.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned for addresses which are neither public
+// nor in an allowed network
+var ErrForbiddenAddress = errors.New("address is not public")
+
+// sharedAddressSpace is the carrier-grade NAT range, which IsPrivate does
+// not report
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+type Guard struct {
+	// AllowedNetworks are reached even though they are not public, like
+	// receivers running inside the network
+	AllowedNetworks []*net.IPNet
+}
+
+// Public reports whether ip is a public unicast address: loopback,
+// private, link-local, multicast and unspecified addresses are not.
+func Public(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+// Allowed reports whether ip is in one of the allowed networks
+func (g Guard) Allowed(ip net.IP) bool {
+	for _, network := range g.AllowedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns ErrForbiddenAddress when ip is neither public nor allowed
+func (g Guard) Check(ip net.IP) error {
+	if Public(ip) || g.Allowed(ip) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrForbiddenAddress, ip)
+}
+
+// LookupHost resolves host and checks every address it resolves to, an IP
+// address is checked as is
+func (g Guard) LookupHost(ctx context.Context, host string) ([]net.IP, error) {
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		var err error
+		if ips, err = net.DefaultResolver.LookupIP(ctx, "ip", host); err != nil {
+			return nil, err
+		}
+	}
+	for _, ip := range ips {
+		if err := g.Check(ip); err != nil {
+			return nil, err
+		}
+	}
+	return ips, nil
+}
+
+// Dialer returns a dialer refusing to connect to the addresses Check
+// rejects. The address is checked once resolved, right before connecting,
+// so a host resolving to another address than when it was looked up cannot
+// reach the internal network either.
+func (g Guard) Dialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+			}
+			return g.Check(ip)
+		},
+	}
+}
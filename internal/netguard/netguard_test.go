@@ -0,0 +1,61 @@
+package netguard_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/netguard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuard_Check(t *testing.T) {
+	_, internal, err := net.ParseCIDR("10.1.0.0/16")
+	require.NoError(t, err)
+	guard := netguard.Guard{AllowedNetworks: []*net.IPNet{internal}}
+
+	for _, ip := range []string{"203.0.113.10", "2001:db8::1", "10.1.2.3"} {
+		assert.NoError(t, guard.Check(net.ParseIP(ip)), ip)
+	}
+	for _, ip := range []string{
+		"127.0.0.1", "::1", "169.254.169.254", "fe80::1", "10.2.0.1", "192.168.1.1",
+		"172.16.0.1", "fd00::1", "100.64.0.1", "0.0.0.0", "::", "224.0.0.1", "::ffff:127.0.0.1",
+	} {
+		assert.ErrorIs(t, guard.Check(net.ParseIP(ip)), netguard.ErrForbiddenAddress, ip)
+	}
+}
+
+func TestGuard_LookupHost(t *testing.T) {
+	guard := netguard.Guard{}
+
+	ips, err := guard.LookupHost(context.Background(), "203.0.113.10")
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.10", ips[0].String())
+
+	_, err = guard.LookupHost(context.Background(), "localhost")
+	assert.ErrorIs(t, err, netguard.ErrForbiddenAddress)
+}
+
+func TestGuard_Dialer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := func(guard netguard.Guard) *http.Client {
+		return &http.Client{Transport: &http.Transport{DialContext: guard.Dialer(time.Second).DialContext}}
+	}
+
+	_, err := client(netguard.Guard{}).Get(server.URL)
+	assert.ErrorIs(t, err, netguard.ErrForbiddenAddress, "loopback is refused")
+
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+	res, err := client(netguard.Guard{AllowedNetworks: []*net.IPNet{loopback}}).Get(server.URL)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+}
@@ -0,0 +1,54 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeySet_Refresh(t *testing.T) {
+	var hits atomic.Int32
+	kids := []string{"k1"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		keys := []map[string]string{}
+		for _, kid := range kids {
+			// A tiny RSA key is enough, only the parsing is exercised
+			keys = append(keys, map[string]string{"kty": "RSA", "kid": kid, "n": "AQAB", "e": "AQAB"})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	set := newKeySet(srv.URL, srv.Client(), time.Hour, func() time.Time { return now })
+	ctx := context.Background()
+
+	_, err := set.key(ctx, "k1")
+	require.NoError(t, err)
+	_, err = set.key(ctx, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), hits.Load(), "cached keys are reused")
+
+	kids = []string{"k2"}
+	_, err = set.key(ctx, "k2")
+	assert.ErrorIs(t, err, ErrInvalidIDToken, "unknown keys don't refetch right away")
+	assert.Equal(t, int32(1), hits.Load())
+
+	now = now.Add(minKeyRefresh)
+	_, err = set.key(ctx, "k2")
+	require.NoError(t, err, "a rolled key is picked up")
+	assert.Equal(t, int32(2), hits.Load())
+
+	now = now.Add(time.Hour)
+	srv.Close()
+	_, err = set.key(ctx, "k2")
+	assert.NoError(t, err, "cached keys outlive an unreachable provider")
+}
@@ -0,0 +1,200 @@
+// Package oidc implements the relying party side of the OpenID Connect
+// authorization code flow with PKCE: provider discovery, the token exchange
+// and the validation of ID tokens against the cached keys of the provider.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidIDToken is returned for ID tokens that fail validation, the
+	// reason is wrapped with it
+	ErrInvalidIDToken = errors.New("oidc: invalid id token")
+	// ErrExchange is returned when the provider refuses an authorization code
+	ErrExchange = errors.New("oidc: code exchange failed")
+)
+
+type Config struct {
+	// Issuer is the URL of the provider, its metadata is discovered from
+	// <Issuer>/.well-known/openid-configuration
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback registered with the provider
+	RedirectURL string
+	// Scopes are requested in addition to openid
+	Scopes []string
+	// GroupsClaim names the ID token claim listing the groups of the user
+	GroupsClaim string
+	// KeysTTL is how long the signing keys of the provider are cached
+	KeysTTL time.Duration
+	// Leeway tolerates clock drift when checking token times
+	Leeway time.Duration
+}
+
+var DefaultConfig = Config{
+	Scopes:      []string{"email", "profile"},
+	GroupsClaim: "groups",
+	KeysTTL:     time.Hour,
+	Leeway:      time.Minute,
+}
+
+// Metadata is the part of the discovery document the flow relies on
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider talks to one OpenID provider. Discovery happens on first use and
+// is retried until it succeeds, so an unreachable provider does not keep
+// the rest of the API from starting.
+type Provider struct {
+	config Config
+	client *http.Client
+	now    func() time.Time
+
+	mu       sync.Mutex
+	metadata *Metadata
+	keys     *keySet
+}
+
+// NewProvider returns a provider for the config, requests go through client
+// or http.DefaultClient when nil.
+func NewProvider(config Config, client *http.Client) *Provider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = DefaultConfig.GroupsClaim
+	}
+	if config.KeysTTL <= 0 {
+		config.KeysTTL = DefaultConfig.KeysTTL
+	}
+	return &Provider{config: config, client: client, now: time.Now}
+}
+
+// Discover returns the metadata of the provider, fetching it once.
+func (p *Provider) Discover(ctx context.Context) (*Metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	var metadata Metadata
+	if err := getJSON(ctx, p.client, p.config.Issuer+"/.well-known/openid-configuration", &metadata); err != nil {
+		return nil, fmt.Errorf("oidc: discovery: %w", err)
+	}
+	// The issuer of the document must be the one configured, or tokens of
+	// another issuer could pass validation
+	if strings.TrimSuffix(metadata.Issuer, "/") != p.config.Issuer {
+		return nil, fmt.Errorf("oidc: discovery: issuer %q does not match %q", metadata.Issuer, p.config.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("oidc: discovery: incomplete provider metadata")
+	}
+	p.metadata = &metadata
+	p.keys = newKeySet(metadata.JWKSURI, p.client, p.config.KeysTTL, p.now)
+	return p.metadata, nil
+}
+
+// AuthCodeURL returns the URL to send the user to. The state and nonce come
+// back with the callback and in the ID token, the verifier is kept until
+// Exchange.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	metadata, err := p.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(append([]string{"openid"}, p.config.Scopes...), " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {CodeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return metadata.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// Exchange trades an authorization code for the ID token of the user and
+// validates it against the nonce of the login.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Claims, error) {
+	metadata, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+		return nil, fmt.Errorf("%w: status %d", ErrExchange, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		return nil, fmt.Errorf("%w: %s %s", ErrExchange, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id_token in response", ErrExchange)
+	}
+	return p.Verify(ctx, token.IDToken, nonce)
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
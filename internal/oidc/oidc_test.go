@@ -0,0 +1,125 @@
+package oidc_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/oidc"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURL = "http://localhost:8000/api/v1/auth/oidc/callback"
+
+func TestProvider_Flow(t *testing.T) {
+	ctx := context.Background()
+	idp := oidctest.NewServer(t)
+	provider := oidc.NewProvider(idp.Config(redirectURL), idp.Client())
+	verifier, err := oidc.RandomString(32)
+	require.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	require.NoError(t, err)
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, idp.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	assert.Equal(t, "code", u.Query().Get("response_type"))
+	assert.Equal(t, "openid email profile", u.Query().Get("scope"))
+	assert.Equal(t, redirectURL, u.Query().Get("redirect_uri"))
+	assert.Equal(t, oidc.CodeChallenge(verifier), u.Query().Get("code_challenge"))
+
+	t.Run("Exchanges the code for validated claims", func(t *testing.T) {
+		code := idp.Authorize(t, authURL, map[string]any{
+			"email":          "jane@newsroom.example",
+			"email_verified": true,
+			"name":           "Jane",
+			"groups":         []string{"editors", "staff"},
+		})
+
+		claims, err := provider.Exchange(ctx, code, verifier, "nonce-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, oidctest.Subject, claims.Subject)
+		assert.Equal(t, idp.URL, claims.Issuer)
+		assert.Equal(t, "jane@newsroom.example", claims.Email)
+		assert.True(t, claims.EmailVerified)
+		assert.Equal(t, []string{"editors", "staff"}, claims.Groups)
+	})
+
+	t.Run("Refuses a wrong PKCE verifier", func(t *testing.T) {
+		code := idp.Authorize(t, authURL, nil)
+
+		_, err := provider.Exchange(ctx, code, "not-the-verifier", "nonce-1")
+
+		assert.ErrorIs(t, err, oidc.ErrExchange)
+	})
+
+	t.Run("Refuses a token of another login", func(t *testing.T) {
+		code := idp.Authorize(t, authURL, nil)
+
+		_, err := provider.Exchange(ctx, code, verifier, "nonce-2")
+
+		assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+	})
+}
+
+func TestProvider_Verify(t *testing.T) {
+	ctx := context.Background()
+	idp := oidctest.NewServer(t)
+	provider := oidc.NewProvider(idp.Config(redirectURL), idp.Client())
+
+	tests := []struct {
+		name  string
+		token func() string
+		ok    bool
+	}{
+		{"RS256", func() string { return idp.Sign(t, "RS256", idp.Claims("n", nil)) }, true},
+		{"ES256", func() string { return idp.Sign(t, "ES256", idp.Claims("n", nil)) }, true},
+		{"Audience list with azp", func() string {
+			return idp.Sign(t, "RS256", idp.Claims("n", map[string]any{"aud": []string{oidctest.ClientID, "other"}, "azp": oidctest.ClientID}))
+		}, true},
+		{"Unsigned", func() string { return idp.Sign(t, "none", idp.Claims("n", nil)) }, false},
+		{"Tampered", func() string {
+			token := strings.Split(idp.Sign(t, "RS256", idp.Claims("n", nil)), ".")
+			forged := strings.Split(idp.Sign(t, "RS256", idp.Claims("n", map[string]any{"sub": "admin"})), ".")
+			return token[0] + "." + forged[1] + "." + token[2]
+		}, false},
+		{"Other audience", func() string { return idp.Sign(t, "RS256", idp.Claims("n", map[string]any{"aud": "other"})) }, false},
+		{"Audience list without azp", func() string {
+			return idp.Sign(t, "RS256", idp.Claims("n", map[string]any{"aud": []string{oidctest.ClientID, "other"}}))
+		}, false},
+		{"Other issuer", func() string {
+			return idp.Sign(t, "RS256", idp.Claims("n", map[string]any{"iss": "https://evil.example"}))
+		}, false},
+		{"Expired", func() string {
+			return idp.Sign(t, "RS256", idp.Claims("n", map[string]any{"exp": time.Now().Add(-time.Hour).Unix()}))
+		}, false},
+		{"Wrong nonce", func() string { return idp.Sign(t, "RS256", idp.Claims("other", nil)) }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := provider.Verify(ctx, tt.token(), "n")
+			if tt.ok {
+				require.NoError(t, err)
+				assert.Equal(t, oidctest.Subject, claims.Subject)
+			} else {
+				assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+			}
+		})
+	}
+
+	assert.Equal(t, 1, idp.KeyRequests(), "keys are fetched once")
+}
+
+func TestProvider_DiscoveryIssuerMismatch(t *testing.T) {
+	idp := oidctest.NewServer(t)
+	idp.Issuer = "https://other.example"
+
+	_, err := oidc.NewProvider(idp.Config(redirectURL), idp.Client()).Discover(context.Background())
+
+	assert.ErrorContains(t, err, "does not match")
+}
@@ -0,0 +1,184 @@
+// Package oidctest provides a stub OpenID provider for tests: it serves
+// discovery and signing keys and hands out ID tokens for the codes of the
+// logins it authorized.
+package oidctest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/oidc"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	ClientID     = "newsroom"
+	ClientSecret = "s3cret"
+	// Subject is the subject of the tokens unless the claims override it
+	Subject = "staff-42"
+)
+
+type Server struct {
+	*httptest.Server
+	// Issuer is announced by discovery, it defaults to the server URL
+	Issuer string
+
+	rsaKey  *rsa.PrivateKey
+	ecKey   *ecdsa.PrivateKey
+	keyHits atomic.Int32
+	mu      sync.Mutex
+	grants  map[string]grant
+}
+
+type grant struct {
+	challenge string
+	claims    map[string]any
+}
+
+// NewServer starts a provider that is closed with the test
+func NewServer(t testing.TB) *Server {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	s := &Server{rsaKey: rsaKey, ecKey: ecKey, grants: map[string]grant{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 s.Issuer,
+			"authorization_endpoint": s.URL + "/authorize",
+			"token_endpoint":         s.URL + "/token",
+			"jwks_uri":               s.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, r *http.Request) {
+		s.keyHits.Add(1)
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{
+			{
+				"kty": "RSA", "kid": "rsa-1", "use": "sig", "alg": "RS256",
+				"n": b64(s.rsaKey.N.Bytes()),
+				"e": b64(big.NewInt(int64(s.rsaKey.E)).Bytes()),
+			},
+			{
+				"kty": "EC", "kid": "ec-1", "use": "sig", "crv": "P-256",
+				"x": b64(s.ecKey.X.FillBytes(make([]byte, 32))),
+				"y": b64(s.ecKey.Y.FillBytes(make([]byte, 32))),
+			},
+		}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, secret, _ := r.BasicAuth()
+		code := r.FormValue("code")
+		s.mu.Lock()
+		g, ok := s.grants[code]
+		delete(s.grants, code)
+		s.mu.Unlock()
+		if !ok || clientID != ClientID || secret != ClientSecret || oidc.CodeChallenge(r.FormValue("code_verifier")) != g.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     s.Sign(t, "RS256", g.claims),
+		})
+	})
+	s.Server = httptest.NewServer(mux)
+	s.Issuer = s.URL
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Config returns a provider config for the server
+func (s *Server) Config(redirectURL string) oidc.Config {
+	config := oidc.DefaultConfig
+	config.Issuer = s.URL
+	config.ClientID = ClientID
+	config.ClientSecret = ClientSecret
+	config.RedirectURL = redirectURL
+	return config
+}
+
+// KeyRequests counts the requests for the signing keys
+func (s *Server) KeyRequests() int {
+	return int(s.keyHits.Load())
+}
+
+// Authorize plays the user logging in at the provider with the URL of
+// AuthCodeURL, and returns the code of the redirect back. The claims are
+// added to those of the ID token.
+func (s *Server) Authorize(t testing.TB, authURL string, claims map[string]any) string {
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	q := u.Query()
+	require.Equal(t, "S256", q.Get("code_challenge_method"))
+	require.Equal(t, ClientID, q.Get("client_id"))
+
+	code := "code-" + q.Get("state")
+	s.mu.Lock()
+	s.grants[code] = grant{challenge: q.Get("code_challenge"), claims: s.Claims(q.Get("nonce"), claims)}
+	s.mu.Unlock()
+	return code
+}
+
+// Claims returns valid ID token claims for the nonce, overridden by extra
+func (s *Server) Claims(nonce string, extra map[string]any) map[string]any {
+	claims := map[string]any{
+		"iss":   s.Issuer,
+		"aud":   ClientID,
+		"sub":   Subject,
+		"nonce": nonce,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(5 * time.Minute).Unix(),
+	}
+	for k, v := range extra {
+		claims[k] = v
+	}
+	return claims
+}
+
+// Sign returns an ID token with the claims, alg is RS256, ES256 or none
+func (s *Server) Sign(t testing.TB, alg string, claims map[string]any) string {
+	kid := "rsa-1"
+	if alg == "ES256" {
+		kid = "ec-1"
+	}
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch alg {
+	case "RS256":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, s.rsaKey, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case "ES256":
+		r, sig, err := ecdsa.Sign(rand.Reader, s.ecKey, digest[:])
+		require.NoError(t, err)
+		signature = append(r.FillBytes(make([]byte, 32)), sig.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + b64(signature)
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
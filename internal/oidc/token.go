@@ -0,0 +1,208 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Claims are the ID token claims the login relies on
+type Claims struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	// Groups are read from the configured groups claim
+	Groups    []string
+	ExpiresAt time.Time
+}
+
+type signingAlgorithm struct {
+	hash crypto.Hash
+	// size is the byte length of each ECDSA signature half, zero for RSA
+	size int
+}
+
+// algorithms are the JWS algorithms accepted for ID tokens. "none" and the
+// HMAC ones are deliberately missing.
+var algorithms = map[string]signingAlgorithm{
+	"RS256": {hash: crypto.SHA256},
+	"RS384": {hash: crypto.SHA384},
+	"RS512": {hash: crypto.SHA512},
+	"ES256": {hash: crypto.SHA256, size: 32},
+	"ES384": {hash: crypto.SHA384, size: 48},
+}
+
+// audience accepts the single string and the array forms of the aud claim
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// Verify checks the signature, issuer, audience, expiry and nonce of an ID
+// token and returns its claims.
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (*Claims, error) {
+	if _, err := p.Discover(ctx); err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(rawIDToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidIDToken)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidIDToken)
+	}
+	alg, ok := algorithms[header.Alg]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidIDToken, header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidIDToken)
+	}
+	key, err := p.keys.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var payload struct {
+		Issuer          string          `json:"iss"`
+		Subject         string          `json:"sub"`
+		Audience        audience        `json:"aud"`
+		AuthorizedParty string          `json:"azp"`
+		Expiry          int64           `json:"exp"`
+		IssuedAt        int64           `json:"iat"`
+		Nonce           string          `json:"nonce"`
+		Email           string          `json:"email"`
+		EmailVerified   json.RawMessage `json:"email_verified"`
+		Name            string          `json:"name"`
+	}
+	if err := decodeSegment(parts[1], &payload); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidIDToken)
+	}
+	now := p.now()
+	switch {
+	case payload.Issuer != p.metadata.Issuer:
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidIDToken, payload.Issuer)
+	case !slices.Contains(payload.Audience, p.config.ClientID):
+		return nil, fmt.Errorf("%w: not issued for this client", ErrInvalidIDToken)
+	case len(payload.Audience) > 1 && payload.AuthorizedParty != p.config.ClientID:
+		return nil, fmt.Errorf("%w: unexpected authorized party %q", ErrInvalidIDToken, payload.AuthorizedParty)
+	case payload.Subject == "":
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	case !now.Before(time.Unix(payload.Expiry, 0).Add(p.config.Leeway)):
+		return nil, fmt.Errorf("%w: expired", ErrInvalidIDToken)
+	case time.Unix(payload.IssuedAt, 0).After(now.Add(p.config.Leeway)):
+		return nil, fmt.Errorf("%w: issued in the future", ErrInvalidIDToken)
+	case subtle.ConstantTimeCompare([]byte(payload.Nonce), []byte(nonce)) != 1:
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	var groups map[string]json.RawMessage
+	if err := decodeSegment(parts[1], &groups); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidIDToken)
+	}
+	return &Claims{
+		Issuer:  payload.Issuer,
+		Subject: payload.Subject,
+		Email:   payload.Email,
+		// Some providers send email_verified as a string
+		EmailVerified: string(payload.EmailVerified) == "true" || string(payload.EmailVerified) == `"true"`,
+		Name:          payload.Name,
+		Groups:        stringList(groups[p.config.GroupsClaim]),
+		ExpiresAt:     time.Unix(payload.Expiry, 0),
+	}, nil
+}
+
+func verifySignature(alg signingAlgorithm, key crypto.PublicKey, signed string, signature []byte) error {
+	h := alg.hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if alg.size == 0 && rsa.VerifyPKCS1v15(key, alg.hash, digest, signature) == nil {
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		if alg.size != 0 && len(signature) == 2*alg.size && (key.Curve.Params().BitSize+7)/8 == alg.size {
+			r := new(big.Int).SetBytes(signature[:alg.size])
+			s := new(big.Int).SetBytes(signature[alg.size:])
+			if ecdsa.Verify(key, digest, r, s) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: bad signature", ErrInvalidIDToken)
+}
+
+func decodeSegment(segment string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// stringList reads a claim holding a string or a list of strings
+func stringList(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list
+	}
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil && single != "" {
+		return []string{single}
+	}
+	return nil
+}
+
+// RandomString returns n random bytes in base64url, for states, nonces and
+// PKCE verifiers
+func RandomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CodeChallenge returns the S256 PKCE challenge of a verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the TATs in the process, each replica limits on its own
+type MemoryStore struct {
+	mu   sync.Mutex
+	tats map[string]time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tats: make(map[string]time.Time)}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, now time.Time, interval, tolerance time.Duration) (bool, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tat := s.tats[key]
+	if tat.Before(now) {
+		tat = now
+	}
+	next := tat.Add(interval)
+	if next.Sub(now) > tolerance {
+		return false, tat, nil
+	}
+	s.tats[key] = next
+	return true, next, nil
+}
+
+func (s *MemoryStore) DeleteExpired(_ context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for key, tat := range s.tats {
+		if tat.Before(now) {
+			delete(s.tats, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
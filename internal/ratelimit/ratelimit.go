@@ -0,0 +1,114 @@
+// Package ratelimit limits the requests of a key with the generic cell rate
+// algorithm (GCRA), a token bucket which keeps a single timestamp per key:
+// the theoretical arrival time (TAT) of the next request. Stores keep the
+// TATs, in memory or shared by every replica.
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"time"
+)
+
+// Limit allows Rate requests per second on average and bursts of up to
+// Burst requests
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// interval is the time one request takes to be replenished
+func (l Limit) interval() time.Duration {
+	return time.Duration(float64(time.Second) / l.Rate)
+}
+
+// tolerance is the time a full bucket takes to be replenished
+func (l Limit) tolerance() time.Duration {
+	return l.interval() * time.Duration(l.Burst)
+}
+
+// Store keeps the TAT of every key
+type Store interface {
+	// Take spends one request of key, atomically: the TAT moves interval
+	// ahead, from now at the latest, unless it ends more than tolerance
+	// after now. It returns whether the request was allowed and the TAT
+	// after the call.
+	Take(ctx context.Context, key string, now time.Time, interval, tolerance time.Duration) (bool, time.Time, error)
+}
+
+// Cleaner is implemented by the stores which must forget the keys
+// themselves
+type Cleaner interface {
+	// DeleteExpired forgets the keys with a TAT before now, their bucket is
+	// full again
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// Result tells the caller how much of the limit is left
+type Result struct {
+	Allowed bool
+	// Limit is the size of the burst
+	Limit     int
+	Remaining int
+	// Reset is when the bucket is full again
+	Reset time.Duration
+	// RetryAfter is when the next request is allowed, zero when allowed
+	RetryAfter time.Duration
+}
+
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{store: store, now: time.Now}
+}
+
+// Take spends one request of key under limit. When the store fails the
+// error comes with a result allowing the request, an outage of the store
+// must not take the API down.
+func (l *Limiter) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := l.now()
+	interval, tolerance := limit.interval(), limit.tolerance()
+
+	allowed, tat, err := l.store.Take(ctx, key, now, interval, tolerance)
+	if err != nil {
+		return Result{Allowed: true, Limit: limit.Burst, Remaining: limit.Burst}, err
+	}
+
+	result := Result{Allowed: allowed, Limit: limit.Burst, Reset: max(tat.Sub(now), 0)}
+	if allowed {
+		result.Remaining = int(math.Floor(float64(tolerance-tat.Sub(now)) / float64(interval)))
+		result.Remaining = min(max(result.Remaining, 0), limit.Burst)
+	} else {
+		result.RetryAfter = max(tat.Add(interval-tolerance).Sub(now), 0)
+	}
+	return result, nil
+}
+
+// RunCleanup forgets the expired keys of the store every interval until
+// ctx is done, for the stores which do not expire keys themselves
+func (l *Limiter) RunCleanup(ctx context.Context, interval time.Duration) {
+	cleaner, ok := l.store.(Cleaner)
+	if !ok {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := cleaner.DeleteExpired(ctx, l.now())
+			if err != nil {
+				slog.ErrorContext(ctx, "Rate limit cleanup failed", slog.String("error", err.Error()))
+			} else if deleted > 0 {
+				slog.DebugContext(ctx, "Expired rate limits deleted", slog.Int64("deleted", deleted))
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stores(t *testing.T) map[string]Store {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return map[string]Store{
+		"memory": NewMemoryStore(),
+		"redis":  NewRedisStore(client, "ratelimit:"),
+	}
+}
+
+func TestLimiter_Take(t *testing.T) {
+	ctx := context.Background()
+	// 2 requests per second in bursts of 3
+	limit := Limit{Rate: 2, Burst: 3}
+
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+			limiter := NewLimiter(store)
+			limiter.now = func() time.Time { return now }
+
+			for remaining := 2; remaining >= 0; remaining-- {
+				result, err := limiter.Take(ctx, "ip:192.0.2.1", limit)
+				require.NoError(t, err)
+				require.True(t, result.Allowed)
+				assert.Equal(t, 3, result.Limit)
+				assert.Equal(t, remaining, result.Remaining)
+			}
+
+			result, _ := limiter.Take(ctx, "ip:192.0.2.1", limit)
+			assert.False(t, result.Allowed, "the burst is spent")
+			assert.Equal(t, 0, result.Remaining)
+			assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+			assert.Equal(t, 1500*time.Millisecond, result.Reset)
+
+			result, _ = limiter.Take(ctx, "ip:192.0.2.2", limit)
+			assert.True(t, result.Allowed, "keys are limited apart")
+
+			now = now.Add(500 * time.Millisecond)
+			result, _ = limiter.Take(ctx, "ip:192.0.2.1", limit)
+			assert.True(t, result.Allowed, "one request replenished")
+			assert.Equal(t, 0, result.Remaining)
+
+			now = now.Add(time.Hour)
+			result, _ = limiter.Take(ctx, "ip:192.0.2.1", limit)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, 2, result.Remaining, "the bucket refills up to the burst")
+		})
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, time.Time, time.Duration, time.Duration) (bool, time.Time, error) {
+	return false, time.Time{}, errors.New("connection refused")
+}
+
+func TestLimiter_AllowsWhenTheStoreFails(t *testing.T) {
+	result, err := NewLimiter(failingStore{}).Take(context.Background(), "ip:192.0.2.1", Limit{Rate: 1, Burst: 1})
+
+	assert.Error(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestMemoryStore_DeleteExpired(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Now()
+	_, _, err := store.Take(ctx, "old", now.Add(-time.Hour), time.Second, time.Second)
+	require.NoError(t, err)
+	_, _, err = store.Take(ctx, "recent", now, time.Second, time.Second)
+	require.NoError(t, err)
+
+	deleted, err := store.DeleteExpired(ctx, now)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	assert.Len(t, store.tats, 1)
+}
+
+func TestLimiter_RunCleanupStops(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewLimiter(NewMemoryStore()).RunCleanup(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("cleanup did not stop")
+	}
+}
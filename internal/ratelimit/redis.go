@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript runs the GCRA in Redis so concurrent replicas cannot race. The
+// times are in microseconds, which Lua numbers hold exactly. The key expires
+// with its TAT. Numbers are formatted with %.0f, tostring would round them.
+var takeScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local tolerance = tonumber(ARGV[3])
+local tat = tonumber(redis.call("GET", KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+local new_tat = tat + interval
+if new_tat - now > tolerance then
+	return {0, tat}
+end
+redis.call("SET", KEYS[1], string.format("%.0f", new_tat), "PX", math.ceil((new_tat - now) / 1000))
+return {1, new_tat}
+`)
+
+// RedisStore keeps the TATs in Redis, or any server speaking its protocol,
+// and shares the limits between replicas
+type RedisStore struct {
+	client redis.Scripter
+	prefix string
+}
+
+// NewRedisStore stores the TATs under keys starting with prefix
+func NewRedisStore(client redis.Scripter, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, now time.Time, interval, tolerance time.Duration) (bool, time.Time, error) {
+	values, err := takeScript.Run(ctx, s.client, []string{s.prefix + key},
+		now.UnixMicro(), interval.Microseconds(), tolerance.Microseconds()).Slice()
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("rate limit script: %w", err)
+	}
+	if len(values) != 2 {
+		return false, time.Time{}, fmt.Errorf("rate limit script returned %v", values)
+	}
+	allowed, _ := values[0].(int64)
+	tat, ok := values[1].(int64)
+	if !ok {
+		return false, time.Time{}, fmt.Errorf("rate limit script returned TAT %v", values[1])
+	}
+	return allowed == 1, time.UnixMicro(tat), nil
+}
@@ -0,0 +1,215 @@
+// Package redact holds the rules keeping credentials and personal data out
+// of the logs and the traces. The logging and tracing packages apply them
+// to the records and the spans, the body capture middleware to the bodies.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+)
+
+// Redacted replaces the redacted values
+const Redacted = "REDACTED"
+
+// DefaultKeys redacts the credentials and the personal data logged or
+// recorded under a key containing one of them, like
+// http.request.header.authorization or db.query.parameter.0.
+var DefaultKeys = []string{
+	"password",
+	"secret",
+	"token",
+	"authorization",
+	"cookie",
+	"api_key",
+	"email",
+	"query.parameter",
+	// TOTP codes and the recovery codes of two-factor authentication
+	"code",
+	"recovery_codes",
+}
+
+// DefaultPatterns redacts the emails, the tokens and the passwords found in
+// any value, like a query string or an error message. Only the capture
+// group is redacted when a pattern has one.
+var DefaultPatterns = []string{
+	// Emails
+	`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+	// Authorization header values
+	`(?i)\b(?:bearer|basic)\s+([A-Za-z0-9._~+/=\-]+)`,
+	// JWTs
+	`\beyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`,
+	// Session tokens and API keys
+	`\b(?:` + domain.SessionTokenPrefix + `|` + domain.APIKeyPrefix + `)[0-9a-f]{16,}`,
+	// Secrets in query strings and forms
+	`(?i)\b(?:password|secret|token|access_token|refresh_token|api_key|code)=([^&\s]+)`,
+	// Secrets in JSON, up to the end of a truncated one
+	`(?i)"(?:password|new_password|current_password|secret|token|access_token|refresh_token|api_key|code)"\s*:\s*"((?:[^"\\]|\\.)*)"?`,
+}
+
+// Rules tell the sensitive keys and values apart
+type Rules struct {
+	keys     []string
+	patterns []*regexp.Regexp
+	// fields matches the string values of the sensitive keys in JSON
+	// which cannot be decoded, like truncated bodies, up to their end
+	fields *regexp.Regexp
+}
+
+// New compiles the rules. A key is sensitive when it contains one of keys,
+// regardless of the case and of - or _ in header names, unless it ends in
+// _id or .id: identifiers like api_key_id are not secrets.
+func New(keys, patterns []string) (*Rules, error) {
+	r := &Rules{keys: make([]string, len(keys))}
+	quoted := make([]string, len(keys))
+	for i, key := range keys {
+		r.keys[i] = normalizeKey(key)
+		quoted[i] = regexp.QuoteMeta(r.keys[i])
+	}
+	if len(keys) > 0 {
+		r.fields = regexp.MustCompile(`(?i)"[^"]*(?:` + strings.Join(quoted, "|") + `)[^"]*"\s*:\s*"((?:[^"\\]|\\.)*)"?`)
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Default returns the rules of DefaultKeys and DefaultPatterns
+func Default() *Rules {
+	r, err := New(DefaultKeys, DefaultPatterns)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Key reports whether the values of key are sensitive
+func (r *Rules) Key(key string) bool {
+	normalized := normalizeKey(key)
+	if strings.HasSuffix(normalized, "_id") || strings.HasSuffix(normalized, ".id") {
+		return false
+	}
+	// HTTP status codes and the source code attributes of OpenTelemetry
+	if strings.HasSuffix(normalized, "status_code") || strings.HasPrefix(normalized, "code.") {
+		return false
+	}
+	for _, k := range r.keys {
+		if strings.Contains(normalized, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns s with the matches of the patterns redacted, and whether
+// there were any
+func (r *Rules) String(s string) (string, bool) {
+	redacted := false
+	for _, re := range r.patterns {
+		if re.MatchString(s) {
+			s = redactMatches(re, s)
+			redacted = true
+		}
+	}
+	return s, redacted
+}
+
+// redactMatches redacts the matches of re in s, or their first capture
+// group which matched
+func redactMatches(re *regexp.Regexp, s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(s, -1) {
+		start, end := m[0], m[1]
+		for i := 2; i < len(m); i += 2 {
+			if m[i] >= 0 {
+				start, end = m[i], m[i+1]
+				break
+			}
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(Redacted)
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// Body returns body with the values of the sensitive fields of JSON and
+// form bodies, and the matches of the patterns, redacted
+func (r *Rules) Body(contentType string, body []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		var value any
+		if err := decoder.Decode(&value); err == nil && !decoder.More() {
+			var out bytes.Buffer
+			encoder := json.NewEncoder(&out)
+			encoder.SetEscapeHTML(false)
+			if err := encoder.Encode(r.value(value)); err == nil {
+				return strings.TrimSuffix(out.String(), "\n")
+			}
+		}
+		// Truncated or invalid JSON
+		s := string(body)
+		if r.fields != nil {
+			s = redactMatches(r.fields, s)
+		}
+		s, _ = r.String(s)
+		return s
+	case mediaType == "application/x-www-form-urlencoded":
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			for key, values := range form {
+				for i, v := range values {
+					if r.Key(key) {
+						values[i] = Redacted
+					} else {
+						values[i], _ = r.String(v)
+					}
+				}
+			}
+			return form.Encode()
+		}
+	}
+	s, _ := r.String(string(body))
+	return s
+}
+
+// value redacts a decoded JSON value
+func (r *Rules) value(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if r.Key(key) {
+				v[key] = Redacted
+			} else {
+				v[key] = r.value(value)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = r.value(value)
+		}
+	case string:
+		s, _ := r.String(v)
+		return s
+	}
+	return v
+}
+
+func normalizeKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "-", "_")
+}
@@ -0,0 +1,86 @@
+package redact_test
+
+import (
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/redact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules_Key(t *testing.T) {
+	rules := redact.Default()
+
+	for _, key := range []string{"password", "new_password", "http.request.header.Authorization", "X-API-Key", "Topic_email", "db.query.parameter.0", "code", "recovery_codes"} {
+		assert.True(t, rules.Key(key), key)
+	}
+	for _, key := range []string{"user_id", "api_key_id", "query", "method", "session.id", "http.response.status_code", "code.function"} {
+		assert.False(t, rules.Key(key), key)
+	}
+}
+
+func TestRules_String(t *testing.T) {
+	rules := redact.Default()
+
+	tests := []struct {
+		name, value, want string
+	}{
+		{"Email", "account:alice@example.com", "account:REDACTED"},
+		{"Bearer token", "Authorization: Bearer abc.def", "Authorization: Bearer REDACTED"},
+		{"JWT", "id_token eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.c2ln", "id_token REDACTED"},
+		{"API key", "key zog_0123456789abcdef0123 revoked", "key REDACTED revoked"},
+		{"Session token", "zogs_0123456789abcdef0123", "REDACTED"},
+		{"Query string", "token=abc&page=2&password=hunter2", "token=REDACTED&page=2&password=REDACTED"},
+		{"JSON", `{"email":"a@b.io","password":"hun\"ter2","name":"Alice"}`, `{"email":"REDACTED","password":"REDACTED","name":"Alice"}`},
+		{"Nothing", "GET /api/v1/news", "GET /api/v1/news"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, redacted := rules.String(tt.value)
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want != tt.value, redacted)
+		})
+	}
+}
+
+func TestNew_InvalidPattern(t *testing.T) {
+	_, err := redact.New(nil, []string{"(unclosed"})
+
+	require.Error(t, err)
+	assert.ErrorContains(t, err, `invalid redaction pattern "(unclosed"`)
+}
+
+func TestRules_Body(t *testing.T) {
+	rules, err := redact.New(append(redact.DefaultKeys, "card"), redact.DefaultPatterns)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name, contentType, body, want string
+	}{
+		{
+			"JSON",
+			"application/json; charset=UTF-8",
+			`{"user":{"email":"a@b.io","card":{"number":4111}},"note":"call <alice@example.com>","tags":["token=abc"],"count":3}`,
+			`{"count":3,"note":"call <REDACTED>","tags":["token=REDACTED"],"user":{"card":"REDACTED","email":"REDACTED"}}`,
+		},
+		{
+			"Truncated JSON",
+			"application/json",
+			`{"card_number":"4111","name":"Alice","password":"hun`,
+			`{"card_number":"REDACTED","name":"Alice","password":"REDACTED`,
+		},
+		{
+			"Form",
+			"application/x-www-form-urlencoded",
+			"grant_type=password&username=alice&password=hunter2",
+			"grant_type=password&password=REDACTED&username=alice",
+		},
+		{"Text", "text/plain", "reset link sent to alice@example.com", "reset link sent to REDACTED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, rules.Body(tt.contentType, []byte(tt.body)))
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const apiKeyColumns = `
+	id, name, owner_id, prefix, scopes, COALESCE(rotated_from::text, ''),
+	expires_at, last_used_at, request_count, revoked_at, created_at, key_hash`
+
+type APIKeyRepository struct {
+	Conn *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(conn *pgxpool.Pool) *APIKeyRepository {
+	return &APIKeyRepository{Conn: conn}
+}
+
+// scanAPIKey reads the apiKeyColumns of a row, followed by extra columns
+func scanAPIKey(row pgx.Row, extra ...any) (*domain.APIKey, error) {
+	var key domain.APIKey
+	dest := []any{
+		&key.ID,
+		&key.Name,
+		&key.OwnerID,
+		&key.Prefix,
+		&key.Scopes,
+		&key.RotatedFrom,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RequestCount,
+		&key.RevokedAt,
+		&key.CreatedAt,
+		&key.Hash,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func insertAPIKey(ctx context.Context, q querier, key *domain.APIKey) (*domain.APIKey, error) {
+	query := `
+		INSERT INTO api_keys (name, owner_id, prefix, key_hash, scopes, rotated_from, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::uuid, $7, NOW(), NOW())
+		RETURNING ` + apiKeyColumns
+
+	return scanAPIKey(q.QueryRow(ctx, query,
+		key.Name,
+		key.OwnerID,
+		key.Prefix,
+		key.Hash,
+		key.Scopes,
+		key.RotatedFrom,
+		key.ExpiresAt,
+	))
+}
+
+func (a *APIKeyRepository) CreateAPIKey(ctx context.Context, key *domain.APIKey) (*domain.APIKey, error) {
+	return insertAPIKey(ctx, a.Conn, key)
+}
+
+// GetAPIKeyByHash finds a key, revoked and expired ones included, from the
+// hash of the plain key along with the current role of its owner. Keys of
+// deleted or inactive users are reported as not found.
+func (a *APIKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	query := `
+		SELECT
+			k.id, k.name, k.owner_id, k.prefix, k.scopes, COALESCE(k.rotated_from::text, ''),
+			k.expires_at, k.last_used_at, k.request_count, k.revoked_at, k.created_at, k.key_hash,
+			u.role
+		FROM api_keys k
+		JOIN users u ON u.id = k.owner_id
+		WHERE k.key_hash = $1
+			AND u.status = 'active'
+			AND u.deleted_at IS NULL`
+
+	var ownerRole string
+	key, err := scanAPIKey(a.Conn.QueryRow(ctx, query, hash), &ownerRole)
+	if err != nil {
+		return nil, err
+	}
+	key.OwnerRole = ownerRole
+	return key, nil
+}
+
+func (a *APIKeyRepository) GetAPIKey(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1`
+	return scanAPIKey(a.Conn.QueryRow(ctx, query, id))
+}
+
+// GetAPIKeys lists the keys of an owner, newest first. An empty owner lists
+// the keys of every user.
+func (a *APIKeyRepository) GetAPIKeys(ctx context.Context, ownerID string) ([]domain.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		WHERE $1 = '' OR owner_id = NULLIF($1, '')::uuid
+		ORDER BY created_at DESC`
+
+	rows, err := a.Conn.Query(ctx, query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]domain.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RotateAPIKey stores the replacement of a key and shortens the lifetime of
+// the old key to the end of the overlap window, both in one transaction.
+func (a *APIKeyRepository) RotateAPIKey(ctx context.Context, id uuid.UUID, replacement *domain.APIKey, overlapUntil time.Time) (*domain.APIKey, error) {
+	tx, err := a.Conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE api_keys
+		SET expires_at = LEAST(COALESCE(expires_at, $2), $2),
+			updated_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL`,
+		id, overlapUntil)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, domain.ErrNotFound
+	}
+
+	created, err := insertAPIKey(ctx, tx, replacement)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (a *APIKeyRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	tag, err := a.Conn.Exec(ctx, `
+		UPDATE api_keys
+		SET revoked_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// RecordAPIKeyUsage adds the accumulated request counts to the keys and moves
+// their last used timestamps forward.
+func (a *APIKeyRepository) RecordAPIKeyUsage(ctx context.Context, usage []domain.APIKeyUsage) error {
+	if len(usage) == 0 {
+		return nil
+	}
+
+	query := `
+		UPDATE api_keys
+		SET request_count = request_count + $2,
+			last_used_at = GREATEST(COALESCE(last_used_at, $3), $3)
+		WHERE id = $1`
+
+	batch := &pgx.Batch{}
+	for _, u := range usage {
+		batch.Queue(query, u.KeyID, u.Requests, u.LastUsed)
+	}
+	return a.Conn.SendBatch(ctx, batch).Close()
+}
@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AuditRepository struct {
+	Conn *pgxpool.Pool
+}
+
+func NewAuditRepository(conn *pgxpool.Pool) *AuditRepository {
+	return &AuditRepository{Conn: conn}
+}
+
+// CreateAuditEvents stores the events in a single round trip.
+func (a *AuditRepository) CreateAuditEvents(ctx context.Context, events []domain.AuditEvent) error {
+	query := `
+		INSERT INTO audit_events (actor_id, actor_client_id, auth_method, action, entity_type, entity_id, before, after, request_id, client_ip, created_at)
+		VALUES (NULLIF($1, '')::uuid, NULLIF($2, ''), NULLIF($3, ''), $4, $5, $6, $7, $8, NULLIF($9, ''), NULLIF($10, ''), NOW())`
+
+	batch := &pgx.Batch{}
+	for _, event := range events {
+		batch.Queue(query,
+			event.ActorID,
+			event.ActorClientID,
+			event.AuthMethod,
+			event.Action,
+			event.EntityType,
+			event.EntityID,
+			event.Before,
+			event.After,
+			event.RequestID,
+			event.ClientIP,
+		)
+	}
+	return a.Conn.SendBatch(ctx, batch).Close()
+}
+
+// GetAuditEvents lists the events matching the filter, newest first, and the
+// total number of matching events.
+func (a *AuditRepository) GetAuditEvents(ctx context.Context, filter *domain.AuditFilter) ([]domain.AuditEvent, int, error) {
+	query := `
+		SELECT
+			id,
+			COALESCE(actor_id::text, ''),
+			COALESCE(actor_client_id, ''),
+			COALESCE(auth_method, ''),
+			action,
+			entity_type,
+			entity_id,
+			before,
+			after,
+			COALESCE(request_id, ''),
+			COALESCE(client_ip, ''),
+			created_at,
+			COUNT(*) OVER ()
+		FROM audit_events`
+
+	var args []any
+	var conditions []string
+	addCondition := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.EntityType != "" {
+		addCondition("entity_type = $%d", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		addCondition("entity_id = $%d", filter.EntityID)
+	}
+	if filter.ActorID != "" {
+		addCondition("actor_id = $%d::uuid", filter.ActorID)
+	}
+	if filter.ClientID != "" {
+		addCondition("actor_client_id = $%d", filter.ClientID)
+	}
+	if filter.Action != "" {
+		addCondition("action = $%d", filter.Action)
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, filter.PerPage, filter.Offset())
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := a.Conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	events := make([]domain.AuditEvent, 0)
+	total := 0
+	for rows.Next() {
+		var event domain.AuditEvent
+		err := rows.Scan(
+			&event.ID,
+			&event.ActorID,
+			&event.ActorClientID,
+			&event.AuthMethod,
+			&event.Action,
+			&event.EntityType,
+			&event.EntityID,
+			&event.Before,
+			&event.After,
+			&event.RequestID,
+			&event.ClientIP,
+			&event.CreatedAt,
+			&total,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// DeleteAuditEventsBefore removes the events recorded before the given time.
+func (a *AuditRepository) DeleteAuditEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := a.Conn.Exec(ctx, `DELETE FROM audit_events WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
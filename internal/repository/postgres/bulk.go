@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SQLSTATE codes the repositories tell apart
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// bulkReferenceNotFound is reported on a bulk item referencing a missing row
+const bulkReferenceNotFound = "referenced item not found"
+
+// inSavepoint runs save in a savepoint of tx and returns its events. An
+// error of the item itself, like a missing row or a violated constraint,
+// rolls the savepoint back and is reported on result, the transaction goes
+// on. The other errors are returned.
+func inSavepoint(ctx context.Context, tx pgx.Tx, result *domain.BulkItemResult, save func(pgx.Tx) ([]domain.Event, error)) ([]domain.Event, error) {
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	events, err := save(savepoint)
+	if err == nil {
+		return events, savepoint.Commit(ctx)
+	}
+
+	message, ok := bulkItemError(err)
+	if !ok {
+		return nil, err
+	}
+	if err := savepoint.Rollback(ctx); err != nil {
+		return nil, err
+	}
+	result.Action = ""
+	result.Error = message
+	return nil, nil
+}
+
+// bulkBatchError reports an error of the query of a bulk batch on its item
+// and closes the batch. The error aborted the transaction, the rest of the
+// batch would fail as well.
+func bulkBatchError(br pgx.BatchResults, err error, result *domain.BulkItemResult) error {
+	br.Close()
+	message, ok := bulkItemError(err)
+	if !ok {
+		return err
+	}
+	result.Error = message
+	return domain.ErrBulkAborted
+}
+
+// bulkItemError returns the error reported on a bulk item, or false when
+// err is not caused by the item
+func bulkItemError(err error) (string, bool) {
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.ErrNotFound.Error(), true
+	}
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return "", false
+	}
+	switch {
+	case pgErr.Code == uniqueViolation:
+		return domain.ErrConflict.Error(), true
+	case pgErr.Code == foreignKeyViolation:
+		return bulkReferenceNotFound, true
+	// Data exceptions and the other integrity constraint violations
+	case strings.HasPrefix(pgErr.Code, "22"), strings.HasPrefix(pgErr.Code, "23"):
+		return domain.ErrBadParamInput.Error(), true
+	}
+	return "", false
+}
@@ -0,0 +1,254 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/database"
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPool connects to the migrated database of DATABASE_URL, the test is
+// skipped when it is not set
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	_ = godotenv.Load("../../../.env")
+	url := os.Getenv("DATABASE_URL")
+	if url == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+	pool, err := database.SetupPgxPool(database.PoolConfig{URL: url})
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestParseBulkNews(t *testing.T) {
+	topicID := uuid.New()
+	authorID := uuid.New()
+
+	news, err := parseBulkNews(domain.BulkNewsItem{
+		Title:    "Banjir Jakarta",
+		Topics:   []domain.NewsTopicNew{{TopicId: topicID.String()}},
+		AuthorID: authorID.String(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Nil, news.id)
+	assert.Equal(t, []uuid.UUID{topicID}, news.topicIDs)
+	assert.Equal(t, &authorID, news.authorID)
+	assert.Equal(t, "banjir-jakarta", news.payload.Slug)
+
+	for name, tc := range map[string]struct {
+		item domain.BulkNewsItem
+		err  string
+	}{
+		"invalid topic":  {domain.BulkNewsItem{Topics: []domain.NewsTopicNew{{TopicId: "x"}}}, "invalid topic ID: x"},
+		"invalid news":   {domain.BulkNewsItem{ID: "x"}, "invalid news ID: x"},
+		"invalid author": {domain.BulkNewsItem{AuthorID: "x"}, "invalid author ID: x"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseBulkNews(tc.item)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestBulkItemError(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err     error
+		message string
+		item    bool
+	}{
+		"not found":      {fmt.Errorf("update: %w", domain.ErrNotFound), domain.ErrNotFound.Error(), true},
+		"unique":         {&pgconn.PgError{Code: uniqueViolation}, domain.ErrConflict.Error(), true},
+		"foreign key":    {&pgconn.PgError{Code: foreignKeyViolation}, "referenced item not found", true},
+		"not null":       {&pgconn.PgError{Code: "23502"}, domain.ErrBadParamInput.Error(), true},
+		"data exception": {&pgconn.PgError{Code: "22001"}, domain.ErrBadParamInput.Error(), true},
+		"deadlock":       {&pgconn.PgError{Code: "40P01"}, "", false},
+		"connection":     {errors.New("conn closed"), "", false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			message, item := bulkItemError(tc.err)
+			assert.Equal(t, tc.message, message)
+			assert.Equal(t, tc.item, item)
+		})
+	}
+}
+
+func TestBulkSaveNews_PartialFailure(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo := NewNewsRepository(pool)
+	title := "Bulk " + uuid.NewString()
+
+	results, err := repo.BulkSaveNews(ctx, []domain.BulkNewsItem{
+		{Title: title, Status: "draft", Content: "ok"},
+		{Title: title + " author", Status: "draft", AuthorID: "not-a-uuid"},
+		{Title: title + " unknown", Status: "draft", AuthorID: uuid.NewString()},
+		{ID: uuid.NewString(), Title: title + " missing", Status: "draft"},
+	}, false)
+
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	assert.Equal(t, domain.BulkActionCreated, results[0].Action)
+	assert.Empty(t, results[0].Error)
+	assert.Equal(t, "invalid author ID: not-a-uuid", results[1].Error)
+	assert.Equal(t, "referenced item not found", results[2].Error)
+	assert.Equal(t, domain.ErrNotFound.Error(), results[3].Error)
+
+	created, err := repo.GetNews(ctx, uuid.MustParse(results[0].ID))
+	require.NoError(t, err, "the valid news is saved despite the failed ones")
+	assert.Equal(t, title, created.Title)
+	t.Cleanup(func() {
+		_, _ = repo.BulkDeleteNews(context.Background(), []uuid.UUID{uuid.MustParse(created.ID)}, false)
+	})
+}
+
+func TestBulkSaveNews_AllOrNothing(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo := NewNewsRepository(pool)
+	title := "Bulk " + uuid.NewString()
+
+	_, err := repo.BulkSaveNews(ctx, []domain.BulkNewsItem{
+		{Title: title, Status: "draft", Content: "ok"},
+		{ID: uuid.NewString(), Title: title + " missing", Status: "draft"},
+	}, true)
+
+	require.ErrorIs(t, err, domain.ErrBulkAborted)
+	var count int
+	require.NoError(t, pool.QueryRow(ctx, `SELECT count(*) FROM news WHERE title = $1`, title).Scan(&count))
+	assert.Zero(t, count, "the valid news is rolled back")
+}
+
+func TestBulkSaveNews_AllOrNothingMissingTopic(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo := NewNewsRepository(pool)
+	title := "Bulk " + uuid.NewString()
+
+	results, err := repo.BulkSaveNews(ctx, []domain.BulkNewsItem{
+		{Title: title, Status: "draft", Content: "ok"},
+		{Title: title, Status: "draft", Topics: []domain.NewsTopicNew{{TopicId: uuid.NewString()}}},
+		{Title: title, Status: "draft", AuthorID: uuid.NewString()},
+	}, true)
+
+	require.ErrorIs(t, err, domain.ErrBulkAborted)
+	require.Len(t, results, 3)
+	assert.Empty(t, results[0].Error)
+	assert.Equal(t, "referenced item not found", results[1].Error)
+	assert.Equal(t, "referenced item not found", results[2].Error)
+	var count int
+	require.NoError(t, pool.QueryRow(ctx, `SELECT count(*) FROM news WHERE title = $1`, title).Scan(&count))
+	assert.Zero(t, count, "the valid news is rolled back")
+}
+
+func TestBulkSaveNews_AllOrNothingUpdateFails(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo := NewNewsRepository(pool)
+	title := "Bulk " + uuid.NewString()
+	saved, err := repo.BulkSaveNews(ctx, []domain.BulkNewsItem{
+		{Title: title, Status: "draft"},
+		{Title: title, Status: "draft"},
+	}, true)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = repo.BulkDeleteNews(context.Background(), []uuid.UUID{
+			uuid.MustParse(saved[0].ID), uuid.MustParse(saved[1].ID),
+		}, false)
+	})
+
+	// The NUL byte is refused by the database, a data exception
+	results, err := repo.BulkSaveNews(ctx, []domain.BulkNewsItem{
+		{ID: saved[0].ID, Title: title + "\x00", Status: "draft"},
+		{ID: saved[1].ID, Title: title + " renamed", Status: "draft"},
+	}, true)
+
+	require.ErrorIs(t, err, domain.ErrBulkAborted)
+	require.Len(t, results, 2)
+	assert.Equal(t, domain.ErrBadParamInput.Error(), results[0].Error)
+	var count int
+	require.NoError(t, pool.QueryRow(ctx, `SELECT count(*) FROM news WHERE title = $1`, title).Scan(&count))
+	assert.Equal(t, 2, count, "the other update is rolled back")
+}
+
+func TestBulkSaveTopics_AllOrNothingUpdateFails(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo := NewTopicRepository(pool)
+	name := "Bulk " + uuid.NewString()
+	saved, err := repo.BulkSaveTopics(ctx, []domain.BulkTopicItem{{Name: name}}, true)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = pool.Exec(context.Background(), `DELETE FROM topik WHERE id = $1`, saved[0].ID)
+	})
+
+	results, err := repo.BulkSaveTopics(ctx, []domain.BulkTopicItem{
+		{ID: saved[0].ID, Name: name + "\x00"},
+	}, true)
+
+	require.ErrorIs(t, err, domain.ErrBulkAborted)
+	require.Len(t, results, 1)
+	assert.Equal(t, domain.ErrBadParamInput.Error(), results[0].Error)
+}
+
+func TestBulkSaveTopics_PartialFailure(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo := NewTopicRepository(pool)
+	name := "Bulk " + uuid.NewString()
+
+	results, err := repo.BulkSaveTopics(ctx, []domain.BulkTopicItem{
+		{Name: name},
+		{ID: "not-a-uuid", Name: name + " invalid"},
+		{ID: uuid.NewString(), Name: name + " missing"},
+	}, false)
+
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, domain.BulkActionCreated, results[0].Action)
+	assert.Equal(t, "invalid topic ID: not-a-uuid", results[1].Error)
+	assert.Equal(t, domain.ErrNotFound.Error(), results[2].Error)
+
+	created, err := repo.GetTopic(ctx, uuid.MustParse(results[0].ID))
+	require.NoError(t, err)
+	assert.Equal(t, name, created.Name)
+	t.Cleanup(func() {
+		_, _ = pool.Exec(context.Background(), `DELETE FROM topik WHERE id = $1`, created.ID)
+	})
+}
+
+func TestBulkDeleteNews_PartialFailure(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	repo := NewNewsRepository(pool)
+
+	title := "Bulk " + uuid.NewString()
+	saved, err := repo.BulkSaveNews(ctx, []domain.BulkNewsItem{
+		{Title: title, Status: "draft"},
+	}, true)
+	require.NoError(t, err)
+	id := uuid.MustParse(saved[0].ID)
+
+	results, err := repo.BulkDeleteNews(ctx, []uuid.UUID{uuid.New(), id}, false)
+
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, domain.ErrNotFound.Error(), results[0].Error)
+	assert.Equal(t, domain.BulkActionDeleted, results[1].Action)
+	assert.Equal(t, domain.BulkNewsItem{ID: id.String(), Title: title, Status: "draft", Topics: []domain.NewsTopicNew{}},
+		results[1].Before, "the deleted news is returned for the audit log")
+	_, err = repo.GetNews(ctx, id)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
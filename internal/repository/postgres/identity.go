@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// UpsertExternalUser returns the user behind an identity of an OpenID
+// provider, syncing their name and role with it. Unknown identities are
+// linked to the user with the same email when the provider verified it,
+// dropping their local password, or get a new active user without one.
+// It reports whether the user was created, a concurrent login creating the
+// same identity yields ErrConflict.
+func (u *UserRepository) UpsertExternalUser(ctx context.Context, identity *domain.ExternalIdentity) (*domain.UserCredentials, bool, error) {
+	creds, created, err := u.upsertExternalUser(ctx, identity)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return nil, false, domain.ErrConflict
+	}
+	return creds, created, err
+}
+
+func (u *UserRepository) upsertExternalUser(ctx context.Context, identity *domain.ExternalIdentity) (*domain.UserCredentials, bool, error) {
+	tx, err := u.Conn.Begin(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var userID string
+	var deleted bool
+	err = tx.QueryRow(ctx, `
+		SELECT i.user_id, u.deleted_at IS NOT NULL
+		FROM user_identities i
+		JOIN users u ON u.id = i.user_id
+		WHERE i.issuer = $1 AND i.subject = $2
+		FOR UPDATE OF i`, identity.Issuer, identity.Subject).Scan(&userID, &deleted)
+	linked := err == nil
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return nil, false, err
+	case deleted:
+		// Deleted users stay out, the provider account is not reused
+		return nil, false, domain.ErrUserNotFound
+	}
+
+	if !linked && identity.EmailVerified {
+		err = tx.QueryRow(ctx, `
+			SELECT id
+			FROM users
+			WHERE lower(email) = lower($1) AND deleted_at IS NULL
+			ORDER BY created_at
+			LIMIT 1`, identity.Email).Scan(&userID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, err
+		}
+	}
+
+	created := userID == ""
+	if created {
+		err = tx.QueryRow(ctx, `
+			INSERT INTO users (name, email, password, role, status, email_verified_at, created_at, updated_at)
+			VALUES ($1, $2, '', $3, 'active', CASE WHEN $4 THEN NOW() END, NOW(), NOW())
+			RETURNING id`, identity.Name, identity.Email, identity.Role, identity.EmailVerified).Scan(&userID)
+		if err != nil {
+			return nil, false, err
+		}
+	} else {
+		// The email stays, it identifies the user for the local logins and
+		// may belong to another user by now. Linking a local user drops
+		// their password, the provider is the one checking it from now on.
+		_, err = tx.Exec(ctx, `
+			UPDATE users
+			SET name = $2,
+				role = $3,
+				status = 'active',
+				password = CASE WHEN $4 THEN password ELSE '' END,
+				email_verified_at = CASE WHEN $5 THEN COALESCE(email_verified_at, NOW()) ELSE email_verified_at END,
+				updated_at = NOW()
+			WHERE id = $1`, userID, identity.Name, identity.Role, linked, identity.EmailVerified)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	if linked {
+		_, err = tx.Exec(ctx, `
+			UPDATE user_identities
+			SET last_login_at = NOW()
+			WHERE issuer = $1 AND subject = $2`, identity.Issuer, identity.Subject)
+	} else {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO user_identities (issuer, subject, user_id, created_at, last_login_at)
+			VALUES ($1, $2, $3, NOW(), NOW())`, identity.Issuer, identity.Subject, userID)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var creds domain.UserCredentials
+	err = tx.QueryRow(ctx, `
+		SELECT
+			u.id,
+			u.name,
+			u.email,
+			u.role,
+			u.status,
+			u.email_verified_at,
+			u.password,
+			u.totp_enabled_at IS NOT NULL,
+			COALESCE(p.required, FALSE),
+			u.created_at,
+			u.updated_at
+		FROM users u
+		LEFT JOIN two_factor_policies p ON p.role = u.role
+		WHERE u.id = $1`, userID).Scan(
+		&creds.User.ID,
+		&creds.User.Name,
+		&creds.User.Email,
+		&creds.User.Role,
+		&creds.User.Status,
+		&creds.User.EmailVerifiedAt,
+		&creds.PasswordHash,
+		&creds.TwoFactorEnabled,
+		&creds.TwoFactorRequired,
+		&creds.User.CreatedAt,
+		&creds.User.UpdatedAt,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, err
+	}
+	return &creds, created, nil
+}
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertExternalUser_LinksLocalUser(t *testing.T) {
+	pool := testPool(t)
+	repo := NewUserRepository(pool)
+	ctx := context.Background()
+	email := "oidc-" + uuid.NewString()[:8] + "@example.com"
+
+	var userID string
+	require.NoError(t, pool.QueryRow(ctx, `
+		INSERT INTO users (name, email, password, role, status)
+		VALUES ('Jane', $1, 'local-hash', 'user', 'active')
+		RETURNING id`, email).Scan(&userID))
+	t.Cleanup(func() { _, _ = pool.Exec(ctx, `DELETE FROM users WHERE id = $1`, userID) })
+
+	identity := &domain.ExternalIdentity{
+		Issuer:        "https://idp.example.com",
+		Subject:       uuid.NewString(),
+		Email:         email,
+		EmailVerified: true,
+		Name:          "Jane Doe",
+		Role:          domain.RoleEditor,
+	}
+	creds, created, err := repo.UpsertExternalUser(ctx, identity)
+	require.NoError(t, err)
+
+	assert.False(t, created)
+	assert.Equal(t, userID, creds.User.ID)
+	assert.Equal(t, "Jane Doe", creds.User.Name)
+	assert.Equal(t, domain.RoleEditor, creds.User.Role)
+	assert.Empty(t, creds.PasswordHash, "the local password no longer logs in")
+
+	// A new email at the provider does not replace the one of the user
+	identity.Email = "renamed-" + email
+	creds, created, err = repo.UpsertExternalUser(ctx, identity)
+	require.NoError(t, err)
+
+	assert.False(t, created)
+	assert.Equal(t, userID, creds.User.ID)
+	assert.Equal(t, email, creds.User.Email)
+}
+
+func TestUpsertExternalUser_CreatesUser(t *testing.T) {
+	pool := testPool(t)
+	repo := NewUserRepository(pool)
+	ctx := context.Background()
+
+	identity := &domain.ExternalIdentity{
+		Issuer:  "https://idp.example.com",
+		Subject: uuid.NewString(),
+		Email:   "oidc-" + uuid.NewString()[:8] + "@example.com",
+		Name:    "John",
+		Role:    domain.RoleUser,
+	}
+	creds, created, err := repo.UpsertExternalUser(ctx, identity)
+	require.NoError(t, err)
+	t.Cleanup(func() { _, _ = pool.Exec(ctx, `DELETE FROM users WHERE id = $1`, creds.User.ID) })
+
+	assert.True(t, created)
+	assert.Equal(t, domain.UserStatusActive, creds.User.Status)
+	assert.Nil(t, creds.User.EmailVerifiedAt)
+	assert.Empty(t, creds.PasswordHash)
+
+	again, created, err := repo.UpsertExternalUser(ctx, identity)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, creds.User.ID, again.User.ID)
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LoginThrottleRepository struct {
+	Conn *pgxpool.Pool
+}
+
+func NewLoginThrottleRepository(conn *pgxpool.Pool) *LoginThrottleRepository {
+	return &LoginThrottleRepository{Conn: conn}
+}
+
+// GetLoginThrottles returns the counters of the keys that have any.
+func (l *LoginThrottleRepository) GetLoginThrottles(ctx context.Context, keys []string) ([]domain.LoginThrottle, error) {
+	query := `
+		SELECT key, failures, last_failure_at, locked_until
+		FROM login_throttles
+		WHERE key = ANY($1)`
+
+	rows, err := l.Conn.Query(ctx, query, keys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	throttles := make([]domain.LoginThrottle, 0, len(keys))
+	for rows.Next() {
+		var t domain.LoginThrottle
+		if err := rows.Scan(&t.Key, &t.Failures, &t.LastFailureAt, &t.LockedUntil); err != nil {
+			return nil, err
+		}
+		throttles = append(throttles, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return throttles, nil
+}
+
+// RecordLoginFailure counts a failed login for the key. Counters restart once
+// the last failure is older than window, and the key is locked for lockout
+// whenever it reaches maxFailures. The upsert keeps concurrent replicas from
+// losing failures.
+func (l *LoginThrottleRepository) RecordLoginFailure(ctx context.Context, key string, window time.Duration, maxFailures int, lockout time.Duration) (*domain.LoginThrottle, error) {
+	query := `
+		INSERT INTO login_throttles (key, failures, last_failure_at, locked_until)
+		VALUES ($1, 1, NOW(), CASE WHEN $3 <= 1 THEN NOW() + make_interval(secs => $4) END)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN login_throttles.last_failure_at < NOW() - make_interval(secs => $2) THEN 1
+				ELSE login_throttles.failures + 1
+			END,
+			last_failure_at = NOW(),
+			locked_until = CASE
+				WHEN (CASE
+					WHEN login_throttles.last_failure_at < NOW() - make_interval(secs => $2) THEN 1
+					ELSE login_throttles.failures + 1
+				END) >= $3 THEN NOW() + make_interval(secs => $4)
+				ELSE login_throttles.locked_until
+			END
+		RETURNING key, failures, last_failure_at, locked_until`
+
+	var t domain.LoginThrottle
+	err := l.Conn.QueryRow(ctx, query, key, window.Seconds(), maxFailures, lockout.Seconds()).Scan(
+		&t.Key,
+		&t.Failures,
+		&t.LastFailureAt,
+		&t.LockedUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ClearLoginFailures forgets the failures and lockouts of the keys.
+func (l *LoginThrottleRepository) ClearLoginFailures(ctx context.Context, keys ...string) error {
+	_, err := l.Conn.Exec(ctx, `DELETE FROM login_throttles WHERE key = ANY($1)`, keys)
+	return err
+}
@@ -2,38 +2,57 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
 	"errors"
+	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/edwinjordan/ZOGTest-Golang.git/database/dbroute"
 	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
 	"github.com/edwinjordan/ZOGTest-Golang.git/utils"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type NewsRepository struct {
 	Conn *pgxpool.Pool
+	// Replica serves the reads when set, see dbroute.Reader
+	Replica *pgxpool.Pool
 }
 
 func NewNewsRepository(conn *pgxpool.Pool) *NewsRepository {
 	return &NewsRepository{Conn: conn}
 }
 
+// WithReplica sends the list and get queries to replica, nil keeps them on
+// the primary
+func (u *NewsRepository) WithReplica(replica *pgxpool.Pool) *NewsRepository {
+	u.Replica = replica
+	return u
+}
+
 func (u *NewsRepository) CreateNews(ctx context.Context, news *domain.CreateNewsRequest) (*domain.News, error) {
 	query := `
-		INSERT INTO news (title, slug, status, content, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		INSERT INTO news (title, slug, status, content, author_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, '')::uuid, NOW(), NOW())
 		RETURNING id`
 
+	tx, err := u.Conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
 	var id uuid.UUID
 
 	//var createdAt, updatedAt string
-	err := u.Conn.QueryRow(ctx, query, news.Title, utils.Slugify(news.Title), news.Status, news.Content).Scan(&id)
+	err = tx.QueryRow(ctx, query, news.Title, utils.Slugify(news.Title), news.Status, news.Content, news.AuthorID).Scan(&id)
 	if err != nil {
 		return nil, err
 	}
 	//panic(news.Topic)
+	topicIDs := make([]string, 0, len(news.Topic))
 	for _, detail := range news.Topic {
 
 		topicID, err := uuid.Parse(detail.TopicId)
@@ -45,24 +64,92 @@ func (u *NewsRepository) CreateNews(ctx context.Context, news *domain.CreateNews
 			INSERT INTO news_topic (news_id, topic_id, created_at, updated_at)
 			VALUES ($1, $2, NOW(), NOW())`
 
-		_, err = u.Conn.Exec(ctx, topicQuery, id, topicID)
+		_, err = tx.Exec(ctx, topicQuery, id, topicID)
 		if err != nil {
 			return nil, err
 		}
+		topicIDs = append(topicIDs, topicID.String())
 	}
 	//createdNews.Details = make([]domain.NewsDetail, 0, len(news.Details))
 
+	if err := replaceCoAuthors(ctx, tx, id, news.CoAuthorIDs); err != nil {
+		return nil, err
+	}
+
+	events, err := newsEvents(domain.EventNewsCreated, domain.NewsEventPayload{
+		ID:       id.String(),
+		Title:    news.Title,
+		Slug:     utils.Slugify(news.Title),
+		Status:   news.Status,
+		AuthorID: news.AuthorID,
+		TopicIDs: topicIDs,
+	}, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := enqueueEvents(ctx, tx, events...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
 	return &domain.News{
-		ID:      id.String(),
-		Title:   news.Title,
-		Slug:    utils.Slugify(news.Title),
-		Status:  news.Status,
-		Content: news.Content,
+		ID:          id.String(),
+		Title:       news.Title,
+		Slug:        utils.Slugify(news.Title),
+		Status:      news.Status,
+		Content:     news.Content,
+		CoAuthorIDs: news.CoAuthorIDs,
 		//CreatedAt: createdAt,
 		//UpdatedAt: updatedAt,
 	}, nil
 }
 
+// authorColumns selects the author and co-author summaries of news n, the
+// author must be joined as a. Both are aggregated in SQL to avoid N+1 lookups.
+const authorColumns = `
+			CASE WHEN a.id IS NULL THEN NULL
+				ELSE json_build_object('id', a.id, 'name', a.name)
+			END AS author,
+			(
+				SELECT COALESCE(json_agg(
+					json_build_object('id', cu.id, 'name', cu.name)
+					ORDER BY cu.name
+				), '[]'::json)
+				FROM news_coauthor nc
+				JOIN users cu ON cu.id = nc.user_id
+				WHERE nc.news_id = n.id
+			) AS co_authors`
+
+// replaceCoAuthors sets the co-authors of a news to exactly userIDs
+func replaceCoAuthors(ctx context.Context, q querier, newsID uuid.UUID, userIDs []string) error {
+	ids := make([]uuid.UUID, 0, len(userIDs))
+	for _, userID := range userIDs {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			return errors.New("invalid co-author ID: " + userID)
+		}
+		ids = append(ids, id)
+	}
+
+	_, err := q.Exec(ctx, `DELETE FROM news_coauthor WHERE news_id = $1`, newsID)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err = q.Exec(ctx, `
+		INSERT INTO news_coauthor (news_id, user_id, created_at)
+		SELECT $1, user_id, NOW()
+		FROM unnest($2::uuid[]) AS t(user_id)
+		ON CONFLICT DO NOTHING`, newsID, ids)
+	return err
+}
+
 func (u *NewsRepository) GetNewsList(ctx context.Context, filter *domain.NewsFilter) ([]domain.News, error) {
 	query := `
 		SELECT
@@ -84,22 +171,30 @@ func (u *NewsRepository) GetNewsList(ctx context.Context, filter *domain.NewsFil
                 FROM news_topic nt
                 LEFT JOIN topik t ON t.id = nt.topic_id
 				WHERE nt.news_id = n.id AND t.deleted_at IS NULL
-			) as topics_list
+			) as topics_list,
+			` + authorColumns + `
 		FROM news n
+		LEFT JOIN users a ON a.id = n.author_id
 		WHERE n.deleted_at is NULL`
 
 	var args []interface{}
 	var conditions []string
 	if filter != nil && filter.Search != "" {
-		conditions = append(conditions, "(n.title ILIKE $1 OR n.content ILIKE $1)")
 		args = append(args, "%"+filter.Search+"%")
+		conditions = append(conditions, fmt.Sprintf("(n.title ILIKE $%d OR n.content ILIKE $%d)", len(args), len(args)))
+	}
+	if filter != nil && filter.AuthorID != "" {
+		args = append(args, filter.AuthorID)
+		conditions = append(conditions, fmt.Sprintf(
+			"(n.author_id = $%d OR EXISTS (SELECT 1 FROM news_coauthor nc WHERE nc.news_id = n.id AND nc.user_id = $%d))",
+			len(args), len(args)))
 	}
 
 	if len(conditions) > 0 {
 		query += " AND " + strings.Join(conditions, " AND ")
 	}
 
-	rows, err := u.Conn.Query(ctx, query, args...)
+	rows, err := dbroute.Reader(ctx, u.Conn, u.Replica).Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -118,6 +213,8 @@ func (u *NewsRepository) GetNewsList(ctx context.Context, filter *domain.NewsFil
 			&news.CreatedAt,
 			&news.UpdatedAt,
 			&news.TopicList,
+			&news.Author,
+			&news.CoAuthors,
 		)
 		if err != nil {
 			return nil, err
@@ -138,13 +235,13 @@ func (u *NewsRepository) GetNewsList(ctx context.Context, filter *domain.NewsFil
 func (u *NewsRepository) GetNews(ctx context.Context, id uuid.UUID) (*domain.News, error) {
 	query := `
 		SELECT
-			id,
-			title,
-			slug,
-			status,
-			content,
-			created_at,
-			updated_at,
+			n.id,
+			n.title,
+			n.slug,
+			n.status,
+			n.content,
+			n.created_at,
+			n.updated_at,
 			 (
                 SELECT COALESCE(json_agg(
                     json_build_object(
@@ -156,12 +253,14 @@ func (u *NewsRepository) GetNews(ctx context.Context, id uuid.UUID) (*domain.New
                 FROM news_topic nt
                 LEFT JOIN topik t ON t.id = nt.topic_id
 				WHERE nt.news_id = n.id AND t.deleted_at IS NULL
-			) as topics
+			) as topics,
+			` + authorColumns + `
 		FROM news as n
+		LEFT JOIN users a ON a.id = n.author_id
 		WHERE n.id = $1 AND n.deleted_at IS NULL`
 
 	var news domain.News
-	err := u.Conn.QueryRow(ctx, query, id).Scan(
+	err := dbroute.Reader(ctx, u.Conn, u.Replica).QueryRow(ctx, query, id).Scan(
 		&news.ID,
 		&news.Title,
 		&news.Slug,
@@ -170,6 +269,8 @@ func (u *NewsRepository) GetNews(ctx context.Context, id uuid.UUID) (*domain.New
 		&news.CreatedAt,
 		&news.UpdatedAt,
 		&news.Topics,
+		&news.Author,
+		&news.CoAuthors,
 	)
 	if err != nil {
 		return nil, err
@@ -180,27 +281,36 @@ func (u *NewsRepository) GetNews(ctx context.Context, id uuid.UUID) (*domain.New
 
 func (u *NewsRepository) UpdateNews(ctx context.Context, id uuid.UUID, news *domain.News) (*domain.News, error) {
 	query := `
-		UPDATE news
+		UPDATE news n
 		SET title = $1,
 			slug = $2,
 			status = $3,
 			content = $4,
 			updated_at = NOW()
-		WHERE id = $5 AND deleted_at IS NULL
-		RETURNING id, title, slug, status, content, updated_at`
+		FROM news previous
+		WHERE n.id = $5 AND n.deleted_at IS NULL AND previous.id = n.id
+		RETURNING n.id, n.title, n.slug, n.status, n.content, n.updated_at, previous.status`
+
+	tx, err := u.Conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
 
 	var updatedNews domain.News
-	err := u.Conn.QueryRow(ctx, query, news.Title, utils.Slugify(news.Title), news.Status, news.Content, id).Scan(
+	var previousStatus string
+	err = tx.QueryRow(ctx, query, news.Title, utils.Slugify(news.Title), news.Status, news.Content, id).Scan(
 		&updatedNews.ID,
 		&updatedNews.Title,
 		&updatedNews.Slug,
 		&updatedNews.Status,
 		&updatedNews.Content,
 		&updatedNews.UpdatedAt,
+		&previousStatus,
 	//	&updatedNews.CreatedAt,
 	)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, domain.ErrUserNotFound
 
 		}
@@ -211,11 +321,12 @@ func (u *NewsRepository) UpdateNews(ctx context.Context, id uuid.UUID, news *dom
 		DELETE FROM news_topic
 		WHERE news_id = $1 `
 
-	_, err2 := u.Conn.Exec(ctx, query_topik, id)
+	_, err2 := tx.Exec(ctx, query_topik, id)
 	if err2 != nil {
 		return nil, err2
 	}
 
+	topicIDs := make([]string, 0, len(news.Topics))
 	for _, detail := range news.Topics {
 
 		topicID, err := uuid.Parse(detail.TopicId)
@@ -227,11 +338,36 @@ func (u *NewsRepository) UpdateNews(ctx context.Context, id uuid.UUID, news *dom
 			INSERT INTO news_topic (news_id, topic_id, created_at, updated_at)
 			VALUES ($1, $2, NOW(), NOW())`
 
-		_, err = u.Conn.Exec(ctx, topicQuery, id, topicID)
+		_, err = tx.Exec(ctx, topicQuery, id, topicID)
 		if err != nil {
 			return nil, err
 		}
+		topicIDs = append(topicIDs, topicID.String())
 	}
+	if news.CoAuthorIDs != nil {
+		if err := replaceCoAuthors(ctx, tx, id, news.CoAuthorIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	events, err := newsEvents(domain.EventNewsUpdated, domain.NewsEventPayload{
+		ID:       updatedNews.ID,
+		Title:    updatedNews.Title,
+		Slug:     updatedNews.Slug,
+		Status:   updatedNews.Status,
+		TopicIDs: topicIDs,
+	}, previousStatus)
+	if err != nil {
+		return nil, err
+	}
+	if err := enqueueEvents(ctx, tx, events...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
 	//news.UpdatedAt = utils.ParseTime(updatedAt)
 	return &updatedNews, nil
 }
@@ -242,7 +378,13 @@ func (u *NewsRepository) DeleteNews(ctx context.Context, id uuid.UUID) error {
 		SET deleted_at = NOW()
 		WHERE id = $1 AND deleted_at IS NULL`
 
-	result, err := u.Conn.Exec(ctx, query, id)
+	tx, err := u.Conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -250,5 +392,478 @@ func (u *NewsRepository) DeleteNews(ctx context.Context, id uuid.UUID) error {
 		return domain.ErrUserNotFound
 	}
 
-	return nil
+	event, err := domain.NewEvent(domain.EventNewsDeleted, domain.AuditEntityNews, id.String(),
+		domain.NewsEventPayload{ID: id.String()})
+	if err != nil {
+		return err
+	}
+	if err := enqueueEvents(ctx, tx, event); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+const bulkUpdateNewsQuery = `
+	WITH updated AS (
+		UPDATE news n
+		SET title = $1,
+			slug = $2,
+			status = $3,
+			content = $4,
+			updated_at = NOW()
+		FROM news previous
+		WHERE n.id = $5 AND n.deleted_at IS NULL AND previous.id = n.id
+		RETURNING n.id, previous.title, previous.status, previous.content
+	), cleared AS (
+		DELETE FROM news_topic
+		WHERE news_id IN (SELECT id FROM updated)
+	), linked AS (
+		INSERT INTO news_topic (news_id, topic_id, created_at, updated_at)
+		SELECT updated.id, t.topic_id, NOW(), NOW()
+		FROM updated, unnest($6::uuid[]) AS t(topic_id)
+	)
+	-- The statement runs on the snapshot taken before it, the topics are the
+	-- previous ones
+	SELECT id, title, status, content, ARRAY(
+		SELECT nt.topic_id::text FROM news_topic nt
+		WHERE nt.news_id = updated.id
+		ORDER BY nt.topic_id)
+	FROM updated`
+
+// scanPreviousNews scans the id, title, status, content and topics of a
+// news as it was before a bulk update or delete
+func scanPreviousNews(row pgx.Row) (uuid.UUID, domain.BulkNewsItem, error) {
+	var id uuid.UUID
+	var previous domain.BulkNewsItem
+	var topicIDs []string
+	if err := row.Scan(&id, &previous.Title, &previous.Status, &previous.Content, &topicIDs); err != nil {
+		return id, previous, err
+	}
+	previous.ID = id.String()
+	previous.Topics = make([]domain.NewsTopicNew, len(topicIDs))
+	for i, topicID := range topicIDs {
+		previous.Topics[i] = domain.NewsTopicNew{TopicId: topicID}
+	}
+	return id, previous, nil
+}
+
+// bulkNews is a bulk news item with its IDs parsed
+type bulkNews struct {
+	index int
+	// id is uuid.Nil for a news to create
+	id uuid.UUID
+	// createID is the id of a news created with the id of its item
+	createID uuid.UUID
+	authorID *uuid.UUID
+	topicIDs []uuid.UUID
+	payload  domain.NewsEventPayload
+}
+
+// newID returns the id of a news to create
+func (news bulkNews) newID() uuid.UUID {
+	if news.createID != uuid.Nil {
+		return news.createID
+	}
+	return uuid.New()
+}
+
+// parseBulkNews parses the IDs of item, an invalid one fails the item only
+func parseBulkNews(item domain.BulkNewsItem) (bulkNews, error) {
+	news := bulkNews{
+		topicIDs: make([]uuid.UUID, 0, len(item.Topics)),
+		payload: domain.NewsEventPayload{
+			Title:  item.Title,
+			Slug:   utils.Slugify(item.Title),
+			Status: item.Status,
+		},
+	}
+	for _, detail := range item.Topics {
+		topicID, err := uuid.Parse(detail.TopicId)
+		if err != nil {
+			return news, errors.New("invalid topic ID: " + detail.TopicId)
+		}
+		news.topicIDs = append(news.topicIDs, topicID)
+		news.payload.TopicIDs = append(news.payload.TopicIDs, topicID.String())
+	}
+	if item.ID != "" {
+		id, err := uuid.Parse(item.ID)
+		if err != nil {
+			return news, errors.New("invalid news ID: " + item.ID)
+		}
+		if !item.Insert {
+			news.id = id
+			return news, nil
+		}
+		news.createID = id
+	}
+	if item.AuthorID != "" {
+		authorID, err := uuid.Parse(item.AuthorID)
+		if err != nil {
+			return news, errors.New("invalid author ID: " + item.AuthorID)
+		}
+		news.authorID = &authorID
+		news.payload.AuthorID = item.AuthorID
+	}
+	return news, nil
+}
+
+// BulkSaveNews creates and updates news inside a single transaction, the
+// events of every saved news are written to the outbox in the same
+// transaction. The returned results are aligned with items.
+//
+// When allOrNothing is set, new rows are loaded with COPY and updates are
+// pipelined through a pgx.Batch, an invalid or missing news rolls the whole
+// transaction back. Otherwise every news is saved in a savepoint of its
+// own, a news failing is reported on its item and the others are kept.
+func (u *NewsRepository) BulkSaveNews(ctx context.Context, items []domain.BulkNewsItem, allOrNothing bool) ([]domain.BulkItemResult, error) {
+	results := make([]domain.BulkItemResult, len(items))
+	parsed := make([]bulkNews, 0, len(items))
+	for i, item := range items {
+		results[i] = domain.BulkItemResult{Index: i, ID: item.ID}
+		news, err := parseBulkNews(item)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		news.index = i
+		parsed = append(parsed, news)
+	}
+	if allOrNothing && len(parsed) < len(items) {
+		return results, domain.ErrBulkAborted
+	}
+
+	tx, err := u.Conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	parsed, err = checkBulkNewsReferences(ctx, tx, parsed, results)
+	if err != nil {
+		return nil, err
+	}
+	if allOrNothing && len(parsed) < len(items) {
+		return results, domain.ErrBulkAborted
+	}
+
+	var events []domain.Event
+	if allOrNothing {
+		events, err = copyBulkNews(ctx, tx, items, parsed, results)
+	} else {
+		events, err = saveEachBulkNews(ctx, tx, items, parsed, results)
+	}
+	if errors.Is(err, domain.ErrBulkAborted) {
+		return results, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := enqueueEvents(ctx, tx, events...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// checkBulkNewsReferences fails the items linking a topic or an author that
+// does not exist and returns the others. news_topic has no foreign key on
+// the topics, and COPY would not tell which row broke the one on the authors.
+func checkBulkNewsReferences(ctx context.Context, tx pgx.Tx, parsed []bulkNews, results []domain.BulkItemResult) ([]bulkNews, error) {
+	var topicIDs, authorIDs []uuid.UUID
+	for _, news := range parsed {
+		topicIDs = append(topicIDs, news.topicIDs...)
+		if news.authorID != nil {
+			authorIDs = append(authorIDs, *news.authorID)
+		}
+	}
+	if len(topicIDs) == 0 && len(authorIDs) == 0 {
+		return parsed, nil
+	}
+
+	var topics, authors []string
+	err := tx.QueryRow(ctx, `
+		SELECT
+			ARRAY(SELECT id::text FROM topik WHERE id = ANY($1) AND deleted_at IS NULL),
+			ARRAY(SELECT id::text FROM users WHERE id = ANY($2))`,
+		topicIDs, authorIDs).Scan(&topics, &authors)
+	if err != nil {
+		return nil, err
+	}
+
+	valid := parsed[:0]
+	for _, news := range parsed {
+		missing := slices.ContainsFunc(news.topicIDs, func(id uuid.UUID) bool {
+			return !slices.Contains(topics, id.String())
+		})
+		if news.authorID != nil && !slices.Contains(authors, news.authorID.String()) {
+			missing = true
+		}
+		if missing {
+			results[news.index].Error = bulkReferenceNotFound
+			continue
+		}
+		valid = append(valid, news)
+	}
+	return valid, nil
+}
+
+// copyBulkNews saves every news with COPY and a pgx.Batch, a missing news
+// or a violated constraint fails with domain.ErrBulkAborted. The first
+// update failing ends the batch, the updates after it are not reported.
+func copyBulkNews(ctx context.Context, tx pgx.Tx, items []domain.BulkNewsItem, parsed []bulkNews, results []domain.BulkItemResult) ([]domain.Event, error) {
+	var newsRows, topicRows [][]any
+	var updates []bulkNews
+	var events []domain.Event
+	batch := &pgx.Batch{}
+	for _, news := range parsed {
+		item := items[news.index]
+		if news.id != uuid.Nil {
+			batch.Queue(bulkUpdateNewsQuery, item.Title, news.payload.Slug, item.Status, item.Content, news.id, news.topicIDs)
+			updates = append(updates, news)
+			continue
+		}
+
+		id := news.newID()
+		newsRows = append(newsRows, []any{id, item.Title, news.payload.Slug, item.Status, item.Content, news.authorID})
+		for _, topicID := range news.topicIDs {
+			topicRows = append(topicRows, []any{id, topicID})
+		}
+		results[news.index].ID = id.String()
+		results[news.index].Action = domain.BulkActionCreated
+
+		news.payload.ID = id.String()
+		created, err := newsEvents(domain.EventNewsCreated, news.payload, "")
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, created...)
+	}
+
+	if len(newsRows) > 0 {
+		_, err := tx.CopyFrom(ctx, pgx.Identifier{"news"},
+			[]string{"id", "title", "slug", "status", "content", "author_id"},
+			pgx.CopyFromRows(newsRows))
+		if err != nil {
+			return nil, copyBulkNewsError(err, parsed, results)
+		}
+	}
+	if len(topicRows) > 0 {
+		_, err := tx.CopyFrom(ctx, pgx.Identifier{"news_topic"},
+			[]string{"news_id", "topic_id"},
+			pgx.CopyFromRows(topicRows))
+		if err != nil {
+			return nil, copyBulkNewsError(err, parsed, results)
+		}
+	}
+
+	failed := false
+	if batch.Len() > 0 {
+		br := tx.SendBatch(ctx, batch)
+		for _, news := range updates {
+			id, previous, err := scanPreviousNews(br.QueryRow())
+			if errors.Is(err, pgx.ErrNoRows) {
+				results[news.index].Error = domain.ErrNotFound.Error()
+				failed = true
+				continue
+			}
+			if err != nil {
+				return nil, bulkBatchError(br, err, &results[news.index])
+			}
+			results[news.index].Action = domain.BulkActionUpdated
+			results[news.index].Before = previous
+
+			news.payload.ID = id.String()
+			updated, err := newsEvents(domain.EventNewsUpdated, news.payload, previous.Status)
+			if err != nil {
+				br.Close()
+				return nil, err
+			}
+			events = append(events, updated...)
+		}
+		if err := br.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if failed {
+		return nil, domain.ErrBulkAborted
+	}
+	return events, nil
+}
+
+// copyBulkNewsError reports a constraint violated by COPY on every news to
+// create, COPY does not tell which row broke it
+func copyBulkNewsError(err error, parsed []bulkNews, results []domain.BulkItemResult) error {
+	message, ok := bulkItemError(err)
+	if !ok {
+		return err
+	}
+	for _, news := range parsed {
+		if news.id == uuid.Nil {
+			results[news.index].Action = ""
+			results[news.index].Error = message
+		}
+	}
+	return domain.ErrBulkAborted
+}
+
+// saveEachBulkNews saves every news in a savepoint of its own, a news
+// failing is reported on its item
+func saveEachBulkNews(ctx context.Context, tx pgx.Tx, items []domain.BulkNewsItem, parsed []bulkNews, results []domain.BulkItemResult) ([]domain.Event, error) {
+	var events []domain.Event
+	for _, news := range parsed {
+		saved, err := inSavepoint(ctx, tx, &results[news.index], func(sp pgx.Tx) ([]domain.Event, error) {
+			return saveBulkNews(ctx, sp, items[news.index], news, &results[news.index])
+		})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, saved...)
+	}
+	return events, nil
+}
+
+// saveBulkNews creates or updates a single news and returns its events
+func saveBulkNews(ctx context.Context, tx pgx.Tx, item domain.BulkNewsItem, news bulkNews, result *domain.BulkItemResult) ([]domain.Event, error) {
+	if news.id != uuid.Nil {
+		_, previous, err := scanPreviousNews(tx.QueryRow(ctx, bulkUpdateNewsQuery,
+			item.Title, news.payload.Slug, item.Status, item.Content, news.id, news.topicIDs))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Action = domain.BulkActionUpdated
+		result.Before = previous
+		news.payload.ID = news.id.String()
+		return newsEvents(domain.EventNewsUpdated, news.payload, previous.Status)
+	}
+
+	id := news.newID()
+	_, err := tx.Exec(ctx, `
+		INSERT INTO news (id, title, slug, status, content, author_id)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		id, item.Title, news.payload.Slug, item.Status, item.Content, news.authorID)
+	if err != nil {
+		return nil, err
+	}
+	if len(news.topicIDs) > 0 {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO news_topic (news_id, topic_id)
+			SELECT $1, topic_id FROM unnest($2::uuid[]) AS t(topic_id)`, id, news.topicIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	result.ID = id.String()
+	result.Action = domain.BulkActionCreated
+	news.payload.ID = id.String()
+	return newsEvents(domain.EventNewsCreated, news.payload, "")
+}
+
+// BulkDeleteNews soft deletes the given news and writes a deleted event for
+// each of them to the outbox. The returned results are aligned with ids, ids
+// that do not exist are reported as not found. When allOrNothing is set the
+// news are deleted in one statement, otherwise each in a savepoint of its
+// own so a news failing is reported on its item.
+func (u *NewsRepository) BulkDeleteNews(ctx context.Context, ids []uuid.UUID, allOrNothing bool) ([]domain.BulkItemResult, error) {
+	query := `
+		UPDATE news
+		SET deleted_at = NOW()
+		WHERE id = ANY($1) AND deleted_at IS NULL
+		RETURNING id, title, status, content, ARRAY(
+			SELECT nt.topic_id::text FROM news_topic nt
+			WHERE nt.news_id = news.id
+			ORDER BY nt.topic_id)`
+
+	tx, err := u.Conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	results := make([]domain.BulkItemResult, len(ids))
+	for i, id := range ids {
+		results[i] = domain.BulkItemResult{Index: i, ID: id.String()}
+	}
+
+	events := make([]domain.Event, 0, len(ids))
+	if allOrNothing {
+		rows, err := tx.Query(ctx, query, ids)
+		if err != nil {
+			return nil, err
+		}
+		deleted := make(map[uuid.UUID]domain.BulkNewsItem, len(ids))
+		for rows.Next() {
+			id, previous, err := scanPreviousNews(rows)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			deleted[id] = previous
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		failed := false
+		for i, id := range ids {
+			previous, found := deleted[id]
+			if !found {
+				results[i].Error = domain.ErrNotFound.Error()
+				failed = true
+				continue
+			}
+			results[i].Action = domain.BulkActionDeleted
+			results[i].Before = previous
+			event, err := newsDeletedEvent(id)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+		}
+		if failed {
+			return results, domain.ErrBulkAborted
+		}
+	} else {
+		for i, id := range ids {
+			deleted, err := inSavepoint(ctx, tx, &results[i], func(sp pgx.Tx) ([]domain.Event, error) {
+				_, previous, err := scanPreviousNews(sp.QueryRow(ctx, query, []uuid.UUID{id}))
+				if errors.Is(err, pgx.ErrNoRows) {
+					return nil, domain.ErrNotFound
+				}
+				if err != nil {
+					return nil, err
+				}
+				results[i].Action = domain.BulkActionDeleted
+				results[i].Before = previous
+				event, err := newsDeletedEvent(id)
+				return []domain.Event{event}, err
+			})
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, deleted...)
+		}
+	}
+
+	if err := enqueueEvents(ctx, tx, events...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func newsDeletedEvent(id uuid.UUID) (domain.Event, error) {
+	return domain.NewEvent(domain.EventNewsDeleted, domain.AuditEntityNews, id.String(),
+		domain.NewsEventPayload{ID: id.String()})
 }
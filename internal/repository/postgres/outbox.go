@@ -0,0 +1,161 @@
+package postgres
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// querier is satisfied by both the pool and a transaction
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// enqueueEvents writes events to the outbox within tx, so they are only
+// published when the change they describe is committed.
+func enqueueEvents(ctx context.Context, tx pgx.Tx, events ...domain.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	rows := make([][]any, len(events))
+	for i, event := range events {
+		id, err := uuid.Parse(event.ID)
+		if err != nil {
+			return err
+		}
+		rows[i] = []any{id, event.Type, event.AggregateType, event.AggregateID, string(event.Payload), event.OccurredAt}
+	}
+	_, err := tx.CopyFrom(ctx, pgx.Identifier{"outbox"},
+		[]string{"id", "event_type", "aggregate_type", "aggregate_id", "payload", "occurred_at"},
+		pgx.CopyFromRows(rows))
+	return err
+}
+
+// newsEvents builds the events of a saved news. A news is published when it
+// reaches the published status, previousStatus is empty for new news.
+func newsEvents(eventType string, payload domain.NewsEventPayload, previousStatus string) ([]domain.Event, error) {
+	event, err := domain.NewEvent(eventType, domain.AuditEntityNews, payload.ID, payload)
+	if err != nil {
+		return nil, err
+	}
+	events := []domain.Event{event}
+
+	if payload.Status == domain.NewsStatusPublished && previousStatus != domain.NewsStatusPublished {
+		published, err := domain.NewEvent(domain.EventNewsPublished, domain.AuditEntityNews, payload.ID, payload)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, published)
+	}
+	return events, nil
+}
+
+type OutboxRepository struct {
+	Conn *pgxpool.Pool
+}
+
+func NewOutboxRepository(conn *pgxpool.Pool) *OutboxRepository {
+	return &OutboxRepository{Conn: conn}
+}
+
+// ClaimOutboxEvents picks up to limit due events and leases them for the given
+// duration. A relay that dies mid-delivery loses its lease and the events are
+// claimed again once it expires, concurrent relays skip each other's events.
+func (o *OutboxRepository) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxMessage, error) {
+	query := `
+		UPDATE outbox o
+		SET attempts = o.attempts + 1,
+			next_attempt_at = NOW() + make_interval(secs => $2)
+		FROM (
+			SELECT id
+			FROM outbox
+			WHERE dispatched_at IS NULL
+				AND failed_at IS NULL
+				AND next_attempt_at <= NOW()
+			ORDER BY occurred_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		) due
+		WHERE o.id = due.id
+		RETURNING o.id, o.event_type, o.aggregate_type, o.aggregate_id, o.payload, o.occurred_at, o.attempts, o.delivered_sinks`
+
+	rows, err := o.Conn.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []domain.OutboxMessage
+	for rows.Next() {
+		var msg domain.OutboxMessage
+		err := rows.Scan(
+			&msg.ID,
+			&msg.Type,
+			&msg.AggregateType,
+			&msg.AggregateID,
+			&msg.Payload,
+			&msg.OccurredAt,
+			&msg.Attempts,
+			&msg.DeliveredSinks,
+		)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].OccurredAt.Before(messages[j].OccurredAt)
+	})
+	return messages, nil
+}
+
+// MarkOutboxDispatched records that every sink received the event.
+func (o *OutboxRepository) MarkOutboxDispatched(ctx context.Context, id string) error {
+	_, err := o.Conn.Exec(ctx, `
+		UPDATE outbox
+		SET dispatched_at = NOW(), last_error = NULL
+		WHERE id = $1`, id)
+	return err
+}
+
+// RetryOutboxEvent schedules another delivery of the event to the sinks
+// missing from deliveredSinks.
+func (o *OutboxRepository) RetryOutboxEvent(ctx context.Context, id string, nextAttemptAt time.Time, lastError string, deliveredSinks []string) error {
+	_, err := o.Conn.Exec(ctx, `
+		UPDATE outbox
+		SET next_attempt_at = $2, last_error = $3, delivered_sinks = $4
+		WHERE id = $1`, id, nextAttemptAt, lastError, deliveredSinks)
+	return err
+}
+
+// ReleaseOutboxEvents makes claimed events which were not delivered due
+// again right away, their claim does not count as an attempt.
+func (o *OutboxRepository) ReleaseOutboxEvents(ctx context.Context, ids []string) error {
+	_, err := o.Conn.Exec(ctx, `
+		UPDATE outbox
+		SET attempts = attempts - 1, next_attempt_at = NOW()
+		WHERE id = ANY($1::uuid[]) AND dispatched_at IS NULL AND failed_at IS NULL`, ids)
+	return err
+}
+
+// FailOutboxEvent gives up on the event, it stays in the outbox for inspection.
+func (o *OutboxRepository) FailOutboxEvent(ctx context.Context, id string, lastError string) error {
+	_, err := o.Conn.Exec(ctx, `
+		UPDATE outbox
+		SET failed_at = NOW(), last_error = $2
+		WHERE id = $1`, id, lastError)
+	return err
+}
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PartnerClientRepository struct {
+	Conn *pgxpool.Pool
+}
+
+func NewPartnerClientRepository(conn *pgxpool.Pool) *PartnerClientRepository {
+	return &PartnerClientRepository{Conn: conn}
+}
+
+// GetPartnerClient returns the client with its secret, revoked clients are
+// reported as not found.
+func (p *PartnerClientRepository) GetPartnerClient(ctx context.Context, id uuid.UUID) (*domain.PartnerClient, error) {
+	query := `
+		SELECT id, name, scopes, secret, created_at
+		FROM partner_clients
+		WHERE id = $1 AND revoked_at IS NULL`
+
+	var client domain.PartnerClient
+	err := p.Conn.QueryRow(ctx, query, id).Scan(
+		&client.ID,
+		&client.Name,
+		&client.Scopes,
+		&client.Secret,
+		&client.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &client, nil
+}
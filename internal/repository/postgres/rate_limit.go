@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// RateLimitRepository keeps the rate limits in Postgres so every replica
+// shares them, it implements ratelimit.Store
+type RateLimitRepository struct {
+	Conn *pgxpool.Pool
+}
+
+func NewRateLimitRepository(conn *pgxpool.Pool) *RateLimitRepository {
+	return &RateLimitRepository{Conn: conn}
+}
+
+// Take runs the GCRA in a single upsert so concurrent replicas cannot race.
+// The SET expressions all see the row before the update, allowed records
+// the decision for RETURNING.
+func (r *RateLimitRepository) Take(ctx context.Context, key string, now time.Time, interval, tolerance time.Duration) (bool, time.Time, error) {
+	query := `
+		INSERT INTO rate_limits (key, tat, allowed)
+		VALUES ($1, $2::timestamptz + make_interval(secs => $3), TRUE)
+		ON CONFLICT (key) DO UPDATE SET
+			allowed = GREATEST(rate_limits.tat, $2) + make_interval(secs => $3) <= $2::timestamptz + make_interval(secs => $4),
+			tat = CASE
+				WHEN GREATEST(rate_limits.tat, $2) + make_interval(secs => $3) <= $2::timestamptz + make_interval(secs => $4)
+				THEN GREATEST(rate_limits.tat, $2) + make_interval(secs => $3)
+				ELSE rate_limits.tat
+			END
+		RETURNING allowed, tat`
+
+	var allowed bool
+	var tat time.Time
+	err := r.Conn.QueryRow(ctx, query, key, now, interval.Seconds(), tolerance.Seconds()).Scan(&allowed, &tat)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	return allowed, tat, nil
+}
+
+// DeleteExpired forgets the keys whose bucket is full again
+func (r *RateLimitRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	tag, err := r.Conn.Exec(ctx, `DELETE FROM rate_limits WHERE tat < $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
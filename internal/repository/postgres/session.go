@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SessionRepository struct {
+	Conn *pgxpool.Pool
+}
+
+func NewSessionRepository(conn *pgxpool.Pool) *SessionRepository {
+	return &SessionRepository{Conn: conn}
+}
+
+// CreateSession stores a new session, filling in its id and creation time.
+func (s *SessionRepository) CreateSession(ctx context.Context, session *domain.Session) error {
+	query := `
+		INSERT INTO sessions (user_id, token_hash, ip, user_agent, expires_at, last_seen_at, last_seen_ip, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), $3, NOW())
+		RETURNING id, last_seen_at, last_seen_ip, created_at`
+
+	return s.Conn.QueryRow(ctx, query,
+		session.UserID,
+		session.TokenHash,
+		session.IP,
+		session.UserAgent,
+		session.ExpiresAt,
+	).Scan(&session.ID, &session.LastSeenAt, &session.LastSeenIP, &session.CreatedAt)
+}
+
+// GetSessionByHash fetches a live session together with its active user.
+// Unknown, expired and revoked sessions yield ErrInvalidToken.
+func (s *SessionRepository) GetSessionByHash(ctx context.Context, tokenHash string) (*domain.SessionCredentials, error) {
+	query := `
+		SELECT
+			s.id,
+			s.user_id,
+			s.ip,
+			s.user_agent,
+			s.last_seen_at,
+			s.last_seen_ip,
+			s.expires_at,
+			s.created_at,
+			u.name,
+			u.email,
+			u.role,
+			u.status,
+			u.totp_enabled_at IS NOT NULL,
+			COALESCE(p.required, FALSE)
+		FROM sessions s
+		JOIN users u ON u.id = s.user_id
+		LEFT JOIN two_factor_policies p ON p.role = u.role
+		WHERE s.token_hash = $1
+			AND s.revoked_at IS NULL
+			AND s.expires_at > NOW()
+			AND u.status = 'active'
+			AND u.deleted_at IS NULL`
+
+	var creds domain.SessionCredentials
+	err := s.Conn.QueryRow(ctx, query, tokenHash).Scan(
+		&creds.Session.ID,
+		&creds.Session.UserID,
+		&creds.Session.IP,
+		&creds.Session.UserAgent,
+		&creds.Session.LastSeenAt,
+		&creds.Session.LastSeenIP,
+		&creds.Session.ExpiresAt,
+		&creds.Session.CreatedAt,
+		&creds.User.Name,
+		&creds.User.Email,
+		&creds.User.Role,
+		&creds.User.Status,
+		&creds.TwoFactorEnabled,
+		&creds.TwoFactorRequired,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, err
+	}
+	creds.User.ID = creds.Session.UserID
+	return &creds, nil
+}
+
+// TouchSession records a request made with the session.
+func (s *SessionRepository) TouchSession(ctx context.Context, id, ip string) error {
+	_, err := s.Conn.Exec(ctx, `
+		UPDATE sessions
+		SET last_seen_at = NOW(), last_seen_ip = $2
+		WHERE id = $1`, id, ip)
+	return err
+}
+
+// GetSessions lists the live sessions of a user, most recently used first.
+func (s *SessionRepository) GetSessions(ctx context.Context, userID string) ([]domain.Session, error) {
+	query := `
+		SELECT id, user_id, ip, user_agent, last_seen_at, last_seen_ip, expires_at, created_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_seen_at DESC`
+
+	rows, err := s.Conn.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]domain.Session, 0)
+	for rows.Next() {
+		var session domain.Session
+		err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.IP,
+			&session.UserAgent,
+			&session.LastSeenAt,
+			&session.LastSeenIP,
+			&session.ExpiresAt,
+			&session.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RevokeSession ends one live session of a user. Sessions of other users,
+// and ended ones, yield ErrNotFound.
+func (s *SessionRepository) RevokeSession(ctx context.Context, userID, id string) error {
+	tag, err := s.Conn.Exec(ctx, `
+		UPDATE sessions
+		SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW()`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// RevokeSessions ends every live session of a user and returns how many
+// there were.
+func (s *SessionRepository) RevokeSessions(ctx context.Context, userID string) (int64, error) {
+	tag, err := s.Conn.Exec(ctx, `
+		UPDATE sessions
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// RevokeOtherSessions ends every live session of a user except keepID and
+// returns how many there were.
+func (s *SessionRepository) RevokeOtherSessions(ctx context.Context, userID, keepID string) (int64, error) {
+	tag, err := s.Conn.Exec(ctx, `
+		UPDATE sessions
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL AND expires_at > NOW()`, userID, keepID)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
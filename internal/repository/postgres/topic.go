@@ -2,24 +2,34 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"strings"
 
+	"github.com/edwinjordan/ZOGTest-Golang.git/database/dbroute"
 	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
 	"github.com/edwinjordan/ZOGTest-Golang.git/utils"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type TopicRepository struct {
 	Conn *pgxpool.Pool
+	// Replica serves the reads when set, see dbroute.Reader
+	Replica *pgxpool.Pool
 }
 
 func NewTopicRepository(conn *pgxpool.Pool) *TopicRepository {
 	return &TopicRepository{Conn: conn}
 }
 
+// WithReplica sends the list and get queries to replica, nil keeps them on
+// the primary
+func (u *TopicRepository) WithReplica(replica *pgxpool.Pool) *TopicRepository {
+	u.Replica = replica
+	return u
+}
+
 func (u *TopicRepository) CreateTopic(ctx context.Context, topic *domain.CreateTopicRequest) (*domain.Topic, error) {
 	query := `
 		INSERT INTO topik (name, slug, created_at, updated_at)
@@ -31,17 +41,48 @@ func (u *TopicRepository) CreateTopic(ctx context.Context, topic *domain.CreateT
 	// 	return nil, err
 	// }
 
+	tx, err := u.Conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
 	var id uuid.UUID
-	err := u.Conn.QueryRow(ctx, query, topic.Name, utils.Slugify(topic.Name)).Scan(&id)
+	err = tx.QueryRow(ctx, query, topic.Name, utils.Slugify(topic.Name)).Scan(&id)
 	if err != nil {
 		return nil, err
 	}
 
-	return &domain.Topic{
+	created := &domain.Topic{
 		ID:   id.String(),
 		Name: topic.Name,
 		Slug: utils.Slugify(topic.Name),
-	}, nil
+	}
+	if err := enqueueTopicEvent(ctx, tx, domain.EventTopicCreated, created); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func topicEvent(eventType string, topic *domain.Topic) (domain.Event, error) {
+	return domain.NewEvent(eventType, domain.AuditEntityTopic, topic.ID, domain.TopicEventPayload{
+		ID:   topic.ID,
+		Name: topic.Name,
+		Slug: topic.Slug,
+	})
+}
+
+// enqueueTopicEvent writes an event about topic to the outbox within tx
+func enqueueTopicEvent(ctx context.Context, tx pgx.Tx, eventType string, topic *domain.Topic) error {
+	event, err := topicEvent(eventType, topic)
+	if err != nil {
+		return err
+	}
+	return enqueueEvents(ctx, tx, event)
 }
 
 func (u *TopicRepository) GetTopicList(ctx context.Context, filter *domain.TopicFilter) ([]domain.Topic, error) {
@@ -65,7 +106,7 @@ func (u *TopicRepository) GetTopicList(ctx context.Context, filter *domain.Topic
 	if len(conditions) > 0 {
 		query += strings.Join(conditions, " AND ")
 	}
-	rows, err := u.Conn.Query(ctx, query, args...)
+	rows, err := dbroute.Reader(ctx, u.Conn, u.Replica).Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -92,10 +133,6 @@ func (u *TopicRepository) GetTopicList(ctx context.Context, filter *domain.Topic
 }
 
 func (u *TopicRepository) GetTopic(ctx context.Context, id uuid.UUID) (*domain.Topic, error) {
-	// tracer := otel.Tracer("repo.Topic")
-	// ctx, span := tracer.Start(ctx, "TopicRepository.GetTopic")
-	// defer span.End()
-
 	query := `
 		SELECT
 			id,
@@ -106,9 +143,7 @@ func (u *TopicRepository) GetTopic(ctx context.Context, id uuid.UUID) (*domain.T
 		FROM topik
 		WHERE id = $1 AND deleted_at IS NULL`
 
-	// span.SetAttributes(attribute.String("query.statement", query))
-	// span.SetAttributes(attribute.String("query.parameter", id.String()))
-	row := u.Conn.QueryRow(ctx, query, id)
+	row := dbroute.Reader(ctx, u.Conn, u.Replica).QueryRow(ctx, query, id)
 
 	var topic domain.Topic
 	err := row.Scan(
@@ -119,12 +154,9 @@ func (u *TopicRepository) GetTopic(ctx context.Context, id uuid.UUID) (*domain.T
 		&topic.UpdatedAt,
 	)
 	if err != nil {
-		//span.RecordError(err)
-		//		u.Metrics.TopicRepoCalls.WithLabelValues("GetTopic", "error").Inc()
 		return nil, err
 	}
 
-	//	u.Metrics.TopicRepoCalls.WithLabelValues("GetTopic", "success").Inc()
 	return &topic, nil
 }
 
@@ -137,8 +169,14 @@ func (u *TopicRepository) UpdateTopic(ctx context.Context, id uuid.UUID, topic *
 		WHERE id = $3 AND deleted_at IS NULL
 		RETURNING id, name, slug, created_at, updated_at`
 
+	tx, err := u.Conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
 	var updatedTopic domain.Topic
-	err := u.Conn.QueryRow(ctx, query, topic.Name, utils.Slugify(topic.Name), id).Scan(
+	err = tx.QueryRow(ctx, query, topic.Name, utils.Slugify(topic.Name), id).Scan(
 		&updatedTopic.ID,
 		&updatedTopic.Name,
 		&updatedTopic.Slug,
@@ -146,12 +184,19 @@ func (u *TopicRepository) UpdateTopic(ctx context.Context, id uuid.UUID, topic *
 		&updatedTopic.UpdatedAt,
 	)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, domain.ErrUserNotFound
 		}
 		return nil, err
 	}
 
+	if err := enqueueTopicEvent(ctx, tx, domain.EventTopicUpdated, &updatedTopic); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
 	return &updatedTopic, nil
 }
 
@@ -161,7 +206,13 @@ func (u *TopicRepository) DeleteTopic(ctx context.Context, id uuid.UUID) error {
 		SET deleted_at = NOW()
 		WHERE id = $1 AND deleted_at IS NULL`
 
-	result, err := u.Conn.Exec(ctx, query, id)
+	tx, err := u.Conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -170,5 +221,290 @@ func (u *TopicRepository) DeleteTopic(ctx context.Context, id uuid.UUID) error {
 		return domain.ErrUserNotFound
 	}
 
-	return nil
+	if err := enqueueTopicEvent(ctx, tx, domain.EventTopicDeleted, &domain.Topic{ID: id.String()}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// MergeTopics moves every news of source over to target and soft deletes
+// source, news already linked to both keep a single link to target. Returns
+// the number of news moved.
+func (u *TopicRepository) MergeTopics(ctx context.Context, sourceID, targetID uuid.UUID) (int64, error) {
+	tx, err := u.Conn.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT id FROM topik
+		WHERE id = ANY($1) AND deleted_at IS NULL
+		FOR UPDATE`, []uuid.UUID{sourceID, targetID})
+	if err != nil {
+		return 0, err
+	}
+	locked, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return 0, err
+	}
+	if len(locked) != 2 {
+		return 0, domain.ErrNotFound
+	}
+
+	moved, err := tx.Exec(ctx, `
+		INSERT INTO news_topic (news_id, topic_id, created_at, updated_at)
+		SELECT DISTINCT nt.news_id, $2::uuid, NOW(), NOW()
+		FROM news_topic nt
+		WHERE nt.topic_id = $1
+			AND NOT EXISTS (
+				SELECT 1 FROM news_topic existing
+				WHERE existing.news_id = nt.news_id AND existing.topic_id = $2
+			)`, sourceID, targetID)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM news_topic WHERE topic_id = $1`, sourceID); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE topik SET deleted_at = NOW() WHERE id = $1`, sourceID); err != nil {
+		return 0, err
+	}
+
+	event, err := domain.NewEvent(domain.EventTopicMerged, domain.AuditEntityTopic, targetID.String(), domain.TopicMergedPayload{
+		SourceID:  sourceID.String(),
+		TargetID:  targetID.String(),
+		MovedNews: moved.RowsAffected(),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := enqueueEvents(ctx, tx, event); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return moved.RowsAffected(), nil
+}
+
+const bulkUpdateTopicQuery = `
+	UPDATE topik t
+	SET name = $1,
+		slug = $2,
+		updated_at = NOW()
+	FROM topik previous
+	WHERE t.id = $3 AND t.deleted_at IS NULL AND previous.id = t.id
+	RETURNING t.id, previous.name`
+
+// BulkSaveTopics creates and updates topics inside a single transaction, the
+// events of every saved topic are written to the outbox in the same
+// transaction. The returned results are aligned with items.
+//
+// When allOrNothing is set, new rows are loaded with COPY and updates are
+// pipelined through a pgx.Batch, an invalid or missing topic rolls the
+// whole transaction back. Otherwise every topic is saved in a savepoint of
+// its own, a topic failing is reported on its item and the others are kept.
+func (u *TopicRepository) BulkSaveTopics(ctx context.Context, items []domain.BulkTopicItem, allOrNothing bool) ([]domain.BulkItemResult, error) {
+	results := make([]domain.BulkItemResult, len(items))
+	// ids are uuid.Nil for the topics to create, see newBulkTopicID
+	ids := make([]uuid.UUID, len(items))
+	var valid []int
+	for i, item := range items {
+		results[i] = domain.BulkItemResult{Index: i, ID: item.ID}
+		if item.ID != "" {
+			id, err := uuid.Parse(item.ID)
+			if err != nil {
+				results[i].Error = "invalid topic ID: " + item.ID
+				continue
+			}
+			if !item.Insert {
+				ids[i] = id
+			}
+		}
+		valid = append(valid, i)
+	}
+	if allOrNothing && len(valid) < len(items) {
+		return results, domain.ErrBulkAborted
+	}
+
+	tx, err := u.Conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var events []domain.Event
+	if allOrNothing {
+		events, err = copyBulkTopics(ctx, tx, items, ids, results)
+	} else {
+		for _, i := range valid {
+			saved, err := inSavepoint(ctx, tx, &results[i], func(sp pgx.Tx) ([]domain.Event, error) {
+				return saveBulkTopic(ctx, sp, items[i], ids[i], &results[i])
+			})
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, saved...)
+		}
+	}
+	if errors.Is(err, domain.ErrBulkAborted) {
+		return results, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := enqueueEvents(ctx, tx, events...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// copyBulkTopics saves every topic with COPY and a pgx.Batch, a missing
+// topic or a violated constraint fails with domain.ErrBulkAborted. The
+// first update failing ends the batch, the updates after it are not
+// reported.
+func copyBulkTopics(ctx context.Context, tx pgx.Tx, items []domain.BulkTopicItem, ids []uuid.UUID, results []domain.BulkItemResult) ([]domain.Event, error) {
+	var topicRows [][]any
+	var updates []int
+	var events []domain.Event
+	batch := &pgx.Batch{}
+	for i, item := range items {
+		if ids[i] != uuid.Nil {
+			batch.Queue(bulkUpdateTopicQuery, item.Name, utils.Slugify(item.Name), ids[i])
+			updates = append(updates, i)
+			continue
+		}
+
+		id := newBulkTopicID(item)
+		topicRows = append(topicRows, []any{id, item.Name, utils.Slugify(item.Name)})
+		results[i].ID = id.String()
+		results[i].Action = domain.BulkActionCreated
+
+		event, err := topicEvent(domain.EventTopicCreated, &domain.Topic{
+			ID:   id.String(),
+			Name: item.Name,
+			Slug: utils.Slugify(item.Name),
+		})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if len(topicRows) > 0 {
+		_, err := tx.CopyFrom(ctx, pgx.Identifier{"topik"},
+			[]string{"id", "name", "slug"},
+			pgx.CopyFromRows(topicRows))
+		if err != nil {
+			return nil, copyBulkTopicsError(err, ids, results)
+		}
+	}
+
+	failed := false
+	if batch.Len() > 0 {
+		br := tx.SendBatch(ctx, batch)
+		for _, i := range updates {
+			var id uuid.UUID
+			var previousName string
+			err := br.QueryRow().Scan(&id, &previousName)
+			if errors.Is(err, pgx.ErrNoRows) {
+				results[i].Error = domain.ErrNotFound.Error()
+				failed = true
+				continue
+			}
+			if err != nil {
+				return nil, bulkBatchError(br, err, &results[i])
+			}
+			results[i].Action = domain.BulkActionUpdated
+			results[i].Before = domain.BulkTopicItem{ID: id.String(), Name: previousName}
+
+			event, err := topicEvent(domain.EventTopicUpdated, &domain.Topic{
+				ID:   id.String(),
+				Name: items[i].Name,
+				Slug: utils.Slugify(items[i].Name),
+			})
+			if err != nil {
+				br.Close()
+				return nil, err
+			}
+			events = append(events, event)
+		}
+		if err := br.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if failed {
+		return nil, domain.ErrBulkAborted
+	}
+	return events, nil
+}
+
+// copyBulkTopicsError reports a constraint violated by COPY on every topic
+// to create, COPY does not tell which row broke it
+func copyBulkTopicsError(err error, ids []uuid.UUID, results []domain.BulkItemResult) error {
+	message, ok := bulkItemError(err)
+	if !ok {
+		return err
+	}
+	for i, id := range ids {
+		if id == uuid.Nil {
+			results[i].Action = ""
+			results[i].Error = message
+		}
+	}
+	return domain.ErrBulkAborted
+}
+
+// newBulkTopicID returns the id of a topic to create, the id of the item
+// when it is inserted with it
+func newBulkTopicID(item domain.BulkTopicItem) uuid.UUID {
+	if id, err := uuid.Parse(item.ID); item.Insert && err == nil {
+		return id
+	}
+	return uuid.New()
+}
+
+// saveBulkTopic creates the topic, or updates it when id is set, and
+// returns its event
+func saveBulkTopic(ctx context.Context, tx pgx.Tx, item domain.BulkTopicItem, id uuid.UUID, result *domain.BulkItemResult) ([]domain.Event, error) {
+	topic := &domain.Topic{Name: item.Name, Slug: utils.Slugify(item.Name)}
+	eventType := domain.EventTopicUpdated
+	if id != uuid.Nil {
+		var previousName string
+		err := tx.QueryRow(ctx, bulkUpdateTopicQuery, topic.Name, topic.Slug, id).Scan(&id, &previousName)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Action = domain.BulkActionUpdated
+		result.Before = domain.BulkTopicItem{ID: id.String(), Name: previousName}
+	} else {
+		id = newBulkTopicID(item)
+		_, err := tx.Exec(ctx, `INSERT INTO topik (id, name, slug) VALUES ($1, $2, $3)`, id, topic.Name, topic.Slug)
+		if err != nil {
+			return nil, err
+		}
+		result.ID = id.String()
+		result.Action = domain.BulkActionCreated
+		eventType = domain.EventTopicCreated
+	}
+
+	topic.ID = id.String()
+	event, err := topicEvent(eventType, topic)
+	if err != nil {
+		return nil, err
+	}
+	return []domain.Event{event}, nil
 }
@@ -0,0 +1,186 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetTwoFactor fetches the second factor state of an active user.
+func (u *UserRepository) GetTwoFactor(ctx context.Context, id uuid.UUID) (*domain.TwoFactor, error) {
+	query := `
+		SELECT
+			id,
+			name,
+			email,
+			role,
+			status,
+			COALESCE(totp_secret, ''),
+			totp_enabled_at,
+			COALESCE(totp_last_step, 0)
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL`
+
+	var t domain.TwoFactor
+	err := u.Conn.QueryRow(ctx, query, id).Scan(
+		&t.User.ID,
+		&t.User.Name,
+		&t.User.Email,
+		&t.User.Role,
+		&t.User.Status,
+		&t.Secret,
+		&t.EnabledAt,
+		&t.LastStep,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// SetTwoFactorSecret stores the secret of a setup in progress, replacing any
+// earlier unconfirmed one. Users who already enabled two-factor
+// authentication yield ErrConflict.
+func (u *UserRepository) SetTwoFactorSecret(ctx context.Context, id uuid.UUID, secret string) error {
+	tag, err := u.Conn.Exec(ctx, `
+		UPDATE users
+		SET totp_secret = $2, totp_last_step = NULL, updated_at = NOW()
+		WHERE id = $1 AND totp_enabled_at IS NULL AND deleted_at IS NULL`, id, secret)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrConflict
+	}
+	return nil
+}
+
+// EnableTwoFactor confirms the stored secret and replaces the recovery codes
+// of the user in one transaction. step is the time step of the code that
+// confirmed the secret so it cannot be used again to log in.
+func (u *UserRepository) EnableTwoFactor(ctx context.Context, id uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	tx, err := u.Conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE users
+		SET totp_enabled_at = NOW(), totp_last_step = $2, updated_at = NOW()
+		WHERE id = $1 AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL`, id, step)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrConflict
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, id); err != nil {
+		return err
+	}
+	batch := &pgx.Batch{}
+	for _, hash := range recoveryCodeHashes {
+		batch.Queue(`INSERT INTO recovery_codes (user_id, code_hash, created_at) VALUES ($1, $2, NOW())`, id, hash)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// DisableTwoFactor forgets the secret, enabled or still pending, and the
+// recovery codes of the user in one transaction. Unknown users yield
+// ErrUserNotFound.
+func (u *UserRepository) DisableTwoFactor(ctx context.Context, id uuid.UUID) error {
+	tx, err := u.Conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE users
+		SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// UseTOTPStep records the time step of an accepted code. It reports false
+// when a code of that step or a later one was already used.
+func (u *UserRepository) UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error) {
+	tag, err := u.Conn.Exec(ctx, `
+		UPDATE users
+		SET totp_last_step = $2
+		WHERE id = $1 AND (totp_last_step IS NULL OR totp_last_step < $2)`, id, step)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// UseRecoveryCode consumes a recovery code of the user, reporting false for
+// unknown and already used codes.
+func (u *UserRepository) UseRecoveryCode(ctx context.Context, id uuid.UUID, codeHash string) (bool, error) {
+	tag, err := u.Conn.Exec(ctx, `
+		UPDATE recovery_codes
+		SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`, id, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// GetTwoFactorPolicies lists the roles that have a policy.
+func (u *UserRepository) GetTwoFactorPolicies(ctx context.Context) ([]domain.TwoFactorPolicy, error) {
+	rows, err := u.Conn.Query(ctx, `SELECT role, required, updated_at FROM two_factor_policies ORDER BY role`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := make([]domain.TwoFactorPolicy, 0)
+	for rows.Next() {
+		var p domain.TwoFactorPolicy
+		if err := rows.Scan(&p.Role, &p.Required, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// SetTwoFactorPolicy creates or replaces the policy of a role.
+func (u *UserRepository) SetTwoFactorPolicy(ctx context.Context, role string, required bool) (*domain.TwoFactorPolicy, error) {
+	query := `
+		INSERT INTO two_factor_policies (role, required, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (role) DO UPDATE SET required = EXCLUDED.required, updated_at = NOW()
+		RETURNING role, required, updated_at`
+
+	var p domain.TwoFactorPolicy
+	if err := u.Conn.QueryRow(ctx, query, role, required).Scan(&p.Role, &p.Required, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
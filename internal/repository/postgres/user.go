@@ -5,49 +5,44 @@ import (
 	"database/sql"
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
-	"github.com/edwinjordan/ZOGTest-Golang.git/internal/metrics"
-	"github.com/edwinjordan/ZOGTest-Golang.git/utils"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/attribute"
 )
 
 type UserRepository struct {
-	Conn    *pgxpool.Pool
-	Metrics *metrics.Metrics
+	Conn *pgxpool.Pool
 }
 
-func NewUserRepository(conn *pgxpool.Pool, metrics *metrics.Metrics) *UserRepository {
-	return &UserRepository{Conn: conn, Metrics: metrics}
+func NewUserRepository(conn *pgxpool.Pool) *UserRepository {
+	return &UserRepository{Conn: conn}
 }
 
-func (u *UserRepository) CreateUser(ctx context.Context, user *domain.CreateUserRequest) (*domain.User, error) {
+// CreateUser stores a new pending user with the given password hash, the
+// plain password of the request is never stored.
+func (u *UserRepository) CreateUser(ctx context.Context, user *domain.CreateUserRequest, passwordHash string) (*domain.User, error) {
 	query := `
-		INSERT INTO users (name, email, password, created_at, updated_at)
-		VALUES ($1, $2, $3, NOW(), NOW())
-		RETURNING id`
+		INSERT INTO users (name, email, password, status, verification_sent_at, created_at, updated_at)
+		VALUES ($1, $2, $3, 'pending', NOW(), NOW(), NOW())
+		RETURNING id, role, status, created_at, updated_at`
 
-	hashedPassword, err := utils.HashPassword(user.Password)
-	if err != nil {
-		return nil, err
-	}
-
-	var id uuid.UUID
-	err = u.Conn.QueryRow(ctx, query, user.Name, user.Email, hashedPassword).Scan(&id)
+	created := domain.User{Name: user.Name, Email: user.Email}
+	err := u.Conn.QueryRow(ctx, query, user.Name, user.Email, passwordHash).Scan(
+		&created.ID,
+		&created.Role,
+		&created.Status,
+		&created.CreatedAt,
+		&created.UpdatedAt,
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	return &domain.User{
-		ID:    id.String(),
-		Name:  user.Name,
-		Email: user.Email,
-	}, nil
+	return &created, nil
 }
 
 func (u *UserRepository) GetUserList(ctx context.Context, filter *domain.UserFilter) ([]domain.User, error) {
@@ -56,6 +51,9 @@ func (u *UserRepository) GetUserList(ctx context.Context, filter *domain.UserFil
 			u.id,
 			u.name,
 			u.email,
+			u.role,
+			u.status,
+			u.email_verified_at,
             u.created_at,
             u.updated_at
 		FROM users u
@@ -85,6 +83,9 @@ func (u *UserRepository) GetUserList(ctx context.Context, filter *domain.UserFil
 			&user.ID,
 			&user.Name,
 			&user.Email,
+			&user.Role,
+			&user.Status,
+			&user.EmailVerifiedAt,
 			&user.CreatedAt,
 			&user.UpdatedAt,
 		)
@@ -98,22 +99,19 @@ func (u *UserRepository) GetUserList(ctx context.Context, filter *domain.UserFil
 }
 
 func (u *UserRepository) GetUser(ctx context.Context, id uuid.UUID) (*domain.User, error) {
-	tracer := otel.Tracer("repo.user")
-	ctx, span := tracer.Start(ctx, "UserRepository.GetUser")
-	defer span.End()
-
 	query := `
 		SELECT
 			id,
 			name,
 			email,
+			role,
+			status,
+			email_verified_at,
 			created_at,
 			updated_at
 		FROM users
 		WHERE id = $1 AND deleted_at IS NULL`
 
-	span.SetAttributes(attribute.String("query.statement", query))
-	span.SetAttributes(attribute.String("query.parameter", id.String()))
 	row := u.Conn.QueryRow(ctx, query, id)
 
 	var user domain.User
@@ -121,33 +119,88 @@ func (u *UserRepository) GetUser(ctx context.Context, id uuid.UUID) (*domain.Use
 		&user.ID,
 		&user.Name,
 		&user.Email,
+		&user.Role,
+		&user.Status,
+		&user.EmailVerifiedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 	if err != nil {
-		span.RecordError(err)
-		u.Metrics.UserRepoCalls.WithLabelValues("GetUser", "error").Inc()
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrUserNotFound
+		}
 		return nil, err
 	}
 
-	u.Metrics.UserRepoCalls.WithLabelValues("GetUser", "success").Inc()
 	return &user, nil
 }
 
+// GetUserCredentials fetches a user together with its password hash and
+// two-factor state by email.
+func (u *UserRepository) GetUserCredentials(ctx context.Context, email string) (*domain.UserCredentials, error) {
+	query := `
+		SELECT
+			u.id,
+			u.name,
+			u.email,
+			u.role,
+			u.status,
+			u.email_verified_at,
+			u.password,
+			u.totp_enabled_at IS NOT NULL,
+			COALESCE(p.required, FALSE),
+			u.created_at,
+			u.updated_at
+		FROM users u
+		LEFT JOIN two_factor_policies p ON p.role = u.role
+		WHERE lower(u.email) = lower($1) AND u.deleted_at IS NULL
+		ORDER BY u.created_at
+		LIMIT 1`
+
+	var creds domain.UserCredentials
+	err := u.Conn.QueryRow(ctx, query, email).Scan(
+		&creds.User.ID,
+		&creds.User.Name,
+		&creds.User.Email,
+		&creds.User.Role,
+		&creds.User.Status,
+		&creds.User.EmailVerifiedAt,
+		&creds.PasswordHash,
+		&creds.TwoFactorEnabled,
+		&creds.TwoFactorRequired,
+		&creds.User.CreatedAt,
+		&creds.User.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return &creds, nil
+}
+
 func (u *UserRepository) UpdateUser(ctx context.Context, id uuid.UUID, user *domain.User) (*domain.User, error) {
 	query := `
 		UPDATE users
 		SET name = $1,
 			email = $2,
+			-- A new email has to be verified again
+			status = CASE WHEN lower(email) = lower($2) THEN status ELSE 'pending' END,
+			email_verified_at = CASE WHEN lower(email) = lower($2) THEN email_verified_at END,
 			updated_at = NOW()
 		WHERE id = $3 AND deleted_at IS NULL
-		RETURNING id, name, email, created_at, updated_at`
+		RETURNING id, name, email, role, status, email_verified_at, created_at, updated_at`
 
 	var updatedUser domain.User
 	err := u.Conn.QueryRow(ctx, query, user.Name, user.Email, id).Scan(
 		&updatedUser.ID,
 		&updatedUser.Name,
 		&updatedUser.Email,
+		&updatedUser.Role,
+		&updatedUser.Status,
+		&updatedUser.EmailVerifiedAt,
 		&updatedUser.CreatedAt,
 		&updatedUser.UpdatedAt,
 	)
@@ -178,3 +231,171 @@ func (u *UserRepository) DeleteUser(ctx context.Context, id uuid.UUID) error {
 
 	return nil
 }
+
+// GetPasswordHash returns the password hash of a user.
+func (u *UserRepository) GetPasswordHash(ctx context.Context, id uuid.UUID) (string, error) {
+	query := `SELECT password FROM users WHERE id = $1 AND deleted_at IS NULL`
+
+	var hash string
+	if err := u.Conn.QueryRow(ctx, query, id).Scan(&hash); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", domain.ErrUserNotFound
+		}
+		return "", err
+	}
+	return hash, nil
+}
+
+// UpdatePassword stores a new password hash and voids the pending reset
+// tokens of the user.
+func (u *UserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	tx, err := u.Conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := setPassword(ctx, tx, id.String(), passwordHash); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// RehashPassword replaces a password hash with an upgraded hash of the same
+// password. It does nothing when the password changed in the meantime.
+func (u *UserRepository) RehashPassword(ctx context.Context, id uuid.UUID, oldHash, newHash string) error {
+	_, err := u.Conn.Exec(ctx, `
+		UPDATE users
+		SET password = $3
+		WHERE id = $1 AND password = $2 AND deleted_at IS NULL`, id, oldHash, newHash)
+	return err
+}
+
+// CreatePasswordResetToken stores the hash of a single-use reset token.
+func (u *UserRepository) CreatePasswordResetToken(ctx context.Context, userID, tokenHash string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, NOW())`
+
+	_, err := u.Conn.Exec(ctx, query, userID, tokenHash, expiresAt)
+	return err
+}
+
+// ResetPassword consumes a reset token and stores the new password hash of
+// its user in one transaction, returning the user id. Unknown, used and
+// expired tokens yield ErrInvalidToken.
+func (u *UserRepository) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (string, error) {
+	tx, err := u.Conn.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var userID string
+	err = tx.QueryRow(ctx, `
+		UPDATE password_reset_tokens
+		SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id`, tokenHash).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", domain.ErrInvalidToken
+		}
+		return "", err
+	}
+
+	if err := setPassword(ctx, tx, userID, passwordHash); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return userID, nil
+}
+
+func setPassword(ctx context.Context, tx pgx.Tx, userID, passwordHash string) error {
+	tag, err := tx.Exec(ctx, `
+		UPDATE users
+		SET password = $1,
+			updated_at = NOW()
+		WHERE id = $2 AND deleted_at IS NULL`, passwordHash, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE password_reset_tokens
+		SET used_at = NOW()
+		WHERE user_id = $1 AND used_at IS NULL`, userID)
+	return err
+}
+
+// ActivateUser marks the email of a user as verified. Activating an active
+// user is a no-op returning the user.
+func (u *UserRepository) ActivateUser(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	query := `
+		UPDATE users
+		SET status = 'active',
+			email_verified_at = COALESCE(email_verified_at, NOW()),
+			updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING id, name, email, role, status, email_verified_at, created_at, updated_at`
+
+	var user domain.User
+	err := u.Conn.QueryRow(ctx, query, id).Scan(
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.Role,
+		&user.Status,
+		&user.EmailVerifiedAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ClaimVerificationResend returns the pending user of an email when its last
+// verification email is older than cooldown, and records the new send. Active,
+// unknown and recently mailed users yield ErrNotFound.
+func (u *UserRepository) ClaimVerificationResend(ctx context.Context, email string, cooldown time.Duration) (*domain.User, error) {
+	query := `
+		UPDATE users
+		SET verification_sent_at = NOW()
+		WHERE id = (
+			SELECT id FROM users
+			WHERE lower(email) = lower($1) AND deleted_at IS NULL
+			ORDER BY created_at
+			LIMIT 1
+		)
+			AND status = 'pending'
+			AND (verification_sent_at IS NULL OR verification_sent_at <= NOW() - make_interval(secs => $2))
+		RETURNING id, name, email, role, status, created_at, updated_at`
+
+	var user domain.User
+	err := u.Conn.QueryRow(ctx, query, email, cooldown.Seconds()).Scan(
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.Role,
+		&user.Status,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
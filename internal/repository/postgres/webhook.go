@@ -0,0 +1,284 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WebhookRepository struct {
+	Conn *pgxpool.Pool
+}
+
+func NewWebhookRepository(conn *pgxpool.Pool) *WebhookRepository {
+	return &WebhookRepository{Conn: conn}
+}
+
+func (w *WebhookRepository) CreateWebhook(ctx context.Context, webhook *domain.Webhook) (*domain.Webhook, error) {
+	query := `
+		INSERT INTO webhooks (url, event_types, secret, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, '')::uuid, NOW(), NOW())
+		RETURNING id, created_at, updated_at`
+
+	created := *webhook
+	err := w.Conn.QueryRow(ctx, query, webhook.URL, webhook.EventTypes, webhook.Secret, webhook.CreatedBy).Scan(
+		&created.ID,
+		&created.CreatedAt,
+		&created.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (w *WebhookRepository) GetWebhookList(ctx context.Context) ([]domain.Webhook, error) {
+	query := `
+		SELECT id, url, event_types, COALESCE(created_by::text, ''), created_at, updated_at
+		FROM webhooks
+		WHERE deleted_at IS NULL
+		ORDER BY created_at`
+
+	rows, err := w.Conn.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := make([]domain.Webhook, 0)
+	for rows.Next() {
+		var webhook domain.Webhook
+		err := rows.Scan(
+			&webhook.ID,
+			&webhook.URL,
+			&webhook.EventTypes,
+			&webhook.CreatedBy,
+			&webhook.CreatedAt,
+			&webhook.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (w *WebhookRepository) GetWebhook(ctx context.Context, id uuid.UUID) (*domain.Webhook, error) {
+	query := `
+		SELECT id, url, event_types, COALESCE(created_by::text, ''), created_at, updated_at
+		FROM webhooks
+		WHERE id = $1 AND deleted_at IS NULL`
+
+	var webhook domain.Webhook
+	err := w.Conn.QueryRow(ctx, query, id).Scan(
+		&webhook.ID,
+		&webhook.URL,
+		&webhook.EventTypes,
+		&webhook.CreatedBy,
+		&webhook.CreatedAt,
+		&webhook.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// DeleteWebhook soft deletes the webhook, its pending deliveries are no longer sent.
+func (w *WebhookRepository) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE webhooks
+		SET deleted_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL`
+
+	result, err := w.Conn.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// EnqueueWebhookDeliveries creates a pending delivery of the event for every
+// webhook subscribed to its type. An event is only queued once per webhook,
+// so relaying the same event again is harmless.
+func (w *WebhookRepository) EnqueueWebhookDeliveries(ctx context.Context, eventID, eventType string, payload []byte) (int64, error) {
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload, created_at, updated_at)
+		SELECT id, $1::uuid, $2, $3, NOW(), NOW()
+		FROM webhooks
+		WHERE deleted_at IS NULL
+			AND ($2 = ANY(event_types) OR '*' = ANY(event_types))
+		ON CONFLICT (webhook_id, event_id) DO NOTHING`
+
+	result, err := w.Conn.Exec(ctx, query, eventID, eventType, string(payload))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const webhookDeliveryColumns = `
+	d.id,
+	d.webhook_id,
+	d.event_id,
+	d.event_type,
+	d.payload,
+	d.status,
+	d.attempts,
+	d.next_attempt_at,
+	COALESCE(d.last_status_code, 0),
+	COALESCE(d.last_error, ''),
+	d.delivered_at,
+	d.created_at,
+	d.updated_at`
+
+func webhookDeliveryScanArgs(d *domain.WebhookDelivery) []any {
+	return []any{
+		&d.ID,
+		&d.WebhookID,
+		&d.EventID,
+		&d.EventType,
+		&d.Payload,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.LastStatusCode,
+		&d.LastError,
+		&d.DeliveredAt,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+	}
+}
+
+// ClaimWebhookDeliveries picks up to limit due deliveries of live webhooks and
+// leases them for the given duration, counting the attempt. Deliveries whose
+// sender dies are claimed again once the lease expires.
+func (w *WebhookRepository) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries d
+		SET attempts = d.attempts + 1,
+			next_attempt_at = NOW() + make_interval(secs => $2),
+			updated_at = NOW()
+		FROM (
+			SELECT pending.id, wh.url, wh.secret
+			FROM webhook_deliveries pending
+			JOIN webhooks wh ON wh.id = pending.webhook_id AND wh.deleted_at IS NULL
+			WHERE pending.status = 'pending' AND pending.next_attempt_at <= NOW()
+			ORDER BY pending.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF pending SKIP LOCKED
+		) due
+		WHERE d.id = due.id
+		RETURNING ` + webhookDeliveryColumns + `, due.url, due.secret`
+
+	rows, err := w.Conn.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []domain.WebhookDelivery
+	for rows.Next() {
+		var d domain.WebhookDelivery
+		if err := rows.Scan(append(webhookDeliveryScanArgs(&d), &d.URL, &d.Secret)...); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// UpdateWebhookDelivery stores the outcome of a delivery attempt.
+func (w *WebhookRepository) UpdateWebhookDelivery(ctx context.Context, d *domain.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2,
+			next_attempt_at = $3,
+			last_status_code = NULLIF($4, 0),
+			last_error = NULLIF($5, ''),
+			delivered_at = $6,
+			updated_at = NOW()
+		WHERE id = $1`
+
+	_, err := w.Conn.Exec(ctx, query, d.ID, d.Status, d.NextAttemptAt, d.LastStatusCode, d.LastError, d.DeliveredAt)
+	return err
+}
+
+// GetWebhookDeliveries lists the deliveries of a webhook, newest first, and
+// the total number of matching deliveries.
+func (w *WebhookRepository) GetWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, filter *domain.WebhookDeliveryFilter) ([]domain.WebhookDelivery, int, error) {
+	query := `
+		SELECT ` + webhookDeliveryColumns + `, COUNT(*) OVER ()
+		FROM webhook_deliveries d
+		WHERE d.webhook_id = $1`
+
+	args := []any{webhookID}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(" AND d.status = $%d", len(args))
+	}
+	args = append(args, filter.PerPage, filter.Offset())
+	query += fmt.Sprintf(" ORDER BY d.created_at DESC, d.id LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := w.Conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]domain.WebhookDelivery, 0)
+	total := 0
+	for rows.Next() {
+		var d domain.WebhookDelivery
+		if err := rows.Scan(append(webhookDeliveryScanArgs(&d), &total)...); err != nil {
+			return nil, 0, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+// RedeliverWebhookDelivery puts a delivery back in the queue with a fresh
+// attempt budget, whatever its current status.
+func (w *WebhookRepository) RedeliverWebhookDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID) (*domain.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries d
+		SET status = 'pending',
+			attempts = 0,
+			next_attempt_at = NOW(),
+			updated_at = NOW()
+		WHERE d.id = $1 AND d.webhook_id = $2
+		RETURNING ` + webhookDeliveryColumns
+
+	var d domain.WebhookDelivery
+	err := w.Conn.QueryRow(ctx, query, deliveryID, webhookID).Scan(webhookDeliveryScanArgs(&d)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &d, nil
+}
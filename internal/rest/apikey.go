@@ -0,0 +1,201 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, req *domain.CreateAPIKeyRequest) (*domain.APIKey, error)
+	GetAPIKeys(ctx context.Context) ([]domain.APIKey, error)
+	RotateAPIKey(ctx context.Context, id uuid.UUID, req *domain.RotateAPIKeyRequest) (*domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+}
+
+type APIKeyHandler struct {
+	Service APIKeyService
+}
+
+// NewAPIKeyHandler registers the API key routes. Keys are managed by signed
+// in users, callers authenticated with an API key have no role and get 403.
+func NewAPIKeyHandler(e *echo.Group, svc APIKeyService) {
+	handler := &APIKeyHandler{Service: svc}
+
+	apiKeyGroup := e.Group("/api-keys", middleware.RequireRole(domain.RoleUser, domain.RoleEditor, domain.RoleAdmin))
+	apiKeyGroup.GET("", handler.GetAPIKeys)
+	apiKeyGroup.POST("", handler.CreateAPIKey)
+	apiKeyGroup.POST("/:id/rotate", handler.RotateAPIKey)
+	apiKeyGroup.DELETE("/:id", handler.RevokeAPIKey)
+}
+
+// apiKeyError answers the errors shared by the API key endpoints
+func apiKeyError(c echo.Context, err error, operation string) error {
+	switch {
+	case errors.Is(err, domain.ErrBadParamInput):
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: err.Error(),
+		})
+	case errors.Is(err, domain.ErrForbidden):
+		return c.JSON(http.StatusForbidden, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusForbidden,
+			Status:  "error",
+			Message: "API keys can only be managed by users",
+		})
+	case errors.Is(err, domain.ErrNotFound):
+		return c.JSON(http.StatusNotFound, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusNotFound,
+			Status:  "error",
+			Message: "API key not found",
+		})
+	}
+	logging.LogError(c.Request().Context(), err, operation)
+	return c.JSON(http.StatusInternalServerError, domain.ResponseSingleData[domain.Empty]{
+		Code:    http.StatusInternalServerError,
+		Status:  "error",
+		Message: "API key operation failed: " + err.Error(),
+	})
+}
+
+// CreateAPIKey godoc
+// @Summary Mint an API key
+// @Description the plain key is only returned once, store it right away
+// @Tags api-keys
+// @Accept  json
+// @Produce  json
+// @Param   key  body  domain.CreateAPIKeyRequest  true  "Name, scopes and expiry"
+// @Success 201 {object} domain.ResponseSingleData[domain.APIKey]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 401 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 403 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security BasicAuth
+// @Router /api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c echo.Context) error {
+	var req domain.CreateAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request payload",
+		})
+	}
+
+	key, err := h.Service.CreateAPIKey(c.Request().Context(), &req)
+	if err != nil {
+		return apiKeyError(c, err, "create_api_key")
+	}
+
+	return c.JSON(http.StatusCreated, domain.ResponseSingleData[domain.APIKey]{
+		Data:    *key,
+		Code:    http.StatusCreated,
+		Status:  "success",
+		Message: "API key successfully created",
+	})
+}
+
+// GetAPIKeys godoc
+// @Summary List API keys
+// @Description keys of the caller with their usage, admins see every key
+// @Tags api-keys
+// @Produce  json
+// @Success 200 {object} domain.ResponseMultipleData[domain.APIKey]
+// @Failure 401 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 403 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security BasicAuth
+// @Router /api-keys [get]
+func (h *APIKeyHandler) GetAPIKeys(c echo.Context) error {
+	keys, err := h.Service.GetAPIKeys(c.Request().Context())
+	if err != nil {
+		return apiKeyError(c, err, "get_api_keys")
+	}
+
+	return c.JSON(http.StatusOK, domain.ResponseMultipleData[domain.APIKey]{
+		Data:    keys,
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Successfully retrieve API keys",
+	})
+}
+
+// RotateAPIKey godoc
+// @Summary Rotate an API key
+// @Description mints a replacement key, the old one keeps working during the overlap window
+// @Tags api-keys
+// @Accept  json
+// @Produce  json
+// @Param   id      path  string                      true   "API key ID"
+// @Param   rotate  body  domain.RotateAPIKeyRequest  false  "Overlap window"
+// @Success 201 {object} domain.ResponseSingleData[domain.APIKey]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 404 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security BasicAuth
+// @Router /api-keys/{id}/rotate [post]
+func (h *APIKeyHandler) RotateAPIKey(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid API key ID format",
+		})
+	}
+
+	var req domain.RotateAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request payload",
+		})
+	}
+
+	key, err := h.Service.RotateAPIKey(c.Request().Context(), id, &req)
+	if err != nil {
+		return apiKeyError(c, err, "rotate_api_key")
+	}
+
+	return c.JSON(http.StatusCreated, domain.ResponseSingleData[domain.APIKey]{
+		Data:    *key,
+		Code:    http.StatusCreated,
+		Status:  "success",
+		Message: "API key successfully rotated",
+	})
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Tags api-keys
+// @Param   id  path  string  true  "API key ID"
+// @Success 204
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 404 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security BasicAuth
+// @Router /api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid API key ID format",
+		})
+	}
+
+	if err := h.Service.RevokeAPIKey(c.Request().Context(), id); err != nil {
+		return apiKeyError(c, err, "revoke_api_key")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,91 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+type AuditService interface {
+	GetAuditEvents(ctx context.Context, filter *domain.AuditFilter) ([]domain.AuditEvent, int, error)
+}
+
+type AuditHandler struct {
+	Service AuditService
+}
+
+// NewAuditHandler registers the audit log routes, they are restricted to admins.
+func NewAuditHandler(e *echo.Group, svc AuditService) {
+	handler := &AuditHandler{Service: svc}
+
+	auditGroup := e.Group("/audit", middleware.RequireRole(domain.RoleAdmin))
+	auditGroup.GET("", handler.GetAuditEvents)
+}
+
+// GetAuditEvents godoc
+// @Summary List audit events
+// @Description List the recorded changes, newest first. Admin only.
+// @Tags audit
+// @Produce  json
+// @Param entity query string false "Entity type (news, topic, user)"
+// @Param id query string false "Entity ID"
+// @Param actor query string false "Actor user ID"
+// @Param client query string false "Actor partner client ID"
+// @Param action query string false "Action (create, update, delete)"
+// @Param page query int false "Page number, starting at 1"
+// @Param per_page query int false "Events per page, at most 200"
+// @Success 200 {object} domain.ResponsePaginatedData[domain.AuditEvent]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 401 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 403 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security BasicAuth
+// @Router /audit [get]
+func (h *AuditHandler) GetAuditEvents(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	filter := new(domain.AuditFilter)
+	if err := c.Bind(filter); err != nil {
+		logging.LogWarn(ctx, "Failed to bind audit filter", slog.String("error", err.Error()))
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid audit filter",
+		})
+	}
+
+	events, total, err := h.Service.GetAuditEvents(ctx, filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrBadParamInput) {
+			return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+				Code:    http.StatusBadRequest,
+				Status:  "error",
+				Message: err.Error(),
+			})
+		}
+		logging.LogError(ctx, err, "get_audit_events")
+		return c.JSON(http.StatusInternalServerError, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "Failed to list audit events: " + err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, domain.ResponsePaginatedData[domain.AuditEvent]{
+		Data: events,
+		Meta: domain.Pagination{
+			Page:    filter.Page,
+			PerPage: filter.PerPage,
+			Total:   total,
+		},
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Successfully retrieve audit events",
+	})
+}
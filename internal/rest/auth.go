@@ -0,0 +1,377 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type AuthService interface {
+	ChangePassword(ctx context.Context, req *domain.ChangePasswordRequest) error
+	ForgotPassword(ctx context.Context, req *domain.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req *domain.ResetPasswordRequest) error
+	VerifyEmail(ctx context.Context, token string) (*domain.User, error)
+	ResendVerification(ctx context.Context, req *domain.ResendVerificationRequest) error
+	UnlockUser(ctx context.Context, id uuid.UUID, req *domain.UnlockUserRequest) error
+	Login(ctx context.Context, req *domain.LoginRequest) (*domain.LoginResult, error)
+	LoginTwoFactor(ctx context.Context, req *domain.TwoFactorLoginRequest) (*domain.LoginResult, error)
+}
+
+type AuthHandler struct {
+	Service AuthService
+}
+
+// NewAuthHandler registers the account routes. Changing a password needs a
+// signed in user and unlocking accounts an admin, the other routes serve
+// users who cannot sign in.
+func NewAuthHandler(e *echo.Group, svc AuthService) {
+	handler := &AuthHandler{Service: svc}
+
+	e.POST("/users/me/password", handler.ChangePassword, middleware.RequireRole(domain.RoleUser, domain.RoleEditor, domain.RoleAdmin))
+	e.POST("/users/:id/unlock", handler.UnlockUser, middleware.RequireRole(domain.RoleAdmin))
+
+	authGroup := e.Group("/auth")
+	authGroup.POST("/login", handler.Login)
+	authGroup.POST("/login/2fa", handler.LoginTwoFactor)
+	authGroup.POST("/password/forgot", handler.ForgotPassword)
+	authGroup.POST("/password/reset", handler.ResetPassword)
+	authGroup.GET("/verify", handler.VerifyEmail)
+	authGroup.POST("/verify/resend", handler.ResendVerification)
+}
+
+// authError answers the errors shared by the account endpoints
+func authError(c echo.Context, err error, operation string) error {
+	switch {
+	case errors.Is(err, domain.ErrBadParamInput):
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: err.Error(),
+		})
+	case errors.Is(err, domain.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusNotFound,
+			Status:  "error",
+			Message: "User not found",
+		})
+	case errors.Is(err, domain.ErrInvalidToken):
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid or expired token",
+		})
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Current password is incorrect",
+		})
+	case errors.Is(err, domain.ErrForbidden):
+		return c.JSON(http.StatusForbidden, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusForbidden,
+			Status:  "error",
+			Message: "Only users can manage their password",
+		})
+	}
+	logging.LogError(c.Request().Context(), err, operation)
+	return c.JSON(http.StatusInternalServerError, domain.ResponseSingleData[domain.Empty]{
+		Code:    http.StatusInternalServerError,
+		Status:  "error",
+		Message: "Account operation failed",
+	})
+}
+
+// ChangePassword godoc
+// @Summary Change the password of the caller
+// @Tags auth
+// @Accept  json
+// @Param   password  body  domain.ChangePasswordRequest  true  "Current and new password"
+// @Success 204
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 401 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security BasicAuth
+// @Router /users/me/password [post]
+func (h *AuthHandler) ChangePassword(c echo.Context) error {
+	var req domain.ChangePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request payload",
+		})
+	}
+
+	if err := h.Service.ChangePassword(c.Request().Context(), &req); err != nil {
+		return authError(c, err, "change_password")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset email
+// @Description always answers 202 so registered emails cannot be discovered
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param   email  body  domain.ForgotPasswordRequest  true  "Account email"
+// @Success 202 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Router /auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(c echo.Context) error {
+	var req domain.ForgotPasswordRequest
+	if err := c.Bind(&req); err != nil || req.Email == "" {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request payload",
+		})
+	}
+
+	if err := h.Service.ForgotPassword(c.Request().Context(), &req); err != nil {
+		return authError(c, err, "forgot_password")
+	}
+	return c.JSON(http.StatusAccepted, domain.ResponseSingleData[domain.Empty]{
+		Code:    http.StatusAccepted,
+		Status:  "success",
+		Message: "If the email is registered, a reset link is on its way",
+	})
+}
+
+// ResetPassword godoc
+// @Summary Reset a password with an emailed token
+// @Tags auth
+// @Accept  json
+// @Param   reset  body  domain.ResetPasswordRequest  true  "Token and new password"
+// @Success 204
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Router /auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c echo.Context) error {
+	var req domain.ResetPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request payload",
+		})
+	}
+
+	if err := h.Service.ResetPassword(c.Request().Context(), &req); err != nil {
+		return authError(c, err, "reset_password")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// VerifyEmail godoc
+// @Summary Verify the email of a new user
+// @Description activates the account the emailed verification link was issued for
+// @Tags auth
+// @Produce  json
+// @Param   token  query  string  true  "Verification token"
+// @Success 200 {object} domain.ResponseSingleData[domain.User]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Router /auth/verify [get]
+func (h *AuthHandler) VerifyEmail(c echo.Context) error {
+	user, err := h.Service.VerifyEmail(c.Request().Context(), c.QueryParam("token"))
+	if err != nil {
+		return authError(c, err, "verify_email")
+	}
+
+	return c.JSON(http.StatusOK, domain.ResponseSingleData[domain.User]{
+		Data:    *user,
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Email successfully verified",
+	})
+}
+
+// ResendVerification godoc
+// @Summary Send the verification email again
+// @Description always answers 202 so registered emails cannot be discovered, at most one email is sent per cooldown
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param   email  body  domain.ResendVerificationRequest  true  "Account email"
+// @Success 202 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Router /auth/verify/resend [post]
+func (h *AuthHandler) ResendVerification(c echo.Context) error {
+	var req domain.ResendVerificationRequest
+	if err := c.Bind(&req); err != nil || req.Email == "" {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request payload",
+		})
+	}
+
+	if err := h.Service.ResendVerification(c.Request().Context(), &req); err != nil {
+		return authError(c, err, "resend_verification")
+	}
+	return c.JSON(http.StatusAccepted, domain.ResponseSingleData[domain.Empty]{
+		Code:    http.StatusAccepted,
+		Status:  "success",
+		Message: "If the account is pending verification, a new link is on its way",
+	})
+}
+
+// UnlockUser godoc
+// @Summary Lift the login lockout of a user
+// @Description clears the failed logins of the account, and of a client IP when given
+// @Tags auth
+// @Accept  json
+// @Param   id      path  string                    true   "User ID"
+// @Param   unlock  body  domain.UnlockUserRequest  false  "Client IP to unlock as well"
+// @Success 204
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 403 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 404 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security BasicAuth
+// @Router /users/{id}/unlock [post]
+func (h *AuthHandler) UnlockUser(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid user ID format",
+		})
+	}
+
+	var req domain.UnlockUserRequest
+	if c.Request().ContentLength != 0 {
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+				Code:    http.StatusBadRequest,
+				Status:  "error",
+				Message: "Invalid request payload",
+			})
+		}
+	}
+
+	if err := h.Service.UnlockUser(c.Request().Context(), id, &req); err != nil {
+		return authError(c, err, "unlock_user")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// loginError answers the errors of the login endpoints, failed logins are
+// 401 unlike the other account endpoints
+func loginError(c echo.Context, err error, operation string) error {
+	var locked *domain.AccountLockedError
+	switch {
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		return c.JSON(http.StatusUnauthorized, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "Invalid credentials",
+		})
+	case errors.Is(err, domain.ErrInvalidToken):
+		return c.JSON(http.StatusUnauthorized, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "Invalid or expired challenge, log in again",
+		})
+	case errors.Is(err, domain.ErrUnverifiedAccount):
+		return c.JSON(http.StatusForbidden, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusForbidden,
+			Status:  "error",
+			Message: "Email address not verified",
+		})
+	case errors.As(err, &locked):
+		c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+		return c.JSON(http.StatusTooManyRequests, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusTooManyRequests,
+			Status:  "error",
+			Message: "Too many failed logins, try again later",
+		})
+	}
+	return authError(c, err, operation)
+}
+
+// Login godoc
+// @Summary Log in with an email and password
+// @Description returns a session token, or a challenge to answer on /auth/login/2fa for users with two-factor authentication
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param   credentials  body  domain.LoginRequest  true  "Email and password"
+// @Success 200 {object} domain.ResponseSingleData[domain.LoginResult]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 401 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 403 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 429 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Router /auth/login [post]
+func (h *AuthHandler) Login(c echo.Context) error {
+	var req domain.LoginRequest
+	if err := c.Bind(&req); err != nil || req.Email == "" || req.Password == "" {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request payload",
+		})
+	}
+
+	result, err := h.Service.Login(c.Request().Context(), &req)
+	if err != nil {
+		return loginError(c, err, "login")
+	}
+
+	message := "Logged in"
+	if result.Challenge != nil {
+		message = "Enter the code of your authenticator app"
+	}
+	return c.JSON(http.StatusOK, domain.ResponseSingleData[domain.LoginResult]{
+		Data:    *result,
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: message,
+	})
+}
+
+// LoginTwoFactor godoc
+// @Summary Complete a login with a second factor
+// @Description exchanges the challenge of /auth/login and a TOTP or recovery code for a session token
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param   challenge  body  domain.TwoFactorLoginRequest  true  "Challenge token and code"
+// @Success 200 {object} domain.ResponseSingleData[domain.LoginResult]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 401 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 429 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Router /auth/login/2fa [post]
+func (h *AuthHandler) LoginTwoFactor(c echo.Context) error {
+	var req domain.TwoFactorLoginRequest
+	if err := c.Bind(&req); err != nil || req.ChallengeToken == "" || req.Code == "" {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request payload",
+		})
+	}
+
+	result, err := h.Service.LoginTwoFactor(c.Request().Context(), &req)
+	if err != nil {
+		return loginError(c, err, "login_two_factor")
+	}
+	return c.JSON(http.StatusOK, domain.ResponseSingleData[domain.LoginResult]{
+		Data:    *result,
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Logged in",
+	})
+}
@@ -0,0 +1,55 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/labstack/echo/v4"
+)
+
+// bulkResponse writes the outcome of a bulk operation. A fully successful run
+// answers 200, a partially applied one 207 and an aborted all-or-nothing run 422.
+func bulkResponse(c echo.Context, operation string, result *domain.BulkResult, err error) error {
+	ctx := c.Request().Context()
+
+	switch {
+	case errors.Is(err, domain.ErrBadParamInput):
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: err.Error(),
+		})
+	case errors.Is(err, domain.ErrBulkAborted):
+		return c.JSON(http.StatusUnprocessableEntity, domain.ResponseSingleData[domain.BulkResult]{
+			Data:    *result,
+			Code:    http.StatusUnprocessableEntity,
+			Status:  "error",
+			Message: "Bulk operation rolled back, no changes were applied",
+		})
+	case err != nil:
+		logging.LogError(ctx, err, operation)
+		return c.JSON(http.StatusInternalServerError, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "Bulk operation failed: " + err.Error(),
+		})
+	}
+
+	if result.Failed > 0 {
+		return c.JSON(http.StatusMultiStatus, domain.ResponseSingleData[domain.BulkResult]{
+			Data:    *result,
+			Code:    http.StatusMultiStatus,
+			Status:  "partial",
+			Message: "Bulk operation partially applied",
+		})
+	}
+
+	return c.JSON(http.StatusOK, domain.ResponseSingleData[domain.BulkResult]{
+		Data:    *result,
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Bulk operation successfully applied",
+	})
+}
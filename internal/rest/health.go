@@ -0,0 +1,69 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/health"
+	"github.com/labstack/echo/v4"
+)
+
+type HealthService interface {
+	Live(ctx context.Context) *health.Report
+	Ready(ctx context.Context) *health.Report
+}
+
+type HealthHandler struct {
+	Service HealthService
+}
+
+// NewHealthHandler registers the probes, outside of /api/v1 and without
+// authentication
+func NewHealthHandler(e *echo.Group, svc HealthService) {
+	handler := &HealthHandler{Service: svc}
+
+	e.GET("/", handler.Ready)
+	e.GET("/healthz", handler.Live)
+	e.GET("/readyz", handler.Ready)
+}
+
+func healthResponse(c echo.Context, report *health.Report) error {
+	if !report.Ready() {
+		return c.JSON(http.StatusServiceUnavailable, domain.ResponseSingleData[health.Report]{
+			Data:    *report,
+			Code:    http.StatusServiceUnavailable,
+			Status:  "error",
+			Message: "Service is not ready",
+		})
+	}
+	return c.JSON(http.StatusOK, domain.ResponseSingleData[health.Report]{
+		Data:    *report,
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Service is " + report.Status,
+	})
+}
+
+// Live godoc
+// @Summary Liveness probe
+// @Description succeeds while the process runs, without checking dependencies
+// @Tags health
+// @Produce  json
+// @Success 200 {object} domain.ResponseSingleData[health.Report]
+// @Router /healthz [get]
+func (h *HealthHandler) Live(c echo.Context) error {
+	return healthResponse(c, h.Service.Live(c.Request().Context()))
+}
+
+// Ready godoc
+// @Summary Readiness probe
+// @Description checks the dependencies with their latency, fails when a required one is down or the server is shutting down
+// @Tags health
+// @Produce  json
+// @Success 200 {object} domain.ResponseSingleData[health.Report]
+// @Failure 503 {object} domain.ResponseSingleData[health.Report]
+// @Router /readyz [get]
+func (h *HealthHandler) Ready(c echo.Context) error {
+	return healthResponse(c, h.Service.Ready(c.Request().Context()))
+}
@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/labstack/echo/v4"
+)
+
+type LogLevelService interface {
+	Get() domain.LogLevels
+	Set(component, level string) error
+}
+
+type LogLevelHandler struct {
+	Service LogLevelService
+}
+
+// NewLogLevelHandler registers the routes changing the log levels while the
+// application runs, they are restricted to admins. The levels go back to
+// the configured ones on restart or SIGHUP.
+func NewLogLevelHandler(e *echo.Group, svc LogLevelService) {
+	handler := &LogLevelHandler{Service: svc}
+
+	adminGroup := e.Group("/admin/log-level", middleware.RequireRole(domain.RoleAdmin))
+	adminGroup.GET("", handler.GetLogLevels)
+	adminGroup.PUT("", handler.SetLogLevel)
+}
+
+// GetLogLevels godoc
+// @Summary Get the log levels
+// @Description The default level and the components with a level of their own. Admin only.
+// @Tags admin
+// @Produce  json
+// @Success 200 {object} domain.ResponseSingleData[domain.LogLevels]
+// @Failure 401 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 403 {object} domain.ResponseSingleData[domain.Empty]
+// @Security BasicAuth
+// @Router /admin/log-level [get]
+func (h *LogLevelHandler) GetLogLevels(c echo.Context) error {
+	return c.JSON(http.StatusOK, domain.ResponseSingleData[domain.LogLevels]{
+		Data:    h.Service.Get(),
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Successfully retrieved log levels",
+	})
+}
+
+// SetLogLevel godoc
+// @Summary Change a log level
+// @Description Sets the default level, or the level of the rest, service or repository component. An empty level makes the component follow the default level again. Admin only.
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Param   level  body  domain.UpdateLogLevelRequest  true  "Component and level"
+// @Success 200 {object} domain.ResponseSingleData[domain.LogLevels]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 401 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 403 {object} domain.ResponseSingleData[domain.Empty]
+// @Security BasicAuth
+// @Router /admin/log-level [put]
+func (h *LogLevelHandler) SetLogLevel(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req domain.UpdateLogLevelRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request payload",
+		})
+	}
+
+	if err := h.Service.Set(req.Component, req.Level); err != nil {
+		if errors.Is(err, domain.ErrBadParamInput) {
+			return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+				Code:    http.StatusBadRequest,
+				Status:  "error",
+				Message: err.Error(),
+			})
+		}
+		logging.LogError(ctx, err, "set_log_level")
+		return c.JSON(http.StatusInternalServerError, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "Failed to set the log level",
+		})
+	}
+
+	logging.LogSecurityEvent(ctx, "log_level_changed",
+		slog.String("component", req.Component),
+		slog.String("level", req.Level),
+	)
+	return c.JSON(http.StatusOK, domain.ResponseSingleData[domain.LogLevels]{
+		Data:    h.Service.Get(),
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Log level updated",
+	})
+}
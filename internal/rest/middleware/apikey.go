@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/labstack/echo/v4"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuthenticator verifies a plain API key
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*auth.Principal, error)
+}
+
+// APIKeyAuthMiddleware attaches the caller of requests carrying an API key,
+// either in X-API-Key or as a bearer token starting with the key prefix.
+// Requests without a key pass through anonymously, invalid keys are rejected
+// with 401.
+func APIKeyAuthMiddleware(authenticator APIKeyAuthenticator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			key := req.Header.Get(APIKeyHeader)
+			if key == "" {
+				token, ok := strings.CutPrefix(req.Header.Get(echo.HeaderAuthorization), "Bearer ")
+				if ok && strings.HasPrefix(token, domain.APIKeyPrefix) {
+					key = token
+				}
+			}
+			if key == "" {
+				return next(c)
+			}
+
+			principal, err := authenticator.AuthenticateAPIKey(req.Context(), key)
+			if err != nil {
+				if errors.Is(err, domain.ErrInvalidCredentials) {
+					c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="api"`)
+					return echo.NewHTTPError(http.StatusUnauthorized, "Invalid API key")
+				}
+				return err
+			}
+
+			c.SetRequest(req.WithContext(auth.WithPrincipal(req.Context(), principal)))
+			return next(c)
+		}
+	}
+}
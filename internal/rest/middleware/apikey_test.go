@@ -0,0 +1,66 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+const readKey = domain.APIKeyPrefix + "read"
+
+type keyAuthenticator struct{}
+
+func (keyAuthenticator) AuthenticateAPIKey(_ context.Context, key string) (*auth.Principal, error) {
+	if key != readKey {
+		return nil, domain.ErrInvalidCredentials
+	}
+	return &auth.Principal{KeyID: "k-1", Scopes: []string{domain.ScopeNewsRead}, Method: auth.MethodAPIKey}, nil
+}
+
+func newKeyServer() *echo.Echo {
+	e := echo.New()
+	e.Use(middleware.APIKeyAuthMiddleware(keyAuthenticator{}))
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusNoContent) }
+	e.GET("/news", ok, middleware.RequireScope(domain.ScopeNewsRead))
+	e.POST("/news", ok, middleware.RequireScope(domain.ScopeNewsWrite))
+	return e
+}
+
+func TestAPIKeyAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		header string
+		value  string
+		want   int
+	}{
+		{"Key header with the scope", http.MethodGet, middleware.APIKeyHeader, readKey, http.StatusNoContent},
+		{"Bearer token with the scope", http.MethodGet, echo.HeaderAuthorization, "Bearer " + readKey, http.StatusNoContent},
+		{"Key without the scope", http.MethodPost, middleware.APIKeyHeader, readKey, http.StatusForbidden},
+		{"Unknown key", http.MethodGet, middleware.APIKeyHeader, domain.APIKeyPrefix + "nope", http.StatusUnauthorized},
+		{"Bearer tokens that are not keys are ignored", http.MethodPost, echo.HeaderAuthorization, "Bearer other-token", http.StatusUnauthorized},
+		{"Anonymous reads pass", http.MethodGet, "", "", http.StatusNoContent},
+		{"Anonymous writes are rejected", http.MethodPost, "", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/news", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+
+			newKeyServer().ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/labstack/echo/v4"
+)
+
+// BasicAuthenticator verifies an email and password pair
+type BasicAuthenticator interface {
+	Authenticate(ctx context.Context, email, password string) (*auth.Principal, error)
+}
+
+// BasicAuthMiddleware attaches the caller of requests carrying HTTP Basic
+// credentials to the request context. Requests without credentials pass
+// through anonymously, invalid credentials and users with two-factor
+// authentication are rejected with 401 and logins locked out after too many
+// failures with 429.
+func BasicAuthMiddleware(authenticator BasicAuthenticator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			email, password, ok := req.BasicAuth()
+			if !ok {
+				return next(c)
+			}
+
+			principal, err := authenticator.Authenticate(req.Context(), email, password)
+			if err != nil {
+				if errors.Is(err, domain.ErrInvalidCredentials) {
+					c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="api"`)
+					return echo.NewHTTPError(http.StatusUnauthorized, "Invalid credentials")
+				}
+				var locked *domain.AccountLockedError
+				if errors.As(err, &locked) {
+					retryAfter := int(math.Ceil(locked.RetryAfter.Seconds()))
+					c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(retryAfter))
+					return echo.NewHTTPError(http.StatusTooManyRequests, "Too many failed logins, try again later")
+				}
+				if errors.Is(err, domain.ErrTwoFactorRequired) {
+					return echo.NewHTTPError(http.StatusUnauthorized, "Two-factor authentication required, sign in through /auth/login")
+				}
+				if errors.Is(err, domain.ErrUnverifiedAccount) {
+					return echo.NewHTTPError(http.StatusForbidden, "Email address not verified")
+				}
+				return err
+			}
+
+			c.SetRequest(req.WithContext(auth.WithPrincipal(req.Context(), principal)))
+			return next(c)
+		}
+	}
+}
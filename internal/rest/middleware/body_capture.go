@@ -0,0 +1,221 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/redact"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// bodyCaptureKey holds the bodies captured by BodyCaptureMiddleware in the
+// Echo context, SlogLoggerMiddleware logs them
+const bodyCaptureKey = "body_capture"
+
+// BodyCaptureRoute captures the bodies of a share of the requests matching
+// Method and Path. Path is an Echo route like /api/v1/partner/news, or a
+// prefix ending with *.
+type BodyCaptureRoute struct {
+	// Method is any method when empty
+	Method string
+	Path   string
+	// SampleRate is the share of the requests captured, between 0 and 1
+	SampleRate float64
+}
+
+type BodyCaptureConfig struct {
+	// Routes are matched in order, the first match applies. Nothing is
+	// captured when there are none.
+	Routes []BodyCaptureRoute
+	// MaxBytes bounds the captured part of each body
+	MaxBytes int
+	// Rules redact the captured bodies, redact.Default() when nil
+	Rules *redact.Rules
+}
+
+var DefaultBodyCaptureConfig = BodyCaptureConfig{
+	MaxBytes: 4 << 10,
+}
+
+// sampled tells whether the bodies of the request are captured
+func (config *BodyCaptureConfig) sampled(c echo.Context) bool {
+	for _, route := range config.Routes {
+		if matchRoute(route.Method, route.Path, c.Request().Method, c.Path()) {
+			return route.SampleRate > 0 && rand.Float64() < route.SampleRate
+		}
+	}
+	return false
+}
+
+// CapturedBody is the start of a request or a response body
+type CapturedBody struct {
+	ContentType string
+	// Size counts the bytes read or written, captured or not
+	Size int64
+	// Content is redacted, and empty for binary bodies
+	Content   string
+	Truncated bool
+}
+
+func (b CapturedBody) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("content_type", b.ContentType),
+		slog.Int64("size", b.Size),
+		slog.String("content", b.Content),
+		slog.Bool("truncated", b.Truncated),
+	)
+}
+
+// BodyCapture holds the bodies of a request captured by
+// BodyCaptureMiddleware
+type BodyCapture struct {
+	Request  CapturedBody
+	Response CapturedBody
+}
+
+// GetBodyCapture returns the bodies of the request captured by
+// BodyCaptureMiddleware, or nil when they were not
+func GetBodyCapture(c echo.Context) *BodyCapture {
+	capture, _ := c.Get(bodyCaptureKey).(*BodyCapture)
+	return capture
+}
+
+// BodyCaptureMiddleware captures the bodies of the requests of some routes
+// to debug integrations, SlogLoggerMiddleware logs them and they are added
+// to the span of the request as http.request.body and http.response.body
+// events.
+//
+// The bodies go through as the handler reads and writes them, only their
+// first MaxBytes are kept: uploads and streaming responses are not held
+// back. It comes after CompressionMiddleware, which compresses what it
+// captures.
+func BodyCaptureMiddleware(config BodyCaptureConfig) echo.MiddlewareFunc {
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = DefaultBodyCaptureConfig.MaxBytes
+	}
+	if config.Rules == nil {
+		config.Rules = redact.Default()
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !config.sampled(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			request := &limitedBuffer{max: config.MaxBytes}
+			if req.Body != nil && req.Body != http.NoBody {
+				req.Body = &captureReader{ReadCloser: req.Body, body: request}
+			}
+			res := c.Response()
+			writer := res.Writer
+			response := &limitedBuffer{max: config.MaxBytes}
+			res.Writer = &captureWriter{ResponseWriter: writer, body: response}
+
+			err := next(c)
+			// The error response is written here to be captured, Echo does
+			// not write it again
+			if err != nil {
+				c.Error(err)
+			}
+			res.Writer = writer
+
+			capture := &BodyCapture{
+				Request:  request.captured(config.Rules, req.Header.Get(echo.HeaderContentType)),
+				Response: response.captured(config.Rules, res.Header().Get(echo.HeaderContentType)),
+			}
+			c.Set(bodyCaptureKey, capture)
+			if span := trace.SpanFromContext(req.Context()); span.IsRecording() {
+				span.AddEvent("http.request.body", trace.WithAttributes(capture.Request.attributes()...))
+				span.AddEvent("http.response.body", trace.WithAttributes(capture.Response.attributes()...))
+			}
+			return err
+		}
+	}
+}
+
+func (b CapturedBody) attributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("content_type", b.ContentType),
+		attribute.Int64("size", b.Size),
+		attribute.String("content", b.Content),
+		attribute.Bool("truncated", b.Truncated),
+	}
+}
+
+// limitedBuffer keeps the first max bytes written to it
+type limitedBuffer struct {
+	max       int
+	buf       bytes.Buffer
+	size      int64
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) {
+	b.size += int64(len(p))
+	if room := b.max - b.buf.Len(); len(p) > room {
+		p = p[:max(room, 0)]
+		b.truncated = true
+	}
+	b.buf.Write(p)
+}
+
+func (b *limitedBuffer) captured(rules *redact.Rules, contentType string) CapturedBody {
+	body := CapturedBody{ContentType: contentType, Size: b.size, Truncated: b.truncated}
+	if textual(contentType) {
+		body.Content = rules.Body(contentType, b.buf.Bytes())
+	}
+	return body
+}
+
+// textual tells whether bodies of contentType are worth logging
+func textual(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") ||
+		mediaType == "application/xml" || strings.HasSuffix(mediaType, "+xml") ||
+		mediaType == "application/x-www-form-urlencoded"
+}
+
+// captureReader keeps what the handler reads of the request body
+type captureReader struct {
+	io.ReadCloser
+	body *limitedBuffer
+}
+
+func (r *captureReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.body.Write(p[:n])
+	return n, err
+}
+
+// captureWriter keeps what the handler writes of the response body
+type captureWriter struct {
+	http.ResponseWriter
+	body *limitedBuffer
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.body.Write(b[:n])
+	return n, err
+}
+
+// Flush lets streaming responses through
+func (w *captureWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,223 @@
+package middleware_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newBodyCaptureServer returns a server capturing bodies with config, and
+// the buffer the default logger writes to for the test
+func newBodyCaptureServer(t *testing.T, config middleware.BodyCaptureConfig) (*echo.Echo, *bytes.Buffer) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	e := echo.New()
+	e.Use(middleware.SlogLoggerMiddleware())
+	e.Use(middleware.CompressionMiddleware())
+	e.Use(middleware.BodyCaptureMiddleware(config))
+	return e, &buf
+}
+
+func logRecord(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	return record
+}
+
+func TestBodyCaptureMiddleware(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	e, buf := newBodyCaptureServer(t, middleware.BodyCaptureConfig{
+		Routes: []middleware.BodyCaptureRoute{{Method: http.MethodPost, Path: "/partner/*", SampleRate: 1}},
+	})
+	e.Pre(middleware.AttachTraceProvider(provider))
+	e.POST("/partner/news", func(c echo.Context) error {
+		var body map[string]any
+		if err := c.Bind(&body); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusCreated, map[string]any{"id": "news-1", "author_email": "alice@example.com"})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/partner/news",
+		strings.NewReader(`{"title":"Banjir","password":"hunter2"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.JSONEq(t, `{"id":"news-1","author_email":"alice@example.com"}`, rec.Body.String(), "the client gets the body")
+	record := logRecord(t, buf)
+	assert.Equal(t, map[string]any{
+		"content_type": echo.MIMEApplicationJSON,
+		"size":         39.0,
+		"content":      `{"password":"REDACTED","title":"Banjir"}`,
+		"truncated":    false,
+	}, record["request_body"])
+	assert.Equal(t, `{"author_email":"REDACTED","id":"news-1"}`, record["response_body"].(map[string]any)["content"])
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	require.Len(t, spans[0].Events, 2)
+	assert.Equal(t, "http.request.body", spans[0].Events[0].Name)
+	assert.Contains(t, spans[0].Events[0].Attributes, attribute.String("content", `{"password":"REDACTED","title":"Banjir"}`))
+	assert.Equal(t, "http.response.body", spans[0].Events[1].Name)
+}
+
+func TestBodyCaptureMiddleware_Gzip(t *testing.T) {
+	e, buf := newBodyCaptureServer(t, middleware.BodyCaptureConfig{
+		Routes: []middleware.BodyCaptureRoute{{Path: "/news", SampleRate: 1}},
+	})
+	e.GET("/news", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{"title": "Banjir"})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/news", nil)
+	req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	require.Equal(t, "gzip", rec.Header().Get(echo.HeaderContentEncoding))
+	reader, err := gzip.NewReader(rec.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"title":"Banjir"}`, string(body))
+	assert.Equal(t, `{"title":"Banjir"}`, logRecord(t, buf)["response_body"].(map[string]any)["content"],
+		"the body is captured before it is compressed")
+}
+
+func TestBodyCaptureMiddleware_Streaming(t *testing.T) {
+	e, buf := newBodyCaptureServer(t, middleware.BodyCaptureConfig{
+		Routes:   []middleware.BodyCaptureRoute{{Path: "/events", SampleRate: 1}},
+		MaxBytes: 8,
+	})
+	e.GET("/events", func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
+		c.Response().WriteHeader(http.StatusOK)
+		for range 3 {
+			if _, err := c.Response().Write([]byte("data: tick\n\n")); err != nil {
+				return err
+			}
+			c.Response().Flush()
+		}
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	assert.True(t, rec.Flushed, "flushes reach the client")
+	assert.Equal(t, strings.Repeat("data: tick\n\n", 3), rec.Body.String())
+	assert.Equal(t, map[string]any{
+		"content_type": "text/event-stream",
+		"size":         36.0,
+		"content":      "data: ti",
+		"truncated":    true,
+	}, logRecord(t, buf)["response_body"])
+}
+
+func TestBodyCaptureMiddleware_NotCaptured(t *testing.T) {
+	e, buf := newBodyCaptureServer(t, middleware.BodyCaptureConfig{
+		Routes: []middleware.BodyCaptureRoute{
+			{Path: "/sampled-out", SampleRate: 0},
+			{Method: http.MethodPost, Path: "/news", SampleRate: 1},
+		},
+	})
+	handler := func(c echo.Context) error { return c.String(http.StatusOK, "ok") }
+	e.GET("/sampled-out", handler)
+	e.GET("/news", handler)
+
+	for _, path := range []string{"/sampled-out", "/news"} {
+		buf.Reset()
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+
+		record := logRecord(t, buf)
+		assert.NotContains(t, record, "request_body", path)
+		assert.NotContains(t, record, "response_body", path)
+	}
+}
+
+func TestBodyCaptureMiddleware_Error(t *testing.T) {
+	e, buf := newBodyCaptureServer(t, middleware.BodyCaptureConfig{
+		Routes: []middleware.BodyCaptureRoute{{Path: "/failing", SampleRate: 1}},
+	})
+	e.GET("/failing", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid signature")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/failing", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.JSONEq(t, `{"message":"Invalid signature"}`, rec.Body.String())
+	record := logRecord(t, buf)
+	assert.Equal(t, 401.0, record["status"], "the error response is written before the log")
+	assert.Equal(t, `{"message":"Invalid signature"}`, record["response_body"].(map[string]any)["content"])
+}
+
+func TestBodyCaptureMiddleware_TwoFactor(t *testing.T) {
+	e, buf := newBodyCaptureServer(t, middleware.BodyCaptureConfig{
+		Routes: []middleware.BodyCaptureRoute{
+			{Method: http.MethodPost, Path: "/api/v1/auth/login/2fa", SampleRate: 1},
+			{Method: http.MethodPost, Path: "/api/v1/users/me/2fa/*", SampleRate: 1},
+		},
+	})
+	e.POST("/api/v1/auth/login/2fa", func(c echo.Context) error {
+		_, _ = io.Copy(io.Discard, c.Request().Body)
+		return c.JSON(http.StatusOK, map[string]any{"code": http.StatusOK, "data": map[string]any{"user_id": "user-1"}})
+	})
+	e.POST("/api/v1/users/me/2fa/verify", func(c echo.Context) error {
+		_, _ = io.Copy(io.Discard, c.Request().Body)
+		return c.JSON(http.StatusOK, map[string]any{"data": map[string]any{"recovery_codes": []string{"a1b2c-3d4e5", "f6a7b-8c9d0"}}})
+	})
+
+	for _, tc := range []struct {
+		path, request, response string
+	}{
+		{
+			"/api/v1/auth/login/2fa",
+			`{"challenge_token":"challenge","code":"123456"}`,
+			`{"code":"REDACTED","data":{"user_id":"user-1"}}`,
+		},
+		{
+			"/api/v1/users/me/2fa/verify",
+			`{"code":"654321"}`,
+			`{"data":{"recovery_codes":"REDACTED"}}`,
+		},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.request))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			record := logRecord(t, buf)
+			request := record["request_body"].(map[string]any)["content"].(string)
+			assert.Contains(t, request, `"code":"REDACTED"`)
+			assert.NotContains(t, request, "123456")
+			assert.NotContains(t, request, "654321")
+			assert.Equal(t, tc.response, record["response_body"].(map[string]any)["content"])
+		})
+	}
+}
@@ -2,21 +2,13 @@ package middleware
 
 import (
 	"net/http"
-	"os"
-	"strings"
 
 	"github.com/labstack/echo/v4"
 	echoMiddleware "github.com/labstack/echo/v4/middleware"
 )
 
-func Cors() echo.MiddlewareFunc {
-
-	allowedOrigins := os.Getenv("CORS_ALLOW_ORIGINS")
-	if allowedOrigins == "" {
-		allowedOrigins = "*"
-	}
-
-	origins := strings.Split(allowedOrigins, ",")
+// Cors allows the given origins, "*" allows every origin
+func Cors(origins []string) echo.MiddlewareFunc {
 	return echoMiddleware.CORSWithConfig(echoMiddleware.CORSConfig{
 		AllowOrigins: origins,
 		AllowMethods: []string{
@@ -32,7 +24,18 @@ func Cors() echo.MiddlewareFunc {
 			echo.HeaderContentType,
 			echo.HeaderAccept,
 			echo.HeaderAuthorization,
-			"X-Signature",
+			SignatureHeader,
+			SignatureTimestampHeader,
+			SignatureClientHeader,
+			SignatureNonceHeader,
+			APIKeyHeader,
+		},
+		// Browsers hide the other response headers from scripts
+		ExposeHeaders: []string{
+			RateLimitLimitHeader,
+			RateLimitRemainingHeader,
+			RateLimitResetHeader,
+			echo.HeaderRetryAfter,
 		},
 	})
 }
@@ -2,12 +2,19 @@ package middleware
 
 import (
 	"log/slog"
+	"net/url"
+	"slices"
 	"time"
 
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/redact"
 	echo "github.com/labstack/echo/v4"
 	"github.com/lmittmann/tint"
 )
 
+// LoggedQueryParams are the query parameters whose values are logged, the
+// values of the others, like search terms, are redacted
+var LoggedQueryParams = []string{"page", "per_page", "status", "action", "entity", "id", "author_id"}
+
 func SlogLoggerMiddleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -34,17 +41,23 @@ func SlogLoggerMiddleware() echo.MiddlewareFunc {
 
 			// Add query parameters if present
 			if req.URL.RawQuery != "" {
-				args = append(args, slog.String("query", req.URL.RawQuery))
+				args = append(args, slog.String("query", redactQuery(req.URL.Query())))
+			}
+			// Add the bodies captured by BodyCaptureMiddleware
+			if capture := GetBodyCapture(c); capture != nil {
+				args = append(args, slog.Any("request_body", capture.Request), slog.Any("response_body", capture.Response))
 			}
 
-			// Log with appropriate level based on status code
+			// Log with appropriate level based on status code, with the
+			// context carrying the trace of the request
+			ctx := req.Context()
 			switch {
 			case status >= 500:
-				slog.Error("HTTP Request", args...)
+				slog.ErrorContext(ctx, "HTTP Request", args...)
 			case status >= 400:
-				slog.Warn("HTTP Request", args...)
+				slog.WarnContext(ctx, "HTTP Request", args...)
 			default:
-				slog.Info("HTTP Request", args...)
+				slog.InfoContext(ctx, "HTTP Request", args...)
 			}
 
 			return err
@@ -52,6 +65,20 @@ func SlogLoggerMiddleware() echo.MiddlewareFunc {
 	}
 }
 
+// redactQuery encodes query with the values of the parameters which are not
+// in LoggedQueryParams redacted
+func redactQuery(query url.Values) string {
+	for name, values := range query {
+		if slices.Contains(LoggedQueryParams, name) {
+			continue
+		}
+		for i := range values {
+			values[i] = redact.Redacted
+		}
+	}
+	return query.Encode()
+}
+
 func ColorizeLogging(groups []string, a slog.Attr) slog.Attr {
 	const LevelTrace = slog.LevelDebug
 	if a.Key == slog.LevelKey && len(groups) == 0 {
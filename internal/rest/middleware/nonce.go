@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NonceCache remembers the nonces of signed requests for as long as their
+// signature could be replayed
+type NonceCache interface {
+	// Remember records key for ttl and reports false when it is already known
+	Remember(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// MemoryNonceCache keeps nonces in process memory. Expired entries are swept
+// while new nonces are remembered, so no background goroutine is needed.
+type MemoryNonceCache struct {
+	mu        sync.Mutex
+	expires   map[string]time.Time
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryNonceCache() *MemoryNonceCache {
+	return &MemoryNonceCache{
+		expires: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+func (m *MemoryNonceCache) Remember(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if now.After(m.nextSweep) {
+		for k, exp := range m.expires {
+			if now.After(exp) {
+				delete(m.expires, k)
+			}
+		}
+		m.nextSweep = now.Add(ttl)
+	}
+
+	if exp, ok := m.expires[key]; ok && !now.After(exp) {
+		return false, nil
+	}
+	m.expires[key] = now.Add(ttl)
+	return true, nil
+}
+
+// RedisNonceCache keeps nonces in Redis, or any server speaking its
+// protocol, so a nonce used on one replica is refused by the others
+type RedisNonceCache struct {
+	client redis.Cmdable
+	prefix string
+}
+
+// NewRedisNonceCache stores the nonces under keys starting with prefix
+func NewRedisNonceCache(client redis.Cmdable, prefix string) *RedisNonceCache {
+	return &RedisNonceCache{client: client, prefix: prefix}
+}
+
+func (r *RedisNonceCache) Remember(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	stored, err := r.client.SetNX(ctx, r.prefix+key, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("remember nonce: %w", err)
+	}
+	return stored, nil
+}
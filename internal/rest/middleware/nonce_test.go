@@ -0,0 +1,48 @@
+package middleware_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisNonceCache(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	ctx := context.Background()
+	first := middleware.NewRedisNonceCache(client, "nonce:")
+	// A second replica sharing the same Redis
+	second := middleware.NewRedisNonceCache(client, "nonce:")
+
+	fresh, err := first.Remember(ctx, "client:n-1", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, fresh)
+
+	fresh, err = first.Remember(ctx, "client:n-1", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, fresh, "a nonce must not be accepted twice")
+
+	fresh, err = second.Remember(ctx, "client:n-1", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, fresh, "a nonce used on one replica must be refused by the others")
+
+	assert.True(t, server.Exists("nonce:client:n-1"))
+	assert.Equal(t, time.Minute, server.TTL("nonce:client:n-1"))
+
+	server.FastForward(time.Minute + time.Second)
+	fresh, err = second.Remember(ctx, "client:n-1", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, fresh, "the nonce is forgotten once it expires")
+
+	server.Close()
+	_, err = first.Remember(ctx, "client:n-2", time.Minute)
+	assert.Error(t, err)
+}
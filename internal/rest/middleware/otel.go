@@ -1,13 +1,14 @@
 package middleware
 
 import (
+	"cmp"
+	"strings"
 	"time"
 
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
 	"github.com/edwinjordan/ZOGTest-Golang.git/internal/metrics"
 	echo "github.com/labstack/echo/v4"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -44,60 +45,31 @@ func OTelMetricsMiddleware(otelMetrics *metrics.OTelMetrics) echo.MiddlewareFunc
 	}
 }
 
-// EnhancedTracingMiddleware adds detailed tracing with spans
-func EnhancedTracingMiddleware(serviceName string) echo.MiddlewareFunc {
-	tracer := otel.Tracer(serviceName)
-
+// EnhancedTracingMiddleware adds what the application knows about a request
+// to the server span started by AttachTraceProvider: the request ID, the
+// principal authenticated by the middlewares of the routes and the error of
+// the handler. The span itself, its status and the HTTP attributes are left
+// to otelecho, which follows the semantic conventions.
+func EnhancedTracingMiddleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			req := c.Request()
-			ctx := req.Context()
-
-			// Start a new span
-			spanName := req.Method + " " + c.Path()
-			ctx, span := tracer.Start(ctx, spanName,
-				trace.WithSpanKind(trace.SpanKindServer),
-				trace.WithAttributes(
-					attribute.String("http.method", req.Method),
-					attribute.String("http.url", req.URL.String()),
-					attribute.String("http.scheme", req.URL.Scheme),
-					attribute.String("http.target", req.URL.Path),
-					attribute.String("http.route", c.Path()),
-					attribute.String("http.user_agent", req.UserAgent()),
-					attribute.String("http.client_ip", c.RealIP()),
-					attribute.String("net.host.name", req.Host),
-				),
-			)
-			defer span.End()
-
-			// Update request context
-			c.SetRequest(req.WithContext(ctx))
+			span := trace.SpanFromContext(c.Request().Context())
+			if !span.IsRecording() {
+				return next(c)
+			}
 
-			// Process request
 			err := next(c)
 
-			// Record response details
-			res := c.Response()
-			span.SetAttributes(
-				attribute.Int("http.status_code", res.Status),
-				attribute.Int64("http.response_content_length", res.Size),
-			)
-
-			// Set span status based on HTTP status code
-			if res.Status >= 500 {
-				span.SetStatus(codes.Error, "Internal Server Error")
-			} else if res.Status >= 400 {
-				span.SetStatus(codes.Error, "Client Error")
-			} else {
-				span.SetStatus(codes.Ok, "Success")
+			span.SetAttributes(semconv.HTTPResponseHeader(strings.ToLower(RequestIDHeader),
+				c.Response().Header().Get(RequestIDHeader)))
+			// The authentication middlewares run after this one and replace
+			// the request
+			if principal := auth.FromContext(c.Request().Context()); principal != nil {
+				span.SetAttributes(semconv.EnduserID(cmp.Or(principal.UserID, principal.ClientID)))
 			}
-
-			// Record error if present
 			if err != nil {
 				span.RecordError(err)
-				span.SetStatus(codes.Error, err.Error())
 			}
-
 			return err
 		}
 	}
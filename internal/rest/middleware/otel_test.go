@@ -0,0 +1,98 @@
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestEnhancedTracingMiddleware(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	e := echo.New()
+	e.Use(middleware.AttachTraceProvider(provider))
+	e.Use(middleware.RequestIDMiddleware())
+	e.Use(middleware.EnhancedTracingMiddleware())
+	// Stands in for the authentication middlewares
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := auth.WithPrincipal(c.Request().Context(), &auth.Principal{UserID: "user-1"})
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	})
+	e.GET("/news/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	e.GET("/failing", func(c echo.Context) error {
+		return errors.New("broken")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/news/1?token=secret", nil)
+	req.Header.Set(middleware.RequestIDHeader, "request-1")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1, "the server span is not duplicated")
+	span := spans[0]
+	assert.Equal(t, "GET /news/:id", span.Name)
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+	assert.Equal(t, codes.Unset, span.Status.Code)
+	assert.Contains(t, span.Attributes, attribute.String("http.route", "/news/:id"))
+	assert.Contains(t, span.Attributes, attribute.String("enduser.id", "user-1"))
+	assert.Contains(t, span.Attributes, attribute.StringSlice("http.response.header.x-request-id", []string{"request-1"}))
+	for _, kv := range span.Attributes {
+		assert.NotContains(t, kv.Value.Emit(), "token=secret", kv.Key)
+	}
+
+	exporter.Reset()
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/failing", nil))
+
+	spans = exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	require.Len(t, spans[0].Events, 1)
+	assert.Equal(t, "exception", spans[0].Events[0].Name)
+}
+
+// The principal is added by the middlewares of the route, which run inside
+// the timeout on a copy of the context
+func TestEnhancedTracingMiddleware_Timeout(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	e := echo.New()
+	e.Use(middleware.AttachTraceProvider(provider))
+	e.Use(middleware.EnhancedTracingMiddleware())
+	e.Use(middleware.TimeoutMiddleware(middleware.TimeoutConfig{Default: time.Second}))
+	api := e.Group("/api", func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := auth.WithPrincipal(c.Request().Context(), &auth.Principal{ClientID: "partner-1"})
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	})
+	api.GET("/news", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/news", nil))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Contains(t, spans[0].Attributes, attribute.String("enduser.id", "partner-1"))
+}
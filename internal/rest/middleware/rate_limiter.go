@@ -1,73 +1,135 @@
 package middleware
 
 import (
+	"log/slog"
+	"math"
 	"net/http"
-	"sync"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/ratelimit"
 	"github.com/labstack/echo/v4"
-	"golang.org/x/time/rate"
 )
 
-type rateLimiter struct {
-	limiter  *rate.Limiter
-	lastSeen time.Time
-}
+// Headers of the IETF RateLimit header fields draft
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+)
 
-type RateLimiterStore struct {
-	visitors map[string]*rateLimiter
-	mu       sync.RWMutex
+// RateLimitKeyFunc returns what the requests of a policy are counted by
+type RateLimitKeyFunc func(c echo.Context) string
+
+// RateLimitKeyIP counts the requests of each client IP
+func RateLimitKeyIP(c echo.Context) string {
+	return "ip:" + c.RealIP()
 }
 
-func NewRateLimiterStore() *RateLimiterStore {
-	store := &RateLimiterStore{
-		visitors: make(map[string]*rateLimiter),
+// RateLimitKeyPrincipal counts the requests of each user, API key or
+// partner, and of each client IP for anonymous requests
+func RateLimitKeyPrincipal(c echo.Context) string {
+	p := auth.FromContext(c.Request().Context())
+	switch {
+	case p == nil:
+		return RateLimitKeyIP(c)
+	case p.KeyID != "":
+		return "api_key:" + p.KeyID
+	case p.ClientID != "":
+		return "client:" + p.ClientID
+	case p.UserID != "":
+		return "user:" + p.UserID
 	}
+	return RateLimitKeyIP(c)
+}
 
-	go store.cleanupVisitors()
-	return store
+// RateLimitKeys names the key functions for the configuration
+var RateLimitKeys = map[string]RateLimitKeyFunc{
+	"ip":        RateLimitKeyIP,
+	"principal": RateLimitKeyPrincipal,
 }
 
-func (store *RateLimiterStore) cleanupVisitors() {
-	for {
-		time.Sleep(time.Minute)
-		store.mu.Lock()
-		for ip, v := range store.visitors {
-			if time.Since(v.lastSeen) > 3*time.Minute {
-				delete(store.visitors, ip)
-			}
-		}
-		store.mu.Unlock()
+type RateLimitPolicy struct {
+	// Name keeps the counters of the policies apart
+	Name  string
+	Limit ratelimit.Limit
+	// Key is nil for the routes left to another rate limit middleware, like
+	// the routes limited by IP before the authentication
+	Key RateLimitKeyFunc
+}
+
+// RateLimitRoute applies a policy to the routes matching Method and Path.
+// Path is an Echo route like /api/v1/auth/login, or a prefix ending with *.
+type RateLimitRoute struct {
+	// Method is any method when empty
+	Method string
+	Path   string
+	Policy RateLimitPolicy
+}
+
+// matchRoute tells whether a request matches the method and Echo route of
+// a rule. An empty method matches any method and a route ending with *
+// matches the routes starting with it.
+func matchRoute(ruleMethod, rulePath, method, path string) bool {
+	if ruleMethod != "" && ruleMethod != method {
+		return false
 	}
+	if prefix, ok := strings.CutSuffix(rulePath, "*"); ok {
+		return strings.HasPrefix(path, prefix)
+	}
+	return rulePath == path
 }
 
-func (store *RateLimiterStore) getRateLimiter(ip string, r rate.Limit, b int) *rate.Limiter {
-	store.mu.Lock()
-	defer store.mu.Unlock()
+type RateLimitConfig struct {
+	// Default applies to the routes matching none of Routes
+	Default RateLimitPolicy
+	// Routes are matched in order, the first match applies
+	Routes []RateLimitRoute
+}
 
-	v, exists := store.visitors[ip]
-	if !exists {
-		limiter := rate.NewLimiter(r, b)
-		store.visitors[ip] = &rateLimiter{
-			limiter:  limiter,
-			lastSeen: time.Now(),
+// policy returns the policy of the route of the request
+func (config *RateLimitConfig) policy(c echo.Context) *RateLimitPolicy {
+	for i := range config.Routes {
+		route := &config.Routes[i]
+		if matchRoute(route.Method, route.Path, c.Request().Method, c.Path()) {
+			return &route.Policy
 		}
-		return limiter
 	}
-
-	v.lastSeen = time.Now()
-	return v.limiter
+	return &config.Default
 }
 
-func RateLimitMiddleware(requestsPerSecond float64, burstSize int) echo.MiddlewareFunc {
-	store := NewRateLimiterStore()
+// seconds rounds d up to whole seconds for the headers
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
 
+// RateLimitMiddleware limits the requests with the policy of their route
+// and tells clients how much of it is left. The IP keyed policies run
+// before the authentication, so rejected credentials are limited too, and
+// the principal keyed ones after it so the principal is known.
+func RateLimitMiddleware(limiter *ratelimit.Limiter, config RateLimitConfig) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			ip := c.RealIP()
-			limiter := store.getRateLimiter(ip, rate.Limit(requestsPerSecond), burstSize)
+			policy := config.policy(c)
+			if policy.Key == nil {
+				return next(c)
+			}
+			result, err := limiter.Take(c.Request().Context(), policy.Name+":"+policy.Key(c), policy.Limit)
+			if err != nil {
+				slog.ErrorContext(c.Request().Context(), "Rate limit store failed, request allowed",
+					slog.String("policy", policy.Name),
+					slog.String("error", err.Error()),
+				)
+			}
 
-			if !limiter.Allow() {
+			header := c.Response().Header()
+			header.Set(RateLimitLimitHeader, strconv.Itoa(result.Limit))
+			header.Set(RateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+			header.Set(RateLimitResetHeader, seconds(result.Reset))
+			if !result.Allowed {
+				header.Set(echo.HeaderRetryAfter, seconds(result.RetryAfter))
 				return echo.NewHTTPError(http.StatusTooManyRequests, "Rate limit exceeded")
 			}
 
@@ -0,0 +1,178 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/ratelimit"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRateLimitedServer() *echo.Echo {
+	e := echo.New()
+	// Stands in for the authentication middlewares
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if user := c.Request().Header.Get("X-Test-User"); user != "" {
+				ctx := auth.WithPrincipal(c.Request().Context(), &auth.Principal{UserID: user})
+				c.SetRequest(c.Request().WithContext(ctx))
+			}
+			return next(c)
+		}
+	})
+	e.Use(middleware.RateLimitMiddleware(ratelimit.NewLimiter(ratelimit.NewMemoryStore()), middleware.RateLimitConfig{
+		Default: middleware.RateLimitPolicy{
+			Name:  "default",
+			Limit: ratelimit.Limit{Rate: 1, Burst: 3},
+			Key:   middleware.RateLimitKeyPrincipal,
+		},
+		Routes: []middleware.RateLimitRoute{{
+			Method: http.MethodPost,
+			Path:   "/auth/login",
+			Policy: middleware.RateLimitPolicy{
+				Name:  "login",
+				Limit: ratelimit.Limit{Rate: 0.1, Burst: 1},
+				Key:   middleware.RateLimitKeyIP,
+			},
+		}},
+	}))
+	handler := func(c echo.Context) error { return c.NoContent(http.StatusNoContent) }
+	e.GET("/news", handler)
+	e.POST("/auth/login", handler)
+	return e
+}
+
+func serve(e *echo.Echo, method, path, user string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	if user != "" {
+		req.Header.Set("X-Test-User", user)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRateLimitMiddleware_Headers(t *testing.T) {
+	e := newRateLimitedServer()
+
+	for _, remaining := range []string{"2", "1", "0"} {
+		rec := serve(e, http.MethodGet, "/news", "alice")
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "3", rec.Header().Get(middleware.RateLimitLimitHeader))
+		assert.Equal(t, remaining, rec.Header().Get(middleware.RateLimitRemainingHeader))
+		assert.NotEmpty(t, rec.Header().Get(middleware.RateLimitResetHeader))
+		assert.Empty(t, rec.Header().Get(echo.HeaderRetryAfter))
+	}
+
+	rec := serve(e, http.MethodGet, "/news", "alice")
+
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get(middleware.RateLimitRemainingHeader))
+	assert.Equal(t, "1", rec.Header().Get(echo.HeaderRetryAfter))
+}
+
+func TestRateLimitMiddleware_KeysByPrincipal(t *testing.T) {
+	e := newRateLimitedServer()
+	for range 3 {
+		serve(e, http.MethodGet, "/news", "alice")
+	}
+
+	assert.Equal(t, http.StatusTooManyRequests, serve(e, http.MethodGet, "/news", "alice").Code)
+	assert.Equal(t, http.StatusNoContent, serve(e, http.MethodGet, "/news", "bob").Code,
+		"users behind the same IP are limited apart")
+	assert.Equal(t, http.StatusNoContent, serve(e, http.MethodGet, "/news", "").Code,
+		"anonymous requests are limited by IP")
+}
+
+func TestRateLimitMiddleware_RoutePolicy(t *testing.T) {
+	e := newRateLimitedServer()
+
+	assert.Equal(t, http.StatusNoContent, serve(e, http.MethodPost, "/auth/login", "alice").Code)
+	rec := serve(e, http.MethodPost, "/auth/login", "bob")
+
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code, "logins are limited by IP")
+	assert.Equal(t, "1", rec.Header().Get(middleware.RateLimitLimitHeader))
+	assert.Equal(t, "10", rec.Header().Get(echo.HeaderRetryAfter))
+	assert.Equal(t, http.StatusNoContent, serve(e, http.MethodGet, "/news", "alice").Code,
+		"the route policy has its own counters")
+}
+
+func TestRateLimitMiddleware_SpoofedForwardedFor(t *testing.T) {
+	e := newRateLimitedServer()
+	e.IPExtractor = echo.ExtractIPDirect()
+	login := func(forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		req.Header.Set(echo.HeaderXRealIP, forwardedFor)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusNoContent, login("203.0.113.1"))
+	assert.Equal(t, http.StatusTooManyRequests, login("203.0.113.2"),
+		"a forged header does not get a new IP key")
+}
+
+func TestRateLimitMiddleware_BeforeAuthentication(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore())
+	e := echo.New()
+	e.Use(middleware.RateLimitMiddleware(limiter, middleware.RateLimitConfig{
+		Default: middleware.RateLimitPolicy{
+			Name:  "ip",
+			Limit: ratelimit.Limit{Rate: 1, Burst: 2},
+			Key:   middleware.RateLimitKeyIP,
+		},
+	}))
+	// Stands in for an authentication middleware rejecting the credentials,
+	// and the principal limit after it which leaves the health check alone
+	api := e.Group("/api", func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			return echo.NewHTTPError(http.StatusUnauthorized)
+		}
+	}, middleware.RateLimitMiddleware(limiter, middleware.RateLimitConfig{
+		Default: middleware.RateLimitPolicy{
+			Name:  "default",
+			Limit: ratelimit.Limit{Rate: 1, Burst: 2},
+			Key:   middleware.RateLimitKeyPrincipal,
+		},
+	}))
+	api.GET("/news", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+	e.GET("/health", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+
+	assert.Equal(t, http.StatusUnauthorized, serve(e, http.MethodGet, "/api/news", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(e, http.MethodGet, "/api/news", "").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(e, http.MethodGet, "/api/news", "").Code,
+		"rejected credentials count against the IP")
+	assert.Equal(t, http.StatusTooManyRequests, serve(e, http.MethodGet, "/health", "").Code,
+		"unauthenticated routes share the IP limit")
+}
+
+func TestRateLimitMiddleware_SkipsPolicyWithoutKey(t *testing.T) {
+	e := echo.New()
+	e.Use(middleware.RateLimitMiddleware(ratelimit.NewLimiter(ratelimit.NewMemoryStore()), middleware.RateLimitConfig{
+		Default: middleware.RateLimitPolicy{
+			Name:  "default",
+			Limit: ratelimit.Limit{Rate: 0.1, Burst: 1},
+			Key:   middleware.RateLimitKeyIP,
+		},
+		Routes: []middleware.RateLimitRoute{{
+			Method: http.MethodPost,
+			Path:   "/auth/login",
+			Policy: middleware.RateLimitPolicy{Name: "POST /auth/login"},
+		}},
+	}))
+	e.POST("/auth/login", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+
+	for range 3 {
+		rec := serve(e, http.MethodPost, "/auth/login", "")
+		assert.Equal(t, http.StatusNoContent, rec.Code, "the route is limited by another middleware")
+		assert.Empty(t, rec.Header().Get(middleware.RateLimitLimitHeader))
+	}
+}
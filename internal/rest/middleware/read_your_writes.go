@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/database/dbroute"
+	"github.com/labstack/echo/v4"
+)
+
+// ReadYourWritesMiddleware sends the reads of requests that change data to
+// the primary database, so the checks and the responses of a write never
+// see a replica lagging behind it. Safe methods keep reading from the
+// replica.
+func ReadYourWritesMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			switch c.Request().Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+			default:
+				c.SetRequest(c.Request().WithContext(dbroute.WithPrimary(c.Request().Context())))
+			}
+			return next(c)
+		}
+	}
+}
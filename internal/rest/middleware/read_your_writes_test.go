@@ -0,0 +1,41 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/database/dbroute"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadYourWritesMiddleware(t *testing.T) {
+	e := echo.New()
+	e.Use(middleware.ReadYourWritesMiddleware())
+	var primary bool
+	handler := func(c echo.Context) error {
+		primary = dbroute.ReadsFromPrimary(c.Request().Context())
+		return c.NoContent(http.StatusNoContent)
+	}
+	e.GET("/news", handler)
+	e.POST("/news", handler)
+	e.DELETE("/news", handler)
+
+	tests := []struct {
+		method  string
+		primary bool
+	}{
+		{http.MethodGet, false},
+		{http.MethodPost, true},
+		{http.MethodDelete, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, "/news", nil))
+
+			assert.Equal(t, tt.primary, primary)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
@@ -27,8 +28,15 @@ func RequestIDMiddleware() echo.MiddlewareFunc {
 			// Set request ID in response headers
 			c.Response().Header().Set(RequestIDHeader, requestID)
 
-			// Store request ID in context for use in handlers
-			ctx := context.WithValue(c.Request().Context(), RequestIDKey, requestID)
+			// Store request ID in context for use in handlers, with the client
+			// IP and user agent so the service layer can record them. The IP
+			// comes from the IPExtractor of Echo, which only reads
+			// X-Forwarded-For from trusted proxies.
+			ctx := auth.WithRequest(c.Request().Context(), auth.Request{
+				ID:        requestID,
+				ClientIP:  c.RealIP(),
+				UserAgent: c.Request().UserAgent(),
+			})
 			c.SetRequest(c.Request().WithContext(ctx))
 
 			// Add request ID to the Echo context for easy access
@@ -41,10 +49,12 @@ func RequestIDMiddleware() echo.MiddlewareFunc {
 
 // GetRequestID extracts the request ID from context
 func GetRequestID(ctx context.Context) string {
-	if requestID, ok := ctx.Value(RequestIDKey).(string); ok {
-		return requestID
-	}
-	return ""
+	return auth.RequestFromContext(ctx).ID
+}
+
+// GetClientIP extracts the client IP from context
+func GetClientIP(ctx context.Context) string {
+	return auth.RequestFromContext(ctx).ClientIP
 }
 
 // LogWithRequestID creates a logger with request ID context
@@ -63,3 +73,8 @@ func GetRequestIDFromEcho(c echo.Context) string {
 	}
 	return ""
 }
+
+// GetUserAgent extracts the client user agent from context
+func GetUserAgent(ctx context.Context) string {
+	return auth.RequestFromContext(ctx).UserAgent
+}
@@ -0,0 +1,50 @@
+package middleware_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDMiddleware_ClientIP(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("192.0.2.0/24")
+	require.NoError(t, err)
+	tests := []struct {
+		name      string
+		extractor echo.IPExtractor
+		want      string
+	}{
+		{"connection", echo.ExtractIPDirect(), "192.0.2.1"},
+		{"trusted proxy", echo.ExtractIPFromXFFHeader(echo.TrustIPRange(proxies)), "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.IPExtractor = tt.extractor
+			e.Use(middleware.RequestIDMiddleware())
+			var got auth.Request
+			e.POST("/auth/login", func(c echo.Context) error {
+				got = auth.RequestFromContext(c.Request().Context())
+				return c.NoContent(http.StatusNoContent)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			req.Header.Set(echo.HeaderXForwardedFor, "203.0.113.7")
+			req.Header.Set(echo.HeaderXRealIP, "203.0.113.7")
+			req.Header.Set("User-Agent", "curl/8.0")
+			e.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.want, got.ClientIP, "the login throttle, sessions and audit log key on it")
+			assert.Equal(t, "curl/8.0", got.UserAgent)
+			assert.NotEmpty(t, got.ID)
+		})
+	}
+}
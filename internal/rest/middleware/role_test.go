@@ -0,0 +1,103 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireScope_TwoFactorSetup(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		want      int
+	}{
+		{"Editor with two-factor set up", &auth.Principal{UserID: "u-1", Role: domain.RoleEditor, Method: auth.MethodSession}, http.StatusNoContent},
+		{"Editor who has to set up two-factor", &auth.Principal{UserID: "u-1", Role: domain.RoleEditor, Method: auth.MethodSession, TwoFactorSetupRequired: true}, http.StatusForbidden},
+		{"Anonymous", nil, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.POST("/news", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) },
+				middleware.RequireScope(domain.ScopeNewsWrite))
+			req := httptest.NewRequest(http.MethodPost, "/news", nil)
+			if tt.principal != nil {
+				req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+			}
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestRequireUserRole(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		want      int
+	}{
+		{"Editor", &auth.Principal{UserID: "u-1", Role: domain.RoleEditor, Method: auth.MethodSession}, http.StatusNoContent},
+		{"User", &auth.Principal{UserID: "u-1", Role: domain.RoleUser, Method: auth.MethodBasic}, http.StatusForbidden},
+		{"Editor who has to set up two-factor", &auth.Principal{UserID: "u-1", Role: domain.RoleEditor, Method: auth.MethodSession, TwoFactorSetupRequired: true}, http.StatusForbidden},
+		{"API key", &auth.Principal{UserID: "u-1", KeyID: "k-1", Method: auth.MethodAPIKey}, http.StatusNoContent},
+		{"Partner client", &auth.Principal{ClientID: "c-1", Scopes: []string{domain.ScopeNewsWrite}, Method: auth.MethodSignature}, http.StatusForbidden},
+		{"Anonymous", nil, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.POST("/news", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) },
+				middleware.RequireUserRole(domain.RoleEditor, domain.RoleAdmin))
+			req := httptest.NewRequest(http.MethodPost, "/news", nil)
+			if tt.principal != nil {
+				req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+			}
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestRequireScope_MachineClients(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		want      int
+	}{
+		{"API key with the scope", &auth.Principal{UserID: "u-1", KeyID: "k-1", Scopes: []string{domain.ScopeNewsWrite}, Method: auth.MethodAPIKey}, http.StatusNoContent},
+		{"API key without the scope", &auth.Principal{UserID: "u-1", KeyID: "k-1", Scopes: []string{domain.ScopeNewsRead}, Method: auth.MethodAPIKey}, http.StatusForbidden},
+		{"Partner client with the scope", &auth.Principal{ClientID: "c-1", Scopes: []string{domain.ScopeNewsWrite}, Method: auth.MethodSignature}, http.StatusNoContent},
+		{"Partner client without the scope", &auth.Principal{ClientID: "c-1", Scopes: []string{domain.ScopeNewsRead}, Method: auth.MethodSignature}, http.StatusForbidden},
+		{"Partner client without scopes", &auth.Principal{ClientID: "c-1", Method: auth.MethodSignature}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.POST("/news", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) },
+				middleware.RequireScope(domain.ScopeNewsWrite))
+			req := httptest.NewRequest(http.MethodPost, "/news", nil)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
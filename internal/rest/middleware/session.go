@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/labstack/echo/v4"
+)
+
+// SessionAuthenticator verifies a session token
+type SessionAuthenticator interface {
+	AuthenticateSession(ctx context.Context, token string) (*auth.Principal, error)
+}
+
+// SessionAuthMiddleware attaches the caller of requests carrying a bearer
+// token starting with the session token prefix. Other requests pass through,
+// unknown, expired and revoked sessions are rejected with 401.
+func SessionAuthMiddleware(authenticator SessionAuthenticator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			token, ok := strings.CutPrefix(req.Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if !ok || !strings.HasPrefix(token, domain.SessionTokenPrefix) {
+				return next(c)
+			}
+
+			principal, err := authenticator.AuthenticateSession(req.Context(), token)
+			if err != nil {
+				if errors.Is(err, domain.ErrInvalidToken) {
+					c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="api"`)
+					return echo.NewHTTPError(http.StatusUnauthorized, "Invalid or expired session")
+				}
+				return err
+			}
+
+			c.SetRequest(req.WithContext(auth.WithPrincipal(req.Context(), principal)))
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	adminSession   = domain.SessionTokenPrefix + "admin"
+	enrollSession  = domain.SessionTokenPrefix + "enroll"
+	revokedSession = domain.SessionTokenPrefix + "revoked"
+)
+
+type sessionAuthenticator struct{}
+
+func (sessionAuthenticator) AuthenticateSession(_ context.Context, token string) (*auth.Principal, error) {
+	switch token {
+	case adminSession:
+		return &auth.Principal{UserID: "u-1", Role: domain.RoleAdmin, Method: auth.MethodSession}, nil
+	case enrollSession:
+		return &auth.Principal{UserID: "u-2", Role: domain.RoleAdmin, Method: auth.MethodSession, TwoFactorSetupRequired: true}, nil
+	}
+	return nil, domain.ErrInvalidToken
+}
+
+func newSessionServer() *echo.Echo {
+	e := echo.New()
+	e.Use(middleware.SessionAuthMiddleware(sessionAuthenticator{}))
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusNoContent) }
+	e.GET("/audit", ok, middleware.RequireRole(domain.RoleAdmin))
+	e.POST("/users/me/2fa/setup", ok, middleware.RequireUser())
+	return e
+}
+
+func TestSessionAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{"Session with the role", http.MethodGet, "/audit", adminSession, http.StatusNoContent},
+		{"Revoked session", http.MethodGet, "/audit", revokedSession, http.StatusUnauthorized},
+		{"Pending two-factor setup blocks role routes", http.MethodGet, "/audit", enrollSession, http.StatusForbidden},
+		{"Pending two-factor setup may enroll", http.MethodPost, "/users/me/2fa/setup", enrollSession, http.StatusNoContent},
+		{"Bearer tokens that are not sessions are ignored", http.MethodGet, "/audit", domain.APIKeyPrefix + "key", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+
+			newSessionServer().ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
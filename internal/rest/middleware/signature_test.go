@@ -0,0 +1,131 @@
+package middleware_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+const partnerID = "3f1c9a52-6f0e-4b8e-9d4a-2c7b5e1f0a93"
+
+type partnerStore map[string]*domain.PartnerClient
+
+func (s partnerStore) GetPartnerClient(_ context.Context, id string) (*domain.PartnerClient, error) {
+	if client, ok := s[id]; ok {
+		return client, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func newSignedServer() *echo.Echo {
+	store := partnerStore{partnerID: {ID: partnerID, Name: "Wire Agency", Secret: "s3cret"}}
+
+	e := echo.New()
+	e.Use(middleware.SignatureAuthMiddleware(store, middleware.DefaultSignatureConfig))
+	e.POST("/news", func(c echo.Context) error {
+		body, _ := io.ReadAll(c.Request().Body)
+		principal := auth.FromContext(c.Request().Context())
+		if principal == nil {
+			return c.String(http.StatusOK, "anonymous:"+string(body))
+		}
+		return c.String(http.StatusOK, principal.ClientID+":"+string(body))
+	})
+	return e
+}
+
+func signedRequest(secret, nonce string, at time.Time, body string) *http.Request {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, "/news?notify=true", strings.NewReader(body))
+	req.Header.Set(middleware.SignatureClientHeader, partnerID)
+	req.Header.Set(middleware.SignatureTimestampHeader, timestamp)
+	req.Header.Set(middleware.SignatureNonceHeader, nonce)
+	req.Header.Set(middleware.SignatureHeader,
+		middleware.SignRequest(secret, http.MethodPost, "/news?notify=true", timestamp, nonce, []byte(body)))
+	return req
+}
+
+func TestSignatureAuthMiddleware(t *testing.T) {
+	body := `{"title":"Signed"}`
+
+	t.Run("Attaches the client of a valid signature and keeps the body", func(t *testing.T) {
+		e := newSignedServer()
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, signedRequest("s3cret", "n-1", time.Now(), body))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, partnerID+":"+body, rec.Body.String())
+	})
+
+	t.Run("Lets unsigned requests through anonymously", func(t *testing.T) {
+		e := newSignedServer()
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/news", strings.NewReader(body)))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "anonymous:"+body, rec.Body.String())
+	})
+
+	t.Run("Rejects a replayed nonce", func(t *testing.T) {
+		e := newSignedServer()
+		first, second := httptest.NewRecorder(), httptest.NewRecorder()
+
+		e.ServeHTTP(first, signedRequest("s3cret", "n-1", time.Now(), body))
+		e.ServeHTTP(second, signedRequest("s3cret", "n-1", time.Now(), body))
+
+		assert.Equal(t, http.StatusOK, first.Code)
+		assert.Equal(t, http.StatusUnauthorized, second.Code)
+	})
+
+	t.Run("Rejects a timestamp outside the window", func(t *testing.T) {
+		e := newSignedServer()
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, signedRequest("s3cret", "n-1", time.Now().Add(-10*time.Minute), body))
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Rejects a wrong secret", func(t *testing.T) {
+		e := newSignedServer()
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, signedRequest("other", "n-1", time.Now(), body))
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Rejects a tampered body", func(t *testing.T) {
+		e := newSignedServer()
+		rec := httptest.NewRecorder()
+
+		req := signedRequest("s3cret", "n-1", time.Now(), body)
+		req.Body = io.NopCloser(strings.NewReader(`{"title":"Tampered"}`))
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("Rejects an unknown client", func(t *testing.T) {
+		e := newSignedServer()
+		rec := httptest.NewRecorder()
+
+		req := signedRequest("s3cret", "n-1", time.Now(), body)
+		req.Header.Set(middleware.SignatureClientHeader, "9b2e7f40-0000-4000-8000-000000000000")
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
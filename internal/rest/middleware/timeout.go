@@ -1,32 +1,313 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"net"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/labstack/echo/v4"
 )
 
-func TimeoutMiddleware(timeout time.Duration) echo.MiddlewareFunc {
+// TimeoutRoute gives the routes matching Method and Path their own timeout.
+// Path is an Echo route like /api/v1/news/export, or a prefix ending with *.
+type TimeoutRoute struct {
+	// Method is any method when empty
+	Method string
+	Path   string
+	// Timeout is not enforced when zero, e.g. for streaming responses
+	Timeout time.Duration
+}
+
+type TimeoutConfig struct {
+	// Default applies to the routes matching none of Routes
+	Default time.Duration
+	// Routes are matched in order, the first match applies
+	Routes []TimeoutRoute
+}
+
+// timeout returns the timeout of the route of the request
+func (config *TimeoutConfig) timeout(c echo.Context) time.Duration {
+	for _, route := range config.Routes {
+		if matchRoute(route.Method, route.Path, c.Request().Method, c.Path()) {
+			return route.Timeout
+		}
+	}
+	return config.Default
+}
+
+// TimeoutMiddleware bounds the handling of a request with a deadline on its
+// context, which the database queries and outgoing requests give up on.
+//
+// The handler runs in a goroutine of its own, on a copy of the Echo context
+// writing to a buffer, so that the response is sent at the deadline even
+// when the handler ignores its context. The buffered response is written
+// once the handler returns, or replaced when the deadline passed: a handler
+// giving up writes an error of its own which must not reach the client, and
+// what a late handler writes is discarded. Clients get 503 when the request
+// took longer than its timeout, and 504 when a dependency timed out first.
+// A handler flushing the response commits it, streaming responses cannot be
+// replaced anymore and are waited for.
+//
+// The request of the handler, carrying what the middlewares of its route
+// added to the context such as the principal, replaces the request of c once
+// the handler returned. Its body fails to read past the deadline, net/http
+// and the middlewares around this one are done with it by then.
+func TimeoutMiddleware(config TimeoutConfig) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			timeout := config.timeout(c)
+			if timeout <= 0 {
+				return next(c)
+			}
+
 			ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
 			defer cancel()
+			req := c.Request().WithContext(ctx)
+			var body *deadlineBody
+			if req.Body != nil && req.Body != http.NoBody {
+				body = &deadlineBody{body: req.Body}
+				req.Body = body
+			}
+			c.SetRequest(req)
 
-			c.SetRequest(c.Request().WithContext(ctx))
-
-			done := make(chan error, 1)
+			res := c.Response()
+			buffer := newBufferedWriter(res)
+			handlerContext := handlerContext(c, req, buffer)
+			done := make(chan handlerResult, 1)
 			go func() {
-				done <- next(c)
+				var result handlerResult
+				defer func() {
+					result.panicked = recover()
+					done <- result
+				}()
+				result.err = next(handlerContext)
 			}()
 
+			var result handlerResult
 			select {
-			case err := <-done:
-				return err
+			case result = <-done:
 			case <-ctx.Done():
-				return echo.NewHTTPError(http.StatusRequestTimeout, "Request timeout")
+				// A canceled request or a streaming response are waited for,
+				// the handler gives up on its own
+				if !errors.Is(ctx.Err(), context.DeadlineExceeded) || !buffer.timeOut() {
+					result = <-done
+					break
+				}
+				body.timeOut()
+				return timeoutError(c, timeout, ctx.Err())
+			}
+			c.SetRequest(handlerContext.Request())
+			if result.panicked != nil {
+				// Panics reach the request goroutine as if there was no
+				// timeout, they would crash the server otherwise
+				panic(result.panicked)
+			}
+
+			err := result.err
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) && buffer.timeOut() {
+				return timeoutError(c, timeout, ctx.Err())
 			}
+			buffer.commit()
+			if err != nil && !res.Committed && timedOut(err) {
+				return echo.NewHTTPError(http.StatusGatewayTimeout, "Upstream timed out").SetInternal(err)
+			}
+			return err
 		}
 	}
 }
+
+type handlerResult struct {
+	err      error
+	panicked any
+}
+
+// handlerContext copies the Echo context of a request for the handler,
+// Echo reuses the context of the request once the middleware returned while
+// the handler may still be running
+func handlerContext(c echo.Context, req *http.Request, w http.ResponseWriter) echo.Context {
+	hc := c.Echo().NewContext(req, w)
+	hc.SetPath(c.Path())
+	hc.SetParamNames(c.ParamNames()...)
+	hc.SetParamValues(c.ParamValues()...)
+	hc.SetHandler(c.Handler())
+	if requestID := GetRequestIDFromEcho(c); requestID != "" {
+		hc.Set(RequestIDKey, requestID)
+	}
+	return hc
+}
+
+// deadlineBody is the request body read by the handler, which may still be
+// running once the response was sent at the deadline
+type deadlineBody struct {
+	mu       sync.Mutex
+	body     io.ReadCloser
+	timedOut bool
+}
+
+func (b *deadlineBody) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	return b.body.Read(p)
+}
+
+func (b *deadlineBody) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.timedOut {
+		return nil
+	}
+	return b.body.Close()
+}
+
+// timeOut waits for a read in progress and fails the next ones. It does
+// nothing on requests without a body.
+func (b *deadlineBody) timeOut() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.timedOut = true
+}
+
+func timeoutError(c echo.Context, timeout time.Duration, err error) error {
+	slog.WarnContext(c.Request().Context(), "Request timed out",
+		slog.String("method", c.Request().Method),
+		slog.String("route", c.Path()),
+		slog.Duration("timeout", timeout),
+	)
+	return echo.NewHTTPError(http.StatusServiceUnavailable, "Request timed out").SetInternal(err)
+}
+
+// timedOut tells whether err comes from a dependency giving up
+func timedOut(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// bufferedWriter holds the response of a handler until it is committed to
+// the response of the request, the headers set before the handler are kept.
+// Once timed out everything the handler writes is discarded.
+type bufferedWriter struct {
+	res    *echo.Response
+	header http.Header
+
+	mu       sync.Mutex
+	status   int
+	body     bytes.Buffer
+	flushed  bool
+	timedOut bool
+}
+
+func newBufferedWriter(res *echo.Response) *bufferedWriter {
+	return &bufferedWriter{res: res, header: res.Header().Clone()}
+}
+
+// Header is only called by the handler, the response of the request is
+// left alone until the buffer is committed
+func (w *bufferedWriter) Header() http.Header {
+	if w.flushed {
+		return w.res.Header()
+	}
+	return w.header
+}
+
+func (w *bufferedWriter) WriteHeader(status int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch {
+	case w.timedOut:
+	case w.flushed:
+		w.res.WriteHeader(status)
+	case w.status == 0:
+		w.status = status
+	}
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.flushed {
+		return w.res.Write(b)
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// Flush commits the response and writes through from now on
+func (w *bufferedWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut {
+		return
+	}
+	w.commitLocked()
+	if flusher, ok := w.res.Writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *bufferedWriter) Unwrap() http.ResponseWriter {
+	return w.res
+}
+
+// timeOut discards the response of the handler, unless it was flushed
+// already. It reports whether the response can be replaced.
+func (w *bufferedWriter) timeOut() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.flushed {
+		return false
+	}
+	w.timedOut = true
+	return true
+}
+
+// commit writes the buffered response to the response of the request
+func (w *bufferedWriter) commit() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.commitLocked()
+}
+
+func (w *bufferedWriter) commitLocked() {
+	if w.flushed || w.timedOut {
+		return
+	}
+	w.flushed = true
+
+	header := w.res.Header()
+	clear(header)
+	maps.Copy(header, w.header)
+	if w.status == 0 {
+		return
+	}
+	w.res.WriteHeader(w.status)
+	if w.body.Len() > 0 {
+		w.res.Write(w.body.Bytes())
+	}
+}
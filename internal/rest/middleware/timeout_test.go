@@ -0,0 +1,251 @@
+package middleware_test
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTimeout = 20 * time.Millisecond
+
+func newTimeoutServer(t *testing.T, middlewares ...echo.MiddlewareFunc) *echo.Echo {
+	t.Helper()
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Response().Header().Set("X-Before", "kept")
+			return next(c)
+		}
+	})
+	e.Use(middlewares...)
+	e.Use(middleware.TimeoutMiddleware(middleware.TimeoutConfig{
+		Default: testTimeout,
+		Routes: []middleware.TimeoutRoute{
+			{Method: http.MethodGet, Path: "/stream", Timeout: 0},
+			{Path: "/slow*", Timeout: time.Second},
+		},
+	}))
+
+	e.GET("/fast", func(c echo.Context) error {
+		c.Response().Header().Set("X-Handler", "set")
+		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+	})
+	// Gives up with the database query, the way the handlers of this
+	// repository report errors
+	e.GET("/query", func(c echo.Context) error {
+		c.Response().Header().Set("X-Handler", "set")
+		<-c.Request().Context().Done()
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": c.Request().Context().Err().Error()})
+	})
+	e.GET("/ignores-context", func(c echo.Context) error {
+		time.Sleep(2 * testTimeout)
+		return c.JSON(http.StatusOK, map[string]string{"status": "late"})
+	})
+	// Keeps going long after the deadline and writes the response late
+	e.GET("/hangs", func(c echo.Context) error {
+		time.Sleep(10 * testTimeout)
+		c.Response().Header().Set("X-Handler", "set")
+		return c.JSON(http.StatusOK, map[string]string{"status": "late"})
+	})
+	e.GET("/panics", func(c echo.Context) error {
+		panic("handler bug")
+	})
+	e.GET("/upstream", func(c echo.Context) error {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), time.Millisecond)
+		defer cancel()
+		<-ctx.Done()
+		return fmt.Errorf("call webhook: %w", ctx.Err())
+	})
+	e.GET("/flush", func(c echo.Context) error {
+		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Write([]byte("first chunk\n"))
+		c.Response().Flush()
+		<-c.Request().Context().Done()
+		c.Response().Write([]byte("second chunk\n"))
+		return nil
+	})
+	e.GET("/stream", func(c echo.Context) error {
+		_, hasDeadline := c.Request().Context().Deadline()
+		return c.String(http.StatusOK, fmt.Sprint(hasDeadline))
+	})
+	e.GET("/slow", func(c echo.Context) error {
+		deadline, _ := c.Request().Context().Deadline()
+		time.Sleep(2 * testTimeout)
+		return c.String(http.StatusOK, time.Until(deadline).Round(time.Second).String())
+	})
+	return e
+}
+
+func get(e *echo.Echo, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestTimeoutMiddleware_InTime(t *testing.T) {
+	rec := get(newTimeoutServer(t), "/fast")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+	assert.Equal(t, "kept", rec.Header().Get("X-Before"))
+	assert.Equal(t, "set", rec.Header().Get("X-Handler"))
+}
+
+func TestTimeoutMiddleware_TimedOut(t *testing.T) {
+	e := newTimeoutServer(t)
+
+	for _, path := range []string{"/query", "/ignores-context"} {
+		t.Run(path, func(t *testing.T) {
+			rec := get(e, path)
+
+			assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+			assert.JSONEq(t, `{"message":"Request timed out"}`, rec.Body.String(),
+				"the response of the handler is replaced")
+			assert.Equal(t, "kept", rec.Header().Get("X-Before"))
+			assert.Empty(t, rec.Header().Get("X-Handler"))
+		})
+	}
+}
+
+// Meant for -race: the response is sent at the deadline while the handler
+// ignoring its context is still running, and its late response is dropped
+func TestTimeoutMiddleware_RespondsAtDeadline(t *testing.T) {
+	server := httptest.NewServer(newTimeoutServer(t))
+	defer server.Close()
+
+	start := time.Now()
+	res, err := http.Get(server.URL + "/hangs")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	elapsed := time.Since(start)
+
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.JSONEq(t, `{"message":"Request timed out"}`, string(body))
+	assert.Empty(t, res.Header.Get("X-Handler"))
+	assert.Less(t, elapsed, 5*testTimeout, "the response does not wait for the handler")
+
+	// Lets the handler write its late response before the server closes
+	time.Sleep(10 * testTimeout)
+}
+
+// Meant for -race: the body captured around the timeout is read once the
+// response was sent, while the handler is still running
+func TestTimeoutMiddleware_BodyAfterDeadline(t *testing.T) {
+	e := echo.New()
+	e.Use(middleware.BodyCaptureMiddleware(middleware.BodyCaptureConfig{
+		Routes: []middleware.BodyCaptureRoute{{Path: "/upload", SampleRate: 1}},
+	}))
+	e.Use(middleware.TimeoutMiddleware(middleware.TimeoutConfig{Default: testTimeout}))
+	readErr := make(chan error, 1)
+	e.POST("/upload", func(c echo.Context) error {
+		time.Sleep(2 * testTimeout)
+		_, err := io.ReadAll(c.Request().Body)
+		readErr <- err
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(`{"title":"late"}`)))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.ErrorIs(t, <-readErr, http.ErrHandlerTimeout, "the body is not read past the deadline")
+}
+
+func TestTimeoutMiddleware_Panic(t *testing.T) {
+	e := newTimeoutServer(t)
+
+	assert.PanicsWithValue(t, "handler bug", func() {
+		get(e, "/panics")
+	}, "the panic of the handler reaches the request goroutine")
+}
+
+func TestTimeoutMiddleware_UpstreamTimedOut(t *testing.T) {
+	rec := get(newTimeoutServer(t), "/upstream")
+
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+	assert.JSONEq(t, `{"message":"Upstream timed out"}`, rec.Body.String())
+}
+
+func TestTimeoutMiddleware_FlushCommits(t *testing.T) {
+	rec := get(newTimeoutServer(t), "/flush")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "first chunk\nsecond chunk\n", rec.Body.String())
+	assert.True(t, rec.Flushed)
+}
+
+func TestTimeoutMiddleware_RouteTimeouts(t *testing.T) {
+	e := newTimeoutServer(t)
+
+	rec := get(e, "/stream")
+	assert.Equal(t, "false", rec.Body.String(), "a zero timeout sets no deadline")
+
+	rec = get(e, "/slow")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1s", rec.Body.String())
+}
+
+func TestTimeoutMiddleware_Gzip(t *testing.T) {
+	e := newTimeoutServer(t, middleware.CompressionMiddleware())
+
+	req := httptest.NewRequest(http.MethodGet, "/fast", nil)
+	req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "gzip", rec.Header().Get(echo.HeaderContentEncoding))
+	reader, err := gzip.NewReader(rec.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status":"ok"}`, string(body))
+
+	req = httptest.NewRequest(http.MethodGet, "/query", nil)
+	req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding), "the error is written after the compression")
+	assert.JSONEq(t, `{"message":"Request timed out"}`, rec.Body.String())
+}
+
+// Meant for -race: the handlers still running at the deadline must not
+// share anything with the response being written
+func TestTimeoutMiddleware_Concurrent(t *testing.T) {
+	server := httptest.NewServer(newTimeoutServer(t))
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		path := []string{"/fast", "/query", "/ignores-context", "/upstream", "/hangs"}[i%5]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := http.Get(server.URL + path)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer res.Body.Close()
+			_, err = io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.NotEqual(t, http.StatusInternalServerError, res.StatusCode, path)
+		}()
+	}
+	wg.Wait()
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
 	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
@@ -19,6 +20,9 @@ type NewsService interface {
 	GetNews(ctx context.Context, id uuid.UUID) (*domain.News, error)
 	UpdateNews(ctx context.Context, id uuid.UUID, news *domain.News) (*domain.News, error)
 	DeleteNews(ctx context.Context, id uuid.UUID) error
+	GetUserNews(ctx context.Context, userID uuid.UUID) ([]domain.News, error)
+	BulkSaveNews(ctx context.Context, req *domain.BulkNewsRequest) (*domain.BulkResult, error)
+	BulkDeleteNews(ctx context.Context, req *domain.BulkDeleteRequest) (*domain.BulkResult, error)
 }
 
 type NewsHandler struct {
@@ -28,12 +32,23 @@ type NewsHandler struct {
 func NewNewsHandler(e *echo.Group, svc NewsService) {
 	handler := &NewsHandler{Service: svc}
 
+	read := middleware.RequireScope(domain.ScopeNewsRead)
+	// Users need the editor role to write news, machine clients the scope
+	write := []echo.MiddlewareFunc{
+		middleware.RequireScope(domain.ScopeNewsWrite),
+		middleware.RequireUserRole(domain.RoleEditor, domain.RoleAdmin),
+	}
+
 	newsGroup := e.Group("/news")
-	newsGroup.GET("", handler.GetNewsList)
-	newsGroup.GET("/:id", handler.GetNews)
-	newsGroup.POST("", handler.CreateNews)
-	newsGroup.PUT("/:id", handler.UpdateNews)
-	newsGroup.DELETE("/:id", handler.DeleteNews)
+	newsGroup.GET("", handler.GetNewsList, read)
+	newsGroup.GET("/:id", handler.GetNews, read)
+	newsGroup.POST("", handler.CreateNews, write...)
+	newsGroup.PUT("/:id", handler.UpdateNews, write...)
+	newsGroup.DELETE("/:id", handler.DeleteNews, write...)
+	newsGroup.POST("/bulk", handler.BulkSaveNews, write...)
+	newsGroup.DELETE("/bulk", handler.BulkDeleteNews, write...)
+
+	e.GET("/users/:id/news", handler.GetUserNews, read)
 }
 
 // GetNews godoc
@@ -263,3 +278,104 @@ func (h *NewsHandler) DeleteNews(c echo.Context) error {
 		Message: "News successfully deleted",
 	})
 }
+
+// GetUserNews godoc
+// @Summary List news of a user
+// @Description get the news written or co-written by a user
+// @Tags news
+// @Produce  json
+// @Param   id   path  string  true  "User ID"
+// @Success 200 {object} domain.ResponseMultipleData[domain.News]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security ApiKeyAuth
+// @Router /users/{id}/news [get]
+func (h *NewsHandler) GetUserNews(c echo.Context) error {
+	ctx := c.Request().Context()
+	idParam := c.Param("id")
+	userID, err := uuid.Parse(idParam)
+	if err != nil {
+		logging.LogWarn(ctx, "Invalid user ID", slog.String("error", err.Error()), slog.String("id", idParam))
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid user ID format",
+		})
+	}
+
+	news, err := h.Service.GetUserNews(ctx, userID)
+	if err != nil {
+		logging.LogError(ctx, err, "get_user_news", slog.String("user_id", userID.String()))
+		return c.JSON(http.StatusInternalServerError, domain.ResponseMultipleData[domain.Empty]{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "Failed to list news: " + err.Error(),
+		})
+	}
+	if news == nil {
+		news = []domain.News{}
+	}
+
+	return c.JSON(http.StatusOK, domain.ResponseMultipleData[domain.News]{
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "News list retrieved successfully",
+		Data:    news,
+	})
+}
+
+// BulkSaveNews godoc
+// @Summary Bulk create or update news
+// @Description create entries without an id and update the others, reporting a result per entry
+// @Tags news
+// @Accept  json
+// @Produce  json
+// @Param   news  body  domain.BulkNewsRequest  true  "Bulk news data"
+// @Success 200 {object} domain.ResponseSingleData[domain.BulkResult]
+// @Success 207 {object} domain.ResponseSingleData[domain.BulkResult]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 422 {object} domain.ResponseSingleData[domain.BulkResult]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security ApiKeyAuth
+// @Router /news/bulk [post]
+func (h *NewsHandler) BulkSaveNews(c echo.Context) error {
+	var req domain.BulkNewsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request payload",
+		})
+	}
+
+	result, err := h.Service.BulkSaveNews(c.Request().Context(), &req)
+	return bulkResponse(c, "bulk_save_news", result, err)
+}
+
+// BulkDeleteNews godoc
+// @Summary Bulk delete news
+// @Description delete every news listed in the request, reporting a result per id
+// @Tags news
+// @Accept  json
+// @Produce  json
+// @Param   ids  body  domain.BulkDeleteRequest  true  "News IDs"
+// @Success 200 {object} domain.ResponseSingleData[domain.BulkResult]
+// @Success 207 {object} domain.ResponseSingleData[domain.BulkResult]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 422 {object} domain.ResponseSingleData[domain.BulkResult]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security ApiKeyAuth
+// @Router /news/bulk [delete]
+func (h *NewsHandler) BulkDeleteNews(c echo.Context) error {
+	var req domain.BulkDeleteRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request payload",
+		})
+	}
+
+	result, err := h.Service.BulkDeleteNews(c.Request().Context(), &req)
+	return bulkResponse(c, "bulk_delete_news", result, err)
+}
@@ -0,0 +1,145 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/labstack/echo/v4"
+)
+
+// oidcStateCookie keeps the signed state of a login between the redirect
+// to the provider and the callback
+const oidcStateCookie = "zog_oidc_state"
+
+type OIDCService interface {
+	StartOIDCLogin(ctx context.Context) (*domain.OIDCAuthorization, error)
+	FinishOIDCLogin(ctx context.Context, req *domain.OIDCCallbackRequest) (*domain.LoginResult, error)
+}
+
+type OIDCHandler struct {
+	Service OIDCService
+}
+
+// NewOIDCHandler registers the routes of the login through the OpenID
+// provider, they are only registered when one is configured.
+func NewOIDCHandler(e *echo.Group, svc OIDCService) {
+	handler := &OIDCHandler{Service: svc}
+
+	oidcGroup := e.Group("/auth/oidc")
+	oidcGroup.GET("/login", handler.Login)
+	oidcGroup.GET("/callback", handler.Callback)
+}
+
+// oidcError answers the errors of the OpenID login
+func oidcError(c echo.Context, err error, operation string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidToken):
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Login expired or was started in another browser, try again",
+		})
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		return c.JSON(http.StatusUnauthorized, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusUnauthorized,
+			Status:  "error",
+			Message: "The identity provider did not confirm the login",
+		})
+	case errors.Is(err, domain.ErrForbidden):
+		return c.JSON(http.StatusForbidden, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusForbidden,
+			Status:  "error",
+			Message: "Your account at the identity provider has no access",
+		})
+	}
+	logging.LogError(c.Request().Context(), err, operation)
+	return c.JSON(http.StatusInternalServerError, domain.ResponseSingleData[domain.Empty]{
+		Code:    http.StatusInternalServerError,
+		Status:  "error",
+		Message: "Login through the identity provider failed",
+	})
+}
+
+// stateCookie scopes the cookie to the OpenID routes. SameSite=Lax still
+// sends it on the top-level redirect back from the provider.
+func stateCookie(c echo.Context, value string, maxAge int) *http.Cookie {
+	path := c.Request().URL.Path
+	return &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		Path:     path[:strings.LastIndex(path, "/")],
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// Login godoc
+// @Summary Log in through the identity provider
+// @Description redirects to the OpenID provider, which sends the user back to /auth/oidc/callback
+// @Tags auth
+// @Success 302
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Router /auth/oidc/login [get]
+func (h *OIDCHandler) Login(c echo.Context) error {
+	authorization, err := h.Service.StartOIDCLogin(c.Request().Context())
+	if err != nil {
+		return oidcError(c, err, "start_oidc_login")
+	}
+
+	c.SetCookie(stateCookie(c, authorization.State, int(time.Until(authorization.ExpiresAt).Seconds())))
+	return c.Redirect(http.StatusFound, authorization.URL)
+}
+
+// Callback godoc
+// @Summary Complete a login through the identity provider
+// @Description the provider redirects here, returns a session token, or a challenge to answer on /auth/login/2fa for users with two-factor authentication
+// @Tags auth
+// @Produce  json
+// @Param   code   query  string  false  "Authorization code"
+// @Param   state  query  string  true   "State of the login"
+// @Param   error  query  string  false  "Error of the provider"
+// @Success 200 {object} domain.ResponseSingleData[domain.LoginResult]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 401 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 403 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Router /auth/oidc/callback [get]
+func (h *OIDCHandler) Callback(c echo.Context) error {
+	var req domain.OIDCCallbackRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request payload",
+		})
+	}
+	req.StateCookie = ""
+	if cookie, err := c.Cookie(oidcStateCookie); err == nil {
+		req.StateCookie = cookie.Value
+	}
+	// The state is single use, whatever the outcome
+	c.SetCookie(stateCookie(c, "", -1))
+
+	result, err := h.Service.FinishOIDCLogin(c.Request().Context(), &req)
+	if err != nil {
+		return oidcError(c, err, "finish_oidc_login")
+	}
+
+	message := "Logged in"
+	if result.Challenge != nil {
+		message = "Enter the code of your authenticator app"
+	}
+	return c.JSON(http.StatusOK, domain.ResponseSingleData[domain.LoginResult]{
+		Data:    *result,
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: message,
+	})
+}
@@ -0,0 +1,227 @@
+package rest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopedRoutes_RequireAuthentication(t *testing.T) {
+	e := echo.New()
+	api := e.Group("/api/v1")
+	rest.NewNewsHandler(api, nil)
+	rest.NewTopicHandler(api, nil)
+	rest.NewUserHandler(api, nil)
+
+	routes := []struct{ method, path string }{
+		{http.MethodPost, "/api/v1/news"},
+		{http.MethodPut, "/api/v1/news/6f1c8a52-7c1e-4b8e-9d43-1a2b3c4d5e6f"},
+		{http.MethodDelete, "/api/v1/news/bulk"},
+		{http.MethodPost, "/api/v1/topics"},
+		{http.MethodPost, "/api/v1/topics/6f1c8a52-7c1e-4b8e-9d43-1a2b3c4d5e6f/merge"},
+		{http.MethodGet, "/api/v1/users"},
+		{http.MethodGet, "/api/v1/users/6f1c8a52-7c1e-4b8e-9d43-1a2b3c4d5e6f"},
+		{http.MethodPost, "/api/v1/users"},
+		{http.MethodPut, "/api/v1/users/6f1c8a52-7c1e-4b8e-9d43-1a2b3c4d5e6f"},
+		{http.MethodDelete, "/api/v1/users/6f1c8a52-7c1e-4b8e-9d43-1a2b3c4d5e6f"},
+	}
+	for _, route := range routes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			// The services are nil, the request must be rejected before them
+			e.ServeHTTP(rec, httptest.NewRequest(route.method, route.path, nil))
+
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		})
+	}
+}
+
+type newsServiceStub struct{ rest.NewsService }
+
+func (newsServiceStub) GetNewsList(context.Context, *domain.NewsFilter) ([]domain.News, error) {
+	return nil, nil
+}
+
+type topicServiceStub struct{ rest.TopicService }
+
+func (topicServiceStub) GetTopicList(context.Context, *domain.TopicFilter) ([]domain.Topic, error) {
+	return nil, nil
+}
+
+func TestReadRoutes_AllowAnonymous(t *testing.T) {
+	e := echo.New()
+	api := e.Group("/api/v1")
+	rest.NewNewsHandler(api, newsServiceStub{})
+	rest.NewTopicHandler(api, topicServiceStub{})
+
+	for _, path := range []string{"/api/v1/news", "/api/v1/topics"} {
+		t.Run(path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+	}
+}
+
+// withPrincipal stands in for the authentication middlewares
+func withPrincipal(principal *auth.Principal) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := auth.WithPrincipal(c.Request().Context(), principal)
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+func TestUserRoutes_RequireAdmin(t *testing.T) {
+	e := echo.New()
+	api := e.Group("/api/v1", withPrincipal(&auth.Principal{
+		UserID: "6f1c8a52-7c1e-4b8e-9d43-1a2b3c4d5e6f", Role: domain.RoleEditor, Method: auth.MethodSession,
+	}))
+	rest.NewUserHandler(api, nil)
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodGet, "/api/v1/users"},
+		{http.MethodPost, "/api/v1/users"},
+		{http.MethodDelete, "/api/v1/users/6f1c8a52-7c1e-4b8e-9d43-1a2b3c4d5e6f"},
+	} {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, httptest.NewRequest(route.method, route.path, nil))
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		})
+	}
+}
+
+func TestNewsWriteRoutes_RequireEditor(t *testing.T) {
+	e := echo.New()
+	api := e.Group("/api/v1", withPrincipal(&auth.Principal{
+		UserID: "6f1c8a52-7c1e-4b8e-9d43-1a2b3c4d5e6f", Role: domain.RoleUser, Method: auth.MethodSession,
+	}))
+	rest.NewNewsHandler(api, nil)
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/api/v1/news"},
+		{http.MethodPut, "/api/v1/news/6f1c8a52-7c1e-4b8e-9d43-1a2b3c4d5e6f"},
+		{http.MethodDelete, "/api/v1/news/6f1c8a52-7c1e-4b8e-9d43-1a2b3c4d5e6f"},
+		{http.MethodPost, "/api/v1/news/bulk"},
+		{http.MethodDelete, "/api/v1/news/bulk"},
+	} {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, httptest.NewRequest(route.method, route.path, nil))
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		})
+	}
+}
+
+func TestTopicWriteRoutes_RequireEditor(t *testing.T) {
+	e := echo.New()
+	api := e.Group("/api/v1", withPrincipal(&auth.Principal{
+		UserID: "6f1c8a52-7c1e-4b8e-9d43-1a2b3c4d5e6f", Role: domain.RoleUser, Method: auth.MethodSession,
+	}))
+	rest.NewTopicHandler(api, nil)
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/api/v1/topics"},
+		{http.MethodPut, "/api/v1/topics/6f1c8a52-7c1e-4b8e-9d43-1a2b3c4d5e6f"},
+		{http.MethodDelete, "/api/v1/topics/6f1c8a52-7c1e-4b8e-9d43-1a2b3c4d5e6f"},
+		{http.MethodPost, "/api/v1/topics/bulk"},
+		{http.MethodPost, "/api/v1/topics/6f1c8a52-7c1e-4b8e-9d43-1a2b3c4d5e6f/merge"},
+	} {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, httptest.NewRequest(route.method, route.path, nil))
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		})
+	}
+}
+
+type authServiceStub struct{ rest.AuthService }
+
+func (authServiceStub) ChangePassword(context.Context, *domain.ChangePasswordRequest) error {
+	return nil
+}
+
+type apiKeyServiceStub struct{ rest.APIKeyService }
+
+func (apiKeyServiceStub) GetAPIKeys(context.Context) ([]domain.APIKey, error) {
+	return nil, nil
+}
+
+func TestAccountRoutes_AllowEditors(t *testing.T) {
+	e := echo.New()
+	api := e.Group("/api/v1", withPrincipal(&auth.Principal{
+		UserID: "6f1c8a52-7c1e-4b8e-9d43-1a2b3c4d5e6f", Role: domain.RoleEditor, Method: auth.MethodSession,
+	}))
+	rest.NewAuthHandler(api, authServiceStub{})
+	rest.NewAPIKeyHandler(api, apiKeyServiceStub{})
+
+	t.Run("change password", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users/me/password",
+			strings.NewReader(`{"current_password":"old secret","new_password":"new secret"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+	t.Run("list API keys", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/api-keys", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
+
+type twoFactorServiceStub struct{ rest.TwoFactorService }
+
+func (twoFactorServiceStub) ResetTwoFactor(context.Context, uuid.UUID) error {
+	return nil
+}
+
+func TestTwoFactorReset_RequiresAdmin(t *testing.T) {
+	path := "/api/v1/users/6f1c8a52-7c1e-4b8e-9d43-1a2b3c4d5e6f/2fa"
+
+	for _, tc := range []struct {
+		role string
+		want int
+	}{
+		{domain.RoleEditor, http.StatusForbidden},
+		{domain.RoleAdmin, http.StatusNoContent},
+	} {
+		t.Run(tc.role, func(t *testing.T) {
+			e := echo.New()
+			api := e.Group("/api/v1", withPrincipal(&auth.Principal{
+				UserID: "0b6c3f7e-2a1d-4c5b-8e9f-1a2b3c4d5e6f", Role: tc.role, Method: auth.MethodSession,
+			}))
+			rest.NewTwoFactorHandler(api, twoFactorServiceStub{})
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, path, nil))
+
+			assert.Equal(t, tc.want, rec.Code)
+		})
+	}
+}
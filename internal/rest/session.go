@@ -0,0 +1,174 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type SessionService interface {
+	GetSessions(ctx context.Context) ([]domain.Session, error)
+	RevokeSession(ctx context.Context, id uuid.UUID) error
+	RevokeAllSessions(ctx context.Context) (*domain.RevokedSessions, error)
+	RevokeUserSessions(ctx context.Context, userID uuid.UUID) (*domain.RevokedSessions, error)
+}
+
+type SessionHandler struct {
+	Service SessionService
+}
+
+// NewSessionHandler registers the session routes. Users manage their own
+// sessions, admins can log any user out everywhere.
+func NewSessionHandler(e *echo.Group, svc SessionService) {
+	handler := &SessionHandler{Service: svc}
+
+	meGroup := e.Group("/users/me/sessions", middleware.RequireUser())
+	meGroup.GET("", handler.GetSessions)
+	meGroup.DELETE("", handler.RevokeAllSessions)
+	meGroup.DELETE("/:id", handler.RevokeSession)
+
+	e.DELETE("/users/:id/sessions", handler.RevokeUserSessions, middleware.RequireRole(domain.RoleAdmin))
+}
+
+// sessionError answers the errors shared by the session endpoints
+func sessionError(c echo.Context, err error, operation string) error {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return c.JSON(http.StatusNotFound, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusNotFound,
+			Status:  "error",
+			Message: err.Error(),
+		})
+	case errors.Is(err, domain.ErrForbidden):
+		return c.JSON(http.StatusForbidden, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusForbidden,
+			Status:  "error",
+			Message: "Only users have sessions",
+		})
+	}
+	logging.LogError(c.Request().Context(), err, operation)
+	return c.JSON(http.StatusInternalServerError, domain.ResponseSingleData[domain.Empty]{
+		Code:    http.StatusInternalServerError,
+		Status:  "error",
+		Message: "Session operation failed",
+	})
+}
+
+// GetSessions godoc
+// @Summary List your sessions
+// @Description lists the live sessions of the caller with their device and last activity, the one of the request is marked as current
+// @Tags sessions
+// @Produce  json
+// @Success 200 {object} domain.ResponseMultipleData[domain.Session]
+// @Failure 401 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 403 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security SessionAuth
+// @Router /users/me/sessions [get]
+func (h *SessionHandler) GetSessions(c echo.Context) error {
+	sessions, err := h.Service.GetSessions(c.Request().Context())
+	if err != nil {
+		return sessionError(c, err, "get_sessions")
+	}
+
+	return c.JSON(http.StatusOK, domain.ResponseMultipleData[domain.Session]{
+		Data:    sessions,
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Successfully retrieved sessions",
+	})
+}
+
+// RevokeSession godoc
+// @Summary Log out one of your sessions
+// @Tags sessions
+// @Produce  json
+// @Param   id  path  string  true  "Session ID"
+// @Success 204
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 401 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 404 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security SessionAuth
+// @Router /users/me/sessions/{id} [delete]
+func (h *SessionHandler) RevokeSession(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid session ID format",
+		})
+	}
+
+	if err := h.Service.RevokeSession(c.Request().Context(), id); err != nil {
+		return sessionError(c, err, "revoke_session")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RevokeAllSessions godoc
+// @Summary Log out everywhere
+// @Description ends every session of the caller, including the one of the request
+// @Tags sessions
+// @Produce  json
+// @Success 200 {object} domain.ResponseSingleData[domain.RevokedSessions]
+// @Failure 401 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 403 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security SessionAuth
+// @Router /users/me/sessions [delete]
+func (h *SessionHandler) RevokeAllSessions(c echo.Context) error {
+	revoked, err := h.Service.RevokeAllSessions(c.Request().Context())
+	if err != nil {
+		return sessionError(c, err, "revoke_all_sessions")
+	}
+
+	return c.JSON(http.StatusOK, domain.ResponseSingleData[domain.RevokedSessions]{
+		Data:    *revoked,
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Logged out everywhere",
+	})
+}
+
+// RevokeUserSessions godoc
+// @Summary Log a user out everywhere
+// @Tags sessions
+// @Produce  json
+// @Param   id  path  string  true  "User ID"
+// @Success 200 {object} domain.ResponseSingleData[domain.RevokedSessions]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 401 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 403 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 404 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security BasicAuth
+// @Router /users/{id}/sessions [delete]
+func (h *SessionHandler) RevokeUserSessions(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid user ID format",
+		})
+	}
+
+	revoked, err := h.Service.RevokeUserSessions(c.Request().Context(), id)
+	if err != nil {
+		return sessionError(c, err, "revoke_user_sessions")
+	}
+	return c.JSON(http.StatusOK, domain.ResponseSingleData[domain.RevokedSessions]{
+		Data:    *revoked,
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "User logged out everywhere",
+	})
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
 	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
@@ -19,6 +20,8 @@ type TopicService interface {
 	GetTopic(ctx context.Context, id uuid.UUID) (*domain.Topic, error)
 	UpdateTopic(ctx context.Context, id uuid.UUID, topic *domain.Topic) (*domain.Topic, error)
 	DeleteTopic(ctx context.Context, id uuid.UUID) error
+	BulkSaveTopics(ctx context.Context, req *domain.BulkTopicRequest) (*domain.BulkResult, error)
+	MergeTopics(ctx context.Context, sourceID uuid.UUID, req *domain.MergeTopicRequest) (int64, error)
 }
 
 type TopicHandler struct {
@@ -28,12 +31,21 @@ type TopicHandler struct {
 func NewTopicHandler(e *echo.Group, svc TopicService) {
 	handler := &TopicHandler{Service: svc}
 
+	read := middleware.RequireScope(domain.ScopeTopicsRead)
+	// Users need the editor role to write topics, machine clients the scope
+	write := []echo.MiddlewareFunc{
+		middleware.RequireScope(domain.ScopeTopicsWrite),
+		middleware.RequireUserRole(domain.RoleEditor, domain.RoleAdmin),
+	}
+
 	topicGroup := e.Group("/topics")
-	topicGroup.GET("", handler.GetTopicList)
-	topicGroup.GET("/:id", handler.GetTopic)
-	topicGroup.POST("", handler.CreateTopic)
-	topicGroup.PUT("/:id", handler.UpdateTopic)
-	topicGroup.DELETE("/:id", handler.DeleteTopic)
+	topicGroup.GET("", handler.GetTopicList, read)
+	topicGroup.GET("/:id", handler.GetTopic, read)
+	topicGroup.POST("", handler.CreateTopic, write...)
+	topicGroup.PUT("/:id", handler.UpdateTopic, write...)
+	topicGroup.DELETE("/:id", handler.DeleteTopic, write...)
+	topicGroup.POST("/bulk", handler.BulkSaveTopics, write...)
+	topicGroup.POST("/:id/merge", handler.MergeTopics, write...)
 }
 
 // GetTopik godoc
@@ -86,15 +98,10 @@ func (h *TopicHandler) GetTopicList(c echo.Context) error {
 // @Router /topics/{id} [get]
 func (h *TopicHandler) GetTopic(c echo.Context) error {
 	ctx := c.Request().Context()
-	// tracer := otel.Tracer("http.handler.Topic")
-	// ctx, span := tracer.Start(c.Request().Context(), "GetTopicHandler")
-	// defer span.End()
 
 	idParam := c.Param("id")
 	id, err := uuid.Parse(idParam)
 	if err != nil {
-		//	span.RecordError(err)
-		//	span.SetStatus(codes.Error, "invalid UUID")
 		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
 			Code:    http.StatusBadRequest,
 			Status:  "error",
@@ -102,12 +109,9 @@ func (h *TopicHandler) GetTopic(c echo.Context) error {
 		})
 	}
 
-	//span.SetAttributes(attribute.String("Topic.id", id.String()))
 	topic, err := h.Service.GetTopic(ctx, id)
 	if err != nil {
-		//	span.RecordError(err)
 		if errors.Is(err, sql.ErrNoRows) {
-			//span.SetStatus(codes.Error, "not found")
 			return c.JSON(http.StatusNotFound, domain.ResponseSingleData[domain.Empty]{
 				Code:    http.StatusNotFound,
 				Status:  "error",
@@ -115,7 +119,6 @@ func (h *TopicHandler) GetTopic(c echo.Context) error {
 			})
 		}
 
-		//span.SetStatus(codes.Error, "service error")
 		logging.LogError(ctx, err, "get_topic")
 		return c.JSON(http.StatusInternalServerError, domain.ResponseSingleData[domain.Empty]{
 			Code:    http.StatusInternalServerError,
@@ -263,3 +266,101 @@ func (h *TopicHandler) DeleteTopic(c echo.Context) error {
 		Message: "Topic successfully deleted",
 	})
 }
+
+// BulkSaveTopics godoc
+// @Summary Bulk create or update topik
+// @Description create entries without an id and update the others, reporting a result per entry
+// @Tags topik
+// @Accept  json
+// @Produce  json
+// @Param   topics  body  domain.BulkTopicRequest  true  "Bulk topic data"
+// @Success 200 {object} domain.ResponseSingleData[domain.BulkResult]
+// @Success 207 {object} domain.ResponseSingleData[domain.BulkResult]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 422 {object} domain.ResponseSingleData[domain.BulkResult]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security ApiKeyAuth
+// @Router /topics/bulk [post]
+func (h *TopicHandler) BulkSaveTopics(c echo.Context) error {
+	var req domain.BulkTopicRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request payload",
+		})
+	}
+
+	result, err := h.Service.BulkSaveTopics(c.Request().Context(), &req)
+	return bulkResponse(c, "bulk_save_topics", result, err)
+}
+
+// MergeTopics godoc
+// @Summary Merge a topic into another
+// @Description move every news of the topic to the target topic and delete the topic
+// @Tags topik
+// @Accept  json
+// @Produce  json
+// @Param   id     path  string                    true  "Topic ID to merge away"
+// @Param   merge  body  domain.MergeTopicRequest  true  "Target topic"
+// @Success 200 {object} domain.ResponseSingleData[domain.TopicMergedPayload]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 404 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security ApiKeyAuth
+// @Router /topics/{id}/merge [post]
+func (h *TopicHandler) MergeTopics(c echo.Context) error {
+	sourceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid topic ID format",
+		})
+	}
+
+	var req domain.MergeTopicRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request payload",
+		})
+	}
+
+	ctx := c.Request().Context()
+	moved, err := h.Service.MergeTopics(ctx, sourceID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrBadParamInput):
+			return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+				Code:    http.StatusBadRequest,
+				Status:  "error",
+				Message: err.Error(),
+			})
+		case errors.Is(err, domain.ErrNotFound):
+			return c.JSON(http.StatusNotFound, domain.ResponseSingleData[domain.Empty]{
+				Code:    http.StatusNotFound,
+				Status:  "error",
+				Message: "Topic not found",
+			})
+		}
+		logging.LogError(ctx, err, "merge_topics")
+		return c.JSON(http.StatusInternalServerError, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: "Failed to merge topic: " + err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, domain.ResponseSingleData[domain.TopicMergedPayload]{
+		Data: domain.TopicMergedPayload{
+			SourceID:  sourceID.String(),
+			TargetID:  req.TargetID,
+			MovedNews: moved,
+		},
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Topic successfully merged",
+	})
+}
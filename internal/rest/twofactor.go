@@ -0,0 +1,236 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type TwoFactorService interface {
+	SetupTwoFactor(ctx context.Context) (*domain.TwoFactorSetup, error)
+	VerifyTwoFactor(ctx context.Context, req *domain.VerifyTwoFactorRequest) (*domain.RecoveryCodes, error)
+	GetTwoFactorPolicies(ctx context.Context) ([]domain.TwoFactorPolicy, error)
+	SetTwoFactorPolicy(ctx context.Context, role string, req *domain.UpdateTwoFactorPolicyRequest) (*domain.TwoFactorPolicy, error)
+	ResetTwoFactor(ctx context.Context, id uuid.UUID) error
+}
+
+type TwoFactorHandler struct {
+	Service TwoFactorService
+}
+
+// NewTwoFactorHandler registers the two-factor routes. Users enroll
+// themselves, even when their role requires two-factor authentication they
+// did not set up yet, and admins decide which roles require it and reset the
+// second factor of users who lost it.
+func NewTwoFactorHandler(e *echo.Group, svc TwoFactorService) {
+	handler := &TwoFactorHandler{Service: svc}
+
+	meGroup := e.Group("/users/me/2fa", middleware.RequireUser())
+	meGroup.POST("/setup", handler.SetupTwoFactor)
+	meGroup.POST("/verify", handler.VerifyTwoFactor)
+
+	policyGroup := e.Group("/two-factor/policies", middleware.RequireRole(domain.RoleAdmin))
+	policyGroup.GET("", handler.GetTwoFactorPolicies)
+	policyGroup.PUT("/:role", handler.SetTwoFactorPolicy)
+
+	e.DELETE("/users/:id/2fa", handler.ResetTwoFactor, middleware.RequireRole(domain.RoleAdmin))
+}
+
+// twoFactorError answers the errors shared by the two-factor endpoints
+func twoFactorError(c echo.Context, err error, operation string) error {
+	switch {
+	case errors.Is(err, domain.ErrBadParamInput):
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: err.Error(),
+		})
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid code",
+		})
+	case errors.Is(err, domain.ErrConflict):
+		return c.JSON(http.StatusConflict, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusConflict,
+			Status:  "error",
+			Message: "Two-factor authentication is already enabled",
+		})
+	case errors.Is(err, domain.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusNotFound,
+			Status:  "error",
+			Message: "User not found",
+		})
+	case errors.Is(err, domain.ErrForbidden):
+		return c.JSON(http.StatusForbidden, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusForbidden,
+			Status:  "error",
+			Message: "Only users can set up two-factor authentication",
+		})
+	}
+	logging.LogError(c.Request().Context(), err, operation)
+	return c.JSON(http.StatusInternalServerError, domain.ResponseSingleData[domain.Empty]{
+		Code:    http.StatusInternalServerError,
+		Status:  "error",
+		Message: "Two-factor operation failed",
+	})
+}
+
+// SetupTwoFactor godoc
+// @Summary Start enrolling an authenticator app
+// @Description returns a new TOTP secret and its otpauth URI, confirm it on /users/me/2fa/verify
+// @Tags two-factor
+// @Produce  json
+// @Success 200 {object} domain.ResponseSingleData[domain.TwoFactorSetup]
+// @Failure 401 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 403 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 409 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security BasicAuth
+// @Router /users/me/2fa/setup [post]
+func (h *TwoFactorHandler) SetupTwoFactor(c echo.Context) error {
+	setup, err := h.Service.SetupTwoFactor(c.Request().Context())
+	if err != nil {
+		return twoFactorError(c, err, "setup_two_factor")
+	}
+
+	return c.JSON(http.StatusOK, domain.ResponseSingleData[domain.TwoFactorSetup]{
+		Data:    *setup,
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Scan the URI with your authenticator app, then verify a code",
+	})
+}
+
+// VerifyTwoFactor godoc
+// @Summary Enable two-factor authentication
+// @Description confirms the enrolled secret with a code and returns the recovery codes, they are only shown once
+// @Tags two-factor
+// @Accept  json
+// @Produce  json
+// @Param   code  body  domain.VerifyTwoFactorRequest  true  "Code of the authenticator app"
+// @Success 200 {object} domain.ResponseSingleData[domain.RecoveryCodes]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 401 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 409 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security BasicAuth
+// @Router /users/me/2fa/verify [post]
+func (h *TwoFactorHandler) VerifyTwoFactor(c echo.Context) error {
+	var req domain.VerifyTwoFactorRequest
+	if err := c.Bind(&req); err != nil || req.Code == "" {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request payload",
+		})
+	}
+
+	codes, err := h.Service.VerifyTwoFactor(c.Request().Context(), &req)
+	if err != nil {
+		return twoFactorError(c, err, "verify_two_factor")
+	}
+	return c.JSON(http.StatusOK, domain.ResponseSingleData[domain.RecoveryCodes]{
+		Data:    *codes,
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Two-factor authentication enabled, store the recovery codes safely",
+	})
+}
+
+// GetTwoFactorPolicies godoc
+// @Summary List which roles require two-factor authentication
+// @Tags two-factor
+// @Produce  json
+// @Success 200 {object} domain.ResponseMultipleData[domain.TwoFactorPolicy]
+// @Failure 401 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 403 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security BasicAuth
+// @Router /two-factor/policies [get]
+func (h *TwoFactorHandler) GetTwoFactorPolicies(c echo.Context) error {
+	policies, err := h.Service.GetTwoFactorPolicies(c.Request().Context())
+	if err != nil {
+		return twoFactorError(c, err, "get_two_factor_policies")
+	}
+
+	return c.JSON(http.StatusOK, domain.ResponseMultipleData[domain.TwoFactorPolicy]{
+		Data:    policies,
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Successfully retrieved two-factor policies",
+	})
+}
+
+// SetTwoFactorPolicy godoc
+// @Summary Require two-factor authentication for a role
+// @Tags two-factor
+// @Accept  json
+// @Produce  json
+// @Param   role    path  string                               true  "Role"
+// @Param   policy  body  domain.UpdateTwoFactorPolicyRequest  true  "Whether the role requires two-factor authentication"
+// @Success 200 {object} domain.ResponseSingleData[domain.TwoFactorPolicy]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 401 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 403 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security BasicAuth
+// @Router /two-factor/policies/{role} [put]
+func (h *TwoFactorHandler) SetTwoFactorPolicy(c echo.Context) error {
+	var req domain.UpdateTwoFactorPolicyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request payload",
+		})
+	}
+
+	policy, err := h.Service.SetTwoFactorPolicy(c.Request().Context(), c.Param("role"), &req)
+	if err != nil {
+		return twoFactorError(c, err, "set_two_factor_policy")
+	}
+	return c.JSON(http.StatusOK, domain.ResponseSingleData[domain.TwoFactorPolicy]{
+		Data:    *policy,
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Two-factor policy updated",
+	})
+}
+
+// ResetTwoFactor godoc
+// @Summary Turn off two-factor authentication of a user
+// @Description for users who lost their authenticator app and recovery codes, they log in with their password and set it up again
+// @Tags two-factor
+// @Param   id  path  string  true  "User ID"
+// @Success 204
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 401 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 403 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 404 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security BasicAuth
+// @Router /users/{id}/2fa [delete]
+func (h *TwoFactorHandler) ResetTwoFactor(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid user ID format",
+		})
+	}
+
+	if err := h.Service.ResetTwoFactor(c.Request().Context(), id); err != nil {
+		return twoFactorError(c, err, "reset_two_factor")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
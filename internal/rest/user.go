@@ -9,12 +9,9 @@ import (
 
 	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
 	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
-
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/codes"
 )
 
 type UserService interface {
@@ -29,15 +26,46 @@ type UserHandler struct {
 	Service UserService
 }
 
+// NewUserHandler registers the user routes. Listing, creating and deleting
+// users is up to admins, a user can read and update themselves and admins
+// anyone, which the service checks.
 func NewUserHandler(e *echo.Group, svc UserService) {
 	handler := &UserHandler{Service: svc}
 
+	admin := middleware.RequireRole(domain.RoleAdmin)
+	user := middleware.RequireUser()
+
 	userGroup := e.Group("/users")
-	userGroup.GET("", handler.GetUserList)
-	userGroup.GET("/:id", handler.GetUser)
-	userGroup.POST("", handler.CreateUser)
-	userGroup.PUT("/:id", handler.UpdateUser)
-	userGroup.DELETE("/:id", handler.DeleteUser)
+	userGroup.GET("", handler.GetUserList, admin)
+	userGroup.GET("/:id", handler.GetUser, user)
+	userGroup.POST("", handler.CreateUser, admin)
+	userGroup.PUT("/:id", handler.UpdateUser, user)
+	userGroup.DELETE("/:id", handler.DeleteUser, admin)
+}
+
+// userError answers the errors shared by the user endpoints, the others
+// with failure and the error
+func userError(c echo.Context, err error, operation, failure string) error {
+	switch {
+	case errors.Is(err, domain.ErrForbidden):
+		return c.JSON(http.StatusForbidden, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusForbidden,
+			Status:  "error",
+			Message: "You can only access your own account",
+		})
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, domain.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusNotFound,
+			Status:  "error",
+			Message: "User not found",
+		})
+	}
+	logging.LogError(c.Request().Context(), err, operation)
+	return c.JSON(http.StatusInternalServerError, domain.ResponseSingleData[domain.Empty]{
+		Code:    http.StatusInternalServerError,
+		Status:  "error",
+		Message: failure + err.Error(),
+	})
 }
 
 func (h *UserHandler) GetUserList(c echo.Context) error {
@@ -71,15 +99,10 @@ func (h *UserHandler) GetUserList(c echo.Context) error {
 
 func (h *UserHandler) GetUser(c echo.Context) error {
 	ctx := c.Request().Context()
-	tracer := otel.Tracer("http.handler.user")
-	ctx, span := tracer.Start(c.Request().Context(), "GetUserHandler")
-	defer span.End()
 
 	idParam := c.Param("id")
 	id, err := uuid.Parse(idParam)
 	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "invalid UUID")
 		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
 			Code:    http.StatusBadRequest,
 			Status:  "error",
@@ -87,26 +110,9 @@ func (h *UserHandler) GetUser(c echo.Context) error {
 		})
 	}
 
-	span.SetAttributes(attribute.String("user.id", id.String()))
 	user, err := h.Service.GetUser(ctx, id)
 	if err != nil {
-		span.RecordError(err)
-		if errors.Is(err, sql.ErrNoRows) {
-			span.SetStatus(codes.Error, "not found")
-			return c.JSON(http.StatusNotFound, domain.ResponseSingleData[domain.Empty]{
-				Code:    http.StatusNotFound,
-				Status:  "error",
-				Message: "User not found",
-			})
-		}
-
-		span.SetStatus(codes.Error, "service error")
-		logging.LogError(ctx, err, "get_user")
-		return c.JSON(http.StatusInternalServerError, domain.ResponseSingleData[domain.Empty]{
-			Code:    http.StatusInternalServerError,
-			Status:  "error",
-			Message: "Failed to get user: " + err.Error(),
-		})
+		return userError(c, err, "get_user", "Failed to get user: ")
 	}
 
 	return c.JSON(http.StatusOK, domain.ResponseSingleData[domain.User]{
@@ -130,6 +136,13 @@ func (h *UserHandler) CreateUser(c echo.Context) error {
 	ctx := c.Request().Context()
 	createdUser, err := h.Service.CreateUser(ctx, &user)
 	if err != nil {
+		if errors.Is(err, domain.ErrBadParamInput) {
+			return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+				Code:    http.StatusBadRequest,
+				Status:  "error",
+				Message: err.Error(),
+			})
+		}
 		logging.LogError(ctx, err, "create_user")
 		return c.JSON(http.StatusInternalServerError, domain.ResponseSingleData[domain.Empty]{
 			Code:    http.StatusInternalServerError,
@@ -169,12 +182,7 @@ func (h *UserHandler) UpdateUser(c echo.Context) error {
 	ctx := c.Request().Context()
 	updatedUser, err := h.Service.UpdateUser(ctx, id, &user)
 	if err != nil {
-		logging.LogError(ctx, err, "update_user")
-		return c.JSON(http.StatusInternalServerError, domain.ResponseSingleData[domain.Empty]{
-			Code:    http.StatusInternalServerError,
-			Status:  "error",
-			Message: "Failed to update user: " + err.Error(),
-		})
+		return userError(c, err, "update_user", "Failed to update user: ")
 	}
 
 	return c.JSON(http.StatusOK, domain.ResponseSingleData[domain.User]{
@@ -198,12 +206,7 @@ func (h *UserHandler) DeleteUser(c echo.Context) error {
 
 	ctx := c.Request().Context()
 	if err := h.Service.DeleteUser(ctx, id); err != nil {
-		logging.LogError(ctx, err, "delete_user")
-		return c.JSON(http.StatusInternalServerError, domain.ResponseSingleData[domain.Empty]{
-			Code:    http.StatusInternalServerError,
-			Status:  "error",
-			Message: "Failed to delete user: " + err.Error(),
-		})
+		return userError(c, err, "delete_user", "Failed to delete user: ")
 	}
 
 	return c.JSON(http.StatusNoContent, domain.ResponseSingleData[domain.Empty]{
@@ -6,9 +6,12 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 
 	"github.com/edwinjordan/ZOGTest-Golang.git/database"
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
 	//"github.com/edwinjordan/ZOGTest-Golang.git/internal/metrics"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -51,12 +54,20 @@ func NewTestKit(t *testing.T) *TestKit {
 	// 1) ensure DATABASE_URL is loaded
 	loadEnv(t)
 
-	// 2) new Echo instance
+	// 2) new Echo instance, the requests are made by an admin since the
+	// routes require an authenticated caller
 	e := echo.New()
 	e.HideBanner = true
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := auth.WithPrincipal(c.Request().Context(), &auth.Principal{Role: domain.RoleAdmin, Method: auth.MethodBasic})
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	})
 
 	// 3) setup Postgres pool
-	dbPool, err := database.SetupPgxPool()
+	dbPool, err := database.SetupPgxPool(database.PoolConfig{URL: os.Getenv("DATABASE_URL")})
 	require.NoError(t, err, "failed to connect to Postgres via DATABASE_URL")
 
 	// 4) metrics collector
@@ -0,0 +1,245 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, req *domain.CreateWebhookRequest) (*domain.Webhook, error)
+	GetWebhookList(ctx context.Context) ([]domain.Webhook, error)
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+	GetWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, filter *domain.WebhookDeliveryFilter) ([]domain.WebhookDelivery, int, error)
+	Redeliver(ctx context.Context, webhookID, deliveryID uuid.UUID) (*domain.WebhookDelivery, error)
+}
+
+type WebhookHandler struct {
+	Service WebhookService
+}
+
+// NewWebhookHandler registers the webhook routes, they are restricted to admins.
+func NewWebhookHandler(e *echo.Group, svc WebhookService) {
+	handler := &WebhookHandler{Service: svc}
+
+	webhookGroup := e.Group("/webhooks", middleware.RequireRole(domain.RoleAdmin))
+	webhookGroup.GET("", handler.GetWebhookList)
+	webhookGroup.POST("", handler.CreateWebhook)
+	webhookGroup.DELETE("/:id", handler.DeleteWebhook)
+	webhookGroup.GET("/:id/deliveries", handler.GetWebhookDeliveries)
+	webhookGroup.POST("/:id/deliveries/:delivery_id/redeliver", handler.Redeliver)
+}
+
+// webhookError answers the errors shared by the webhook endpoints
+func webhookError(c echo.Context, err error, operation string) error {
+	switch {
+	case errors.Is(err, domain.ErrBadParamInput):
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: err.Error(),
+		})
+	case errors.Is(err, domain.ErrNotFound):
+		return c.JSON(http.StatusNotFound, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusNotFound,
+			Status:  "error",
+			Message: "Webhook not found",
+		})
+	}
+	logging.LogError(c.Request().Context(), err, operation)
+	return c.JSON(http.StatusInternalServerError, domain.ResponseSingleData[domain.Empty]{
+		Code:    http.StatusInternalServerError,
+		Status:  "error",
+		Message: "Webhook operation failed: " + err.Error(),
+	})
+}
+
+// CreateWebhook godoc
+// @Summary Create a webhook
+// @Description subscribe a URL to domain events, deliveries are signed with the returned secret
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param   webhook  body  domain.CreateWebhookRequest  true  "Webhook data"
+// @Success 201 {object} domain.ResponseSingleData[domain.Webhook]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 401 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 403 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security BasicAuth
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c echo.Context) error {
+	var req domain.CreateWebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid request payload",
+		})
+	}
+
+	webhook, err := h.Service.CreateWebhook(c.Request().Context(), &req)
+	if err != nil {
+		return webhookError(c, err, "create_webhook")
+	}
+
+	return c.JSON(http.StatusCreated, domain.ResponseSingleData[domain.Webhook]{
+		Data:    *webhook,
+		Code:    http.StatusCreated,
+		Status:  "success",
+		Message: "Webhook successfully created",
+	})
+}
+
+// GetWebhookList godoc
+// @Summary List webhooks
+// @Tags webhooks
+// @Produce  json
+// @Success 200 {object} domain.ResponseMultipleData[domain.Webhook]
+// @Failure 401 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 403 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security BasicAuth
+// @Router /webhooks [get]
+func (h *WebhookHandler) GetWebhookList(c echo.Context) error {
+	webhooks, err := h.Service.GetWebhookList(c.Request().Context())
+	if err != nil {
+		return webhookError(c, err, "get_webhook_list")
+	}
+
+	return c.JSON(http.StatusOK, domain.ResponseMultipleData[domain.Webhook]{
+		Data:    webhooks,
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Successfully retrieve webhook list",
+	})
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Tags webhooks
+// @Param   id  path  string  true  "Webhook ID"
+// @Success 204
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 404 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security BasicAuth
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid webhook ID format",
+		})
+	}
+
+	if err := h.Service.DeleteWebhook(c.Request().Context(), id); err != nil {
+		return webhookError(c, err, "delete_webhook")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetWebhookDeliveries godoc
+// @Summary List the deliveries of a webhook
+// @Description delivery history, newest first
+// @Tags webhooks
+// @Produce  json
+// @Param   id        path   string  true   "Webhook ID"
+// @Param   status    query  string  false  "Delivery status (pending, succeeded, dead)"
+// @Param   page      query  int     false  "Page number, starting at 1"
+// @Param   per_page  query  int     false  "Deliveries per page, at most 200"
+// @Success 200 {object} domain.ResponsePaginatedData[domain.WebhookDelivery]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 404 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security BasicAuth
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetWebhookDeliveries(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid webhook ID format",
+		})
+	}
+
+	filter := new(domain.WebhookDeliveryFilter)
+	if err := c.Bind(filter); err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid delivery filter",
+		})
+	}
+
+	deliveries, total, err := h.Service.GetWebhookDeliveries(c.Request().Context(), id, filter)
+	if err != nil {
+		return webhookError(c, err, "get_webhook_deliveries")
+	}
+
+	return c.JSON(http.StatusOK, domain.ResponsePaginatedData[domain.WebhookDelivery]{
+		Data: deliveries,
+		Meta: domain.Pagination{
+			Page:    filter.Page,
+			PerPage: filter.PerPage,
+			Total:   total,
+		},
+		Code:    http.StatusOK,
+		Status:  "success",
+		Message: "Successfully retrieve webhook deliveries",
+	})
+}
+
+// Redeliver godoc
+// @Summary Redeliver a webhook delivery
+// @Description queue the delivery again with a fresh attempt budget, dead lettered deliveries included
+// @Tags webhooks
+// @Produce  json
+// @Param   id           path  string  true  "Webhook ID"
+// @Param   delivery_id  path  string  true  "Delivery ID"
+// @Success 202 {object} domain.ResponseSingleData[domain.WebhookDelivery]
+// @Failure 400 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 404 {object} domain.ResponseSingleData[domain.Empty]
+// @Failure 500 {object} domain.ResponseSingleData[domain.Empty]
+// @Security BasicAuth
+// @Router /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (h *WebhookHandler) Redeliver(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid webhook ID format",
+		})
+	}
+	deliveryID, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, domain.ResponseSingleData[domain.Empty]{
+			Code:    http.StatusBadRequest,
+			Status:  "error",
+			Message: "Invalid delivery ID format",
+		})
+	}
+
+	delivery, err := h.Service.Redeliver(c.Request().Context(), id, deliveryID)
+	if err != nil {
+		return webhookError(c, err, "redeliver_webhook")
+	}
+
+	return c.JSON(http.StatusAccepted, domain.ResponseSingleData[domain.WebhookDelivery]{
+		Data:    *delivery,
+		Code:    http.StatusAccepted,
+		Status:  "success",
+		Message: "Delivery queued again",
+	})
+}
@@ -0,0 +1,55 @@
+package totp_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret is the SHA1 test key of RFC 6238, "12345678901234567890" in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// The RFC lists 8 digit codes, these are their last 6 digits
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range vectors {
+		code, err := totp.Code(rfcSecret, totp.Step(time.Unix(unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, want, code, "time %d", unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	previous, err := totp.Code(rfcSecret, totp.Step(now)-1)
+	assert.NoError(t, err)
+
+	t.Run("Accepts codes within the skew", func(t *testing.T) {
+		step, ok := totp.Validate(rfcSecret, previous, now, 1)
+		assert.True(t, ok)
+		assert.Equal(t, totp.Step(now)-1, step)
+	})
+
+	t.Run("Rejects codes outside the skew", func(t *testing.T) {
+		_, ok := totp.Validate(rfcSecret, previous, now, 0)
+		assert.False(t, ok)
+	})
+
+	t.Run("Rejects malformed codes", func(t *testing.T) {
+		_, ok := totp.Validate(rfcSecret, "12345", now, 1)
+		assert.False(t, ok)
+	})
+}
+
+func TestURI(t *testing.T) {
+	uri := totp.URI("ZOG News", "jane@example.com", rfcSecret)
+
+	assert.Equal(t, "otpauth://totp/ZOG%20News:jane@example.com?algorithm=SHA1&digits=6&issuer=ZOG+News&period=30&secret="+rfcSecret, uri)
+}
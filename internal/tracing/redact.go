@@ -0,0 +1,117 @@
+package tracing
+
+import (
+	"slices"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/redact"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+type redactingProcessor struct {
+	sdktrace.SpanProcessor
+	rules *redact.Rules
+}
+
+// NewRedactingProcessor redacts the attributes of the spans, and of their
+// events, before handing them to next: the ones whose keys are sensitive,
+// and the emails, tokens and passwords found in the strings of the others.
+// The error message of the span status is redacted the same way.
+func NewRedactingProcessor(next sdktrace.SpanProcessor, rules *redact.Rules) sdktrace.SpanProcessor {
+	return &redactingProcessor{SpanProcessor: next, rules: rules}
+}
+
+func (p *redactingProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	p.SpanProcessor.OnEnd(p.redact(s))
+}
+
+// redactedSpan overrides the attributes and status of a span which had some
+// redacted
+type redactedSpan struct {
+	sdktrace.ReadOnlySpan
+	attributes []attribute.KeyValue
+	events     []sdktrace.Event
+	status     sdktrace.Status
+}
+
+func (s *redactedSpan) Attributes() []attribute.KeyValue { return s.attributes }
+
+func (s *redactedSpan) Events() []sdktrace.Event { return s.events }
+
+func (s *redactedSpan) Status() sdktrace.Status { return s.status }
+
+// redact returns s itself when it has nothing to redact, which is the case
+// of most spans
+func (p *redactingProcessor) redact(s sdktrace.ReadOnlySpan) sdktrace.ReadOnlySpan {
+	attributes, redacted := p.redactAttributes(s.Attributes())
+	events := s.Events()
+	eventsCloned := false
+	for i, event := range events {
+		eventAttributes, eventRedacted := p.redactAttributes(event.Attributes)
+		if !eventRedacted {
+			continue
+		}
+		if !eventsCloned {
+			events = slices.Clone(events)
+			eventsCloned = true
+		}
+		events[i].Attributes = eventAttributes
+		redacted = true
+	}
+	status := s.Status()
+	if description, ok := p.rules.String(status.Description); ok {
+		status.Description = description
+		redacted = true
+	}
+	if !redacted {
+		return s
+	}
+	return &redactedSpan{ReadOnlySpan: s, attributes: attributes, events: events, status: status}
+}
+
+// redactAttributes returns a copy of attributes with the sensitive values
+// redacted, or attributes and false when there are none
+func (p *redactingProcessor) redactAttributes(attributes []attribute.KeyValue) ([]attribute.KeyValue, bool) {
+	var redacted []attribute.KeyValue
+	for i, kv := range attributes {
+		value, ok := p.redactValue(kv)
+		if !ok {
+			continue
+		}
+		if redacted == nil {
+			redacted = slices.Clone(attributes)
+		}
+		redacted[i] = attribute.KeyValue{Key: kv.Key, Value: value}
+	}
+	if redacted == nil {
+		return attributes, false
+	}
+	return redacted, true
+}
+
+// redactValue returns the redacted value of kv, and false when nothing is
+// redacted
+func (p *redactingProcessor) redactValue(kv attribute.KeyValue) (attribute.Value, bool) {
+	if p.rules.Key(string(kv.Key)) {
+		return attribute.StringValue(redact.Redacted), true
+	}
+	switch kv.Value.Type() {
+	case attribute.STRING:
+		if s, ok := p.rules.String(kv.Value.AsString()); ok {
+			return attribute.StringValue(s), true
+		}
+	case attribute.STRINGSLICE:
+		values := kv.Value.AsStringSlice()
+		redacted := false
+		for i, v := range values {
+			if s, ok := p.rules.String(v); ok {
+				values[i] = s
+				redacted = true
+			}
+		}
+		if redacted {
+			return attribute.StringSliceValue(values), true
+		}
+	}
+	return kv.Value, false
+}
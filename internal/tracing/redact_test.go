@@ -0,0 +1,82 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/redact"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRedactingProcessor(t *testing.T) {
+	rules, err := redact.New(append(redact.DefaultKeys, "phone"), redact.DefaultPatterns)
+	require.NoError(t, err)
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(tracing.NewRedactingProcessor(
+		sdktrace.NewSimpleSpanProcessor(exporter), rules,
+	)))
+
+	_, span := provider.Tracer("test").Start(context.Background(), "POST /api/v1/auth/login")
+	span.SetAttributes(
+		attribute.String("http.request.method", "POST"),
+		attribute.String("user.email", "alice@example.com"),
+		attribute.StringSlice("http.request.header.authorization", []string{"Bearer abc"}),
+		attribute.StringSlice("http.request.header.x-api-key", []string{"zog_abc"}),
+		attribute.String("db.query.parameter.0", "hunter2"),
+		attribute.String("customer.Phone", "+62 812"),
+		attribute.String("url.query", "search=alice@example.com&token=abc&page=2"),
+		attribute.StringSlice("http.request.header.x-debug", []string{"Basic YWxpY2U6aHVudGVyMg==", "on"}),
+		attribute.String("api_key_id", "01J9"),
+	)
+	span.AddEvent("login", trace.WithAttributes(
+		attribute.String("reset_token", "abc"),
+		attribute.Int("attempt", 2),
+	))
+	span.AddEvent("kept", trace.WithAttributes(attribute.String("outcome", "ok")))
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, map[attribute.Key]string{
+		"http.request.method":               "POST",
+		"user.email":                        redact.Redacted,
+		"http.request.header.authorization": redact.Redacted,
+		"http.request.header.x-api-key":     redact.Redacted,
+		"db.query.parameter.0":              redact.Redacted,
+		"customer.Phone":                    redact.Redacted,
+		"url.query":                         "search=REDACTED&token=REDACTED&page=2",
+		"http.request.header.x-debug":       `["Basic REDACTED","on"]`,
+		"api_key_id":                        "01J9",
+	}, attributes(spans[0]))
+	require.Len(t, spans[0].Events, 2)
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("reset_token", redact.Redacted),
+		attribute.Int("attempt", 2),
+	}, spans[0].Events[0].Attributes)
+	assert.Equal(t, []attribute.KeyValue{attribute.String("outcome", "ok")}, spans[0].Events[1].Attributes)
+}
+
+func TestRedactingProcessor_Status(t *testing.T) {
+	rules, err := redact.New(redact.DefaultKeys, redact.DefaultPatterns)
+	require.NoError(t, err)
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(tracing.NewRedactingProcessor(
+		sdktrace.NewSimpleSpanProcessor(exporter), rules,
+	)))
+
+	_, span := provider.Tracer("test").Start(context.Background(), "UserService.CreateUser")
+	span.SetStatus(codes.Error, "user alice@example.com already exists")
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, "user REDACTED already exists", spans[0].Status.Description)
+}
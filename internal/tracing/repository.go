@@ -0,0 +1,536 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service"
+	"github.com/google/uuid"
+)
+
+type apiKeyRepository struct {
+	next     service.APIKeyRepository
+	function string
+}
+
+// APIKeyRepository traces the calls to next
+func APIKeyRepository(next service.APIKeyRepository) service.APIKeyRepository {
+	return &apiKeyRepository{next: next, function: typeName(next)}
+}
+
+func (a *apiKeyRepository) CreateAPIKey(ctx context.Context, key *domain.APIKey) (_ *domain.APIKey, err error) {
+	ctx, span := start(ctx, "APIKeyRepository.CreateAPIKey", a.function+".CreateAPIKey")
+	defer func() { end(span, err) }()
+	return a.next.CreateAPIKey(ctx, key)
+}
+
+func (a *apiKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (_ *domain.APIKey, err error) {
+	ctx, span := start(ctx, "APIKeyRepository.GetAPIKeyByHash", a.function+".GetAPIKeyByHash")
+	defer func() { end(span, err) }()
+	return a.next.GetAPIKeyByHash(ctx, hash)
+}
+
+func (a *apiKeyRepository) GetAPIKey(ctx context.Context, id uuid.UUID) (_ *domain.APIKey, err error) {
+	ctx, span := start(ctx, "APIKeyRepository.GetAPIKey", a.function+".GetAPIKey")
+	defer func() { end(span, err) }()
+	return a.next.GetAPIKey(ctx, id)
+}
+
+func (a *apiKeyRepository) GetAPIKeys(ctx context.Context, ownerID string) (_ []domain.APIKey, err error) {
+	ctx, span := start(ctx, "APIKeyRepository.GetAPIKeys", a.function+".GetAPIKeys")
+	defer func() { end(span, err) }()
+	return a.next.GetAPIKeys(ctx, ownerID)
+}
+
+func (a *apiKeyRepository) RotateAPIKey(ctx context.Context, id uuid.UUID, replacement *domain.APIKey, overlapUntil time.Time) (_ *domain.APIKey, err error) {
+	ctx, span := start(ctx, "APIKeyRepository.RotateAPIKey", a.function+".RotateAPIKey")
+	defer func() { end(span, err) }()
+	return a.next.RotateAPIKey(ctx, id, replacement, overlapUntil)
+}
+
+func (a *apiKeyRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := start(ctx, "APIKeyRepository.RevokeAPIKey", a.function+".RevokeAPIKey")
+	defer func() { end(span, err) }()
+	return a.next.RevokeAPIKey(ctx, id)
+}
+
+func (a *apiKeyRepository) RecordAPIKeyUsage(ctx context.Context, usage []domain.APIKeyUsage) (err error) {
+	ctx, span := start(ctx, "APIKeyRepository.RecordAPIKeyUsage", a.function+".RecordAPIKeyUsage")
+	defer func() { end(span, err) }()
+	return a.next.RecordAPIKeyUsage(ctx, usage)
+}
+
+type auditRepository struct {
+	next     service.AuditRepository
+	function string
+}
+
+// AuditRepository traces the calls to next
+func AuditRepository(next service.AuditRepository) service.AuditRepository {
+	return &auditRepository{next: next, function: typeName(next)}
+}
+
+func (a *auditRepository) CreateAuditEvents(ctx context.Context, events []domain.AuditEvent) (err error) {
+	ctx, span := start(ctx, "AuditRepository.CreateAuditEvents", a.function+".CreateAuditEvents")
+	defer func() { end(span, err) }()
+	return a.next.CreateAuditEvents(ctx, events)
+}
+
+func (a *auditRepository) GetAuditEvents(ctx context.Context, filter *domain.AuditFilter) (_ []domain.AuditEvent, _ int, err error) {
+	ctx, span := start(ctx, "AuditRepository.GetAuditEvents", a.function+".GetAuditEvents")
+	defer func() { end(span, err) }()
+	return a.next.GetAuditEvents(ctx, filter)
+}
+
+func (a *auditRepository) DeleteAuditEventsBefore(ctx context.Context, before time.Time) (_ int64, err error) {
+	ctx, span := start(ctx, "AuditRepository.DeleteAuditEventsBefore", a.function+".DeleteAuditEventsBefore")
+	defer func() { end(span, err) }()
+	return a.next.DeleteAuditEventsBefore(ctx, before)
+}
+
+type loginThrottleRepository struct {
+	next     service.LoginThrottleRepository
+	function string
+}
+
+// LoginThrottleRepository traces the calls to next
+func LoginThrottleRepository(next service.LoginThrottleRepository) service.LoginThrottleRepository {
+	return &loginThrottleRepository{next: next, function: typeName(next)}
+}
+
+func (l *loginThrottleRepository) GetLoginThrottles(ctx context.Context, keys []string) (_ []domain.LoginThrottle, err error) {
+	ctx, span := start(ctx, "LoginThrottleRepository.GetLoginThrottles", l.function+".GetLoginThrottles")
+	defer func() { end(span, err) }()
+	return l.next.GetLoginThrottles(ctx, keys)
+}
+
+func (l *loginThrottleRepository) RecordLoginFailure(ctx context.Context, key string, window time.Duration, maxFailures int, lockout time.Duration) (_ *domain.LoginThrottle, err error) {
+	ctx, span := start(ctx, "LoginThrottleRepository.RecordLoginFailure", l.function+".RecordLoginFailure")
+	defer func() { end(span, err) }()
+	return l.next.RecordLoginFailure(ctx, key, window, maxFailures, lockout)
+}
+
+func (l *loginThrottleRepository) ClearLoginFailures(ctx context.Context, keys ...string) (err error) {
+	ctx, span := start(ctx, "LoginThrottleRepository.ClearLoginFailures", l.function+".ClearLoginFailures")
+	defer func() { end(span, err) }()
+	return l.next.ClearLoginFailures(ctx, keys...)
+}
+
+type newsRepository struct {
+	next     service.NewsRepository
+	function string
+}
+
+// NewsRepository traces the calls to next
+func NewsRepository(next service.NewsRepository) service.NewsRepository {
+	return &newsRepository{next: next, function: typeName(next)}
+}
+
+func (n *newsRepository) CreateNews(ctx context.Context, news *domain.CreateNewsRequest) (_ *domain.News, err error) {
+	ctx, span := start(ctx, "NewsRepository.CreateNews", n.function+".CreateNews")
+	defer func() { end(span, err) }()
+	return n.next.CreateNews(ctx, news)
+}
+
+func (n *newsRepository) GetNewsList(ctx context.Context, filter *domain.NewsFilter) (_ []domain.News, err error) {
+	ctx, span := start(ctx, "NewsRepository.GetNewsList", n.function+".GetNewsList")
+	defer func() { end(span, err) }()
+	return n.next.GetNewsList(ctx, filter)
+}
+
+func (n *newsRepository) GetNews(ctx context.Context, id uuid.UUID) (_ *domain.News, err error) {
+	ctx, span := start(ctx, "NewsRepository.GetNews", n.function+".GetNews")
+	defer func() { end(span, err) }()
+	return n.next.GetNews(ctx, id)
+}
+
+func (n *newsRepository) UpdateNews(ctx context.Context, id uuid.UUID, news *domain.News) (_ *domain.News, err error) {
+	ctx, span := start(ctx, "NewsRepository.UpdateNews", n.function+".UpdateNews")
+	defer func() { end(span, err) }()
+	return n.next.UpdateNews(ctx, id, news)
+}
+
+func (n *newsRepository) DeleteNews(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := start(ctx, "NewsRepository.DeleteNews", n.function+".DeleteNews")
+	defer func() { end(span, err) }()
+	return n.next.DeleteNews(ctx, id)
+}
+
+func (n *newsRepository) BulkSaveNews(ctx context.Context, items []domain.BulkNewsItem, allOrNothing bool) (_ []domain.BulkItemResult, err error) {
+	ctx, span := start(ctx, "NewsRepository.BulkSaveNews", n.function+".BulkSaveNews")
+	defer func() { end(span, err) }()
+	return n.next.BulkSaveNews(ctx, items, allOrNothing)
+}
+
+func (n *newsRepository) BulkDeleteNews(ctx context.Context, ids []uuid.UUID, allOrNothing bool) (_ []domain.BulkItemResult, err error) {
+	ctx, span := start(ctx, "NewsRepository.BulkDeleteNews", n.function+".BulkDeleteNews")
+	defer func() { end(span, err) }()
+	return n.next.BulkDeleteNews(ctx, ids, allOrNothing)
+}
+
+type outboxRepository struct {
+	next     service.OutboxRepository
+	function string
+}
+
+// OutboxRepository traces the calls to next
+func OutboxRepository(next service.OutboxRepository) service.OutboxRepository {
+	return &outboxRepository{next: next, function: typeName(next)}
+}
+
+func (o *outboxRepository) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) (_ []domain.OutboxMessage, err error) {
+	ctx, span := start(ctx, "OutboxRepository.ClaimOutboxEvents", o.function+".ClaimOutboxEvents")
+	defer func() { end(span, err) }()
+	return o.next.ClaimOutboxEvents(ctx, limit, lease)
+}
+
+func (o *outboxRepository) MarkOutboxDispatched(ctx context.Context, id string) (err error) {
+	ctx, span := start(ctx, "OutboxRepository.MarkOutboxDispatched", o.function+".MarkOutboxDispatched")
+	defer func() { end(span, err) }()
+	return o.next.MarkOutboxDispatched(ctx, id)
+}
+
+func (o *outboxRepository) RetryOutboxEvent(ctx context.Context, id string, nextAttemptAt time.Time, lastError string, deliveredSinks []string) (err error) {
+	ctx, span := start(ctx, "OutboxRepository.RetryOutboxEvent", o.function+".RetryOutboxEvent")
+	defer func() { end(span, err) }()
+	return o.next.RetryOutboxEvent(ctx, id, nextAttemptAt, lastError, deliveredSinks)
+}
+
+func (o *outboxRepository) ReleaseOutboxEvents(ctx context.Context, ids []string) (err error) {
+	ctx, span := start(ctx, "OutboxRepository.ReleaseOutboxEvents", o.function+".ReleaseOutboxEvents")
+	defer func() { end(span, err) }()
+	return o.next.ReleaseOutboxEvents(ctx, ids)
+}
+
+func (o *outboxRepository) FailOutboxEvent(ctx context.Context, id string, lastError string) (err error) {
+	ctx, span := start(ctx, "OutboxRepository.FailOutboxEvent", o.function+".FailOutboxEvent")
+	defer func() { end(span, err) }()
+	return o.next.FailOutboxEvent(ctx, id, lastError)
+}
+
+type partnerClientRepository struct {
+	next     service.PartnerClientRepository
+	function string
+}
+
+// PartnerClientRepository traces the calls to next
+func PartnerClientRepository(next service.PartnerClientRepository) service.PartnerClientRepository {
+	return &partnerClientRepository{next: next, function: typeName(next)}
+}
+
+func (p *partnerClientRepository) GetPartnerClient(ctx context.Context, id uuid.UUID) (_ *domain.PartnerClient, err error) {
+	ctx, span := start(ctx, "PartnerClientRepository.GetPartnerClient", p.function+".GetPartnerClient")
+	defer func() { end(span, err) }()
+	return p.next.GetPartnerClient(ctx, id)
+}
+
+type sessionRepository struct {
+	next     service.SessionRepository
+	function string
+}
+
+// SessionRepository traces the calls to next
+func SessionRepository(next service.SessionRepository) service.SessionRepository {
+	return &sessionRepository{next: next, function: typeName(next)}
+}
+
+func (s *sessionRepository) CreateSession(ctx context.Context, session *domain.Session) (err error) {
+	ctx, span := start(ctx, "SessionRepository.CreateSession", s.function+".CreateSession")
+	defer func() { end(span, err) }()
+	return s.next.CreateSession(ctx, session)
+}
+
+func (s *sessionRepository) GetSessionByHash(ctx context.Context, tokenHash string) (_ *domain.SessionCredentials, err error) {
+	ctx, span := start(ctx, "SessionRepository.GetSessionByHash", s.function+".GetSessionByHash")
+	defer func() { end(span, err) }()
+	return s.next.GetSessionByHash(ctx, tokenHash)
+}
+
+func (s *sessionRepository) TouchSession(ctx context.Context, id, ip string) (err error) {
+	ctx, span := start(ctx, "SessionRepository.TouchSession", s.function+".TouchSession")
+	defer func() { end(span, err) }()
+	return s.next.TouchSession(ctx, id, ip)
+}
+
+func (s *sessionRepository) GetSessions(ctx context.Context, userID string) (_ []domain.Session, err error) {
+	ctx, span := start(ctx, "SessionRepository.GetSessions", s.function+".GetSessions")
+	defer func() { end(span, err) }()
+	return s.next.GetSessions(ctx, userID)
+}
+
+func (s *sessionRepository) RevokeSession(ctx context.Context, userID, id string) (err error) {
+	ctx, span := start(ctx, "SessionRepository.RevokeSession", s.function+".RevokeSession")
+	defer func() { end(span, err) }()
+	return s.next.RevokeSession(ctx, userID, id)
+}
+
+func (s *sessionRepository) RevokeSessions(ctx context.Context, userID string) (_ int64, err error) {
+	ctx, span := start(ctx, "SessionRepository.RevokeSessions", s.function+".RevokeSessions")
+	defer func() { end(span, err) }()
+	return s.next.RevokeSessions(ctx, userID)
+}
+
+func (s *sessionRepository) RevokeOtherSessions(ctx context.Context, userID, keepID string) (_ int64, err error) {
+	ctx, span := start(ctx, "SessionRepository.RevokeOtherSessions", s.function+".RevokeOtherSessions")
+	defer func() { end(span, err) }()
+	return s.next.RevokeOtherSessions(ctx, userID, keepID)
+}
+
+type topicRepository struct {
+	next     service.TopicRepository
+	function string
+}
+
+// TopicRepository traces the calls to next
+func TopicRepository(next service.TopicRepository) service.TopicRepository {
+	return &topicRepository{next: next, function: typeName(next)}
+}
+
+func (t *topicRepository) CreateTopic(ctx context.Context, topic *domain.CreateTopicRequest) (_ *domain.Topic, err error) {
+	ctx, span := start(ctx, "TopicRepository.CreateTopic", t.function+".CreateTopic")
+	defer func() { end(span, err) }()
+	return t.next.CreateTopic(ctx, topic)
+}
+
+func (t *topicRepository) GetTopicList(ctx context.Context, filter *domain.TopicFilter) (_ []domain.Topic, err error) {
+	ctx, span := start(ctx, "TopicRepository.GetTopicList", t.function+".GetTopicList")
+	defer func() { end(span, err) }()
+	return t.next.GetTopicList(ctx, filter)
+}
+
+func (t *topicRepository) GetTopic(ctx context.Context, id uuid.UUID) (_ *domain.Topic, err error) {
+	ctx, span := start(ctx, "TopicRepository.GetTopic", t.function+".GetTopic")
+	defer func() { end(span, err) }()
+	return t.next.GetTopic(ctx, id)
+}
+
+func (t *topicRepository) UpdateTopic(ctx context.Context, id uuid.UUID, topic *domain.Topic) (_ *domain.Topic, err error) {
+	ctx, span := start(ctx, "TopicRepository.UpdateTopic", t.function+".UpdateTopic")
+	defer func() { end(span, err) }()
+	return t.next.UpdateTopic(ctx, id, topic)
+}
+
+func (t *topicRepository) DeleteTopic(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := start(ctx, "TopicRepository.DeleteTopic", t.function+".DeleteTopic")
+	defer func() { end(span, err) }()
+	return t.next.DeleteTopic(ctx, id)
+}
+
+func (t *topicRepository) BulkSaveTopics(ctx context.Context, items []domain.BulkTopicItem, allOrNothing bool) (_ []domain.BulkItemResult, err error) {
+	ctx, span := start(ctx, "TopicRepository.BulkSaveTopics", t.function+".BulkSaveTopics")
+	defer func() { end(span, err) }()
+	return t.next.BulkSaveTopics(ctx, items, allOrNothing)
+}
+
+func (t *topicRepository) MergeTopics(ctx context.Context, sourceID, targetID uuid.UUID) (_ int64, err error) {
+	ctx, span := start(ctx, "TopicRepository.MergeTopics", t.function+".MergeTopics")
+	defer func() { end(span, err) }()
+	return t.next.MergeTopics(ctx, sourceID, targetID)
+}
+
+type userRepository struct {
+	next     service.UserRepository
+	function string
+}
+
+// UserRepository traces the calls to next
+func UserRepository(next service.UserRepository) service.UserRepository {
+	return &userRepository{next: next, function: typeName(next)}
+}
+
+func (u *userRepository) CreateUser(ctx context.Context, user *domain.CreateUserRequest, passwordHash string) (_ *domain.User, err error) {
+	ctx, span := start(ctx, "UserRepository.CreateUser", u.function+".CreateUser")
+	defer func() { end(span, err) }()
+	return u.next.CreateUser(ctx, user, passwordHash)
+}
+
+func (u *userRepository) GetUserList(ctx context.Context, filter *domain.UserFilter) (_ []domain.User, err error) {
+	ctx, span := start(ctx, "UserRepository.GetUserList", u.function+".GetUserList")
+	defer func() { end(span, err) }()
+	return u.next.GetUserList(ctx, filter)
+}
+
+func (u *userRepository) GetUser(ctx context.Context, id uuid.UUID) (_ *domain.User, err error) {
+	ctx, span := start(ctx, "UserRepository.GetUser", u.function+".GetUser")
+	defer func() { end(span, err) }()
+	return u.next.GetUser(ctx, id)
+}
+
+func (u *userRepository) UpdateUser(ctx context.Context, id uuid.UUID, user *domain.User) (_ *domain.User, err error) {
+	ctx, span := start(ctx, "UserRepository.UpdateUser", u.function+".UpdateUser")
+	defer func() { end(span, err) }()
+	return u.next.UpdateUser(ctx, id, user)
+}
+
+func (u *userRepository) DeleteUser(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := start(ctx, "UserRepository.DeleteUser", u.function+".DeleteUser")
+	defer func() { end(span, err) }()
+	return u.next.DeleteUser(ctx, id)
+}
+
+func (u *userRepository) GetUserCredentials(ctx context.Context, email string) (_ *domain.UserCredentials, err error) {
+	ctx, span := start(ctx, "UserRepository.GetUserCredentials", u.function+".GetUserCredentials")
+	defer func() { end(span, err) }()
+	return u.next.GetUserCredentials(ctx, email)
+}
+
+func (u *userRepository) GetPasswordHash(ctx context.Context, id uuid.UUID) (_ string, err error) {
+	ctx, span := start(ctx, "UserRepository.GetPasswordHash", u.function+".GetPasswordHash")
+	defer func() { end(span, err) }()
+	return u.next.GetPasswordHash(ctx, id)
+}
+
+func (u *userRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) (err error) {
+	ctx, span := start(ctx, "UserRepository.UpdatePassword", u.function+".UpdatePassword")
+	defer func() { end(span, err) }()
+	return u.next.UpdatePassword(ctx, id, passwordHash)
+}
+
+func (u *userRepository) RehashPassword(ctx context.Context, id uuid.UUID, oldHash, newHash string) (err error) {
+	ctx, span := start(ctx, "UserRepository.RehashPassword", u.function+".RehashPassword")
+	defer func() { end(span, err) }()
+	return u.next.RehashPassword(ctx, id, oldHash, newHash)
+}
+
+func (u *userRepository) CreatePasswordResetToken(ctx context.Context, userID, tokenHash string, expiresAt time.Time) (err error) {
+	ctx, span := start(ctx, "UserRepository.CreatePasswordResetToken", u.function+".CreatePasswordResetToken")
+	defer func() { end(span, err) }()
+	return u.next.CreatePasswordResetToken(ctx, userID, tokenHash, expiresAt)
+}
+
+func (u *userRepository) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (_ string, err error) {
+	ctx, span := start(ctx, "UserRepository.ResetPassword", u.function+".ResetPassword")
+	defer func() { end(span, err) }()
+	return u.next.ResetPassword(ctx, tokenHash, passwordHash)
+}
+
+func (u *userRepository) ActivateUser(ctx context.Context, id uuid.UUID) (_ *domain.User, err error) {
+	ctx, span := start(ctx, "UserRepository.ActivateUser", u.function+".ActivateUser")
+	defer func() { end(span, err) }()
+	return u.next.ActivateUser(ctx, id)
+}
+
+func (u *userRepository) ClaimVerificationResend(ctx context.Context, email string, cooldown time.Duration) (_ *domain.User, err error) {
+	ctx, span := start(ctx, "UserRepository.ClaimVerificationResend", u.function+".ClaimVerificationResend")
+	defer func() { end(span, err) }()
+	return u.next.ClaimVerificationResend(ctx, email, cooldown)
+}
+
+func (u *userRepository) GetTwoFactor(ctx context.Context, id uuid.UUID) (_ *domain.TwoFactor, err error) {
+	ctx, span := start(ctx, "UserRepository.GetTwoFactor", u.function+".GetTwoFactor")
+	defer func() { end(span, err) }()
+	return u.next.GetTwoFactor(ctx, id)
+}
+
+func (u *userRepository) SetTwoFactorSecret(ctx context.Context, id uuid.UUID, secret string) (err error) {
+	ctx, span := start(ctx, "UserRepository.SetTwoFactorSecret", u.function+".SetTwoFactorSecret")
+	defer func() { end(span, err) }()
+	return u.next.SetTwoFactorSecret(ctx, id, secret)
+}
+
+func (u *userRepository) EnableTwoFactor(ctx context.Context, id uuid.UUID, step int64, recoveryCodeHashes []string) (err error) {
+	ctx, span := start(ctx, "UserRepository.EnableTwoFactor", u.function+".EnableTwoFactor")
+	defer func() { end(span, err) }()
+	return u.next.EnableTwoFactor(ctx, id, step, recoveryCodeHashes)
+}
+
+func (u *userRepository) DisableTwoFactor(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := start(ctx, "UserRepository.DisableTwoFactor", u.function+".DisableTwoFactor")
+	defer func() { end(span, err) }()
+	return u.next.DisableTwoFactor(ctx, id)
+}
+
+func (u *userRepository) UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) (_ bool, err error) {
+	ctx, span := start(ctx, "UserRepository.UseTOTPStep", u.function+".UseTOTPStep")
+	defer func() { end(span, err) }()
+	return u.next.UseTOTPStep(ctx, id, step)
+}
+
+func (u *userRepository) UseRecoveryCode(ctx context.Context, id uuid.UUID, codeHash string) (_ bool, err error) {
+	ctx, span := start(ctx, "UserRepository.UseRecoveryCode", u.function+".UseRecoveryCode")
+	defer func() { end(span, err) }()
+	return u.next.UseRecoveryCode(ctx, id, codeHash)
+}
+
+func (u *userRepository) GetTwoFactorPolicies(ctx context.Context) (_ []domain.TwoFactorPolicy, err error) {
+	ctx, span := start(ctx, "UserRepository.GetTwoFactorPolicies", u.function+".GetTwoFactorPolicies")
+	defer func() { end(span, err) }()
+	return u.next.GetTwoFactorPolicies(ctx)
+}
+
+func (u *userRepository) SetTwoFactorPolicy(ctx context.Context, role string, required bool) (_ *domain.TwoFactorPolicy, err error) {
+	ctx, span := start(ctx, "UserRepository.SetTwoFactorPolicy", u.function+".SetTwoFactorPolicy")
+	defer func() { end(span, err) }()
+	return u.next.SetTwoFactorPolicy(ctx, role, required)
+}
+
+func (u *userRepository) UpsertExternalUser(ctx context.Context, identity *domain.ExternalIdentity) (_ *domain.UserCredentials, _ bool, err error) {
+	ctx, span := start(ctx, "UserRepository.UpsertExternalUser", u.function+".UpsertExternalUser")
+	defer func() { end(span, err) }()
+	return u.next.UpsertExternalUser(ctx, identity)
+}
+
+type webhookRepository struct {
+	next     service.WebhookRepository
+	function string
+}
+
+// WebhookRepository traces the calls to next
+func WebhookRepository(next service.WebhookRepository) service.WebhookRepository {
+	return &webhookRepository{next: next, function: typeName(next)}
+}
+
+func (w *webhookRepository) CreateWebhook(ctx context.Context, webhook *domain.Webhook) (_ *domain.Webhook, err error) {
+	ctx, span := start(ctx, "WebhookRepository.CreateWebhook", w.function+".CreateWebhook")
+	defer func() { end(span, err) }()
+	return w.next.CreateWebhook(ctx, webhook)
+}
+
+func (w *webhookRepository) GetWebhookList(ctx context.Context) (_ []domain.Webhook, err error) {
+	ctx, span := start(ctx, "WebhookRepository.GetWebhookList", w.function+".GetWebhookList")
+	defer func() { end(span, err) }()
+	return w.next.GetWebhookList(ctx)
+}
+
+func (w *webhookRepository) GetWebhook(ctx context.Context, id uuid.UUID) (_ *domain.Webhook, err error) {
+	ctx, span := start(ctx, "WebhookRepository.GetWebhook", w.function+".GetWebhook")
+	defer func() { end(span, err) }()
+	return w.next.GetWebhook(ctx, id)
+}
+
+func (w *webhookRepository) DeleteWebhook(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := start(ctx, "WebhookRepository.DeleteWebhook", w.function+".DeleteWebhook")
+	defer func() { end(span, err) }()
+	return w.next.DeleteWebhook(ctx, id)
+}
+
+func (w *webhookRepository) EnqueueWebhookDeliveries(ctx context.Context, eventID, eventType string, payload []byte) (_ int64, err error) {
+	ctx, span := start(ctx, "WebhookRepository.EnqueueWebhookDeliveries", w.function+".EnqueueWebhookDeliveries")
+	defer func() { end(span, err) }()
+	return w.next.EnqueueWebhookDeliveries(ctx, eventID, eventType, payload)
+}
+
+func (w *webhookRepository) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) (_ []domain.WebhookDelivery, err error) {
+	ctx, span := start(ctx, "WebhookRepository.ClaimWebhookDeliveries", w.function+".ClaimWebhookDeliveries")
+	defer func() { end(span, err) }()
+	return w.next.ClaimWebhookDeliveries(ctx, limit, lease)
+}
+
+func (w *webhookRepository) UpdateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (err error) {
+	ctx, span := start(ctx, "WebhookRepository.UpdateWebhookDelivery", w.function+".UpdateWebhookDelivery")
+	defer func() { end(span, err) }()
+	return w.next.UpdateWebhookDelivery(ctx, delivery)
+}
+
+func (w *webhookRepository) GetWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, filter *domain.WebhookDeliveryFilter) (_ []domain.WebhookDelivery, _ int, err error) {
+	ctx, span := start(ctx, "WebhookRepository.GetWebhookDeliveries", w.function+".GetWebhookDeliveries")
+	defer func() { end(span, err) }()
+	return w.next.GetWebhookDeliveries(ctx, webhookID, filter)
+}
+
+func (w *webhookRepository) RedeliverWebhookDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID) (_ *domain.WebhookDelivery, err error) {
+	ctx, span := start(ctx, "WebhookRepository.RedeliverWebhookDelivery", w.function+".RedeliverWebhookDelivery")
+	defer func() { end(span, err) }()
+	return w.next.RedeliverWebhookDelivery(ctx, webhookID, deliveryID)
+}
@@ -0,0 +1,426 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/health"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest"
+	"github.com/google/uuid"
+)
+
+type apiKeyService struct {
+	next     rest.APIKeyService
+	function string
+}
+
+// APIKeyService traces the calls to next
+func APIKeyService(next rest.APIKeyService) rest.APIKeyService {
+	return &apiKeyService{next: next, function: typeName(next)}
+}
+
+func (a *apiKeyService) CreateAPIKey(ctx context.Context, req *domain.CreateAPIKeyRequest) (_ *domain.APIKey, err error) {
+	ctx, span := start(ctx, "APIKeyService.CreateAPIKey", a.function+".CreateAPIKey")
+	defer func() { end(span, err) }()
+	return a.next.CreateAPIKey(ctx, req)
+}
+
+func (a *apiKeyService) GetAPIKeys(ctx context.Context) (_ []domain.APIKey, err error) {
+	ctx, span := start(ctx, "APIKeyService.GetAPIKeys", a.function+".GetAPIKeys")
+	defer func() { end(span, err) }()
+	return a.next.GetAPIKeys(ctx)
+}
+
+func (a *apiKeyService) RotateAPIKey(ctx context.Context, id uuid.UUID, req *domain.RotateAPIKeyRequest) (_ *domain.APIKey, err error) {
+	ctx, span := start(ctx, "APIKeyService.RotateAPIKey", a.function+".RotateAPIKey")
+	defer func() { end(span, err) }()
+	return a.next.RotateAPIKey(ctx, id, req)
+}
+
+func (a *apiKeyService) RevokeAPIKey(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := start(ctx, "APIKeyService.RevokeAPIKey", a.function+".RevokeAPIKey")
+	defer func() { end(span, err) }()
+	return a.next.RevokeAPIKey(ctx, id)
+}
+
+type auditService struct {
+	next     rest.AuditService
+	function string
+}
+
+// AuditService traces the calls to next
+func AuditService(next rest.AuditService) rest.AuditService {
+	return &auditService{next: next, function: typeName(next)}
+}
+
+func (a *auditService) GetAuditEvents(ctx context.Context, filter *domain.AuditFilter) (_ []domain.AuditEvent, _ int, err error) {
+	ctx, span := start(ctx, "AuditService.GetAuditEvents", a.function+".GetAuditEvents")
+	defer func() { end(span, err) }()
+	return a.next.GetAuditEvents(ctx, filter)
+}
+
+type authService struct {
+	next     rest.AuthService
+	function string
+}
+
+// AuthService traces the calls to next
+func AuthService(next rest.AuthService) rest.AuthService {
+	return &authService{next: next, function: typeName(next)}
+}
+
+func (a *authService) ChangePassword(ctx context.Context, req *domain.ChangePasswordRequest) (err error) {
+	ctx, span := start(ctx, "AuthService.ChangePassword", a.function+".ChangePassword")
+	defer func() { end(span, err) }()
+	return a.next.ChangePassword(ctx, req)
+}
+
+func (a *authService) ForgotPassword(ctx context.Context, req *domain.ForgotPasswordRequest) (err error) {
+	ctx, span := start(ctx, "AuthService.ForgotPassword", a.function+".ForgotPassword")
+	defer func() { end(span, err) }()
+	return a.next.ForgotPassword(ctx, req)
+}
+
+func (a *authService) ResetPassword(ctx context.Context, req *domain.ResetPasswordRequest) (err error) {
+	ctx, span := start(ctx, "AuthService.ResetPassword", a.function+".ResetPassword")
+	defer func() { end(span, err) }()
+	return a.next.ResetPassword(ctx, req)
+}
+
+func (a *authService) VerifyEmail(ctx context.Context, token string) (_ *domain.User, err error) {
+	ctx, span := start(ctx, "AuthService.VerifyEmail", a.function+".VerifyEmail")
+	defer func() { end(span, err) }()
+	return a.next.VerifyEmail(ctx, token)
+}
+
+func (a *authService) ResendVerification(ctx context.Context, req *domain.ResendVerificationRequest) (err error) {
+	ctx, span := start(ctx, "AuthService.ResendVerification", a.function+".ResendVerification")
+	defer func() { end(span, err) }()
+	return a.next.ResendVerification(ctx, req)
+}
+
+func (a *authService) UnlockUser(ctx context.Context, id uuid.UUID, req *domain.UnlockUserRequest) (err error) {
+	ctx, span := start(ctx, "AuthService.UnlockUser", a.function+".UnlockUser")
+	defer func() { end(span, err) }()
+	return a.next.UnlockUser(ctx, id, req)
+}
+
+func (a *authService) Login(ctx context.Context, req *domain.LoginRequest) (_ *domain.LoginResult, err error) {
+	ctx, span := start(ctx, "AuthService.Login", a.function+".Login")
+	defer func() { end(span, err) }()
+	return a.next.Login(ctx, req)
+}
+
+func (a *authService) LoginTwoFactor(ctx context.Context, req *domain.TwoFactorLoginRequest) (_ *domain.LoginResult, err error) {
+	ctx, span := start(ctx, "AuthService.LoginTwoFactor", a.function+".LoginTwoFactor")
+	defer func() { end(span, err) }()
+	return a.next.LoginTwoFactor(ctx, req)
+}
+
+type healthService struct {
+	next     rest.HealthService
+	function string
+}
+
+// HealthService traces the calls to next
+func HealthService(next rest.HealthService) rest.HealthService {
+	return &healthService{next: next, function: typeName(next)}
+}
+
+func (h *healthService) Live(ctx context.Context) *health.Report {
+	ctx, span := start(ctx, "HealthService.Live", h.function+".Live")
+	defer span.End()
+	return h.next.Live(ctx)
+}
+
+func (h *healthService) Ready(ctx context.Context) *health.Report {
+	ctx, span := start(ctx, "HealthService.Ready", h.function+".Ready")
+	defer span.End()
+	return h.next.Ready(ctx)
+}
+
+type newsService struct {
+	next     rest.NewsService
+	function string
+}
+
+// NewsService traces the calls to next
+func NewsService(next rest.NewsService) rest.NewsService {
+	return &newsService{next: next, function: typeName(next)}
+}
+
+func (n *newsService) CreateNews(ctx context.Context, news *domain.CreateNewsRequest) (_ *domain.News, err error) {
+	ctx, span := start(ctx, "NewsService.CreateNews", n.function+".CreateNews")
+	defer func() { end(span, err) }()
+	return n.next.CreateNews(ctx, news)
+}
+
+func (n *newsService) GetNewsList(ctx context.Context, filter *domain.NewsFilter) (_ []domain.News, err error) {
+	ctx, span := start(ctx, "NewsService.GetNewsList", n.function+".GetNewsList")
+	defer func() { end(span, err) }()
+	return n.next.GetNewsList(ctx, filter)
+}
+
+func (n *newsService) GetNews(ctx context.Context, id uuid.UUID) (_ *domain.News, err error) {
+	ctx, span := start(ctx, "NewsService.GetNews", n.function+".GetNews")
+	defer func() { end(span, err) }()
+	return n.next.GetNews(ctx, id)
+}
+
+func (n *newsService) UpdateNews(ctx context.Context, id uuid.UUID, news *domain.News) (_ *domain.News, err error) {
+	ctx, span := start(ctx, "NewsService.UpdateNews", n.function+".UpdateNews")
+	defer func() { end(span, err) }()
+	return n.next.UpdateNews(ctx, id, news)
+}
+
+func (n *newsService) DeleteNews(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := start(ctx, "NewsService.DeleteNews", n.function+".DeleteNews")
+	defer func() { end(span, err) }()
+	return n.next.DeleteNews(ctx, id)
+}
+
+func (n *newsService) GetUserNews(ctx context.Context, userID uuid.UUID) (_ []domain.News, err error) {
+	ctx, span := start(ctx, "NewsService.GetUserNews", n.function+".GetUserNews")
+	defer func() { end(span, err) }()
+	return n.next.GetUserNews(ctx, userID)
+}
+
+func (n *newsService) BulkSaveNews(ctx context.Context, req *domain.BulkNewsRequest) (_ *domain.BulkResult, err error) {
+	ctx, span := start(ctx, "NewsService.BulkSaveNews", n.function+".BulkSaveNews")
+	defer func() { end(span, err) }()
+	return n.next.BulkSaveNews(ctx, req)
+}
+
+func (n *newsService) BulkDeleteNews(ctx context.Context, req *domain.BulkDeleteRequest) (_ *domain.BulkResult, err error) {
+	ctx, span := start(ctx, "NewsService.BulkDeleteNews", n.function+".BulkDeleteNews")
+	defer func() { end(span, err) }()
+	return n.next.BulkDeleteNews(ctx, req)
+}
+
+type oidcService struct {
+	next     rest.OIDCService
+	function string
+}
+
+// OIDCService traces the calls to next
+func OIDCService(next rest.OIDCService) rest.OIDCService {
+	return &oidcService{next: next, function: typeName(next)}
+}
+
+func (o *oidcService) StartOIDCLogin(ctx context.Context) (_ *domain.OIDCAuthorization, err error) {
+	ctx, span := start(ctx, "OIDCService.StartOIDCLogin", o.function+".StartOIDCLogin")
+	defer func() { end(span, err) }()
+	return o.next.StartOIDCLogin(ctx)
+}
+
+func (o *oidcService) FinishOIDCLogin(ctx context.Context, req *domain.OIDCCallbackRequest) (_ *domain.LoginResult, err error) {
+	ctx, span := start(ctx, "OIDCService.FinishOIDCLogin", o.function+".FinishOIDCLogin")
+	defer func() { end(span, err) }()
+	return o.next.FinishOIDCLogin(ctx, req)
+}
+
+type sessionService struct {
+	next     rest.SessionService
+	function string
+}
+
+// SessionService traces the calls to next
+func SessionService(next rest.SessionService) rest.SessionService {
+	return &sessionService{next: next, function: typeName(next)}
+}
+
+func (s *sessionService) GetSessions(ctx context.Context) (_ []domain.Session, err error) {
+	ctx, span := start(ctx, "SessionService.GetSessions", s.function+".GetSessions")
+	defer func() { end(span, err) }()
+	return s.next.GetSessions(ctx)
+}
+
+func (s *sessionService) RevokeSession(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := start(ctx, "SessionService.RevokeSession", s.function+".RevokeSession")
+	defer func() { end(span, err) }()
+	return s.next.RevokeSession(ctx, id)
+}
+
+func (s *sessionService) RevokeAllSessions(ctx context.Context) (_ *domain.RevokedSessions, err error) {
+	ctx, span := start(ctx, "SessionService.RevokeAllSessions", s.function+".RevokeAllSessions")
+	defer func() { end(span, err) }()
+	return s.next.RevokeAllSessions(ctx)
+}
+
+func (s *sessionService) RevokeUserSessions(ctx context.Context, userID uuid.UUID) (_ *domain.RevokedSessions, err error) {
+	ctx, span := start(ctx, "SessionService.RevokeUserSessions", s.function+".RevokeUserSessions")
+	defer func() { end(span, err) }()
+	return s.next.RevokeUserSessions(ctx, userID)
+}
+
+type topicService struct {
+	next     rest.TopicService
+	function string
+}
+
+// TopicService traces the calls to next
+func TopicService(next rest.TopicService) rest.TopicService {
+	return &topicService{next: next, function: typeName(next)}
+}
+
+func (t *topicService) CreateTopic(ctx context.Context, topic *domain.CreateTopicRequest) (_ *domain.Topic, err error) {
+	ctx, span := start(ctx, "TopicService.CreateTopic", t.function+".CreateTopic")
+	defer func() { end(span, err) }()
+	return t.next.CreateTopic(ctx, topic)
+}
+
+func (t *topicService) GetTopicList(ctx context.Context, filter *domain.TopicFilter) (_ []domain.Topic, err error) {
+	ctx, span := start(ctx, "TopicService.GetTopicList", t.function+".GetTopicList")
+	defer func() { end(span, err) }()
+	return t.next.GetTopicList(ctx, filter)
+}
+
+func (t *topicService) GetTopic(ctx context.Context, id uuid.UUID) (_ *domain.Topic, err error) {
+	ctx, span := start(ctx, "TopicService.GetTopic", t.function+".GetTopic")
+	defer func() { end(span, err) }()
+	return t.next.GetTopic(ctx, id)
+}
+
+func (t *topicService) UpdateTopic(ctx context.Context, id uuid.UUID, topic *domain.Topic) (_ *domain.Topic, err error) {
+	ctx, span := start(ctx, "TopicService.UpdateTopic", t.function+".UpdateTopic")
+	defer func() { end(span, err) }()
+	return t.next.UpdateTopic(ctx, id, topic)
+}
+
+func (t *topicService) DeleteTopic(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := start(ctx, "TopicService.DeleteTopic", t.function+".DeleteTopic")
+	defer func() { end(span, err) }()
+	return t.next.DeleteTopic(ctx, id)
+}
+
+func (t *topicService) BulkSaveTopics(ctx context.Context, req *domain.BulkTopicRequest) (_ *domain.BulkResult, err error) {
+	ctx, span := start(ctx, "TopicService.BulkSaveTopics", t.function+".BulkSaveTopics")
+	defer func() { end(span, err) }()
+	return t.next.BulkSaveTopics(ctx, req)
+}
+
+func (t *topicService) MergeTopics(ctx context.Context, sourceID uuid.UUID, req *domain.MergeTopicRequest) (_ int64, err error) {
+	ctx, span := start(ctx, "TopicService.MergeTopics", t.function+".MergeTopics")
+	defer func() { end(span, err) }()
+	return t.next.MergeTopics(ctx, sourceID, req)
+}
+
+type twoFactorService struct {
+	next     rest.TwoFactorService
+	function string
+}
+
+// TwoFactorService traces the calls to next
+func TwoFactorService(next rest.TwoFactorService) rest.TwoFactorService {
+	return &twoFactorService{next: next, function: typeName(next)}
+}
+
+func (t *twoFactorService) SetupTwoFactor(ctx context.Context) (_ *domain.TwoFactorSetup, err error) {
+	ctx, span := start(ctx, "TwoFactorService.SetupTwoFactor", t.function+".SetupTwoFactor")
+	defer func() { end(span, err) }()
+	return t.next.SetupTwoFactor(ctx)
+}
+
+func (t *twoFactorService) VerifyTwoFactor(ctx context.Context, req *domain.VerifyTwoFactorRequest) (_ *domain.RecoveryCodes, err error) {
+	ctx, span := start(ctx, "TwoFactorService.VerifyTwoFactor", t.function+".VerifyTwoFactor")
+	defer func() { end(span, err) }()
+	return t.next.VerifyTwoFactor(ctx, req)
+}
+
+func (t *twoFactorService) GetTwoFactorPolicies(ctx context.Context) (_ []domain.TwoFactorPolicy, err error) {
+	ctx, span := start(ctx, "TwoFactorService.GetTwoFactorPolicies", t.function+".GetTwoFactorPolicies")
+	defer func() { end(span, err) }()
+	return t.next.GetTwoFactorPolicies(ctx)
+}
+
+func (t *twoFactorService) SetTwoFactorPolicy(ctx context.Context, role string, req *domain.UpdateTwoFactorPolicyRequest) (_ *domain.TwoFactorPolicy, err error) {
+	ctx, span := start(ctx, "TwoFactorService.SetTwoFactorPolicy", t.function+".SetTwoFactorPolicy")
+	defer func() { end(span, err) }()
+	return t.next.SetTwoFactorPolicy(ctx, role, req)
+}
+
+func (t *twoFactorService) ResetTwoFactor(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := start(ctx, "TwoFactorService.ResetTwoFactor", t.function+".ResetTwoFactor")
+	defer func() { end(span, err) }()
+	return t.next.ResetTwoFactor(ctx, id)
+}
+
+type userService struct {
+	next     rest.UserService
+	function string
+}
+
+// UserService traces the calls to next
+func UserService(next rest.UserService) rest.UserService {
+	return &userService{next: next, function: typeName(next)}
+}
+
+func (u *userService) CreateUser(ctx context.Context, user *domain.CreateUserRequest) (_ *domain.User, err error) {
+	ctx, span := start(ctx, "UserService.CreateUser", u.function+".CreateUser")
+	defer func() { end(span, err) }()
+	return u.next.CreateUser(ctx, user)
+}
+
+func (u *userService) GetUserList(ctx context.Context, filter *domain.UserFilter) (_ []domain.User, err error) {
+	ctx, span := start(ctx, "UserService.GetUserList", u.function+".GetUserList")
+	defer func() { end(span, err) }()
+	return u.next.GetUserList(ctx, filter)
+}
+
+func (u *userService) GetUser(ctx context.Context, id uuid.UUID) (_ *domain.User, err error) {
+	ctx, span := start(ctx, "UserService.GetUser", u.function+".GetUser")
+	defer func() { end(span, err) }()
+	return u.next.GetUser(ctx, id)
+}
+
+func (u *userService) UpdateUser(ctx context.Context, id uuid.UUID, user *domain.User) (_ *domain.User, err error) {
+	ctx, span := start(ctx, "UserService.UpdateUser", u.function+".UpdateUser")
+	defer func() { end(span, err) }()
+	return u.next.UpdateUser(ctx, id, user)
+}
+
+func (u *userService) DeleteUser(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := start(ctx, "UserService.DeleteUser", u.function+".DeleteUser")
+	defer func() { end(span, err) }()
+	return u.next.DeleteUser(ctx, id)
+}
+
+type webhookService struct {
+	next     rest.WebhookService
+	function string
+}
+
+// WebhookService traces the calls to next
+func WebhookService(next rest.WebhookService) rest.WebhookService {
+	return &webhookService{next: next, function: typeName(next)}
+}
+
+func (w *webhookService) CreateWebhook(ctx context.Context, req *domain.CreateWebhookRequest) (_ *domain.Webhook, err error) {
+	ctx, span := start(ctx, "WebhookService.CreateWebhook", w.function+".CreateWebhook")
+	defer func() { end(span, err) }()
+	return w.next.CreateWebhook(ctx, req)
+}
+
+func (w *webhookService) GetWebhookList(ctx context.Context) (_ []domain.Webhook, err error) {
+	ctx, span := start(ctx, "WebhookService.GetWebhookList", w.function+".GetWebhookList")
+	defer func() { end(span, err) }()
+	return w.next.GetWebhookList(ctx)
+}
+
+func (w *webhookService) DeleteWebhook(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := start(ctx, "WebhookService.DeleteWebhook", w.function+".DeleteWebhook")
+	defer func() { end(span, err) }()
+	return w.next.DeleteWebhook(ctx, id)
+}
+
+func (w *webhookService) GetWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, filter *domain.WebhookDeliveryFilter) (_ []domain.WebhookDelivery, _ int, err error) {
+	ctx, span := start(ctx, "WebhookService.GetWebhookDeliveries", w.function+".GetWebhookDeliveries")
+	defer func() { end(span, err) }()
+	return w.next.GetWebhookDeliveries(ctx, webhookID, filter)
+}
+
+func (w *webhookService) Redeliver(ctx context.Context, webhookID, deliveryID uuid.UUID) (_ *domain.WebhookDelivery, err error) {
+	ctx, span := start(ctx, "WebhookService.Redeliver", w.function+".Redeliver")
+	defer func() { end(span, err) }()
+	return w.next.Redeliver(ctx, webhookID, deliveryID)
+}
@@ -0,0 +1,60 @@
+// Package tracing traces the services and repositories of the application.
+//
+// The decorators of this package wrap the repository interfaces of the
+// service package and the service interfaces of the rest package, each call
+// gets an internal span named after the interface and the method, like
+// NewsRepository.GetNews, under the server span of the request. The
+// arguments are not recorded, the SQL of the queries is recorded without
+// its parameters by the spans of the database pools.
+//
+// The attributes matching the redaction policy are redacted from every span
+// before it is exported, see NewRedactingProcessor.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the spans of the decorators
+const ScopeName = "github.com/edwinjordan/ZOGTest-Golang.git/internal/tracing"
+
+// tracer follows the global tracer provider, which is set once the
+// instrumentation is initialized
+var tracer = otel.Tracer(ScopeName)
+
+// start starts the span of a call, function names the method of the
+// implementation, like postgres.NewsRepository.GetNews. Calls outside of a
+// trace are not traced, the workers polling the database would start a trace
+// every few seconds.
+func start(ctx context.Context, name, function string) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(semconv.CodeFunctionName(function)),
+	)
+}
+
+// end ends the span of a call which returned err
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.SetAttributes(semconv.ErrorType(err))
+	}
+	span.End()
+}
+
+// typeName names the implementation being decorated, like
+// postgres.NewsRepository
+func typeName(v any) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", v), "*")
+}
@@ -0,0 +1,96 @@
+package tracing_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/tracing"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// The decorators trace with the global provider, which can be set once
+var exporter = tracetest.NewInMemoryExporter()
+
+func TestMain(m *testing.M) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	os.Exit(m.Run())
+}
+
+func attributes(span tracetest.SpanStub) map[attribute.Key]string {
+	values := map[attribute.Key]string{}
+	for _, kv := range span.Attributes {
+		values[kv.Key] = kv.Value.Emit()
+	}
+	return values
+}
+
+func TestDecorator(t *testing.T) {
+	exporter.Reset()
+	id := uuid.New()
+	repo := mocks.NewNewsRepository(t)
+	repo.On("GetNews", mock.Anything, id).Return(&domain.News{ID: id.String()}, nil)
+	newsService := service.NewNewsService(tracing.NewsRepository(repo))
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "GET /api/v1/news/:id")
+	news, err := tracing.NewsService(newsService).GetNews(ctx, id)
+	parent.End()
+
+	require.NoError(t, err)
+	assert.Equal(t, id.String(), news.ID)
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	repoSpan, serviceSpan := spans[0], spans[1]
+
+	assert.Equal(t, "NewsService.GetNews", serviceSpan.Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), serviceSpan.Parent.SpanID())
+	assert.Equal(t, tracing.ScopeName, serviceSpan.InstrumentationScope.Name)
+	assert.Equal(t, "service.NewsService.GetNews", attributes(serviceSpan)["code.function.name"])
+
+	assert.Equal(t, "NewsRepository.GetNews", repoSpan.Name)
+	assert.Equal(t, serviceSpan.SpanContext.SpanID(), repoSpan.Parent.SpanID())
+	assert.Equal(t, "mocks.NewsRepository.GetNews", attributes(repoSpan)["code.function.name"])
+	assert.Equal(t, codes.Unset, repoSpan.Status.Code)
+	assert.NotContains(t, attributes(repoSpan), attribute.Key("error.type"))
+}
+
+func TestDecorator_Error(t *testing.T) {
+	exporter.Reset()
+	repo := mocks.NewTopicRepository(t)
+	repo.On("DeleteTopic", mock.Anything, mock.Anything).Return(domain.ErrNotFound)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "DELETE /api/v1/topics/:id")
+	err := tracing.TopicRepository(repo).DeleteTopic(ctx, uuid.New())
+	parent.End()
+
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, domain.ErrNotFound.Error(), spans[0].Status.Description)
+	assert.Equal(t, "*errors.errorString", attributes(spans[0])["error.type"])
+	require.Len(t, spans[0].Events, 1)
+	assert.Equal(t, "exception", spans[0].Events[0].Name)
+}
+
+func TestDecorator_OutsideOfTrace(t *testing.T) {
+	exporter.Reset()
+	repo := mocks.NewOutboxRepository(t)
+	repo.On("ClaimOutboxEvents", mock.Anything, 10, mock.Anything).Return(nil, nil)
+
+	_, err := tracing.OutboxRepository(repo).ClaimOutboxEvents(context.Background(), 10, 0)
+
+	require.NoError(t, err)
+	assert.Empty(t, exporter.GetSpans(), "the polls of the workers are not traced")
+}
@@ -15,31 +15,55 @@
 
 // @securityDefinitions.basic  BasicAuth
 
+// @securityDefinitions.apikey  ApiKeyAuth
+// @in                          header
+// @name                        X-API-Key
+
+// @securityDefinitions.apikey  SessionAuth
+// @in                          header
+// @name                        Authorization
+// @description                 "Bearer <token>" with a session token from /auth/login
+
 // @externalDocs.description  OpenAPI
 // @externalDocs.url          https://swagger.io/resources/open-api/
 package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
+	"syscall"
 
 	//"os/user"
 	"time"
 
 	"github.com/edwinjordan/ZOGTest-Golang.git/config"
 	"github.com/edwinjordan/ZOGTest-Golang.git/database"
-	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/events"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/health"
 	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/mail"
 	"github.com/edwinjordan/ZOGTest-Golang.git/internal/metrics"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/oidc"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/ratelimit"
 	"github.com/edwinjordan/ZOGTest-Golang.git/internal/repository/postgres"
 	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest"
 	"github.com/edwinjordan/ZOGTest-Golang.git/internal/rest/middleware"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/tracing"
+	"github.com/edwinjordan/ZOGTest-Golang.git/migrations"
 	"github.com/edwinjordan/ZOGTest-Golang.git/service"
+	"github.com/edwinjordan/ZOGTest-Golang.git/utils"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
 
 	echoSwagger "github.com/swaggo/echo-swagger"
 
@@ -57,9 +81,38 @@ func init() {
 // }
 
 func main() {
-	config.SetupLogging()
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	logConfig := config.SetupLogging(cfg)
+
+	serviceName := cfg.App.Name
+
+	// Prometheus metrics live on their own registry, served on /metrics
+	// when instrumentation is enabled
+	appMetrics := metrics.NewMetrics()
 
-	dbPool, err := database.SetupPgxPool()
+	// Initialize OpenTelemetry metrics, the instruments are bound to the
+	// meter provider once instrumentation sets it up
+	otelMetrics, err := metrics.NewOTelMetrics(serviceName)
+	if err != nil {
+		logging.LogError(context.Background(), err, "otel_metrics_setup")
+		// Continue without OTel metrics
+		slog.Warn("Running without OpenTelemetry metrics")
+	}
+
+	// The queries of every repository and the business operations of the
+	// services are recorded on both
+	recorder := &metrics.Recorder{Prometheus: appMetrics, OTel: otelMetrics}
+
+	primaryPoolConfig := cfg.Database.Pool("primary", cfg.Database.URL)
+	primaryPoolConfig.Recorder = recorder
+	dbPool, err := database.SetupPgxPool(primaryPoolConfig)
 	if err != nil {
 		logging.LogError(context.Background(), err, "database_setup")
 		os.Exit(1)
@@ -67,98 +120,288 @@ func main() {
 
 	defer dbPool.Close()
 
+	// Lists and gets of news and topics go to the replica when there is one,
+	// requests writing data read from the primary
+	var replicaPool *pgxpool.Pool
+	if cfg.Database.ReplicaURL != "" {
+		replicaPoolConfig := cfg.Database.Pool("replica", cfg.Database.ReplicaURL)
+		replicaPoolConfig.Recorder = recorder
+		replicaPool, err = database.SetupPgxPool(replicaPoolConfig)
+		if err != nil {
+			logging.LogError(context.Background(), err, "database_replica_setup")
+			os.Exit(1)
+		}
+		defer replicaPool.Close()
+	}
+
+	// Readiness checks the databases and that they were migrated up to the
+	// migrations this binary was built with
+	expectedMigration, err := migrations.Latest()
+	if err != nil {
+		logging.LogError(context.Background(), err, "migrations_version")
+		os.Exit(1)
+	}
+	healthChecks := health.New(2 * time.Second)
+	healthChecks.Register(health.Postgres("postgres", dbPool))
+	healthChecks.Register(health.Migrations(dbPool, expectedMigration))
+	if replicaPool != nil {
+		healthChecks.Register(health.Postgres("postgres_replica", replicaPool))
+	}
+
 	e := echo.New()
 	e.HideBanner = true
+	// X-Forwarded-For is only read from TRUSTED_PROXIES, clients could
+	// forge it to get around the limits by IP
+	e.IPExtractor = cfg.Server.IPExtractor()
 
 	e.Logger.SetOutput(os.Stdout)
 	e.Logger.SetLevel(0)
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 
 	defer stop()
 
-	// Get service name from environment
-	serviceName := os.Getenv("SERVICE_NAME")
-	if serviceName == "" {
-		serviceName = "zogtest-golang-api"
-	}
-
-	// Initialize Prometheus-style metrics
-	appMetrics := metrics.NewMetrics()
+	// SIGHUP reloads the log levels from the configuration file and the
+	// environment, replacing the ones changed on /admin/log-level
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		for range hangup {
+			reloaded, err := config.Load(os.Args[1:])
+			if err == nil {
+				err = logConfig.Reload(reloaded)
+			}
+			if err != nil {
+				logging.LogError(ctx, err, "log_level_reload")
+				continue
+			}
+			logging.LogInfo(ctx, "Log levels reloaded", slog.Any("levels", logConfig.Levels.Get()))
+		}
+	}()
 
 	// Initialize OpenTelemetry instrumentation
-	shutdown, err := config.ApplyInstrumentation(ctx, e, appMetrics)
+	shutdown, err := config.ApplyInstrumentation(ctx, e, appMetrics, cfg, healthChecks)
 	if err != nil {
 		logging.LogError(ctx, err, "instrumentation_setup")
 		os.Exit(1)
 	}
 	defer shutdown(ctx)
 
-	// Initialize OpenTelemetry metrics
-	otelMetrics, err := metrics.NewOTelMetrics(serviceName)
-	if err != nil {
-		logging.LogError(ctx, err, "otel_metrics_setup")
-		// Continue without OTel metrics
-		slog.Warn("Running without OpenTelemetry metrics")
-	}
-
 	// Apply middleware in order
 	e.Use(middleware.RequestIDMiddleware())
+	e.Use(middleware.ReadYourWritesMiddleware())
 	e.Use(middleware.SlogLoggerMiddleware())
 
 	// Add OpenTelemetry middleware if metrics are available
 	if otelMetrics != nil {
 		e.Use(middleware.OTelMetricsMiddleware(otelMetrics))
-		e.Use(middleware.EnhancedTracingMiddleware(serviceName))
 	}
+	e.Use(middleware.EnhancedTracingMiddleware())
 
-	e.Use(middleware.Cors())
+	e.Use(middleware.Cors(cfg.Server.CORSAllowOrigins))
 	e.Use(middleware.SecurityHeadersMiddleware())
 	e.Use(middleware.CompressionMiddleware())
-	e.Use(middleware.RateLimitMiddleware(10.0, 20))
-	e.Use(middleware.TimeoutMiddleware(30 * time.Second))
+	// Captures the bodies before they are compressed, only for the routes
+	// listed in [[body_capture.routes]]
+	e.Use(middleware.BodyCaptureMiddleware(cfg.BodyCapture.Capture(cfg.Redact)))
+	e.Use(middleware.TimeoutMiddleware(cfg.Server.Timeouts()))
 	//e.GET("/swagger/*", echoSwagger.WrapHandler)
-	e.GET("/", func(c echo.Context) error {
-		return c.JSON(http.StatusOK, domain.Response{
-			Code:    200,
-			Status:  "Succes",
-			Message: "All is well!",
-		})
-	})
+	rest.NewHealthHandler(e.Group(""), tracing.HealthService(healthChecks))
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 
 	//e.Logger.Fatal(e.Start(":8080"))
-	userRepo := postgres.NewUserRepository(dbPool, appMetrics)
-	userService := service.NewUserService(userRepo)
+	// The services and the repositories are traced by decorators, under the
+	// server span of the request
+	auditRepo := tracing.AuditRepository(postgres.NewAuditRepository(dbPool))
+	auditService := service.NewAuditService(auditRepo, time.Duration(cfg.Audit.RetentionDays)*24*time.Hour)
+	go auditService.RunRetention(ctx, 24*time.Hour)
+
+	// Emails go through SMTP when SMTP_HOST is set and are dropped otherwise
+	var mailer service.Mailer
+	if cfg.SMTP.Host != "" {
+		mailer = mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.SMTP.From,
+		})
+	} else {
+		slog.Warn("SMTP_HOST is not set, emails are not sent")
+	}
 
-	topicRepo := postgres.NewTopicRepository(dbPool)
-	topicService := service.NewTopicService(topicRepo)
+	// Passwords are hashed with argon2id unless PASSWORD_HASHER=bcrypt, hashes
+	// made with another algorithm or cost are upgraded on the next login
+	argon2idParams := utils.DefaultArgon2idParams
+	argon2idParams.Memory = cfg.Password.Argon2MemoryKiB
+	argon2idParams.Iterations = cfg.Password.Argon2Iterations
+	argon2idParams.Parallelism = cfg.Password.Argon2Parallelism
+	var passwordHasher utils.PasswordHasher = utils.Argon2idHasher{Params: argon2idParams}
+	if cfg.Password.Hasher == "bcrypt" {
+		passwordHasher = utils.BcryptHasher{Cost: cfg.Password.BcryptCost}
+	}
 
-	newsRepo := postgres.NewNewsRepository(dbPool)
-	newsService := service.NewNewsService(newsRepo)
-	apiV1 := e.Group("/api/v1")
-	usersGroup := apiV1.Group("")
-	topicGroup := apiV1.Group("")
-	newsGroup := apiV1.Group("")
+	passwordPolicy := service.DefaultPasswordPolicy
+	passwordPolicy.MinLength = cfg.Password.MinLength
+	passwordPolicy.MinClasses = cfg.Password.MinClasses
+	passwordPolicy.Banned = append(slices.Clip(passwordPolicy.Banned), cfg.Password.Banned...)
+	passwordReset := service.PasswordResetConfig{
+		URL: cfg.Password.ResetURL,
+		TTL: cfg.Password.ResetTTL,
+	}
 
-	rest.NewUserHandler(usersGroup, userService)
-	rest.NewTopicHandler(topicGroup, topicService)
-	rest.NewNewsHandler(newsGroup, newsService)
+	verification := service.EmailVerificationConfig{
+		Secret:         []byte(cfg.Auth.VerificationSecret),
+		URL:            cfg.Auth.VerificationURL,
+		TTL:            cfg.Auth.VerificationTTL,
+		ResendCooldown: cfg.Auth.VerificationResendCooldown,
+	}
+	if len(verification.Secret) == 0 {
+		slog.Warn("EMAIL_VERIFICATION_SECRET is not set, verification links stop working on restart")
+	}
 
-	// Get host from environment variable, default to 127.0.0.1 if not set
-	host := os.Getenv("APP_HOST")
-	if host == "" {
-		host = "0.0.0.0"
+	loginThrottle := service.DefaultLoginThrottleConfig
+	loginThrottle.MaxAccountFailures = cfg.Auth.LoginMaxFailures
+	loginThrottle.MaxIPFailures = cfg.Auth.LoginMaxIPFailures
+	loginThrottle.Lockout = cfg.Auth.LoginLockout
+
+	twoFactor := service.DefaultTwoFactorConfig
+	twoFactor.Issuer = cfg.Auth.TOTPIssuer
+	sessions := service.DefaultSessionConfig
+	sessions.TTL = cfg.Auth.SessionTTL
+
+	// Staff log in through the OpenID provider when OIDC_ISSUER is set, their
+	// groups decide their role
+	var oidcProvider *oidc.Provider
+	oidcConfig := service.DefaultOIDCConfig
+	if cfg.OIDC.Issuer != "" {
+		providerConfig := oidc.DefaultConfig
+		providerConfig.Issuer = cfg.OIDC.Issuer
+		providerConfig.ClientID = cfg.OIDC.ClientID
+		providerConfig.ClientSecret = cfg.OIDC.ClientSecret
+		providerConfig.RedirectURL = cfg.OIDC.RedirectURL
+		providerConfig.Scopes = cfg.OIDC.Scopes
+		providerConfig.GroupsClaim = cfg.OIDC.GroupsClaim
+		oidcProvider = oidc.NewProvider(providerConfig, &http.Client{Timeout: 10 * time.Second})
+
+		oidcConfig.Secret = []byte(cfg.OIDC.StateSecret)
+		oidcConfig.GroupRoles = cfg.OIDC.GroupRoles
+		oidcConfig.DefaultRole = cfg.OIDC.DefaultRole
 	}
 
-	// Get port from environment variable, default to 8000 if not set
-	port := os.Getenv("APP_PORT")
-	if port == "" {
-		port = "8000"
+	userOptions := []service.Option{
+		service.WithAuditor(auditService),
+		service.WithMetrics(recorder),
+		service.WithLoginThrottle(tracing.LoginThrottleRepository(postgres.NewLoginThrottleRepository(dbPool)), loginThrottle),
+		service.WithTwoFactor(twoFactor),
+		service.WithSessions(tracing.SessionRepository(postgres.NewSessionRepository(dbPool)), sessions),
+		service.WithPasswordPolicy(passwordPolicy),
+		service.WithPasswordHasher(passwordHasher),
+		service.WithPasswordReset(passwordReset),
+		service.WithEmailVerification(verification),
+	}
+	if mailer != nil {
+		userOptions = append(userOptions, service.WithMailer(mailer))
 	}
+	if oidcProvider != nil {
+		userOptions = append(userOptions, service.WithOIDC(oidcProvider, oidcConfig))
+	}
+	userRepo := tracing.UserRepository(postgres.NewUserRepository(dbPool))
+	userService := service.NewUserService(userRepo, userOptions...)
+
+	topicRepo := tracing.TopicRepository(postgres.NewTopicRepository(dbPool).WithReplica(replicaPool))
+	topicService := service.NewTopicService(topicRepo, service.WithAuditor(auditService), service.WithMetrics(recorder))
+
+	newsRepo := tracing.NewsRepository(postgres.NewNewsRepository(dbPool).WithReplica(replicaPool))
+	newsService := service.NewNewsService(newsRepo, service.WithAuditor(auditService), service.WithMetrics(recorder))
+
+	webhookService := service.NewWebhookService(tracing.WebhookRepository(postgres.NewWebhookRepository(dbPool)), cfg.Webhook.Dispatch(),
+		service.WithAuditor(auditService))
+	go webhookService.RunDispatcher(ctx)
+
+	// Relay the domain events written to the outbox by the repositories
+	eventBus := events.NewBus()
+	eventBus.Subscribe(events.AllEvents, webhookService.EnqueueDeliveries)
+	eventSinks := []service.EventSink{events.NewLogSink(), eventBus}
+	if cfg.Outbox.WebhookURL != "" {
+		eventSinks = append(eventSinks, events.NewHTTPSink(cfg.Outbox.WebhookURL, 10*time.Second))
+	}
+	outboxRelay := service.NewOutboxRelay(tracing.OutboxRepository(postgres.NewOutboxRepository(dbPool)), service.DefaultOutboxRelayConfig, eventSinks...)
+	go outboxRelay.Run(ctx)
+
+	// Partner systems authenticate with HMAC signed requests instead of users
+	partnerService := service.NewPartnerService(tracing.PartnerClientRepository(postgres.NewPartnerClientRepository(dbPool)))
+
+	// Machine clients authenticate with scoped API keys, their usage is
+	// counted in memory and flushed periodically
+	apiKeyService := service.NewAPIKeyService(tracing.APIKeyRepository(postgres.NewAPIKeyRepository(dbPool)), service.WithAuditor(auditService))
+	go apiKeyService.RunUsageFlusher(ctx, 30*time.Second)
+
+	// Requests are limited by IP, and by principal once authenticated, the
+	// counters are shared between replicas with RATE_LIMIT_BACKEND=postgres or redis.
+	// The redis backend shares the nonces of signed requests as well.
+	var rateLimitStore ratelimit.Store
+	signatureConfig := middleware.DefaultSignatureConfig
+	switch cfg.RateLimit.Backend {
+	case "postgres":
+		rateLimitStore = postgres.NewRateLimitRepository(dbPool)
+	case "redis":
+		redisOptions, err := redis.ParseURL(cfg.RateLimit.RedisURL)
+		if err != nil {
+			logging.LogError(ctx, err, "rate_limit_redis_setup")
+			os.Exit(1)
+		}
+		redisClient := redis.NewClient(redisOptions)
+		defer redisClient.Close()
+		healthChecks.RegisterOptional(health.CheckerFunc("redis", func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		}))
+		rateLimitStore = ratelimit.NewRedisStore(redisClient, "ratelimit:")
+		signatureConfig.Nonces = middleware.NewRedisNonceCache(redisClient, "nonce:")
+	default:
+		rateLimitStore = ratelimit.NewMemoryStore()
+	}
+	rateLimiter := ratelimit.NewLimiter(rateLimitStore)
+	go rateLimiter.RunCleanup(ctx, time.Minute)
+	// Every request is limited by IP before the authentication, so floods of
+	// rejected credentials, health checks and swagger are limited too. Echo
+	// applies it to the routes registered above as well.
+	e.Use(middleware.RateLimitMiddleware(rateLimiter, cfg.RateLimit.IPPolicies()))
+
+	apiV1 := e.Group("/api/v1",
+		middleware.BasicAuthMiddleware(userService),
+		middleware.SessionAuthMiddleware(userService),
+		middleware.APIKeyAuthMiddleware(apiKeyService),
+		middleware.SignatureAuthMiddleware(partnerService, signatureConfig),
+		middleware.RateLimitMiddleware(rateLimiter, cfg.RateLimit.Policies()),
+	)
+	usersGroup := apiV1.Group("")
+	topicGroup := apiV1.Group("")
+	newsGroup := apiV1.Group("")
+	auditGroup := apiV1.Group("")
+	webhookGroup := apiV1.Group("")
+	apiKeyGroup := apiV1.Group("")
+	authGroup := apiV1.Group("")
+	twoFactorGroup := apiV1.Group("")
+	sessionGroup := apiV1.Group("")
+	adminGroup := apiV1.Group("")
+
+	rest.NewUserHandler(usersGroup, tracing.UserService(userService))
+	rest.NewTopicHandler(topicGroup, tracing.TopicService(topicService))
+	rest.NewNewsHandler(newsGroup, tracing.NewsService(newsService))
+	rest.NewAuditHandler(auditGroup, tracing.AuditService(auditService))
+	rest.NewWebhookHandler(webhookGroup, tracing.WebhookService(webhookService))
+	rest.NewAPIKeyHandler(apiKeyGroup, tracing.APIKeyService(apiKeyService))
+	rest.NewAuthHandler(authGroup, tracing.AuthService(userService))
+	rest.NewTwoFactorHandler(twoFactorGroup, tracing.TwoFactorService(userService))
+	rest.NewSessionHandler(sessionGroup, tracing.SessionService(userService))
+	if oidcProvider != nil {
+		rest.NewOIDCHandler(authGroup, tracing.OIDCService(userService))
+	}
+	rest.NewLogLevelHandler(adminGroup, logConfig.Levels)
 
 	// Server address and port to listen on
-	serverAddr := fmt.Sprintf("%s:%s", host, port)
+	serverAddr := net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port))
 
 	go func() {
 		logging.LogInfo(ctx, "Server starting", slog.String("address", serverAddr))
@@ -168,13 +411,22 @@ func main() {
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown the server with a timeout of 10 seconds.
+	// Wait for an interrupt or termination signal to gracefully shutdown the server within SHUTDOWN_TIMEOUT.
 	<-ctx.Done()
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+
+	// Fail the readiness first and give the load balancers SHUTDOWN_DRAIN_DELAY
+	// to notice before the server stops accepting connections
+	healthChecks.ShutDown()
+	logging.LogInfo(context.Background(), "Draining before shutdown", slog.Duration("delay", cfg.Server.ShutdownDrainDelay))
+	time.Sleep(cfg.Server.ShutdownDrainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	logging.LogInfo(ctx, "Shutting down server gracefully...")
 	if err := e.Shutdown(ctx); err != nil {
 		logging.LogError(ctx, err, "server_shutdown")
 	}
+	// Lets the password reset emails still being sent go out
+	userService.Wait()
 }
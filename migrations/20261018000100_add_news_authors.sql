@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE news ADD COLUMN IF NOT EXISTS author_id UUID NULL REFERENCES users(id);
+CREATE INDEX IF NOT EXISTS news_author_id_idx ON news (author_id);
+
+CREATE TABLE IF NOT EXISTS news_coauthor (
+    news_id UUID NOT NULL REFERENCES news(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (news_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS news_coauthor_user_id_idx ON news_coauthor (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS news_coauthor;
+DROP INDEX IF EXISTS news_author_id_idx;
+ALTER TABLE news DROP COLUMN IF EXISTS author_id;
@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user';
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_id UUID NULL,
+    actor_client_id TEXT NULL,
+    auth_method TEXT NULL,
+    action TEXT NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_id TEXT NOT NULL,
+    before JSONB NULL,
+    after JSONB NULL,
+    request_id TEXT NULL,
+    client_ip TEXT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS audit_events_entity_idx ON audit_events (entity_type, entity_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS audit_events_actor_idx ON audit_events (actor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS audit_events_actor_client_idx ON audit_events (actor_client_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS audit_events_created_at_idx ON audit_events (created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_events;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
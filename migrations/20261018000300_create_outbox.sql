@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS outbox (
+    id UUID PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    aggregate_type TEXT NOT NULL,
+    aggregate_id TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT NULL,
+    delivered_sinks TEXT[] NOT NULL DEFAULT '{}',
+    dispatched_at TIMESTAMPTZ NULL,
+    failed_at TIMESTAMPTZ NULL
+);
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (next_attempt_at)
+    WHERE dispatched_at IS NULL AND failed_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Existing users stay active, new users start pending until they verify their email
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active';
+ALTER TABLE users ALTER COLUMN status SET DEFAULT 'pending';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS verification_sent_at TIMESTAMPTZ NULL;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS verification_sent_at;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
+ALTER TABLE users DROP COLUMN IF EXISTS status;
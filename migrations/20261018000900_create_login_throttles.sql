@@ -0,0 +1,11 @@
+-- +goose Up
+-- Failed logins per account (account:<email>) and per client IP (ip:<address>)
+CREATE TABLE IF NOT EXISTS login_throttles (
+    key TEXT PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMPTZ NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS login_throttles;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- ip and user_agent describe the login, last_seen_* the latest request
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS last_seen_ip TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE sessions DROP COLUMN IF EXISTS last_seen_ip;
+ALTER TABLE sessions DROP COLUMN IF EXISTS last_seen_at;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Links users to their account at an external OpenID provider, users
+-- provisioned through it have no local password
+CREATE TABLE IF NOT EXISTS user_identities (
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (issuer, subject)
+);
+CREATE INDEX IF NOT EXISTS user_identities_user_idx ON user_identities (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS user_identities;
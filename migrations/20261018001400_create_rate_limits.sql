@@ -0,0 +1,13 @@
+-- +goose Up
+-- Theoretical arrival time of the next request of every rate limited key,
+-- see internal/ratelimit. The table is rebuilt from nothing after a crash,
+-- so it skips the write-ahead log.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limits (
+    key TEXT PRIMARY KEY,
+    tat TIMESTAMPTZ NOT NULL,
+    allowed BOOLEAN NOT NULL
+);
+CREATE INDEX IF NOT EXISTS rate_limits_tat_idx ON rate_limits (tat);
+
+-- +goose Down
+DROP TABLE IF EXISTS rate_limits;
@@ -0,0 +1,22 @@
+package migrations_test
+
+import (
+	"io/fs"
+	"strconv"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatest(t *testing.T) {
+	files, err := fs.Glob(migrations.FS, "*.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	latest, err := migrations.Latest()
+
+	require.NoError(t, err)
+	assert.Equal(t, files[len(files)-1][:14], strconv.FormatInt(latest, 10), "the newest file sorts last")
+}
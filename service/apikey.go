@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/edwinjordan/ZOGTest-Golang.git/utils"
+	"github.com/google/uuid"
+)
+
+// apiKeyPrefixLength is the number of key characters kept to recognise a key
+const apiKeyPrefixLength = len(domain.APIKeyPrefix) + 8
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *domain.APIKey) (*domain.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (*domain.APIKey, error)
+	GetAPIKey(ctx context.Context, id uuid.UUID) (*domain.APIKey, error)
+	GetAPIKeys(ctx context.Context, ownerID string) ([]domain.APIKey, error)
+	RotateAPIKey(ctx context.Context, id uuid.UUID, replacement *domain.APIKey, overlapUntil time.Time) (*domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+	RecordAPIKeyUsage(ctx context.Context, usage []domain.APIKeyUsage) error
+}
+
+type APIKeyService struct {
+	apiKeyRepo APIKeyRepository
+
+	// usage accumulates the requests made with each key until it is flushed
+	mu    sync.Mutex
+	usage map[string]*domain.APIKeyUsage
+	serviceOptions
+}
+
+func NewAPIKeyService(a APIKeyRepository, opts ...Option) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo:     a,
+		usage:          make(map[string]*domain.APIKeyUsage),
+		serviceOptions: newServiceOptions(opts),
+	}
+}
+
+// mintAPIKey fills a new random key in, returning the plain key
+func mintAPIKey(key *domain.APIKey) (string, error) {
+	token, err := utils.GenerateToken(32)
+	if err != nil {
+		return "", err
+	}
+	plain := domain.APIKeyPrefix + token
+	key.Prefix = plain[:apiKeyPrefixLength]
+	key.Hash = HashToken(plain)
+	return plain, nil
+}
+
+// keyOwner returns the user managing API keys, keys cannot mint other keys
+// and partner clients have no user to own them.
+func keyOwner(ctx context.Context) (*auth.Principal, error) {
+	caller := auth.FromContext(ctx)
+	if caller == nil || caller.UserID == "" || caller.Method == auth.MethodAPIKey {
+		return nil, domain.ErrForbidden
+	}
+	return caller, nil
+}
+
+func (as *APIKeyService) CreateAPIKey(ctx context.Context, req *domain.CreateAPIKeyRequest) (*domain.APIKey, error) {
+	caller, err := keyOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", domain.ErrBadParamInput)
+	}
+	if len(req.Scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", domain.ErrBadParamInput)
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(domain.APIKeyScopes, scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q", domain.ErrBadParamInput, scope)
+		}
+	}
+	scopes := slices.Clone(req.Scopes)
+	slices.Sort(scopes)
+	scopes = slices.Compact(scopes)
+	// Keys cannot do more than their owner, writing content needs an editor
+	for _, scope := range scopes {
+		if roles, ok := domain.ScopeRoles[scope]; ok && !caller.HasRole(roles...) {
+			return nil, fmt.Errorf("%w: the %s scope needs the %s role", domain.ErrForbidden, scope, strings.Join(roles, " or "))
+		}
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(domain.DefaultAPIKeyLifetime)
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) {
+			return nil, fmt.Errorf("%w: expires_at must be in the future", domain.ErrBadParamInput)
+		}
+		expiresAt = *req.ExpiresAt
+	}
+
+	key := &domain.APIKey{
+		Name:      name,
+		OwnerID:   caller.UserID,
+		Scopes:    scopes,
+		ExpiresAt: &expiresAt,
+	}
+	plain, err := mintAPIKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := as.apiKeyRepo.CreateAPIKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	created.Key = plain
+
+	as.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionCreate,
+		EntityType: domain.AuditEntityAPIKey,
+		EntityID:   created.ID,
+		After:      domain.APIKey{ID: created.ID, Name: created.Name, OwnerID: created.OwnerID, Prefix: created.Prefix, Scopes: created.Scopes, ExpiresAt: created.ExpiresAt},
+	})
+	return created, nil
+}
+
+// GetAPIKeys lists the keys of the caller, admins see the keys of every user.
+func (as *APIKeyService) GetAPIKeys(ctx context.Context) ([]domain.APIKey, error) {
+	caller, err := keyOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerID := caller.UserID
+	if caller.HasRole(domain.RoleAdmin) {
+		ownerID = ""
+	}
+	return as.apiKeyRepo.GetAPIKeys(ctx, ownerID)
+}
+
+// getOwnedKey loads a key the caller may manage, keys of other users are
+// reported as not found unless the caller is an admin.
+func (as *APIKeyService) getOwnedKey(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	caller, err := keyOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := as.apiKeyRepo.GetAPIKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if key.OwnerID != caller.UserID && !caller.HasRole(domain.RoleAdmin) {
+		return nil, domain.ErrNotFound
+	}
+	return key, nil
+}
+
+// RotateAPIKey mints a replacement with the same name, scopes and lifetime.
+// The old key keeps working until the end of the overlap window so clients
+// can switch without downtime.
+func (as *APIKeyService) RotateAPIKey(ctx context.Context, id uuid.UUID, req *domain.RotateAPIKeyRequest) (*domain.APIKey, error) {
+	overlap := time.Duration(req.OverlapSeconds) * time.Second
+	if overlap < 0 || overlap > domain.MaxAPIKeyOverlap {
+		return nil, fmt.Errorf("%w: overlap_seconds must be between 0 and %d", domain.ErrBadParamInput, int(domain.MaxAPIKeyOverlap.Seconds()))
+	}
+	if overlap == 0 {
+		overlap = domain.DefaultAPIKeyOverlap
+	}
+
+	old, err := as.getOwnedKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !old.Active(now) {
+		return nil, fmt.Errorf("%w: revoked or expired keys cannot be rotated", domain.ErrBadParamInput)
+	}
+
+	replacement := &domain.APIKey{
+		Name:        old.Name,
+		OwnerID:     old.OwnerID,
+		Scopes:      old.Scopes,
+		RotatedFrom: old.ID,
+	}
+	if old.ExpiresAt != nil {
+		expiresAt := now.Add(old.ExpiresAt.Sub(old.CreatedAt))
+		replacement.ExpiresAt = &expiresAt
+	}
+	plain, err := mintAPIKey(replacement)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := as.apiKeyRepo.RotateAPIKey(ctx, id, replacement, now.Add(overlap))
+	if err != nil {
+		return nil, err
+	}
+	created.Key = plain
+
+	as.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionRotate,
+		EntityType: domain.AuditEntityAPIKey,
+		EntityID:   old.ID,
+		After:      map[string]any{"replaced_by": created.ID, "overlap_seconds": int(overlap.Seconds())},
+	})
+	return created, nil
+}
+
+// RevokeAPIKey stops a key from authenticating right away.
+func (as *APIKeyService) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	if _, err := as.getOwnedKey(ctx, id); err != nil {
+		return err
+	}
+	if err := as.apiKeyRepo.RevokeAPIKey(ctx, id); err != nil {
+		return err
+	}
+
+	as.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionRevoke,
+		EntityType: domain.AuditEntityAPIKey,
+		EntityID:   id.String(),
+	})
+	return nil
+}
+
+// AuthenticateAPIKey checks a plain API key and returns the caller acting on
+// behalf of the key owner. The caller never inherits the role of the owner,
+// only the scopes of the key the owner still holds the role for. Unknown,
+// revoked and expired keys and keys of deleted users all yield
+// ErrInvalidCredentials.
+func (as *APIKeyService) AuthenticateAPIKey(ctx context.Context, plain string) (*auth.Principal, error) {
+	if !strings.HasPrefix(plain, domain.APIKeyPrefix) {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	key, err := as.apiKeyRepo.GetAPIKeyByHash(ctx, HashToken(plain))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, domain.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if !key.Active(now) {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	as.trackUsage(key.ID, now)
+	return &auth.Principal{
+		UserID: key.OwnerID,
+		KeyID:  key.ID,
+		Name:   key.Name,
+		Scopes: ownerScopes(key),
+		Method: auth.MethodAPIKey,
+	}, nil
+}
+
+// ownerScopes drops the scopes of a key whose owner lost the role they need,
+// so that demoting a user also demotes their keys
+func ownerScopes(key *domain.APIKey) []string {
+	owner := &auth.Principal{Role: key.OwnerRole}
+	scopes := make([]string, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		if roles, ok := domain.ScopeRoles[scope]; ok && !owner.HasRole(roles...) {
+			continue
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes
+}
+
+func (as *APIKeyService) trackUsage(keyID string, at time.Time) {
+	as.addUsage(domain.APIKeyUsage{KeyID: keyID, Requests: 1, LastUsed: at})
+}
+
+func (as *APIKeyService) addUsage(usage ...domain.APIKeyUsage) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	for _, add := range usage {
+		u, ok := as.usage[add.KeyID]
+		if !ok {
+			u = &domain.APIKeyUsage{KeyID: add.KeyID}
+			as.usage[add.KeyID] = u
+		}
+		u.Requests += add.Requests
+		if add.LastUsed.After(u.LastUsed) {
+			u.LastUsed = add.LastUsed
+		}
+	}
+}
+
+// FlushUsage writes the usage accumulated since the last flush. When the
+// write fails the usage is kept for the next flush.
+func (as *APIKeyService) FlushUsage(ctx context.Context) error {
+	as.mu.Lock()
+	pending := as.usage
+	as.usage = make(map[string]*domain.APIKeyUsage)
+	as.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+	usage := make([]domain.APIKeyUsage, 0, len(pending))
+	for _, u := range pending {
+		usage = append(usage, *u)
+	}
+
+	if err := as.apiKeyRepo.RecordAPIKeyUsage(ctx, usage); err != nil {
+		as.addUsage(usage...)
+		return err
+	}
+	return nil
+}
+
+// RunUsageFlusher flushes the key usage every interval until ctx is done,
+// then flushes one last time.
+func (as *APIKeyService) RunUsageFlusher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := as.FlushUsage(flushCtx); err != nil {
+				logging.LogError(flushCtx, err, "api_key_usage_flush")
+			}
+			return
+		case <-ticker.C:
+			if err := as.FlushUsage(ctx); err != nil {
+				logging.LogError(ctx, err, "api_key_usage_flush")
+			}
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const keyOwnerID = "2d9f6b1e-4c3a-4e8f-b1d2-7a6c5e4f3b2a"
+
+func keyOwnerContext(role string) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{UserID: keyOwnerID, Role: role, Method: auth.MethodBasic})
+}
+
+func TestAPIKeyService_CreateAPIKey(t *testing.T) {
+	t.Run("Mints a hashed key with sorted unique scopes", func(t *testing.T) {
+		mockAPIKeyRepo := new(mocks.APIKeyRepository)
+		apiKeyService := service.NewAPIKeyService(mockAPIKeyRepo)
+
+		var stored *domain.APIKey
+		mockAPIKeyRepo.On("CreateAPIKey", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { stored = args.Get(1).(*domain.APIKey) }).
+			Return(func(_ context.Context, key *domain.APIKey) *domain.APIKey {
+				created := *key
+				created.ID = uuid.NewString()
+				return &created
+			}, nil).Once()
+
+		key, err := apiKeyService.CreateAPIKey(keyOwnerContext(domain.RoleEditor), &domain.CreateAPIKeyRequest{
+			Name:   "importer",
+			Scopes: []string{domain.ScopeNewsWrite, domain.ScopeNewsRead, domain.ScopeNewsWrite},
+		})
+
+		assert.NoError(t, err)
+		assert.Contains(t, key.Key, domain.APIKeyPrefix)
+		assert.Equal(t, service.HashToken(key.Key), stored.Hash)
+		assert.Equal(t, key.Key[:len(stored.Prefix)], stored.Prefix)
+		assert.Equal(t, []string{domain.ScopeNewsRead, domain.ScopeNewsWrite}, stored.Scopes)
+		assert.Equal(t, keyOwnerID, stored.OwnerID)
+		assert.WithinDuration(t, time.Now().Add(domain.DefaultAPIKeyLifetime), *stored.ExpiresAt, time.Minute)
+		mockAPIKeyRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects unknown scopes", func(t *testing.T) {
+		mockAPIKeyRepo := new(mocks.APIKeyRepository)
+		apiKeyService := service.NewAPIKeyService(mockAPIKeyRepo)
+
+		key, err := apiKeyService.CreateAPIKey(keyOwnerContext(domain.RoleUser), &domain.CreateAPIKeyRequest{
+			Name:   "importer",
+			Scopes: []string{"users:write"},
+		})
+
+		assert.ErrorIs(t, err, domain.ErrBadParamInput)
+		assert.Nil(t, key)
+		mockAPIKeyRepo.AssertNotCalled(t, "CreateAPIKey", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Users cannot mint keys writing news", func(t *testing.T) {
+		mockAPIKeyRepo := new(mocks.APIKeyRepository)
+		apiKeyService := service.NewAPIKeyService(mockAPIKeyRepo)
+
+		key, err := apiKeyService.CreateAPIKey(keyOwnerContext(domain.RoleUser), &domain.CreateAPIKeyRequest{
+			Name:   "importer",
+			Scopes: []string{domain.ScopeNewsRead, domain.ScopeNewsWrite},
+		})
+
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		assert.Nil(t, key)
+		mockAPIKeyRepo.AssertNotCalled(t, "CreateAPIKey", mock.Anything, mock.Anything)
+	})
+
+	t.Run("API key callers cannot mint keys", func(t *testing.T) {
+		mockAPIKeyRepo := new(mocks.APIKeyRepository)
+		apiKeyService := service.NewAPIKeyService(mockAPIKeyRepo)
+
+		ctx := auth.WithPrincipal(context.Background(), &auth.Principal{UserID: keyOwnerID, Method: auth.MethodAPIKey})
+		key, err := apiKeyService.CreateAPIKey(ctx, &domain.CreateAPIKeyRequest{
+			Name:   "escalation",
+			Scopes: []string{domain.ScopeNewsWrite},
+		})
+
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		assert.Nil(t, key)
+	})
+}
+
+func TestAPIKeyService_AuthenticateAPIKey(t *testing.T) {
+	plain := domain.APIKeyPrefix + "0123456789abcdef"
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	t.Run("Returns a scoped caller without the owner role and tracks usage", func(t *testing.T) {
+		mockAPIKeyRepo := new(mocks.APIKeyRepository)
+		apiKeyService := service.NewAPIKeyService(mockAPIKeyRepo)
+
+		key := &domain.APIKey{ID: "k-1", OwnerID: keyOwnerID, Name: "importer", Scopes: []string{domain.ScopeNewsRead}, ExpiresAt: &future}
+		mockAPIKeyRepo.On("GetAPIKeyByHash", mock.Anything, service.HashToken(plain)).Return(key, nil).Twice()
+		mockAPIKeyRepo.On("RecordAPIKeyUsage", mock.Anything, mock.MatchedBy(func(usage []domain.APIKeyUsage) bool {
+			return len(usage) == 1 && usage[0].KeyID == "k-1" && usage[0].Requests == 2
+		})).Return(nil).Once()
+
+		principal, err := apiKeyService.AuthenticateAPIKey(context.Background(), plain)
+		assert.NoError(t, err)
+		_, err = apiKeyService.AuthenticateAPIKey(context.Background(), plain)
+		assert.NoError(t, err)
+
+		assert.Equal(t, keyOwnerID, principal.UserID)
+		assert.Equal(t, "k-1", principal.KeyID)
+		assert.Empty(t, principal.Role)
+		assert.True(t, principal.HasScope(domain.ScopeNewsRead))
+		assert.False(t, principal.HasScope(domain.ScopeNewsWrite))
+
+		assert.NoError(t, apiKeyService.FlushUsage(context.Background()))
+		// Nothing is left to flush
+		assert.NoError(t, apiKeyService.FlushUsage(context.Background()))
+		mockAPIKeyRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects expired and revoked keys", func(t *testing.T) {
+		for name, key := range map[string]*domain.APIKey{
+			"expired": {ID: "k-1", ExpiresAt: &past},
+			"revoked": {ID: "k-1", ExpiresAt: &future, RevokedAt: &past},
+		} {
+			t.Run(name, func(t *testing.T) {
+				mockAPIKeyRepo := new(mocks.APIKeyRepository)
+				apiKeyService := service.NewAPIKeyService(mockAPIKeyRepo)
+				mockAPIKeyRepo.On("GetAPIKeyByHash", mock.Anything, service.HashToken(plain)).Return(key, nil).Once()
+
+				principal, err := apiKeyService.AuthenticateAPIKey(context.Background(), plain)
+
+				assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+				assert.Nil(t, principal)
+			})
+		}
+	})
+
+	t.Run("Drops the scopes the owner lost the role for", func(t *testing.T) {
+		for role, canWrite := range map[string]bool{
+			domain.RoleEditor: true,
+			domain.RoleUser:   false,
+		} {
+			t.Run(role, func(t *testing.T) {
+				mockAPIKeyRepo := new(mocks.APIKeyRepository)
+				apiKeyService := service.NewAPIKeyService(mockAPIKeyRepo)
+				key := &domain.APIKey{ID: "k-1", OwnerID: keyOwnerID, OwnerRole: role, Scopes: []string{domain.ScopeNewsRead, domain.ScopeNewsWrite}, ExpiresAt: &future}
+				mockAPIKeyRepo.On("GetAPIKeyByHash", mock.Anything, service.HashToken(plain)).Return(key, nil).Once()
+
+				principal, err := apiKeyService.AuthenticateAPIKey(context.Background(), plain)
+
+				assert.NoError(t, err)
+				assert.True(t, principal.HasScope(domain.ScopeNewsRead))
+				assert.Equal(t, canWrite, principal.HasScope(domain.ScopeNewsWrite))
+			})
+		}
+	})
+
+	t.Run("Rejects unknown keys", func(t *testing.T) {
+		mockAPIKeyRepo := new(mocks.APIKeyRepository)
+		apiKeyService := service.NewAPIKeyService(mockAPIKeyRepo)
+		mockAPIKeyRepo.On("GetAPIKeyByHash", mock.Anything, mock.Anything).Return(nil, domain.ErrNotFound).Once()
+
+		principal, err := apiKeyService.AuthenticateAPIKey(context.Background(), plain)
+
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+		assert.Nil(t, principal)
+	})
+}
+
+func TestAPIKeyService_RotateAPIKey(t *testing.T) {
+	id := uuid.New()
+	created := time.Now().Add(-24 * time.Hour)
+	expires := created.Add(30 * 24 * time.Hour)
+
+	t.Run("Mints a replacement and keeps the old key during the overlap", func(t *testing.T) {
+		mockAPIKeyRepo := new(mocks.APIKeyRepository)
+		apiKeyService := service.NewAPIKeyService(mockAPIKeyRepo)
+
+		old := &domain.APIKey{ID: id.String(), OwnerID: keyOwnerID, Name: "importer", Scopes: []string{domain.ScopeNewsRead}, CreatedAt: created, ExpiresAt: &expires}
+		mockAPIKeyRepo.On("GetAPIKey", mock.Anything, id).Return(old, nil).Once()
+		mockAPIKeyRepo.On("RotateAPIKey", mock.Anything, id,
+			mock.MatchedBy(func(k *domain.APIKey) bool {
+				return k.RotatedFrom == id.String() && k.Name == "importer" && k.Hash != "" &&
+					k.ExpiresAt.Sub(time.Now()) > 29*24*time.Hour
+			}),
+			mock.MatchedBy(func(until time.Time) bool {
+				return until.Sub(time.Now()) > 59*time.Minute && until.Sub(time.Now()) <= time.Hour
+			}),
+		).Return(&domain.APIKey{ID: uuid.NewString()}, nil).Once()
+
+		key, err := apiKeyService.RotateAPIKey(keyOwnerContext(domain.RoleUser), id, &domain.RotateAPIKeyRequest{OverlapSeconds: 3600})
+
+		assert.NoError(t, err)
+		assert.Contains(t, key.Key, domain.APIKeyPrefix)
+		mockAPIKeyRepo.AssertExpectations(t)
+	})
+
+	t.Run("Hides the keys of other users", func(t *testing.T) {
+		mockAPIKeyRepo := new(mocks.APIKeyRepository)
+		apiKeyService := service.NewAPIKeyService(mockAPIKeyRepo)
+
+		other := &domain.APIKey{ID: id.String(), OwnerID: uuid.NewString(), CreatedAt: created, ExpiresAt: &expires}
+		mockAPIKeyRepo.On("GetAPIKey", mock.Anything, id).Return(other, nil).Once()
+
+		key, err := apiKeyService.RotateAPIKey(keyOwnerContext(domain.RoleUser), id, &domain.RotateAPIKeyRequest{})
+
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.Nil(t, key)
+		mockAPIKeyRepo.AssertNotCalled(t, "RotateAPIKey", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAPIKeyService_RevokeAPIKey(t *testing.T) {
+	id := uuid.New()
+
+	t.Run("Admins can revoke the keys of other users", func(t *testing.T) {
+		mockAPIKeyRepo := new(mocks.APIKeyRepository)
+		apiKeyService := service.NewAPIKeyService(mockAPIKeyRepo)
+
+		mockAPIKeyRepo.On("GetAPIKey", mock.Anything, id).Return(&domain.APIKey{ID: id.String(), OwnerID: uuid.NewString()}, nil).Once()
+		mockAPIKeyRepo.On("RevokeAPIKey", mock.Anything, id).Return(nil).Once()
+
+		err := apiKeyService.RevokeAPIKey(keyOwnerContext(domain.RoleAdmin), id)
+
+		assert.NoError(t, err)
+		mockAPIKeyRepo.AssertExpectations(t)
+	})
+}
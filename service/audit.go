@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/google/uuid"
+)
+
+type AuditRepository interface {
+	CreateAuditEvents(ctx context.Context, events []domain.AuditEvent) error
+	GetAuditEvents(ctx context.Context, filter *domain.AuditFilter) ([]domain.AuditEvent, int, error)
+	DeleteAuditEventsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// AuditEntry describes a single change, Before and After are the entity as it
+// was and as it is now and are reduced to the changed fields when recorded.
+type AuditEntry struct {
+	Action     string
+	EntityType string
+	EntityID   string
+	Before     any
+	After      any
+}
+
+// Auditor records changes made by the services. Recording is best effort, a
+// failure is logged and never fails the change itself. The entries are
+// written after the change was committed, outside of its transaction: an
+// entry is lost when writing it fails or the process stops in between.
+type Auditor interface {
+	Record(ctx context.Context, entries ...AuditEntry)
+}
+
+type nopAuditor struct{}
+
+func (nopAuditor) Record(context.Context, ...AuditEntry) {}
+
+// auditIgnoredFields change on every write and only add noise to the diff
+var auditIgnoredFields = []string{"updated_at"}
+
+type AuditService struct {
+	auditRepo AuditRepository
+	// retention is how long events are kept, zero keeps them forever
+	retention time.Duration
+}
+
+func NewAuditService(a AuditRepository, retention time.Duration) *AuditService {
+	return &AuditService{
+		auditRepo: a,
+		retention: retention,
+	}
+}
+
+// Record stores the entries together with the caller, request ID and client
+// IP found in the context. The caller is the user, or the owner of the API
+// key, or else the partner client. Updates that changed nothing are dropped.
+func (as *AuditService) Record(ctx context.Context, entries ...AuditEntry) {
+	var actorID, clientID, method string
+	if caller := auth.FromContext(ctx); caller != nil {
+		actorID, clientID, method = caller.UserID, caller.ClientID, caller.Method
+	}
+	request := auth.RequestFromContext(ctx)
+
+	events := make([]domain.AuditEvent, 0, len(entries))
+	for _, entry := range entries {
+		before, after, err := auditDiff(entry.Before, entry.After)
+		if err != nil {
+			logging.LogError(ctx, err, "audit_diff",
+				slog.String("entity_type", entry.EntityType),
+				slog.String("entity_id", entry.EntityID),
+			)
+			continue
+		}
+		if entry.Action == domain.AuditActionUpdate && before == nil && after == nil {
+			continue
+		}
+
+		events = append(events, domain.AuditEvent{
+			ActorID:       actorID,
+			ActorClientID: clientID,
+			AuthMethod:    method,
+			Action:        entry.Action,
+			EntityType:    entry.EntityType,
+			EntityID:      entry.EntityID,
+			Before:        before,
+			After:         after,
+			RequestID:     request.ID,
+			ClientIP:      request.ClientIP,
+		})
+		logging.LogBusinessEvent(ctx, entry.Action, entry.EntityType, entry.EntityID,
+			slog.String("actor_id", actorID),
+			slog.String("actor_client_id", clientID),
+		)
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	if err := as.auditRepo.CreateAuditEvents(ctx, events); err != nil {
+		logging.LogError(ctx, err, "audit_record", slog.Int("events", len(events)))
+	}
+}
+
+// GetAuditEvents lists the recorded events matching the filter, newest first,
+// along with the total number of matches.
+func (as *AuditService) GetAuditEvents(ctx context.Context, filter *domain.AuditFilter) ([]domain.AuditEvent, int, error) {
+	filter.Normalize()
+	if filter.ActorID != "" {
+		if _, err := uuid.Parse(filter.ActorID); err != nil {
+			return nil, 0, fmt.Errorf("%w: invalid actor id", domain.ErrBadParamInput)
+		}
+	}
+
+	events, total, err := as.auditRepo.GetAuditEvents(ctx, filter)
+	if err != nil {
+		logging.LogDataAccess(ctx, "audit_events", "list", "error")
+		return nil, 0, err
+	}
+	logging.LogDataAccess(ctx, "audit_events", "list", "success")
+	return events, total, nil
+}
+
+// Purge deletes the events older than the retention period.
+func (as *AuditService) Purge(ctx context.Context) (int64, error) {
+	if as.retention <= 0 {
+		return 0, nil
+	}
+	return as.auditRepo.DeleteAuditEventsBefore(ctx, time.Now().Add(-as.retention))
+}
+
+// RunRetention purges expired events right away and then on every interval
+// until the context is cancelled.
+func (as *AuditService) RunRetention(ctx context.Context, interval time.Duration) {
+	if as.retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		deleted, err := as.Purge(ctx)
+		if err != nil {
+			logging.LogError(ctx, err, "audit_retention")
+		} else if deleted > 0 {
+			logging.LogInfo(ctx, "Expired audit events purged", slog.Int64("deleted", deleted))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// auditDiff reduces before and after to the fields that differ between them.
+// When only one side is given it is kept whole.
+func auditDiff(before, after any) (json.RawMessage, json.RawMessage, error) {
+	b, err := auditFields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	a, err := auditFields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if b != nil && a != nil {
+		for key, value := range b {
+			if other, ok := a[key]; ok && reflect.DeepEqual(value, other) {
+				delete(b, key)
+				delete(a, key)
+			}
+		}
+	}
+
+	beforeJSON, err := marshalAuditFields(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterJSON, err := marshalAuditFields(a)
+	if err != nil {
+		return nil, nil, err
+	}
+	return beforeJSON, afterJSON, nil
+}
+
+func auditFields(v any) (map[string]any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	for _, key := range auditIgnoredFields {
+		delete(fields, key)
+	}
+	return fields, nil
+}
+
+func marshalAuditFields(fields map[string]any) (json.RawMessage, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(fields)
+}
+
+// bulkAuditEntries turns the applied entries of a bulk result into audit
+// entries, saved entries carry their request payload.
+func bulkAuditEntries[T any](entityType string, result *domain.BulkResult, items []T) []AuditEntry {
+	if result == nil {
+		return nil
+	}
+
+	entries := make([]AuditEntry, 0, result.Succeeded)
+	for _, item := range result.Items {
+		if item.Error != "" {
+			continue
+		}
+		entry := AuditEntry{EntityType: entityType, EntityID: item.ID}
+		switch item.Action {
+		case domain.BulkActionCreated:
+			entry.Action = domain.AuditActionCreate
+			entry.After = items[item.Index]
+		case domain.BulkActionUpdated:
+			entry.Action = domain.AuditActionUpdate
+			entry.Before = item.Before
+			entry.After = items[item.Index]
+		case domain.BulkActionDeleted:
+			entry.Action = domain.AuditActionDelete
+			entry.Before = item.Before
+		default:
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
@@ -0,0 +1,233 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func auditContext() context.Context {
+	ctx := auth.WithRequest(context.Background(), auth.Request{ID: "req-1", ClientIP: "203.0.113.7"})
+	return auth.WithPrincipal(ctx, &auth.Principal{UserID: "6f1c8a52-7c1e-4b8e-9d43-1a2b3c4d5e6f"})
+}
+
+func TestAuditService_Record(t *testing.T) {
+	t.Run("Records only the changed fields with the request metadata", func(t *testing.T) {
+		mockAuditRepo := new(mocks.AuditRepository)
+		auditService := service.NewAuditService(mockAuditRepo, 0)
+
+		var recorded []domain.AuditEvent
+		mockAuditRepo.On("CreateAuditEvents", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { recorded = args.Get(1).([]domain.AuditEvent) }).
+			Return(nil).Once()
+
+		auditService.Record(auditContext(), service.AuditEntry{
+			Action:     domain.AuditActionUpdate,
+			EntityType: domain.AuditEntityTopic,
+			EntityID:   "topic-1",
+			Before:     domain.Topic{ID: "topic-1", Name: "Old", Slug: "old"},
+			After:      domain.Topic{ID: "topic-1", Name: "New", Slug: "old", UpdatedAt: time.Now()},
+		})
+
+		if assert.Len(t, recorded, 1) {
+			event := recorded[0]
+			assert.Equal(t, "6f1c8a52-7c1e-4b8e-9d43-1a2b3c4d5e6f", event.ActorID)
+			assert.Equal(t, "req-1", event.RequestID)
+			assert.Equal(t, "203.0.113.7", event.ClientIP)
+			assert.JSONEq(t, `{"name":"Old"}`, string(event.Before))
+			assert.JSONEq(t, `{"name":"New"}`, string(event.After))
+		}
+		mockAuditRepo.AssertExpectations(t)
+	})
+
+	t.Run("Records the partner client and the authentication method", func(t *testing.T) {
+		mockAuditRepo := new(mocks.AuditRepository)
+		auditService := service.NewAuditService(mockAuditRepo, 0)
+
+		var recorded []domain.AuditEvent
+		mockAuditRepo.On("CreateAuditEvents", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { recorded = args.Get(1).([]domain.AuditEvent) }).
+			Return(nil).Once()
+
+		ctx := auth.WithPrincipal(context.Background(), &auth.Principal{ClientID: "wire-agency", Method: auth.MethodSignature})
+		auditService.Record(ctx, service.AuditEntry{
+			Action:     domain.AuditActionCreate,
+			EntityType: domain.AuditEntityNews,
+			EntityID:   "news-1",
+			After:      domain.News{ID: "news-1", Title: "Banjir"},
+		})
+
+		if assert.Len(t, recorded, 1) {
+			assert.Empty(t, recorded[0].ActorID)
+			assert.Equal(t, "wire-agency", recorded[0].ActorClientID)
+			assert.Equal(t, auth.MethodSignature, recorded[0].AuthMethod)
+		}
+		mockAuditRepo.AssertExpectations(t)
+	})
+
+	t.Run("Skips updates that changed nothing", func(t *testing.T) {
+		mockAuditRepo := new(mocks.AuditRepository)
+		auditService := service.NewAuditService(mockAuditRepo, 0)
+
+		topic := domain.Topic{ID: "topic-1", Name: "Same", Slug: "same"}
+		auditService.Record(auditContext(), service.AuditEntry{
+			Action:     domain.AuditActionUpdate,
+			EntityType: domain.AuditEntityTopic,
+			EntityID:   "topic-1",
+			Before:     topic,
+			After:      topic,
+		})
+
+		mockAuditRepo.AssertNotCalled(t, "CreateAuditEvents", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Does not fail when the repository fails", func(t *testing.T) {
+		mockAuditRepo := new(mocks.AuditRepository)
+		auditService := service.NewAuditService(mockAuditRepo, 0)
+
+		mockAuditRepo.On("CreateAuditEvents", mock.Anything, mock.Anything).Return(errors.New("db down")).Once()
+
+		assert.NotPanics(t, func() {
+			auditService.Record(context.Background(), service.AuditEntry{
+				Action:     domain.AuditActionDelete,
+				EntityType: domain.AuditEntityNews,
+				EntityID:   "news-1",
+				Before:     domain.News{ID: "news-1"},
+			})
+		})
+		mockAuditRepo.AssertExpectations(t)
+	})
+}
+
+func TestNewsService_UpdateNews_RecordsAudit(t *testing.T) {
+	mockNewsRepo := new(mocks.NewsRepository)
+	mockAuditRepo := new(mocks.AuditRepository)
+	newsService := service.NewNewsService(mockNewsRepo,
+		service.WithAuditor(service.NewAuditService(mockAuditRepo, 0)))
+
+	id := uuid.New()
+	existing := &domain.News{ID: id.String(), Title: "Old title", Status: "draft", Content: "Body"}
+	mockNewsRepo.On("GetNews", mock.Anything, id).Return(existing, nil).Once()
+	mockNewsRepo.On("UpdateNews", mock.Anything, id, mock.Anything).Return(existing, nil).Once()
+	mockAuditRepo.On("CreateAuditEvents", mock.Anything, mock.MatchedBy(func(events []domain.AuditEvent) bool {
+		if len(events) != 1 {
+			return false
+		}
+		var before, after map[string]any
+		_ = json.Unmarshal(events[0].Before, &before)
+		_ = json.Unmarshal(events[0].After, &after)
+		return events[0].Action == domain.AuditActionUpdate &&
+			events[0].EntityType == domain.AuditEntityNews &&
+			events[0].EntityID == id.String() &&
+			before["title"] == "Old title" && after["title"] == "New title" &&
+			before["status"] == nil
+	})).Return(nil).Once()
+
+	_, err := newsService.UpdateNews(auditContext(), id, &domain.News{Title: "New title", Status: "draft", Content: "Body"})
+
+	assert.NoError(t, err)
+	mockNewsRepo.AssertExpectations(t)
+	mockAuditRepo.AssertExpectations(t)
+}
+
+func TestNewsService_BulkSaveNews_RecordsAudit(t *testing.T) {
+	mockNewsRepo := new(mocks.NewsRepository)
+	mockAuditRepo := new(mocks.AuditRepository)
+	newsService := service.NewNewsService(mockNewsRepo,
+		service.WithAuditor(service.NewAuditService(mockAuditRepo, 0)))
+
+	id := uuid.NewString()
+	item := domain.BulkNewsItem{ID: id, Title: "New title", Status: "draft", Content: "Body"}
+	mockNewsRepo.On("BulkSaveNews", mock.Anything, mock.Anything, false).
+		Return([]domain.BulkItemResult{{
+			Index:  0,
+			ID:     id,
+			Action: domain.BulkActionUpdated,
+			Before: domain.BulkNewsItem{ID: id, Title: "Old title", Status: "draft", Content: "Body"},
+		}}, nil).Once()
+	mockAuditRepo.On("CreateAuditEvents", mock.Anything, mock.MatchedBy(func(events []domain.AuditEvent) bool {
+		if len(events) != 1 {
+			return false
+		}
+		var before, after map[string]any
+		_ = json.Unmarshal(events[0].Before, &before)
+		_ = json.Unmarshal(events[0].After, &after)
+		return events[0].Action == domain.AuditActionUpdate &&
+			events[0].EntityID == id &&
+			before["title"] == "Old title" && after["title"] == "New title" &&
+			before["status"] == nil
+	})).Return(nil).Once()
+
+	_, err := newsService.BulkSaveNews(auditContext(), &domain.BulkNewsRequest{Items: []domain.BulkNewsItem{item}})
+
+	assert.NoError(t, err)
+	mockNewsRepo.AssertExpectations(t)
+	mockAuditRepo.AssertExpectations(t)
+}
+
+func TestAuditService_GetAuditEvents(t *testing.T) {
+	t.Run("Normalizes the pagination", func(t *testing.T) {
+		mockAuditRepo := new(mocks.AuditRepository)
+		auditService := service.NewAuditService(mockAuditRepo, 0)
+
+		filter := &domain.AuditFilter{EntityType: domain.AuditEntityNews, PageRequest: domain.PageRequest{PerPage: 1000}}
+		mockAuditRepo.On("GetAuditEvents", mock.Anything, filter).Return([]domain.AuditEvent{{ID: "1"}}, 1, nil).Once()
+
+		events, total, err := auditService.GetAuditEvents(context.Background(), filter)
+
+		assert.NoError(t, err)
+		assert.Len(t, events, 1)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, 1, filter.Page)
+		assert.Equal(t, domain.MaxPerPage, filter.PerPage)
+		mockAuditRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects an invalid actor", func(t *testing.T) {
+		mockAuditRepo := new(mocks.AuditRepository)
+		auditService := service.NewAuditService(mockAuditRepo, 0)
+
+		_, _, err := auditService.GetAuditEvents(context.Background(), &domain.AuditFilter{ActorID: "nope"})
+
+		assert.ErrorIs(t, err, domain.ErrBadParamInput)
+		mockAuditRepo.AssertNotCalled(t, "GetAuditEvents", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuditService_Purge(t *testing.T) {
+	t.Run("Deletes the events older than the retention", func(t *testing.T) {
+		mockAuditRepo := new(mocks.AuditRepository)
+		auditService := service.NewAuditService(mockAuditRepo, 30*24*time.Hour)
+
+		mockAuditRepo.On("DeleteAuditEventsBefore", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+			return time.Since(before) > 29*24*time.Hour && time.Since(before) < 31*24*time.Hour
+		})).Return(int64(3), nil).Once()
+
+		deleted, err := auditService.Purge(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), deleted)
+		mockAuditRepo.AssertExpectations(t)
+	})
+
+	t.Run("Keeps everything without a retention", func(t *testing.T) {
+		mockAuditRepo := new(mocks.AuditRepository)
+		auditService := service.NewAuditService(mockAuditRepo, 0)
+
+		deleted, err := auditService.Purge(context.Background())
+
+		assert.NoError(t, err)
+		assert.Zero(t, deleted)
+		mockAuditRepo.AssertNotCalled(t, "DeleteAuditEventsBefore", mock.Anything, mock.Anything)
+	})
+}
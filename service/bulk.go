@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/google/uuid"
+)
+
+// runBulk validates every entry, hands the valid ones to save and merges the
+// per-item results back into request order. In all-or-nothing mode a single
+// invalid entry aborts the whole operation before anything is written.
+func runBulk[T any](
+	ctx context.Context,
+	items []T,
+	allOrNothing bool,
+	validate func(T) error,
+	save func(context.Context, []T, bool) ([]domain.BulkItemResult, error),
+) (*domain.BulkResult, error) {
+	if len(items) == 0 || len(items) > domain.MaxBulkItems {
+		return nil, fmt.Errorf("%w: expected between 1 and %d items", domain.ErrBadParamInput, domain.MaxBulkItems)
+	}
+
+	result := &domain.BulkResult{
+		AllOrNothing: allOrNothing,
+		Items:        make([]domain.BulkItemResult, len(items)),
+	}
+
+	valid := make([]T, 0, len(items))
+	positions := make([]int, 0, len(items))
+	for i, item := range items {
+		result.Items[i].Index = i
+		if err := validate(item); err != nil {
+			result.Items[i].Error = err.Error()
+			continue
+		}
+		valid = append(valid, item)
+		positions = append(positions, i)
+	}
+
+	var err error
+	if allOrNothing && len(valid) < len(items) {
+		err = domain.ErrBulkAborted
+	} else if len(valid) > 0 {
+		var saved []domain.BulkItemResult
+		saved, err = save(ctx, valid, allOrNothing)
+		if err != nil && !errors.Is(err, domain.ErrBulkAborted) {
+			return nil, err
+		}
+		for j, r := range saved {
+			r.Index = positions[j]
+			result.Items[positions[j]] = r
+		}
+	}
+
+	if errors.Is(err, domain.ErrBulkAborted) {
+		abortBulkResult(result)
+	}
+	result.Tally()
+	return result, err
+}
+
+// abortBulkResult marks every entry that did not fail on its own as rolled
+// back, created IDs are dropped since they were never persisted.
+func abortBulkResult(result *domain.BulkResult) {
+	for i := range result.Items {
+		item := &result.Items[i]
+		if item.Error != "" {
+			continue
+		}
+		if item.Action == domain.BulkActionCreated {
+			item.ID = ""
+		}
+		item.Action = ""
+		item.Error = domain.ErrBulkAborted.Error()
+	}
+}
+
+func validateOptionalID(id string) error {
+	if id == "" {
+		return nil
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return fmt.Errorf("invalid id: %s", id)
+	}
+	return nil
+}
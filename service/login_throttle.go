@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+)
+
+type LoginThrottleRepository interface {
+	GetLoginThrottles(ctx context.Context, keys []string) ([]domain.LoginThrottle, error)
+	RecordLoginFailure(ctx context.Context, key string, window time.Duration, maxFailures int, lockout time.Duration) (*domain.LoginThrottle, error)
+	ClearLoginFailures(ctx context.Context, keys ...string) error
+}
+
+type LoginThrottleConfig struct {
+	// MaxAccountFailures failed logins lock an account out
+	MaxAccountFailures int
+	// MaxIPFailures failed logins lock a client IP out, whatever the
+	// accounts it tried
+	MaxIPFailures int
+	// Window is the quiet period after which failure counters restart
+	Window time.Duration
+	// Lockout is how long a locked account or client IP is refused
+	Lockout time.Duration
+	// Delay slows the logins of an account down after its first failure,
+	// growing with every failure
+	Delay Backoff
+}
+
+var DefaultLoginThrottleConfig = LoginThrottleConfig{
+	MaxAccountFailures: 5,
+	MaxIPFailures:      50,
+	Window:             15 * time.Minute,
+	Lockout:            15 * time.Minute,
+	Delay:              Backoff{Base: 250 * time.Millisecond, Max: 4 * time.Second},
+}
+
+func accountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// loginThrottle guards logins against brute force. Counters live in the
+// database so every replica sees the same failures.
+type loginThrottle struct {
+	repo   LoginThrottleRepository
+	config LoginThrottleConfig
+}
+
+// before refuses logins of locked keys and otherwise waits the progressive
+// delay of the account. It returns the failures recorded for the account.
+func (t *loginThrottle) before(ctx context.Context, accountKey, ipKey string) (int, error) {
+	keys := []string{accountKey}
+	if ipKey != "" {
+		keys = append(keys, ipKey)
+	}
+	throttles, err := t.repo.GetLoginThrottles(ctx, keys)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	failures := 0
+	for _, throttle := range throttles {
+		if throttle.Locked(now) {
+			logging.LogSecurityEvent(ctx, "login_refused_locked", slog.String("key", throttle.Key))
+			return 0, &domain.AccountLockedError{RetryAfter: throttle.LockedUntil.Sub(now)}
+		}
+		if throttle.Key == accountKey && now.Sub(throttle.LastFailureAt) < t.config.Window {
+			failures = throttle.Failures
+		}
+	}
+
+	if failures > 0 {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(t.config.Delay.Delay(failures)):
+		}
+	}
+	return failures, nil
+}
+
+// failed counts a failed login against the account and the client IP,
+// reporting the lockouts it causes. The repository locks a key again on every
+// failure past the limit, e.g. once an earlier lockout ran out within the
+// window, so each of them is reported.
+func (t *loginThrottle) failed(ctx context.Context, accountKey, ipKey string) {
+	limits := map[string]int{accountKey: t.config.MaxAccountFailures}
+	if ipKey != "" {
+		limits[ipKey] = t.config.MaxIPFailures
+	}
+
+	for key, max := range limits {
+		throttle, err := t.repo.RecordLoginFailure(ctx, key, t.config.Window, max, t.config.Lockout)
+		if err != nil {
+			logging.LogError(ctx, err, "record_login_failure", slog.String("key", key))
+			continue
+		}
+		if throttle.Locked(time.Now()) && throttle.Failures >= max {
+			logging.LogSecurityEvent(ctx, "login_lockout",
+				slog.String("key", key),
+				slog.Int("failures", throttle.Failures),
+				slog.Time("locked_until", *throttle.LockedUntil),
+			)
+		}
+	}
+}
+
+// succeeded forgets the failures of an account. The client IP keeps its
+// count so one valid account cannot launder the guesses made on others.
+func (t *loginThrottle) succeeded(ctx context.Context, accountKey string) {
+	if err := t.repo.ClearLoginFailures(ctx, accountKey); err != nil {
+		logging.LogError(ctx, err, "clear_login_failures")
+	}
+}
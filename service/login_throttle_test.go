@@ -0,0 +1,223 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service/mocks"
+	"github.com/edwinjordan/ZOGTest-Golang.git/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testLoginThrottleConfig = service.LoginThrottleConfig{
+	MaxAccountFailures: 3,
+	MaxIPFailures:      10,
+	Window:             15 * time.Minute,
+	Lockout:            15 * time.Minute,
+	Delay:              service.Backoff{Base: time.Millisecond, Max: 2 * time.Millisecond},
+}
+
+func TestUserService_AuthenticateThrottle(t *testing.T) {
+	ctx := auth.WithRequest(context.Background(), auth.Request{ClientIP: "203.0.113.7"})
+	keys := []string{"account:jane@example.com", "ip:203.0.113.7"}
+
+	hash, err := utils.HashPassword("Password1234")
+	assert.NoError(t, err)
+	creds := &domain.UserCredentials{
+		User:         domain.User{ID: uuid.New().String(), Email: "jane@example.com", Status: domain.UserStatusActive},
+		PasswordHash: hash,
+	}
+
+	t.Run("Refuses a locked account without checking the password", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockThrottleRepo := new(mocks.LoginThrottleRepository)
+		userService := service.NewUserService(mockUserRepo, service.WithLoginThrottle(mockThrottleRepo, testLoginThrottleConfig))
+
+		lockedUntil := time.Now().Add(10 * time.Minute)
+		mockThrottleRepo.On("GetLoginThrottles", mock.Anything, keys).Return([]domain.LoginThrottle{
+			{Key: "account:jane@example.com", Failures: 3, LastFailureAt: time.Now(), LockedUntil: &lockedUntil},
+		}, nil).Once()
+
+		principal, err := userService.Authenticate(ctx, "Jane@example.com", "Password1234")
+
+		var locked *domain.AccountLockedError
+		assert.True(t, errors.As(err, &locked))
+		assert.ErrorIs(t, err, domain.ErrAccountLocked)
+		assert.InDelta(t, 10*time.Minute, locked.RetryAfter, float64(time.Second))
+		assert.Nil(t, principal)
+		mockUserRepo.AssertNotCalled(t, "GetUserCredentials", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Refuses every account from a locked client IP", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockThrottleRepo := new(mocks.LoginThrottleRepository)
+		userService := service.NewUserService(mockUserRepo, service.WithLoginThrottle(mockThrottleRepo, testLoginThrottleConfig))
+
+		lockedUntil := time.Now().Add(time.Minute)
+		mockThrottleRepo.On("GetLoginThrottles", mock.Anything, keys).Return([]domain.LoginThrottle{
+			{Key: "ip:203.0.113.7", Failures: 10, LastFailureAt: time.Now(), LockedUntil: &lockedUntil},
+		}, nil).Once()
+
+		_, err := userService.Authenticate(ctx, "jane@example.com", "Password1234")
+
+		assert.ErrorIs(t, err, domain.ErrAccountLocked)
+	})
+
+	t.Run("Records failures against the account and the client IP", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockThrottleRepo := new(mocks.LoginThrottleRepository)
+		userService := service.NewUserService(mockUserRepo, service.WithLoginThrottle(mockThrottleRepo, testLoginThrottleConfig))
+
+		lockedUntil := time.Now().Add(15 * time.Minute)
+		mockThrottleRepo.On("GetLoginThrottles", mock.Anything, keys).Return([]domain.LoginThrottle{
+			{Key: "account:jane@example.com", Failures: 2, LastFailureAt: time.Now()},
+		}, nil).Once()
+		mockUserRepo.On("GetUserCredentials", mock.Anything, "jane@example.com").Return(creds, nil).Once()
+		mockThrottleRepo.On("RecordLoginFailure", mock.Anything, "account:jane@example.com", 15*time.Minute, 3, 15*time.Minute).
+			Return(&domain.LoginThrottle{Key: "account:jane@example.com", Failures: 3, LastFailureAt: time.Now(), LockedUntil: &lockedUntil}, nil).Once()
+		mockThrottleRepo.On("RecordLoginFailure", mock.Anything, "ip:203.0.113.7", 15*time.Minute, 10, 15*time.Minute).
+			Return(&domain.LoginThrottle{Key: "ip:203.0.113.7", Failures: 3, LastFailureAt: time.Now()}, nil).Once()
+
+		principal, err := userService.Authenticate(ctx, "jane@example.com", "wrong")
+
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+		assert.Nil(t, principal)
+		mockThrottleRepo.AssertExpectations(t)
+	})
+
+	t.Run("Reports a lockout that starts again after an earlier one ran out", func(t *testing.T) {
+		var buf bytes.Buffer
+		previous := slog.Default()
+		slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+		t.Cleanup(func() { slog.SetDefault(previous) })
+
+		mockUserRepo := new(mocks.UserRepository)
+		mockThrottleRepo := new(mocks.LoginThrottleRepository)
+		userService := service.NewUserService(mockUserRepo, service.WithLoginThrottle(mockThrottleRepo, testLoginThrottleConfig))
+
+		expired := time.Now().Add(-time.Minute)
+		lockedUntil := time.Now().Add(15 * time.Minute)
+		mockThrottleRepo.On("GetLoginThrottles", mock.Anything, keys).Return([]domain.LoginThrottle{
+			{Key: "account:jane@example.com", Failures: 3, LastFailureAt: time.Now(), LockedUntil: &expired},
+		}, nil).Once()
+		mockUserRepo.On("GetUserCredentials", mock.Anything, "jane@example.com").Return(creds, nil).Once()
+		mockThrottleRepo.On("RecordLoginFailure", mock.Anything, "account:jane@example.com", 15*time.Minute, 3, 15*time.Minute).
+			Return(&domain.LoginThrottle{Key: "account:jane@example.com", Failures: 4, LastFailureAt: time.Now(), LockedUntil: &lockedUntil}, nil).Once()
+		mockThrottleRepo.On("RecordLoginFailure", mock.Anything, "ip:203.0.113.7", 15*time.Minute, 10, 15*time.Minute).
+			Return(&domain.LoginThrottle{Key: "ip:203.0.113.7", Failures: 4, LastFailureAt: time.Now()}, nil).Once()
+
+		_, err := userService.Authenticate(ctx, "jane@example.com", "wrong")
+
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+		assert.Contains(t, buf.String(), `"security_event":"login_lockout","key":"account:jane@example.com","failures":4`)
+		assert.NotContains(t, buf.String(), `"security_event":"login_lockout","key":"ip:203.0.113.7"`)
+	})
+
+	t.Run("Counts unknown emails as failures", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockThrottleRepo := new(mocks.LoginThrottleRepository)
+		userService := service.NewUserService(mockUserRepo, service.WithLoginThrottle(mockThrottleRepo, testLoginThrottleConfig))
+
+		mockThrottleRepo.On("GetLoginThrottles", mock.Anything, []string{"account:nobody@example.com", "ip:203.0.113.7"}).Return(nil, nil).Once()
+		mockUserRepo.On("GetUserCredentials", mock.Anything, "nobody@example.com").Return(nil, domain.ErrUserNotFound).Once()
+		mockThrottleRepo.On("RecordLoginFailure", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(&domain.LoginThrottle{Failures: 1, LastFailureAt: time.Now()}, nil).Twice()
+
+		_, err := userService.Authenticate(ctx, "nobody@example.com", "Password1234")
+
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+		mockThrottleRepo.AssertExpectations(t)
+	})
+
+	t.Run("Clears the account failures after a successful login", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockThrottleRepo := new(mocks.LoginThrottleRepository)
+		userService := service.NewUserService(mockUserRepo, service.WithLoginThrottle(mockThrottleRepo, testLoginThrottleConfig))
+
+		mockThrottleRepo.On("GetLoginThrottles", mock.Anything, keys).Return([]domain.LoginThrottle{
+			{Key: "account:jane@example.com", Failures: 2, LastFailureAt: time.Now()},
+			{Key: "ip:203.0.113.7", Failures: 4, LastFailureAt: time.Now()},
+		}, nil).Once()
+		mockUserRepo.On("GetUserCredentials", mock.Anything, "jane@example.com").Return(creds, nil).Once()
+		mockThrottleRepo.On("ClearLoginFailures", mock.Anything, []string{"account:jane@example.com"}).Return(nil).Once()
+
+		principal, err := userService.Authenticate(ctx, "jane@example.com", "Password1234")
+
+		assert.NoError(t, err)
+		assert.Equal(t, creds.User.ID, principal.UserID)
+		mockThrottleRepo.AssertExpectations(t)
+	})
+
+	t.Run("Gives up waiting when the request is cancelled", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockThrottleRepo := new(mocks.LoginThrottleRepository)
+		slow := testLoginThrottleConfig
+		slow.Delay = service.Backoff{Base: time.Hour, Max: time.Hour}
+		userService := service.NewUserService(mockUserRepo, service.WithLoginThrottle(mockThrottleRepo, slow))
+
+		mockThrottleRepo.On("GetLoginThrottles", mock.Anything, keys).Return([]domain.LoginThrottle{
+			{Key: "account:jane@example.com", Failures: 1, LastFailureAt: time.Now()},
+		}, nil).Once()
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := userService.Authenticate(cancelled, "jane@example.com", "Password1234")
+
+		assert.ErrorIs(t, err, context.Canceled)
+		mockUserRepo.AssertNotCalled(t, "GetUserCredentials", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUserService_UnlockUser(t *testing.T) {
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New().String(), Email: "Jane@example.com"}
+	id := uuid.MustParse(user.ID)
+
+	t.Run("Clears the account and client IP", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockThrottleRepo := new(mocks.LoginThrottleRepository)
+		userService := service.NewUserService(mockUserRepo, service.WithLoginThrottle(mockThrottleRepo, testLoginThrottleConfig))
+
+		mockUserRepo.On("GetUser", mock.Anything, id).Return(user, nil).Once()
+		mockThrottleRepo.On("ClearLoginFailures", mock.Anything, []string{"account:jane@example.com", "ip:203.0.113.7"}).Return(nil).Once()
+
+		err := userService.UnlockUser(ctx, id, &domain.UnlockUserRequest{IP: "203.0.113.7"})
+
+		assert.NoError(t, err)
+		mockThrottleRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects an invalid IP", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockThrottleRepo := new(mocks.LoginThrottleRepository)
+		userService := service.NewUserService(mockUserRepo, service.WithLoginThrottle(mockThrottleRepo, testLoginThrottleConfig))
+
+		mockUserRepo.On("GetUser", mock.Anything, id).Return(user, nil).Once()
+
+		err := userService.UnlockUser(ctx, id, &domain.UnlockUserRequest{IP: "not-an-ip"})
+
+		assert.ErrorIs(t, err, domain.ErrBadParamInput)
+		mockThrottleRepo.AssertNotCalled(t, "ClearLoginFailures")
+	})
+
+	t.Run("Returns ErrUserNotFound for an unknown user", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockThrottleRepo := new(mocks.LoginThrottleRepository)
+		userService := service.NewUserService(mockUserRepo, service.WithLoginThrottle(mockThrottleRepo, testLoginThrottleConfig))
+
+		mockUserRepo.On("GetUser", mock.Anything, id).Return(nil, domain.ErrUserNotFound).Once()
+
+		err := userService.UnlockUser(ctx, id, &domain.UnlockUserRequest{})
+
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	})
+}
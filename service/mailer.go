@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+)
+
+// Mailer delivers emails to users
+type Mailer interface {
+	Send(ctx context.Context, email domain.Email) error
+}
+
+// nopMailer drops emails when no mailer is configured, the body is never
+// logged since it may hold one-time tokens
+type nopMailer struct{}
+
+func (nopMailer) Send(ctx context.Context, email domain.Email) error {
+	logging.LogWarn(ctx, "Email not sent, no mailer configured", slog.String("subject", email.Subject))
+	return nil
+}
+
+// sendEmail delivers an email on a best effort basis, failures are logged
+func sendEmail(ctx context.Context, mailer Mailer, email domain.Email) {
+	if err := mailer.Send(ctx, email); err != nil {
+		logging.LogError(ctx, err, "send_email", slog.String("subject", email.Subject))
+	}
+}
@@ -0,0 +1,19 @@
+package service
+
+import "context"
+
+// OperationRecorder counts the business operations of the services, entity
+// is one of the domain.AuditEntity values
+type OperationRecorder interface {
+	RecordOperation(ctx context.Context, entity, operation string, success bool)
+}
+
+type nopOperationRecorder struct{}
+
+func (nopOperationRecorder) RecordOperation(context.Context, string, string, bool) {}
+
+// countOperation records the outcome of an operation, it is deferred with a
+// pointer to the named error result of the operation
+func (o *serviceOptions) countOperation(ctx context.Context, entity, operation string, err *error) {
+	o.operations.RecordOperation(ctx, entity, operation, *err == nil)
+}
@@ -0,0 +1,52 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type recordedOperation struct {
+	entity, operation string
+	success           bool
+}
+
+type fakeOperationRecorder struct {
+	operations []recordedOperation
+}
+
+func (r *fakeOperationRecorder) RecordOperation(_ context.Context, entity, operation string, success bool) {
+	r.operations = append(r.operations, recordedOperation{entity, operation, success})
+}
+
+func TestWithMetrics_CountsOperations(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.NewsRepository)
+	recorder := &fakeOperationRecorder{}
+	newsService := service.NewNewsService(repo, service.WithMetrics(recorder))
+	req := &domain.CreateNewsRequest{Title: "Test News", Slug: "test-news"}
+	id := uuid.New()
+
+	repo.On("CreateNews", mock.Anything, req).Return(&domain.News{ID: id.String()}, nil).Once()
+	repo.On("GetNews", mock.Anything, id).Return(nil, errors.New("connection refused")).Once()
+	repo.On("GetNewsList", mock.Anything, mock.Anything).Return(nil, nil).Once()
+
+	_, err := newsService.CreateNews(ctx, req)
+	assert.NoError(t, err)
+	err = newsService.DeleteNews(ctx, id)
+	assert.Error(t, err)
+	_, err = newsService.GetNewsList(ctx, &domain.NewsFilter{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []recordedOperation{
+		{domain.AuditEntityNews, "create", true},
+		{domain.AuditEntityNews, "delete", false},
+	}, recorder.operations, "reads are not business operations")
+}
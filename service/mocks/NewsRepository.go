@@ -367,3 +367,151 @@ func (_c *NewsRepository_UpdateNews_Call) RunAndReturn(run func(ctx context.Cont
 	_c.Call.Return(run)
 	return _c
 }
+
+// BulkSaveNews provides a mock function for the type NewsRepository
+func (_mock *NewsRepository) BulkSaveNews(ctx context.Context, items []domain.BulkNewsItem, allOrNothing bool) ([]domain.BulkItemResult, error) {
+	ret := _mock.Called(ctx, items, allOrNothing)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BulkSaveNews")
+	}
+
+	var r0 []domain.BulkItemResult
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []domain.BulkNewsItem, bool) ([]domain.BulkItemResult, error)); ok {
+		return returnFunc(ctx, items, allOrNothing)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []domain.BulkNewsItem, bool) []domain.BulkItemResult); ok {
+		r0 = returnFunc(ctx, items, allOrNothing)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.BulkItemResult)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []domain.BulkNewsItem, bool) error); ok {
+		r1 = returnFunc(ctx, items, allOrNothing)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// NewsRepository_BulkSaveNews_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BulkSaveNews'
+type NewsRepository_BulkSaveNews_Call struct {
+	*mock.Call
+}
+
+// BulkSaveNews is a helper method to define mock.On call
+//   - ctx context.Context
+//   - items []domain.BulkNewsItem
+//   - allOrNothing bool
+func (_e *NewsRepository_Expecter) BulkSaveNews(ctx interface{}, items interface{}, allOrNothing interface{}) *NewsRepository_BulkSaveNews_Call {
+	return &NewsRepository_BulkSaveNews_Call{Call: _e.mock.On("BulkSaveNews", ctx, items, allOrNothing)}
+}
+
+func (_c *NewsRepository_BulkSaveNews_Call) Run(run func(ctx context.Context, items []domain.BulkNewsItem, allOrNothing bool)) *NewsRepository_BulkSaveNews_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []domain.BulkNewsItem
+		if args[1] != nil {
+			arg1 = args[1].([]domain.BulkNewsItem)
+		}
+		var arg2 bool
+		if args[2] != nil {
+			arg2 = args[2].(bool)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *NewsRepository_BulkSaveNews_Call) Return(r0 []domain.BulkItemResult, err error) *NewsRepository_BulkSaveNews_Call {
+	_c.Call.Return(r0, err)
+	return _c
+}
+
+func (_c *NewsRepository_BulkSaveNews_Call) RunAndReturn(run func(ctx context.Context, items []domain.BulkNewsItem, allOrNothing bool) ([]domain.BulkItemResult, error)) *NewsRepository_BulkSaveNews_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// BulkDeleteNews provides a mock function for the type NewsRepository
+func (_mock *NewsRepository) BulkDeleteNews(ctx context.Context, ids []uuid.UUID, allOrNothing bool) ([]domain.BulkItemResult, error) {
+	ret := _mock.Called(ctx, ids, allOrNothing)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BulkDeleteNews")
+	}
+
+	var r0 []domain.BulkItemResult
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []uuid.UUID, bool) ([]domain.BulkItemResult, error)); ok {
+		return returnFunc(ctx, ids, allOrNothing)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []uuid.UUID, bool) []domain.BulkItemResult); ok {
+		r0 = returnFunc(ctx, ids, allOrNothing)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.BulkItemResult)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []uuid.UUID, bool) error); ok {
+		r1 = returnFunc(ctx, ids, allOrNothing)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// NewsRepository_BulkDeleteNews_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BulkDeleteNews'
+type NewsRepository_BulkDeleteNews_Call struct {
+	*mock.Call
+}
+
+// BulkDeleteNews is a helper method to define mock.On call
+//   - ctx context.Context
+//   - ids []uuid.UUID
+//   - allOrNothing bool
+func (_e *NewsRepository_Expecter) BulkDeleteNews(ctx interface{}, ids interface{}, allOrNothing interface{}) *NewsRepository_BulkDeleteNews_Call {
+	return &NewsRepository_BulkDeleteNews_Call{Call: _e.mock.On("BulkDeleteNews", ctx, ids, allOrNothing)}
+}
+
+func (_c *NewsRepository_BulkDeleteNews_Call) Run(run func(ctx context.Context, ids []uuid.UUID, allOrNothing bool)) *NewsRepository_BulkDeleteNews_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []uuid.UUID
+		if args[1] != nil {
+			arg1 = args[1].([]uuid.UUID)
+		}
+		var arg2 bool
+		if args[2] != nil {
+			arg2 = args[2].(bool)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *NewsRepository_BulkDeleteNews_Call) Return(r0 []domain.BulkItemResult, err error) *NewsRepository_BulkDeleteNews_Call {
+	_c.Call.Return(r0, err)
+	return _c
+}
+
+func (_c *NewsRepository_BulkDeleteNews_Call) RunAndReturn(run func(ctx context.Context, ids []uuid.UUID, allOrNothing bool) ([]domain.BulkItemResult, error)) *NewsRepository_BulkDeleteNews_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -367,3 +367,149 @@ func (_c *TopicRepository_UpdateTopic_Call) RunAndReturn(run func(ctx context.Co
 	_c.Call.Return(run)
 	return _c
 }
+
+// BulkSaveTopics provides a mock function for the type TopicRepository
+func (_mock *TopicRepository) BulkSaveTopics(ctx context.Context, items []domain.BulkTopicItem, allOrNothing bool) ([]domain.BulkItemResult, error) {
+	ret := _mock.Called(ctx, items, allOrNothing)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BulkSaveTopics")
+	}
+
+	var r0 []domain.BulkItemResult
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []domain.BulkTopicItem, bool) ([]domain.BulkItemResult, error)); ok {
+		return returnFunc(ctx, items, allOrNothing)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []domain.BulkTopicItem, bool) []domain.BulkItemResult); ok {
+		r0 = returnFunc(ctx, items, allOrNothing)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.BulkItemResult)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []domain.BulkTopicItem, bool) error); ok {
+		r1 = returnFunc(ctx, items, allOrNothing)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// TopicRepository_BulkSaveTopics_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BulkSaveTopics'
+type TopicRepository_BulkSaveTopics_Call struct {
+	*mock.Call
+}
+
+// BulkSaveTopics is a helper method to define mock.On call
+//   - ctx context.Context
+//   - items []domain.BulkTopicItem
+//   - allOrNothing bool
+func (_e *TopicRepository_Expecter) BulkSaveTopics(ctx interface{}, items interface{}, allOrNothing interface{}) *TopicRepository_BulkSaveTopics_Call {
+	return &TopicRepository_BulkSaveTopics_Call{Call: _e.mock.On("BulkSaveTopics", ctx, items, allOrNothing)}
+}
+
+func (_c *TopicRepository_BulkSaveTopics_Call) Run(run func(ctx context.Context, items []domain.BulkTopicItem, allOrNothing bool)) *TopicRepository_BulkSaveTopics_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []domain.BulkTopicItem
+		if args[1] != nil {
+			arg1 = args[1].([]domain.BulkTopicItem)
+		}
+		var arg2 bool
+		if args[2] != nil {
+			arg2 = args[2].(bool)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *TopicRepository_BulkSaveTopics_Call) Return(r0 []domain.BulkItemResult, err error) *TopicRepository_BulkSaveTopics_Call {
+	_c.Call.Return(r0, err)
+	return _c
+}
+
+func (_c *TopicRepository_BulkSaveTopics_Call) RunAndReturn(run func(ctx context.Context, items []domain.BulkTopicItem, allOrNothing bool) ([]domain.BulkItemResult, error)) *TopicRepository_BulkSaveTopics_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MergeTopics provides a mock function for the type TopicRepository
+func (_mock *TopicRepository) MergeTopics(ctx context.Context, sourceID uuid.UUID, targetID uuid.UUID) (int64, error) {
+	ret := _mock.Called(ctx, sourceID, targetID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MergeTopics")
+	}
+
+	var r0 int64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) (int64, error)); ok {
+		return returnFunc(ctx, sourceID, targetID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) int64); ok {
+		r0 = returnFunc(ctx, sourceID, targetID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r1 = returnFunc(ctx, sourceID, targetID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// TopicRepository_MergeTopics_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MergeTopics'
+type TopicRepository_MergeTopics_Call struct {
+	*mock.Call
+}
+
+// MergeTopics is a helper method to define mock.On call
+//   - ctx context.Context
+//   - sourceID uuid.UUID
+//   - targetID uuid.UUID
+func (_e *TopicRepository_Expecter) MergeTopics(ctx interface{}, sourceID interface{}, targetID interface{}) *TopicRepository_MergeTopics_Call {
+	return &TopicRepository_MergeTopics_Call{Call: _e.mock.On("MergeTopics", ctx, sourceID, targetID)}
+}
+
+func (_c *TopicRepository_MergeTopics_Call) Run(run func(ctx context.Context, sourceID uuid.UUID, targetID uuid.UUID)) *TopicRepository_MergeTopics_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uuid.UUID
+		if args[1] != nil {
+			arg1 = args[1].(uuid.UUID)
+		}
+		var arg2 uuid.UUID
+		if args[2] != nil {
+			arg2 = args[2].(uuid.UUID)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *TopicRepository_MergeTopics_Call) Return(r0 int64, err error) *TopicRepository_MergeTopics_Call {
+	_c.Call.Return(r0, err)
+	return _c
+}
+
+func (_c *TopicRepository_MergeTopics_Call) RunAndReturn(run func(ctx context.Context, sourceID uuid.UUID, targetID uuid.UUID) (int64, error)) *TopicRepository_MergeTopics_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -6,6 +6,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
 	"github.com/google/uuid"
@@ -40,8 +41,8 @@ func (_m *UserRepository) EXPECT() *UserRepository_Expecter {
 }
 
 // CreateUser provides a mock function for the type UserRepository
-func (_mock *UserRepository) CreateUser(ctx context.Context, user *domain.CreateUserRequest) (*domain.User, error) {
-	ret := _mock.Called(ctx, user)
+func (_mock *UserRepository) CreateUser(ctx context.Context, user *domain.CreateUserRequest, passwordHash string) (*domain.User, error) {
+	ret := _mock.Called(ctx, user, passwordHash)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateUser")
@@ -49,18 +50,18 @@ func (_mock *UserRepository) CreateUser(ctx context.Context, user *domain.Create
 
 	var r0 *domain.User
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, *domain.CreateUserRequest) (*domain.User, error)); ok {
-		return returnFunc(ctx, user)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *domain.CreateUserRequest, string) (*domain.User, error)); ok {
+		return returnFunc(ctx, user, passwordHash)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, *domain.CreateUserRequest) *domain.User); ok {
-		r0 = returnFunc(ctx, user)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *domain.CreateUserRequest, string) *domain.User); ok {
+		r0 = returnFunc(ctx, user, passwordHash)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.User)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, *domain.CreateUserRequest) error); ok {
-		r1 = returnFunc(ctx, user)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *domain.CreateUserRequest, string) error); ok {
+		r1 = returnFunc(ctx, user, passwordHash)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -75,11 +76,12 @@ type UserRepository_CreateUser_Call struct {
 // CreateUser is a helper method to define mock.On call
 //   - ctx context.Context
 //   - user *domain.CreateUserRequest
-func (_e *UserRepository_Expecter) CreateUser(ctx interface{}, user interface{}) *UserRepository_CreateUser_Call {
-	return &UserRepository_CreateUser_Call{Call: _e.mock.On("CreateUser", ctx, user)}
+//   - passwordHash string
+func (_e *UserRepository_Expecter) CreateUser(ctx interface{}, user interface{}, passwordHash interface{}) *UserRepository_CreateUser_Call {
+	return &UserRepository_CreateUser_Call{Call: _e.mock.On("CreateUser", ctx, user, passwordHash)}
 }
 
-func (_c *UserRepository_CreateUser_Call) Run(run func(ctx context.Context, user *domain.CreateUserRequest)) *UserRepository_CreateUser_Call {
+func (_c *UserRepository_CreateUser_Call) Run(run func(ctx context.Context, user *domain.CreateUserRequest, passwordHash string)) *UserRepository_CreateUser_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -89,20 +91,25 @@ func (_c *UserRepository_CreateUser_Call) Run(run func(ctx context.Context, user
 		if args[1] != nil {
 			arg1 = args[1].(*domain.CreateUserRequest)
 		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
 		run(
 			arg0,
 			arg1,
+			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *UserRepository_CreateUser_Call) Return(user1 *domain.User, err error) *UserRepository_CreateUser_Call {
-	_c.Call.Return(user1, err)
+func (_c *UserRepository_CreateUser_Call) Return(r0 *domain.User, err error) *UserRepository_CreateUser_Call {
+	_c.Call.Return(r0, err)
 	return _c
 }
 
-func (_c *UserRepository_CreateUser_Call) RunAndReturn(run func(ctx context.Context, user *domain.CreateUserRequest) (*domain.User, error)) *UserRepository_CreateUser_Call {
+func (_c *UserRepository_CreateUser_Call) RunAndReturn(run func(ctx context.Context, user *domain.CreateUserRequest, passwordHash string) (*domain.User, error)) *UserRepository_CreateUser_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -373,3 +380,1163 @@ func (_c *UserRepository_UpdateUser_Call) RunAndReturn(run func(ctx context.Cont
 	_c.Call.Return(run)
 	return _c
 }
+
+// GetUserCredentials provides a mock function for the type UserRepository
+func (_mock *UserRepository) GetUserCredentials(ctx context.Context, email string) (*domain.UserCredentials, error) {
+	ret := _mock.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserCredentials")
+	}
+
+	var r0 *domain.UserCredentials
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*domain.UserCredentials, error)); ok {
+		return returnFunc(ctx, email)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *domain.UserCredentials); ok {
+		r0 = returnFunc(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.UserCredentials)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// UserRepository_GetUserCredentials_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserCredentials'
+type UserRepository_GetUserCredentials_Call struct {
+	*mock.Call
+}
+
+// GetUserCredentials is a helper method to define mock.On call
+//   - ctx context.Context
+//   - email string
+func (_e *UserRepository_Expecter) GetUserCredentials(ctx interface{}, email interface{}) *UserRepository_GetUserCredentials_Call {
+	return &UserRepository_GetUserCredentials_Call{Call: _e.mock.On("GetUserCredentials", ctx, email)}
+}
+
+func (_c *UserRepository_GetUserCredentials_Call) Run(run func(ctx context.Context, email string)) *UserRepository_GetUserCredentials_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *UserRepository_GetUserCredentials_Call) Return(r0 *domain.UserCredentials, err error) *UserRepository_GetUserCredentials_Call {
+	_c.Call.Return(r0, err)
+	return _c
+}
+
+func (_c *UserRepository_GetUserCredentials_Call) RunAndReturn(run func(ctx context.Context, email string) (*domain.UserCredentials, error)) *UserRepository_GetUserCredentials_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetPasswordHash provides a mock function for the type UserRepository
+func (_mock *UserRepository) GetPasswordHash(ctx context.Context, id uuid.UUID) (string, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPasswordHash")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID) (string, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID) string); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// UserRepository_GetPasswordHash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPasswordHash'
+type UserRepository_GetPasswordHash_Call struct {
+	*mock.Call
+}
+
+// GetPasswordHash is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+func (_e *UserRepository_Expecter) GetPasswordHash(ctx interface{}, id interface{}) *UserRepository_GetPasswordHash_Call {
+	return &UserRepository_GetPasswordHash_Call{Call: _e.mock.On("GetPasswordHash", ctx, id)}
+}
+
+func (_c *UserRepository_GetPasswordHash_Call) Run(run func(ctx context.Context, id uuid.UUID)) *UserRepository_GetPasswordHash_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uuid.UUID
+		if args[1] != nil {
+			arg1 = args[1].(uuid.UUID)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *UserRepository_GetPasswordHash_Call) Return(r0 string, err error) *UserRepository_GetPasswordHash_Call {
+	_c.Call.Return(r0, err)
+	return _c
+}
+
+func (_c *UserRepository_GetPasswordHash_Call) RunAndReturn(run func(ctx context.Context, id uuid.UUID) (string, error)) *UserRepository_GetPasswordHash_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdatePassword provides a mock function for the type UserRepository
+func (_mock *UserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	ret := _mock.Called(ctx, id, passwordHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePassword")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) error); ok {
+		r0 = returnFunc(ctx, id, passwordHash)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// UserRepository_UpdatePassword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdatePassword'
+type UserRepository_UpdatePassword_Call struct {
+	*mock.Call
+}
+
+// UpdatePassword is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+//   - passwordHash string
+func (_e *UserRepository_Expecter) UpdatePassword(ctx interface{}, id interface{}, passwordHash interface{}) *UserRepository_UpdatePassword_Call {
+	return &UserRepository_UpdatePassword_Call{Call: _e.mock.On("UpdatePassword", ctx, id, passwordHash)}
+}
+
+func (_c *UserRepository_UpdatePassword_Call) Run(run func(ctx context.Context, id uuid.UUID, passwordHash string)) *UserRepository_UpdatePassword_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uuid.UUID
+		if args[1] != nil {
+			arg1 = args[1].(uuid.UUID)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *UserRepository_UpdatePassword_Call) Return(err error) *UserRepository_UpdatePassword_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *UserRepository_UpdatePassword_Call) RunAndReturn(run func(ctx context.Context, id uuid.UUID, passwordHash string) error) *UserRepository_UpdatePassword_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreatePasswordResetToken provides a mock function for the type UserRepository
+func (_mock *UserRepository) CreatePasswordResetToken(ctx context.Context, userID string, tokenHash string, expiresAt time.Time) error {
+	ret := _mock.Called(ctx, userID, tokenHash, expiresAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePasswordResetToken")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, time.Time) error); ok {
+		r0 = returnFunc(ctx, userID, tokenHash, expiresAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// UserRepository_CreatePasswordResetToken_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreatePasswordResetToken'
+type UserRepository_CreatePasswordResetToken_Call struct {
+	*mock.Call
+}
+
+// CreatePasswordResetToken is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+//   - tokenHash string
+//   - expiresAt time.Time
+func (_e *UserRepository_Expecter) CreatePasswordResetToken(ctx interface{}, userID interface{}, tokenHash interface{}, expiresAt interface{}) *UserRepository_CreatePasswordResetToken_Call {
+	return &UserRepository_CreatePasswordResetToken_Call{Call: _e.mock.On("CreatePasswordResetToken", ctx, userID, tokenHash, expiresAt)}
+}
+
+func (_c *UserRepository_CreatePasswordResetToken_Call) Run(run func(ctx context.Context, userID string, tokenHash string, expiresAt time.Time)) *UserRepository_CreatePasswordResetToken_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 time.Time
+		if args[3] != nil {
+			arg3 = args[3].(time.Time)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *UserRepository_CreatePasswordResetToken_Call) Return(err error) *UserRepository_CreatePasswordResetToken_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *UserRepository_CreatePasswordResetToken_Call) RunAndReturn(run func(ctx context.Context, userID string, tokenHash string, expiresAt time.Time) error) *UserRepository_CreatePasswordResetToken_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResetPassword provides a mock function for the type UserRepository
+func (_mock *UserRepository) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (string, error) {
+	ret := _mock.Called(ctx, tokenHash, passwordHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetPassword")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+		return returnFunc(ctx, tokenHash, passwordHash)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = returnFunc(ctx, tokenHash, passwordHash)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, tokenHash, passwordHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// UserRepository_ResetPassword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResetPassword'
+type UserRepository_ResetPassword_Call struct {
+	*mock.Call
+}
+
+// ResetPassword is a helper method to define mock.On call
+//   - ctx context.Context
+//   - tokenHash string
+//   - passwordHash string
+func (_e *UserRepository_Expecter) ResetPassword(ctx interface{}, tokenHash interface{}, passwordHash interface{}) *UserRepository_ResetPassword_Call {
+	return &UserRepository_ResetPassword_Call{Call: _e.mock.On("ResetPassword", ctx, tokenHash, passwordHash)}
+}
+
+func (_c *UserRepository_ResetPassword_Call) Run(run func(ctx context.Context, tokenHash string, passwordHash string)) *UserRepository_ResetPassword_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *UserRepository_ResetPassword_Call) Return(r0 string, err error) *UserRepository_ResetPassword_Call {
+	_c.Call.Return(r0, err)
+	return _c
+}
+
+func (_c *UserRepository_ResetPassword_Call) RunAndReturn(run func(ctx context.Context, tokenHash string, passwordHash string) (string, error)) *UserRepository_ResetPassword_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ActivateUser provides a mock function for the type UserRepository
+func (_mock *UserRepository) ActivateUser(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ActivateUser")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*domain.User, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID) *domain.User); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// UserRepository_ActivateUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ActivateUser'
+type UserRepository_ActivateUser_Call struct {
+	*mock.Call
+}
+
+// ActivateUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+func (_e *UserRepository_Expecter) ActivateUser(ctx interface{}, id interface{}) *UserRepository_ActivateUser_Call {
+	return &UserRepository_ActivateUser_Call{Call: _e.mock.On("ActivateUser", ctx, id)}
+}
+
+func (_c *UserRepository_ActivateUser_Call) Run(run func(ctx context.Context, id uuid.UUID)) *UserRepository_ActivateUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uuid.UUID
+		if args[1] != nil {
+			arg1 = args[1].(uuid.UUID)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *UserRepository_ActivateUser_Call) Return(r0 *domain.User, err error) *UserRepository_ActivateUser_Call {
+	_c.Call.Return(r0, err)
+	return _c
+}
+
+func (_c *UserRepository_ActivateUser_Call) RunAndReturn(run func(ctx context.Context, id uuid.UUID) (*domain.User, error)) *UserRepository_ActivateUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ClaimVerificationResend provides a mock function for the type UserRepository
+func (_mock *UserRepository) ClaimVerificationResend(ctx context.Context, email string, cooldown time.Duration) (*domain.User, error) {
+	ret := _mock.Called(ctx, email, cooldown)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimVerificationResend")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Duration) (*domain.User, error)); ok {
+		return returnFunc(ctx, email, cooldown)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Duration) *domain.User); ok {
+		r0 = returnFunc(ctx, email, cooldown)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, time.Duration) error); ok {
+		r1 = returnFunc(ctx, email, cooldown)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// UserRepository_ClaimVerificationResend_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClaimVerificationResend'
+type UserRepository_ClaimVerificationResend_Call struct {
+	*mock.Call
+}
+
+// ClaimVerificationResend is a helper method to define mock.On call
+//   - ctx context.Context
+//   - email string
+//   - cooldown time.Duration
+func (_e *UserRepository_Expecter) ClaimVerificationResend(ctx interface{}, email interface{}, cooldown interface{}) *UserRepository_ClaimVerificationResend_Call {
+	return &UserRepository_ClaimVerificationResend_Call{Call: _e.mock.On("ClaimVerificationResend", ctx, email, cooldown)}
+}
+
+func (_c *UserRepository_ClaimVerificationResend_Call) Run(run func(ctx context.Context, email string, cooldown time.Duration)) *UserRepository_ClaimVerificationResend_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 time.Duration
+		if args[2] != nil {
+			arg2 = args[2].(time.Duration)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *UserRepository_ClaimVerificationResend_Call) Return(r0 *domain.User, err error) *UserRepository_ClaimVerificationResend_Call {
+	_c.Call.Return(r0, err)
+	return _c
+}
+
+func (_c *UserRepository_ClaimVerificationResend_Call) RunAndReturn(run func(ctx context.Context, email string, cooldown time.Duration) (*domain.User, error)) *UserRepository_ClaimVerificationResend_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetTwoFactor provides a mock function for the type UserRepository
+func (_mock *UserRepository) GetTwoFactor(ctx context.Context, id uuid.UUID) (*domain.TwoFactor, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTwoFactor")
+	}
+
+	var r0 *domain.TwoFactor
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID) (*domain.TwoFactor, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID) *domain.TwoFactor); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.TwoFactor)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// UserRepository_GetTwoFactor_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTwoFactor'
+type UserRepository_GetTwoFactor_Call struct {
+	*mock.Call
+}
+
+// GetTwoFactor is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+func (_e *UserRepository_Expecter) GetTwoFactor(ctx interface{}, id interface{}) *UserRepository_GetTwoFactor_Call {
+	return &UserRepository_GetTwoFactor_Call{Call: _e.mock.On("GetTwoFactor", ctx, id)}
+}
+
+func (_c *UserRepository_GetTwoFactor_Call) Run(run func(ctx context.Context, id uuid.UUID)) *UserRepository_GetTwoFactor_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uuid.UUID
+		if args[1] != nil {
+			arg1 = args[1].(uuid.UUID)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *UserRepository_GetTwoFactor_Call) Return(r0 *domain.TwoFactor, err error) *UserRepository_GetTwoFactor_Call {
+	_c.Call.Return(r0, err)
+	return _c
+}
+
+func (_c *UserRepository_GetTwoFactor_Call) RunAndReturn(run func(ctx context.Context, id uuid.UUID) (*domain.TwoFactor, error)) *UserRepository_GetTwoFactor_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetTwoFactorSecret provides a mock function for the type UserRepository
+func (_mock *UserRepository) SetTwoFactorSecret(ctx context.Context, id uuid.UUID, secret string) error {
+	ret := _mock.Called(ctx, id, secret)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetTwoFactorSecret")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) error); ok {
+		r0 = returnFunc(ctx, id, secret)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// UserRepository_SetTwoFactorSecret_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetTwoFactorSecret'
+type UserRepository_SetTwoFactorSecret_Call struct {
+	*mock.Call
+}
+
+// SetTwoFactorSecret is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+//   - secret string
+func (_e *UserRepository_Expecter) SetTwoFactorSecret(ctx interface{}, id interface{}, secret interface{}) *UserRepository_SetTwoFactorSecret_Call {
+	return &UserRepository_SetTwoFactorSecret_Call{Call: _e.mock.On("SetTwoFactorSecret", ctx, id, secret)}
+}
+
+func (_c *UserRepository_SetTwoFactorSecret_Call) Run(run func(ctx context.Context, id uuid.UUID, secret string)) *UserRepository_SetTwoFactorSecret_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uuid.UUID
+		if args[1] != nil {
+			arg1 = args[1].(uuid.UUID)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *UserRepository_SetTwoFactorSecret_Call) Return(err error) *UserRepository_SetTwoFactorSecret_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *UserRepository_SetTwoFactorSecret_Call) RunAndReturn(run func(ctx context.Context, id uuid.UUID, secret string) error) *UserRepository_SetTwoFactorSecret_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// EnableTwoFactor provides a mock function for the type UserRepository
+func (_mock *UserRepository) EnableTwoFactor(ctx context.Context, id uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	ret := _mock.Called(ctx, id, step, recoveryCodeHashes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnableTwoFactor")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID, int64, []string) error); ok {
+		r0 = returnFunc(ctx, id, step, recoveryCodeHashes)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// UserRepository_EnableTwoFactor_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EnableTwoFactor'
+type UserRepository_EnableTwoFactor_Call struct {
+	*mock.Call
+}
+
+// EnableTwoFactor is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+//   - step int64
+//   - recoveryCodeHashes []string
+func (_e *UserRepository_Expecter) EnableTwoFactor(ctx interface{}, id interface{}, step interface{}, recoveryCodeHashes interface{}) *UserRepository_EnableTwoFactor_Call {
+	return &UserRepository_EnableTwoFactor_Call{Call: _e.mock.On("EnableTwoFactor", ctx, id, step, recoveryCodeHashes)}
+}
+
+func (_c *UserRepository_EnableTwoFactor_Call) Run(run func(ctx context.Context, id uuid.UUID, step int64, recoveryCodeHashes []string)) *UserRepository_EnableTwoFactor_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uuid.UUID
+		if args[1] != nil {
+			arg1 = args[1].(uuid.UUID)
+		}
+		var arg2 int64
+		if args[2] != nil {
+			arg2 = args[2].(int64)
+		}
+		var arg3 []string
+		if args[3] != nil {
+			arg3 = args[3].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *UserRepository_EnableTwoFactor_Call) Return(err error) *UserRepository_EnableTwoFactor_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *UserRepository_EnableTwoFactor_Call) RunAndReturn(run func(ctx context.Context, id uuid.UUID, step int64, recoveryCodeHashes []string) error) *UserRepository_EnableTwoFactor_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DisableTwoFactor provides a mock function for the type UserRepository
+func (_mock *UserRepository) DisableTwoFactor(ctx context.Context, id uuid.UUID) error {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DisableTwoFactor")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// UserRepository_DisableTwoFactor_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DisableTwoFactor'
+type UserRepository_DisableTwoFactor_Call struct {
+	*mock.Call
+}
+
+// DisableTwoFactor is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+func (_e *UserRepository_Expecter) DisableTwoFactor(ctx interface{}, id interface{}) *UserRepository_DisableTwoFactor_Call {
+	return &UserRepository_DisableTwoFactor_Call{Call: _e.mock.On("DisableTwoFactor", ctx, id)}
+}
+
+func (_c *UserRepository_DisableTwoFactor_Call) Run(run func(ctx context.Context, id uuid.UUID)) *UserRepository_DisableTwoFactor_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uuid.UUID
+		if args[1] != nil {
+			arg1 = args[1].(uuid.UUID)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *UserRepository_DisableTwoFactor_Call) Return(err error) *UserRepository_DisableTwoFactor_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *UserRepository_DisableTwoFactor_Call) RunAndReturn(run func(ctx context.Context, id uuid.UUID) error) *UserRepository_DisableTwoFactor_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UseTOTPStep provides a mock function for the type UserRepository
+func (_mock *UserRepository) UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error) {
+	ret := _mock.Called(ctx, id, step)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UseTOTPStep")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID, int64) (bool, error)); ok {
+		return returnFunc(ctx, id, step)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID, int64) bool); ok {
+		r0 = returnFunc(ctx, id, step)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, uuid.UUID, int64) error); ok {
+		r1 = returnFunc(ctx, id, step)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// UserRepository_UseTOTPStep_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UseTOTPStep'
+type UserRepository_UseTOTPStep_Call struct {
+	*mock.Call
+}
+
+// UseTOTPStep is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+//   - step int64
+func (_e *UserRepository_Expecter) UseTOTPStep(ctx interface{}, id interface{}, step interface{}) *UserRepository_UseTOTPStep_Call {
+	return &UserRepository_UseTOTPStep_Call{Call: _e.mock.On("UseTOTPStep", ctx, id, step)}
+}
+
+func (_c *UserRepository_UseTOTPStep_Call) Run(run func(ctx context.Context, id uuid.UUID, step int64)) *UserRepository_UseTOTPStep_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uuid.UUID
+		if args[1] != nil {
+			arg1 = args[1].(uuid.UUID)
+		}
+		var arg2 int64
+		if args[2] != nil {
+			arg2 = args[2].(int64)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *UserRepository_UseTOTPStep_Call) Return(r0 bool, err error) *UserRepository_UseTOTPStep_Call {
+	_c.Call.Return(r0, err)
+	return _c
+}
+
+func (_c *UserRepository_UseTOTPStep_Call) RunAndReturn(run func(ctx context.Context, id uuid.UUID, step int64) (bool, error)) *UserRepository_UseTOTPStep_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UseRecoveryCode provides a mock function for the type UserRepository
+func (_mock *UserRepository) UseRecoveryCode(ctx context.Context, id uuid.UUID, codeHash string) (bool, error) {
+	ret := _mock.Called(ctx, id, codeHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UseRecoveryCode")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) (bool, error)); ok {
+		return returnFunc(ctx, id, codeHash)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) bool); ok {
+		r0 = returnFunc(ctx, id, codeHash)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, uuid.UUID, string) error); ok {
+		r1 = returnFunc(ctx, id, codeHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// UserRepository_UseRecoveryCode_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UseRecoveryCode'
+type UserRepository_UseRecoveryCode_Call struct {
+	*mock.Call
+}
+
+// UseRecoveryCode is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+//   - codeHash string
+func (_e *UserRepository_Expecter) UseRecoveryCode(ctx interface{}, id interface{}, codeHash interface{}) *UserRepository_UseRecoveryCode_Call {
+	return &UserRepository_UseRecoveryCode_Call{Call: _e.mock.On("UseRecoveryCode", ctx, id, codeHash)}
+}
+
+func (_c *UserRepository_UseRecoveryCode_Call) Run(run func(ctx context.Context, id uuid.UUID, codeHash string)) *UserRepository_UseRecoveryCode_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uuid.UUID
+		if args[1] != nil {
+			arg1 = args[1].(uuid.UUID)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *UserRepository_UseRecoveryCode_Call) Return(r0 bool, err error) *UserRepository_UseRecoveryCode_Call {
+	_c.Call.Return(r0, err)
+	return _c
+}
+
+func (_c *UserRepository_UseRecoveryCode_Call) RunAndReturn(run func(ctx context.Context, id uuid.UUID, codeHash string) (bool, error)) *UserRepository_UseRecoveryCode_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetTwoFactorPolicies provides a mock function for the type UserRepository
+func (_mock *UserRepository) GetTwoFactorPolicies(ctx context.Context) ([]domain.TwoFactorPolicy, error) {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetTwoFactorPolicies")
+	}
+
+	var r0 []domain.TwoFactorPolicy
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) ([]domain.TwoFactorPolicy, error)); ok {
+		return returnFunc(ctx)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context) []domain.TwoFactorPolicy); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.TwoFactorPolicy)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = returnFunc(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// UserRepository_GetTwoFactorPolicies_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTwoFactorPolicies'
+type UserRepository_GetTwoFactorPolicies_Call struct {
+	*mock.Call
+}
+
+// GetTwoFactorPolicies is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *UserRepository_Expecter) GetTwoFactorPolicies(ctx interface{}) *UserRepository_GetTwoFactorPolicies_Call {
+	return &UserRepository_GetTwoFactorPolicies_Call{Call: _e.mock.On("GetTwoFactorPolicies", ctx)}
+}
+
+func (_c *UserRepository_GetTwoFactorPolicies_Call) Run(run func(ctx context.Context)) *UserRepository_GetTwoFactorPolicies_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *UserRepository_GetTwoFactorPolicies_Call) Return(r0 []domain.TwoFactorPolicy, err error) *UserRepository_GetTwoFactorPolicies_Call {
+	_c.Call.Return(r0, err)
+	return _c
+}
+
+func (_c *UserRepository_GetTwoFactorPolicies_Call) RunAndReturn(run func(ctx context.Context) ([]domain.TwoFactorPolicy, error)) *UserRepository_GetTwoFactorPolicies_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetTwoFactorPolicy provides a mock function for the type UserRepository
+func (_mock *UserRepository) SetTwoFactorPolicy(ctx context.Context, role string, required bool) (*domain.TwoFactorPolicy, error) {
+	ret := _mock.Called(ctx, role, required)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetTwoFactorPolicy")
+	}
+
+	var r0 *domain.TwoFactorPolicy
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, bool) (*domain.TwoFactorPolicy, error)); ok {
+		return returnFunc(ctx, role, required)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, bool) *domain.TwoFactorPolicy); ok {
+		r0 = returnFunc(ctx, role, required)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.TwoFactorPolicy)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
+		r1 = returnFunc(ctx, role, required)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// UserRepository_SetTwoFactorPolicy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetTwoFactorPolicy'
+type UserRepository_SetTwoFactorPolicy_Call struct {
+	*mock.Call
+}
+
+// SetTwoFactorPolicy is a helper method to define mock.On call
+//   - ctx context.Context
+//   - role string
+//   - required bool
+func (_e *UserRepository_Expecter) SetTwoFactorPolicy(ctx interface{}, role interface{}, required interface{}) *UserRepository_SetTwoFactorPolicy_Call {
+	return &UserRepository_SetTwoFactorPolicy_Call{Call: _e.mock.On("SetTwoFactorPolicy", ctx, role, required)}
+}
+
+func (_c *UserRepository_SetTwoFactorPolicy_Call) Run(run func(ctx context.Context, role string, required bool)) *UserRepository_SetTwoFactorPolicy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 bool
+		if args[2] != nil {
+			arg2 = args[2].(bool)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *UserRepository_SetTwoFactorPolicy_Call) Return(r0 *domain.TwoFactorPolicy, err error) *UserRepository_SetTwoFactorPolicy_Call {
+	_c.Call.Return(r0, err)
+	return _c
+}
+
+func (_c *UserRepository_SetTwoFactorPolicy_Call) RunAndReturn(run func(ctx context.Context, role string, required bool) (*domain.TwoFactorPolicy, error)) *UserRepository_SetTwoFactorPolicy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RehashPassword provides a mock function for the type UserRepository
+func (_mock *UserRepository) RehashPassword(ctx context.Context, id uuid.UUID, oldHash string, newHash string) error {
+	ret := _mock.Called(ctx, id, oldHash, newHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RehashPassword")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, string) error); ok {
+		r0 = returnFunc(ctx, id, oldHash, newHash)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// UserRepository_RehashPassword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RehashPassword'
+type UserRepository_RehashPassword_Call struct {
+	*mock.Call
+}
+
+// RehashPassword is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id uuid.UUID
+//   - oldHash string
+//   - newHash string
+func (_e *UserRepository_Expecter) RehashPassword(ctx interface{}, id interface{}, oldHash interface{}, newHash interface{}) *UserRepository_RehashPassword_Call {
+	return &UserRepository_RehashPassword_Call{Call: _e.mock.On("RehashPassword", ctx, id, oldHash, newHash)}
+}
+
+func (_c *UserRepository_RehashPassword_Call) Run(run func(ctx context.Context, id uuid.UUID, oldHash string, newHash string)) *UserRepository_RehashPassword_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uuid.UUID
+		if args[1] != nil {
+			arg1 = args[1].(uuid.UUID)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *UserRepository_RehashPassword_Call) Return(err error) *UserRepository_RehashPassword_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *UserRepository_RehashPassword_Call) RunAndReturn(run func(ctx context.Context, id uuid.UUID, oldHash string, newHash string) error) *UserRepository_RehashPassword_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpsertExternalUser provides a mock function for the type UserRepository
+func (_mock *UserRepository) UpsertExternalUser(ctx context.Context, identity *domain.ExternalIdentity) (*domain.UserCredentials, bool, error) {
+	ret := _mock.Called(ctx, identity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpsertExternalUser")
+	}
+
+	var r0 *domain.UserCredentials
+	var r1 bool
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *domain.ExternalIdentity) (*domain.UserCredentials, bool, error)); ok {
+		return returnFunc(ctx, identity)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *domain.ExternalIdentity) *domain.UserCredentials); ok {
+		r0 = returnFunc(ctx, identity)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.UserCredentials)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *domain.ExternalIdentity) bool); ok {
+		r1 = returnFunc(ctx, identity)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, *domain.ExternalIdentity) error); ok {
+		r2 = returnFunc(ctx, identity)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// UserRepository_UpsertExternalUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpsertExternalUser'
+type UserRepository_UpsertExternalUser_Call struct {
+	*mock.Call
+}
+
+// UpsertExternalUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - identity *domain.ExternalIdentity
+func (_e *UserRepository_Expecter) UpsertExternalUser(ctx interface{}, identity interface{}) *UserRepository_UpsertExternalUser_Call {
+	return &UserRepository_UpsertExternalUser_Call{Call: _e.mock.On("UpsertExternalUser", ctx, identity)}
+}
+
+func (_c *UserRepository_UpsertExternalUser_Call) Run(run func(ctx context.Context, identity *domain.ExternalIdentity)) *UserRepository_UpsertExternalUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *domain.ExternalIdentity
+		if args[1] != nil {
+			arg1 = args[1].(*domain.ExternalIdentity)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *UserRepository_UpsertExternalUser_Call) Return(r0 *domain.UserCredentials, r1 bool, err error) *UserRepository_UpsertExternalUser_Call {
+	_c.Call.Return(r0, r1, err)
+	return _c
+}
+
+func (_c *UserRepository_UpsertExternalUser_Call) RunAndReturn(run func(ctx context.Context, identity *domain.ExternalIdentity) (*domain.UserCredentials, bool, error)) *UserRepository_UpsertExternalUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -2,8 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
 	"github.com/google/uuid"
 )
 
@@ -13,26 +16,43 @@ type NewsRepository interface {
 	GetNews(ctx context.Context, id uuid.UUID) (*domain.News, error)
 	UpdateNews(ctx context.Context, id uuid.UUID, news *domain.News) (*domain.News, error)
 	DeleteNews(ctx context.Context, id uuid.UUID) error
+	BulkSaveNews(ctx context.Context, items []domain.BulkNewsItem, allOrNothing bool) ([]domain.BulkItemResult, error)
+	BulkDeleteNews(ctx context.Context, ids []uuid.UUID, allOrNothing bool) ([]domain.BulkItemResult, error)
 }
 
 type NewsService struct {
 	newsRepo NewsRepository
+	serviceOptions
 }
 
-func NewNewsService(n NewsRepository) *NewsService {
+func NewNewsService(n NewsRepository, opts ...Option) *NewsService {
 	return &NewsService{
-		newsRepo: n,
+		newsRepo:       n,
+		serviceOptions: newServiceOptions(opts),
 	}
 }
 
+// CreateNews adds a news written by the authenticated caller, if any.
 func (ns *NewsService) CreateNews(
 	ctx context.Context,
 	u *domain.CreateNewsRequest,
-) (*domain.News, error) {
+) (_ *domain.News, err error) {
+	defer ns.countOperation(ctx, domain.AuditEntityNews, "create", &err)
+	if caller := auth.FromContext(ctx); caller != nil {
+		u.AuthorID = caller.UserID
+	}
+
 	createdNews, err := ns.newsRepo.CreateNews(ctx, u)
 	if err != nil {
 		return nil, err
 	}
+
+	ns.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionCreate,
+		EntityType: domain.AuditEntityNews,
+		EntityID:   createdNews.ID,
+		After:      createdNews,
+	})
 	return createdNews, nil
 }
 
@@ -51,8 +71,8 @@ func (us *NewsService) UpdateNews(
 	ctx context.Context,
 	id uuid.UUID,
 	u *domain.News,
-) (*domain.News, error) {
-
+) (_ *domain.News, err error) {
+	defer us.countOperation(ctx, domain.AuditEntityNews, "update", &err)
 	existing, err := us.newsRepo.GetNews(ctx, id)
 	if err != nil {
 		return nil, err
@@ -61,25 +81,35 @@ func (us *NewsService) UpdateNews(
 	if existing == nil {
 		return nil, domain.ErrUserNotFound
 	}
+	before := *existing
 
 	existing.Title = u.Title
 	existing.Slug = u.Slug
 	existing.Status = u.Status
 	existing.Content = u.Content
 	existing.Topics = u.Topics
+	existing.CoAuthorIDs = u.CoAuthorIDs
 
 	_, err = us.newsRepo.UpdateNews(ctx, id, existing)
 	if err != nil {
 		return nil, err
 	}
+
+	us.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityNews,
+		EntityID:   id.String(),
+		Before:     before,
+		After:      existing,
+	})
 	return existing, nil
 }
 
 func (us *NewsService) DeleteNews(
 	ctx context.Context,
 	id uuid.UUID,
-) error {
-
+) (err error) {
+	defer us.countOperation(ctx, domain.AuditEntityNews, "delete", &err)
 	news, err := us.newsRepo.GetNews(ctx, id)
 	if err != nil {
 		return err
@@ -92,6 +122,13 @@ func (us *NewsService) DeleteNews(
 	if err != nil {
 		return err
 	}
+
+	us.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionDelete,
+		EntityType: domain.AuditEntityNews,
+		EntityID:   id.String(),
+		Before:     news,
+	})
 	return nil
 }
 
@@ -102,3 +139,61 @@ func (us *NewsService) GetNewsList(ctx context.Context, filter *domain.NewsFilte
 	}
 	return newsList, nil
 }
+
+// GetUserNews lists the news written or co-written by a user.
+func (us *NewsService) GetUserNews(ctx context.Context, userID uuid.UUID) ([]domain.News, error) {
+	return us.GetNewsList(ctx, &domain.NewsFilter{AuthorID: userID.String()})
+}
+
+// BulkSaveNews creates the entries without an ID and updates the others.
+func (us *NewsService) BulkSaveNews(ctx context.Context, req *domain.BulkNewsRequest) (_ *domain.BulkResult, err error) {
+	defer us.countOperation(ctx, domain.AuditEntityNews, "bulk_save", &err)
+	// The items of imports may have an author of their own
+	if caller := auth.FromContext(ctx); caller != nil {
+		for i := range req.Items {
+			if req.Items[i].AuthorID == "" {
+				req.Items[i].AuthorID = caller.UserID
+			}
+		}
+	}
+	result, err := runBulk(ctx, req.Items, req.AllOrNothing, validateBulkNewsItem, us.newsRepo.BulkSaveNews)
+	us.auditor.Record(ctx, bulkAuditEntries(domain.AuditEntityNews, result, req.Items)...)
+	return result, err
+}
+
+// BulkDeleteNews soft deletes every news listed in the request.
+func (us *NewsService) BulkDeleteNews(ctx context.Context, req *domain.BulkDeleteRequest) (_ *domain.BulkResult, err error) {
+	defer us.countOperation(ctx, domain.AuditEntityNews, "bulk_delete", &err)
+	result, err := runBulk(ctx, req.IDs, req.AllOrNothing, validateBulkID,
+		func(ctx context.Context, ids []string, allOrNothing bool) ([]domain.BulkItemResult, error) {
+			parsed := make([]uuid.UUID, len(ids))
+			for i, id := range ids {
+				parsed[i] = uuid.MustParse(id)
+			}
+			return us.newsRepo.BulkDeleteNews(ctx, parsed, allOrNothing)
+		})
+	us.auditor.Record(ctx, bulkAuditEntries(domain.AuditEntityNews, result, req.IDs)...)
+	return result, err
+}
+
+func validateBulkNewsItem(item domain.BulkNewsItem) error {
+	if err := validateOptionalID(item.ID); err != nil {
+		return err
+	}
+	if item.Title == "" || item.Status == "" || item.Content == "" {
+		return errors.New("title, status and content are required")
+	}
+	for _, topic := range item.Topics {
+		if _, err := uuid.Parse(topic.TopicId); err != nil {
+			return fmt.Errorf("invalid topic id: %s", topic.TopicId)
+		}
+	}
+	return nil
+}
+
+func validateBulkID(id string) error {
+	if id == "" {
+		return errors.New("id is required")
+	}
+	return validateOptionalID(id)
+}
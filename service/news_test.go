@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
 	"github.com/edwinjordan/ZOGTest-Golang.git/service"
 	"github.com/edwinjordan/ZOGTest-Golang.git/service/mocks"
 	"github.com/google/uuid"
@@ -366,3 +367,185 @@ func TestNewsService_GetNewsList(t *testing.T) {
 		mockNewsRepo.AssertExpectations(t)
 	})
 }
+
+func TestNewsService_BulkSaveNews(t *testing.T) {
+	ctx := context.Background()
+	topicID := uuid.New().String()
+	existingID := uuid.New().String()
+
+	validNew := domain.BulkNewsItem{
+		Title:   "Bulk News",
+		Status:  "draft",
+		Content: "Bulk content",
+		Topics:  []domain.NewsTopicNew{{TopicId: topicID}},
+	}
+	validUpdate := domain.BulkNewsItem{
+		ID:      existingID,
+		Title:   "Bulk Update",
+		Status:  "published",
+		Content: "Updated content",
+	}
+	invalid := domain.BulkNewsItem{Title: "Missing content", Status: "draft"}
+
+	t.Run("Saves valid items and reports invalid ones", func(t *testing.T) {
+		mockNewsRepo := new(mocks.NewsRepository)
+		newsService := service.NewNewsService(mockNewsRepo)
+
+		createdID := uuid.New().String()
+		mockNewsRepo.On("BulkSaveNews", mock.Anything, []domain.BulkNewsItem{validNew, validUpdate}, false).
+			Return([]domain.BulkItemResult{
+				{Index: 0, ID: createdID, Action: domain.BulkActionCreated},
+				{Index: 1, ID: existingID, Action: domain.BulkActionUpdated},
+			}, nil).Once()
+
+		result, err := newsService.BulkSaveNews(ctx, &domain.BulkNewsRequest{
+			Items: []domain.BulkNewsItem{validNew, invalid, validUpdate},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, result.Succeeded)
+		assert.Equal(t, 1, result.Failed)
+		assert.Equal(t, createdID, result.Items[0].ID)
+		assert.Equal(t, 1, result.Items[1].Index)
+		assert.NotEmpty(t, result.Items[1].Error)
+		assert.Equal(t, 2, result.Items[2].Index)
+		assert.Equal(t, domain.BulkActionUpdated, result.Items[2].Action)
+
+		mockNewsRepo.AssertExpectations(t)
+	})
+
+	t.Run("All or nothing aborts before touching the repository", func(t *testing.T) {
+		mockNewsRepo := new(mocks.NewsRepository)
+		newsService := service.NewNewsService(mockNewsRepo)
+
+		result, err := newsService.BulkSaveNews(ctx, &domain.BulkNewsRequest{
+			AllOrNothing: true,
+			Items:        []domain.BulkNewsItem{validNew, invalid},
+		})
+
+		assert.ErrorIs(t, err, domain.ErrBulkAborted)
+		assert.Equal(t, 0, result.Succeeded)
+		assert.Equal(t, 2, result.Failed)
+		assert.Equal(t, domain.ErrBulkAborted.Error(), result.Items[0].Error)
+
+		mockNewsRepo.AssertNotCalled(t, "BulkSaveNews", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("All or nothing rollback in the repository drops created ids", func(t *testing.T) {
+		mockNewsRepo := new(mocks.NewsRepository)
+		newsService := service.NewNewsService(mockNewsRepo)
+
+		mockNewsRepo.On("BulkSaveNews", mock.Anything, []domain.BulkNewsItem{validNew, validUpdate}, true).
+			Return([]domain.BulkItemResult{
+				{Index: 0, ID: uuid.New().String(), Action: domain.BulkActionCreated},
+				{Index: 1, ID: existingID, Error: domain.ErrNotFound.Error()},
+			}, domain.ErrBulkAborted).Once()
+
+		result, err := newsService.BulkSaveNews(ctx, &domain.BulkNewsRequest{
+			AllOrNothing: true,
+			Items:        []domain.BulkNewsItem{validNew, validUpdate},
+		})
+
+		assert.ErrorIs(t, err, domain.ErrBulkAborted)
+		assert.Empty(t, result.Items[0].ID)
+		assert.Equal(t, domain.ErrBulkAborted.Error(), result.Items[0].Error)
+		assert.Equal(t, domain.ErrNotFound.Error(), result.Items[1].Error)
+
+		mockNewsRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects empty requests", func(t *testing.T) {
+		mockNewsRepo := new(mocks.NewsRepository)
+		newsService := service.NewNewsService(mockNewsRepo)
+
+		result, err := newsService.BulkSaveNews(ctx, &domain.BulkNewsRequest{})
+
+		assert.ErrorIs(t, err, domain.ErrBadParamInput)
+		assert.Nil(t, result)
+	})
+
+	t.Run("Returns error when repository fails", func(t *testing.T) {
+		mockNewsRepo := new(mocks.NewsRepository)
+		newsService := service.NewNewsService(mockNewsRepo)
+
+		repoErr := errors.New("copy failed")
+		mockNewsRepo.On("BulkSaveNews", mock.Anything, mock.Anything, false).Return(nil, repoErr).Once()
+
+		result, err := newsService.BulkSaveNews(ctx, &domain.BulkNewsRequest{
+			Items: []domain.BulkNewsItem{validNew},
+		})
+
+		assert.Equal(t, repoErr, err)
+		assert.Nil(t, result)
+
+		mockNewsRepo.AssertExpectations(t)
+	})
+}
+
+func TestNewsService_BulkDeleteNews(t *testing.T) {
+	ctx := context.Background()
+	first, second := uuid.New(), uuid.New()
+
+	t.Run("Deletes valid ids and reports invalid ones", func(t *testing.T) {
+		mockNewsRepo := new(mocks.NewsRepository)
+		newsService := service.NewNewsService(mockNewsRepo)
+
+		mockNewsRepo.On("BulkDeleteNews", mock.Anything, []uuid.UUID{first, second}, false).
+			Return([]domain.BulkItemResult{
+				{Index: 0, ID: first.String(), Action: domain.BulkActionDeleted},
+				{Index: 1, ID: second.String(), Error: domain.ErrNotFound.Error()},
+			}, nil).Once()
+
+		result, err := newsService.BulkDeleteNews(ctx, &domain.BulkDeleteRequest{
+			IDs: []string{first.String(), "not-a-uuid", second.String()},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.Succeeded)
+		assert.Equal(t, 2, result.Failed)
+		assert.Equal(t, domain.BulkActionDeleted, result.Items[0].Action)
+		assert.NotEmpty(t, result.Items[1].Error)
+		assert.Equal(t, 2, result.Items[2].Index)
+
+		mockNewsRepo.AssertExpectations(t)
+	})
+}
+
+func TestNewsService_CreateNews_SetsAuthor(t *testing.T) {
+	mockNewsRepo := new(mocks.NewsRepository)
+	newsService := service.NewNewsService(mockNewsRepo)
+
+	callerID := uuid.New().String()
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{UserID: callerID, Name: "Jane"})
+	req := &domain.CreateNewsRequest{
+		Title:    "Authored News",
+		Status:   "draft",
+		Content:  "Content",
+		AuthorID: uuid.New().String(),
+	}
+
+	mockNewsRepo.On("CreateNews", mock.Anything, mock.MatchedBy(func(r *domain.CreateNewsRequest) bool {
+		return r.AuthorID == callerID
+	})).Return(&domain.News{ID: uuid.New().String()}, nil).Once()
+
+	news, err := newsService.CreateNews(ctx, req)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, news)
+	mockNewsRepo.AssertExpectations(t)
+}
+
+func TestNewsService_GetUserNews(t *testing.T) {
+	mockNewsRepo := new(mocks.NewsRepository)
+	newsService := service.NewNewsService(mockNewsRepo)
+
+	userID := uuid.New()
+	expected := []domain.News{{ID: uuid.New().String(), Author: &domain.AuthorSummary{ID: userID.String(), Name: "Jane"}}}
+	mockNewsRepo.On("GetNewsList", mock.Anything, &domain.NewsFilter{AuthorID: userID.String()}).Return(expected, nil).Once()
+
+	news, err := newsService.GetUserNews(context.Background(), userID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, news)
+	mockNewsRepo.AssertExpectations(t)
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/oidc"
+)
+
+// OIDCProvider runs the authorization code flow with an OpenID provider
+type OIDCProvider interface {
+	AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error)
+	Exchange(ctx context.Context, code, verifier, nonce string) (*oidc.Claims, error)
+}
+
+type OIDCConfig struct {
+	// Secret signs the state cookie of a login, a random one is generated
+	// when empty so logins in progress fail after a restart
+	Secret []byte
+	// StateTTL is how long a user has to log in at the provider
+	StateTTL time.Duration
+	// GroupRoles maps the groups of the provider to local roles, a user in
+	// several groups gets the most privileged role
+	GroupRoles map[string]string
+	// DefaultRole is given to users in none of the mapped groups, they are
+	// refused when it is empty
+	DefaultRole string
+}
+
+var DefaultOIDCConfig = OIDCConfig{
+	StateTTL: 10 * time.Minute,
+}
+
+var errOIDCDisabled = errors.New("oidc login is not configured")
+
+// StartOIDCLogin returns the provider URL to send the user to. The state,
+// nonce and PKCE verifier of the login are signed into the returned state,
+// which the caller keeps in a cookie until the callback.
+func (us *UserService) StartOIDCLogin(ctx context.Context) (*domain.OIDCAuthorization, error) {
+	if us.oidcProvider == nil {
+		return nil, errOIDCDisabled
+	}
+
+	values := make([]string, 3)
+	for i := range values {
+		v, err := oidc.RandomString(32)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	url, err := us.oidcProvider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(us.oidc.StateTTL)
+	return &domain.OIDCAuthorization{
+		URL:       url,
+		State:     signOIDCState(us.oidc.Secret, state, nonce, verifier, expiresAt),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// FinishOIDCLogin handles the redirect back from the provider: it checks
+// the state, exchanges the code, provisions the user and completes the
+// login like a password one.
+func (us *UserService) FinishOIDCLogin(ctx context.Context, req *domain.OIDCCallbackRequest) (_ *domain.LoginResult, err error) {
+	defer us.countOperation(ctx, domain.AuditEntityUser, "oidc_login", &err)
+	if us.oidcProvider == nil {
+		return nil, errOIDCDisabled
+	}
+
+	state, nonce, verifier, err := parseOIDCState(us.oidc.Secret, req.StateCookie, time.Now())
+	if err != nil || subtle.ConstantTimeCompare([]byte(state), []byte(req.State)) != 1 {
+		logging.LogSecurityEvent(ctx, "oidc_state_mismatch")
+		return nil, domain.ErrInvalidToken
+	}
+	if req.Error != "" {
+		logging.LogSecurityEvent(ctx, "oidc_login_denied", slog.String("error", req.Error))
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	claims, err := us.oidcProvider.Exchange(ctx, req.Code, verifier, nonce)
+	if err != nil {
+		if errors.Is(err, oidc.ErrExchange) || errors.Is(err, oidc.ErrInvalidIDToken) {
+			logging.LogSecurityEvent(ctx, "oidc_token_rejected", slog.String("error", err.Error()))
+			return nil, domain.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if claims.Email == "" {
+		logging.LogSecurityEvent(ctx, "oidc_login_refused", slog.String("subject", claims.Subject), slog.String("reason", "no email"))
+		return nil, fmt.Errorf("%w: the provider did not share an email address", domain.ErrForbidden)
+	}
+	role := us.oidcRole(claims.Groups)
+	if role == "" {
+		logging.LogSecurityEvent(ctx, "oidc_login_refused", slog.String("subject", claims.Subject), slog.String("reason", "no mapped group"))
+		return nil, fmt.Errorf("%w: none of your groups has access", domain.ErrForbidden)
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.Email
+	}
+	creds, created, err := us.userRepo.UpsertExternalUser(ctx, &domain.ExternalIdentity{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          name,
+		Role:          role,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			logging.LogSecurityEvent(ctx, "oidc_login_refused", slog.String("subject", claims.Subject), slog.String("reason", "user deleted"))
+			return nil, domain.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	if created {
+		us.auditor.Record(ctx, AuditEntry{
+			Action:     domain.AuditActionCreate,
+			EntityType: domain.AuditEntityUser,
+			EntityID:   creds.User.ID,
+			After:      map[string]any{"email": creds.User.Email, "role": creds.User.Role, "issuer": claims.Issuer},
+		})
+	}
+	logging.LogSecurityEvent(ctx, "oidc_login",
+		slog.String("user_id", creds.User.ID),
+		slog.String("role", creds.User.Role),
+		slog.Bool("provisioned", created),
+	)
+	return us.completeLogin(ctx, creds)
+}
+
+// oidcRole returns the most privileged role the groups map to, in the
+// order of domain.Roles
+func (us *UserService) oidcRole(groups []string) string {
+	best := -1
+	for _, group := range groups {
+		role, ok := us.oidc.GroupRoles[group]
+		if !ok {
+			continue
+		}
+		if i := slices.Index(domain.Roles, role); i > best {
+			best = i
+		}
+	}
+	if best < 0 {
+		return us.oidc.DefaultRole
+	}
+	return domain.Roles[best]
+}
+
+// signOIDCState binds the values of a login together until expiresAt. They
+// are base64url, so dots can separate them.
+func signOIDCState(secret []byte, state, nonce, verifier string, expiresAt time.Time) string {
+	payload := strings.Join([]string{state, nonce, verifier, strconv.FormatInt(expiresAt.Unix(), 10)}, ".")
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(oidcStateMAC(secret, payload))
+}
+
+func oidcStateMAC(secret []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("oidc-login\n" + payload))
+	return mac.Sum(nil)
+}
+
+func parseOIDCState(secret []byte, signed string, now time.Time) (state, nonce, verifier string, err error) {
+	encodedPayload, encodedMAC, ok := strings.Cut(signed, ".")
+	if !ok {
+		return "", "", "", domain.ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return "", "", "", domain.ErrInvalidToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, oidcStateMAC(secret, string(payload))) {
+		return "", "", "", domain.ErrInvalidToken
+	}
+
+	parts := strings.Split(string(payload), ".")
+	if len(parts) != 4 {
+		return "", "", "", domain.ErrInvalidToken
+	}
+	expiry, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil || !now.Before(time.Unix(expiry, 0)) {
+		return "", "", "", domain.ErrInvalidToken
+	}
+	return parts[0], parts[1], parts[2], nil
+}
@@ -0,0 +1,166 @@
+package service_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/oidc"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/oidc/oidctest"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_OIDCLogin(t *testing.T) {
+	ctx := context.Background()
+	idp := oidctest.NewServer(t)
+	provider := oidc.NewProvider(idp.Config("http://localhost:8000/api/v1/auth/oidc/callback"), idp.Client())
+	config := service.DefaultOIDCConfig
+	config.GroupRoles = map[string]string{"newsroom-admins": domain.RoleAdmin, "newsroom": domain.RoleUser}
+
+	newService := func(config service.OIDCConfig) (*mocks.UserRepository, *mocks.SessionRepository, *service.UserService) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockSessionRepo := new(mocks.SessionRepository)
+		userService := service.NewUserService(mockUserRepo,
+			service.WithOIDC(provider, config),
+			service.WithSessions(mockSessionRepo, service.DefaultSessionConfig),
+		)
+		return mockUserRepo, mockSessionRepo, userService
+	}
+
+	// login runs the browser side: the redirect to the provider and back
+	login := func(t *testing.T, userService *service.UserService, claims map[string]any) *domain.OIDCCallbackRequest {
+		authorization, err := userService.StartOIDCLogin(ctx)
+		require.NoError(t, err)
+		u, err := url.Parse(authorization.URL)
+		require.NoError(t, err)
+		return &domain.OIDCCallbackRequest{
+			Code:        idp.Authorize(t, authorization.URL, claims),
+			State:       u.Query().Get("state"),
+			StateCookie: authorization.State,
+		}
+	}
+
+	t.Run("Provisions the user with the most privileged mapped role", func(t *testing.T) {
+		mockUserRepo, mockSessionRepo, userService := newService(config)
+		id := uuid.NewString()
+
+		mockUserRepo.On("UpsertExternalUser", mock.Anything, &domain.ExternalIdentity{
+			Issuer:        idp.URL,
+			Subject:       oidctest.Subject,
+			Email:         "jane@newsroom.example",
+			EmailVerified: true,
+			Name:          "Jane",
+			Role:          domain.RoleAdmin,
+		}).Return(&domain.UserCredentials{User: domain.User{ID: id, Role: domain.RoleAdmin, Status: domain.UserStatusActive}}, true, nil).Once()
+		mockSessionRepo.On("CreateSession", mock.Anything, mock.MatchedBy(func(s *domain.Session) bool {
+			return s.UserID == id
+		})).Return(nil).Once()
+
+		result, err := userService.FinishOIDCLogin(ctx, login(t, userService, map[string]any{
+			"email":          "jane@newsroom.example",
+			"email_verified": true,
+			"name":           "Jane",
+			"groups":         []string{"newsroom", "newsroom-admins", "unrelated"},
+		}))
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.Session.Token)
+		mockUserRepo.AssertExpectations(t)
+		mockSessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Asks users with two-factor for a code", func(t *testing.T) {
+		mockUserRepo, _, userService := newService(config)
+		id := uuid.New()
+
+		mockUserRepo.On("UpsertExternalUser", mock.Anything, mock.Anything).Return(&domain.UserCredentials{
+			User:             domain.User{ID: id.String(), Status: domain.UserStatusActive},
+			TwoFactorEnabled: true,
+		}, false, nil).Once()
+		mockUserRepo.On("GetTwoFactor", mock.Anything, id).Return(&domain.TwoFactor{Secret: "JBSWY3DPEHPK3PXP"}, nil).Once()
+
+		result, err := userService.FinishOIDCLogin(ctx, login(t, userService, map[string]any{
+			"email":  "jane@newsroom.example",
+			"groups": []string{"newsroom"},
+		}))
+
+		require.NoError(t, err)
+		assert.Nil(t, result.Session)
+		assert.NotEmpty(t, result.Challenge.Token)
+	})
+
+	t.Run("Refuses users in no mapped group", func(t *testing.T) {
+		_, _, userService := newService(config)
+
+		_, err := userService.FinishOIDCLogin(ctx, login(t, userService, map[string]any{
+			"email":  "guest@example.com",
+			"groups": []string{"contractors"},
+		}))
+
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+
+	t.Run("Gives the default role to users in no mapped group", func(t *testing.T) {
+		withDefault := config
+		withDefault.DefaultRole = domain.RoleUser
+		mockUserRepo, mockSessionRepo, userService := newService(withDefault)
+
+		mockUserRepo.On("UpsertExternalUser", mock.Anything, mock.MatchedBy(func(i *domain.ExternalIdentity) bool {
+			// Without a name claim the email names the user
+			return i.Role == domain.RoleUser && i.Name == "guest@example.com"
+		})).Return(&domain.UserCredentials{User: domain.User{ID: uuid.NewString(), Status: domain.UserStatusActive}}, true, nil).Once()
+		mockSessionRepo.On("CreateSession", mock.Anything, mock.Anything).Return(nil).Once()
+
+		_, err := userService.FinishOIDCLogin(ctx, login(t, userService, map[string]any{"email": "guest@example.com"}))
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("Refuses a callback of another browser", func(t *testing.T) {
+		_, _, userService := newService(config)
+		req := login(t, userService, map[string]any{"email": "jane@newsroom.example"})
+		other := login(t, userService, map[string]any{"email": "jane@newsroom.example"})
+		req.StateCookie = other.StateCookie
+
+		_, err := userService.FinishOIDCLogin(ctx, req)
+
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+	})
+
+	t.Run("Refuses a forged state cookie", func(t *testing.T) {
+		_, _, userService := newService(config)
+		req := login(t, userService, map[string]any{"email": "jane@newsroom.example"})
+		req.StateCookie = req.StateCookie[:len(req.StateCookie)-2] + "AA"
+
+		_, err := userService.FinishOIDCLogin(ctx, req)
+
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+	})
+
+	t.Run("Refuses codes the provider does not honour", func(t *testing.T) {
+		_, _, userService := newService(config)
+		req := login(t, userService, map[string]any{"email": "jane@newsroom.example"})
+		req.Code = "made-up"
+
+		_, err := userService.FinishOIDCLogin(ctx, req)
+
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+	})
+
+	t.Run("Reports the denial of the provider", func(t *testing.T) {
+		_, _, userService := newService(config)
+		req := login(t, userService, nil)
+		req.Code, req.Error = "", "access_denied"
+
+		_, err := userService.FinishOIDCLogin(ctx, req)
+
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+	})
+}
@@ -0,0 +1,124 @@
+package service
+
+import "github.com/edwinjordan/ZOGTest-Golang.git/utils"
+
+// Option configures the optional collaborators shared by the services
+type Option func(*serviceOptions)
+
+type serviceOptions struct {
+	auditor        Auditor
+	operations     OperationRecorder
+	mailer         Mailer
+	passwordPolicy PasswordPolicy
+	passwordHasher utils.PasswordHasher
+	passwordReset  PasswordResetConfig
+	verification   EmailVerificationConfig
+	loginThrottle  *loginThrottle
+	twoFactor      TwoFactorConfig
+	sessions       SessionRepository
+	sessionConfig  SessionConfig
+	oidcProvider   OIDCProvider
+	oidc           OIDCConfig
+}
+
+// WithAuditor records the changes made through the service
+func WithAuditor(a Auditor) Option {
+	return func(o *serviceOptions) {
+		o.auditor = a
+	}
+}
+
+// WithMailer sends the emails of the service, they are dropped otherwise
+func WithMailer(m Mailer) Option {
+	return func(o *serviceOptions) {
+		o.mailer = m
+	}
+}
+
+// WithPasswordPolicy replaces DefaultPasswordPolicy
+func WithPasswordPolicy(p PasswordPolicy) Option {
+	return func(o *serviceOptions) {
+		o.passwordPolicy = p
+	}
+}
+
+// WithPasswordHasher replaces utils.DefaultPasswordHasher. Passwords hashed
+// another way keep working and are rehashed on the next login.
+func WithPasswordHasher(h utils.PasswordHasher) Option {
+	return func(o *serviceOptions) {
+		o.passwordHasher = h
+	}
+}
+
+// WithPasswordReset replaces DefaultPasswordResetConfig
+func WithPasswordReset(c PasswordResetConfig) Option {
+	return func(o *serviceOptions) {
+		o.passwordReset = c
+	}
+}
+
+// WithEmailVerification replaces DefaultEmailVerificationConfig
+func WithEmailVerification(c EmailVerificationConfig) Option {
+	return func(o *serviceOptions) {
+		o.verification = c
+	}
+}
+
+// WithLoginThrottle locks accounts and client IPs out after repeated failed
+// logins, logins are not throttled otherwise
+func WithLoginThrottle(repo LoginThrottleRepository, c LoginThrottleConfig) Option {
+	return func(o *serviceOptions) {
+		o.loginThrottle = &loginThrottle{repo: repo, config: c}
+	}
+}
+
+// WithTwoFactor replaces DefaultTwoFactorConfig
+func WithTwoFactor(c TwoFactorConfig) Option {
+	return func(o *serviceOptions) {
+		o.twoFactor = c
+	}
+}
+
+// WithSessions stores the sessions handed out by the login endpoints, which
+// fail without it
+func WithSessions(repo SessionRepository, c SessionConfig) Option {
+	return func(o *serviceOptions) {
+		o.sessions = repo
+		o.sessionConfig = c
+	}
+}
+
+// WithOIDC lets users log in through an OpenID provider, which provisions
+// them on their first login
+func WithOIDC(p OIDCProvider, c OIDCConfig) Option {
+	return func(o *serviceOptions) {
+		o.oidcProvider = p
+		o.oidc = c
+	}
+}
+
+// WithMetrics counts the business operations of the service
+func WithMetrics(r OperationRecorder) Option {
+	return func(o *serviceOptions) {
+		o.operations = r
+	}
+}
+
+func newServiceOptions(opts []Option) serviceOptions {
+	o := serviceOptions{
+		auditor:        nopAuditor{},
+		operations:     nopOperationRecorder{},
+		mailer:         nopMailer{},
+		passwordPolicy: DefaultPasswordPolicy,
+		passwordHasher: utils.DefaultPasswordHasher,
+		passwordReset:  DefaultPasswordResetConfig,
+		verification:   DefaultEmailVerificationConfig,
+		twoFactor:      DefaultTwoFactorConfig,
+		sessionConfig:  DefaultSessionConfig,
+		oidc:           DefaultOIDCConfig,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
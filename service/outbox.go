@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+)
+
+type OutboxRepository interface {
+	ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxMessage, error)
+	MarkOutboxDispatched(ctx context.Context, id string) error
+	RetryOutboxEvent(ctx context.Context, id string, nextAttemptAt time.Time, lastError string, deliveredSinks []string) error
+	ReleaseOutboxEvents(ctx context.Context, ids []string) error
+	FailOutboxEvent(ctx context.Context, id string, lastError string) error
+}
+
+// EventSink receives the events relayed from the outbox. Delivery is at least
+// once: an event accepted by a sink is not retried on it when another sink
+// fails, but it is delivered again when the relay stops before recording
+// the delivery. Sinks should use the event ID to ignore duplicates, and keep
+// their name unique and stable as the deliveries are recorded under it.
+type EventSink interface {
+	Name() string
+	Deliver(ctx context.Context, event domain.Event) error
+}
+
+// Backoff computes exponentially growing retry delays
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Delay returns the wait before the next try after the given failed attempt,
+// attempts start at 1.
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := b.Base
+	for i := 1; i < attempt && delay < b.Max; i++ {
+		delay *= 2
+	}
+	if delay > b.Max {
+		delay = b.Max
+	}
+	return delay
+}
+
+type OutboxRelayConfig struct {
+	// BatchSize is the number of events claimed at once
+	BatchSize int
+	// PollInterval is the wait between polls when the outbox is drained
+	PollInterval time.Duration
+	// Lease is how long a claimed event is hidden from other relays, the
+	// deliveries of a batch end before it does
+	Lease time.Duration
+	// MaxAttempts is the number of deliveries tried before giving up
+	MaxAttempts int
+	Backoff     Backoff
+}
+
+var DefaultOutboxRelayConfig = OutboxRelayConfig{
+	BatchSize:    100,
+	PollInterval: time.Second,
+	Lease:        time.Minute,
+	MaxAttempts:  10,
+	Backoff:      Backoff{Base: time.Second, Max: 10 * time.Minute},
+}
+
+// OutboxRelay delivers the events written to the outbox to every sink. An
+// event is only marked dispatched once all sinks accepted it, otherwise it
+// is retried with backoff on the sinks which did not until MaxAttempts is
+// reached.
+type OutboxRelay struct {
+	outboxRepo OutboxRepository
+	sinks      []EventSink
+	config     OutboxRelayConfig
+}
+
+func NewOutboxRelay(o OutboxRepository, config OutboxRelayConfig, sinks ...EventSink) *OutboxRelay {
+	return &OutboxRelay{
+		outboxRepo: o,
+		sinks:      sinks,
+		config:     config,
+	}
+}
+
+// Run relays events until the context is cancelled. The outbox is polled
+// again right away as long as full batches come back.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	for {
+		dispatched, err := r.DispatchPending(ctx)
+		if err != nil && ctx.Err() == nil {
+			logging.LogError(ctx, err, "outbox_relay")
+		}
+		if err == nil && dispatched == r.config.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.config.PollInterval):
+		}
+	}
+}
+
+// DispatchPending claims one batch of due events and delivers it, returning
+// the number of events claimed.
+//
+// The deliveries are bounded by the lease of the batch, less a tenth of it
+// left to record their outcome, so that no other relay claims the events
+// while they are delivered. The events not delivered by then are released
+// for the next poll. An event whose outcome cannot be recorded does not stop
+// the batch, the errors are returned once it is dispatched.
+func (r *OutboxRelay) DispatchPending(ctx context.Context) (int, error) {
+	deliveries, cancel := context.WithTimeout(ctx, r.config.Lease-r.config.Lease/10)
+	defer cancel()
+
+	messages, err := r.outboxRepo.ClaimOutboxEvents(ctx, r.config.BatchSize, r.config.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	for i, msg := range messages {
+		if deliveries.Err() != nil {
+			if err := r.release(ctx, messages[i:]); err != nil {
+				errs = append(errs, err)
+			}
+			break
+		}
+		if err := r.dispatch(ctx, deliveries, msg); err != nil {
+			errs = append(errs, fmt.Errorf("event %s: %w", msg.ID, err))
+		}
+	}
+	return len(messages), errors.Join(errs...)
+}
+
+// release gives the events back to the outbox without delivering them
+func (r *OutboxRelay) release(ctx context.Context, messages []domain.OutboxMessage) error {
+	ids := make([]string, len(messages))
+	for i, msg := range messages {
+		ids[i] = msg.ID
+	}
+	logging.LogWarn(ctx, "Outbox batch ran out of its lease, releasing the rest",
+		slog.Int("events", len(ids)),
+	)
+	if err := r.outboxRepo.ReleaseOutboxEvents(ctx, ids); err != nil {
+		return fmt.Errorf("release events: %w", err)
+	}
+	return nil
+}
+
+// dispatch delivers msg to the sinks which did not accept it yet, the sinks
+// get the deliveries context. The sinks accepting it are recorded with the
+// retry, so a failing sink does not make the others receive the event
+// again.
+func (r *OutboxRelay) dispatch(ctx, deliveries context.Context, msg domain.OutboxMessage) error {
+	delivered := slices.Clone(msg.DeliveredSinks)
+	var errs []error
+	for _, sink := range r.sinks {
+		name := sink.Name()
+		if slices.Contains(delivered, name) {
+			continue
+		}
+		if err := sink.Deliver(deliveries, msg.Event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		delivered = append(delivered, name)
+	}
+	if len(errs) == 0 {
+		return r.outboxRepo.MarkOutboxDispatched(ctx, msg.ID)
+	}
+
+	deliveryErr := errors.Join(errs...)
+	if msg.Attempts >= r.config.MaxAttempts {
+		logging.LogErrorMessage(ctx, "Outbox event dead lettered",
+			slog.String("event_id", msg.ID),
+			slog.String("event_type", msg.Type),
+			slog.Int("attempts", msg.Attempts),
+			slog.String("error", deliveryErr.Error()),
+		)
+		return r.outboxRepo.FailOutboxEvent(ctx, msg.ID, deliveryErr.Error())
+	}
+
+	delay := r.config.Backoff.Delay(msg.Attempts)
+	logging.LogWarn(ctx, "Outbox event delivery failed, retrying",
+		slog.String("event_id", msg.ID),
+		slog.String("event_type", msg.Type),
+		slog.Int("attempts", msg.Attempts),
+		slog.Duration("retry_in", delay),
+		slog.String("error", deliveryErr.Error()),
+	)
+	return r.outboxRepo.RetryOutboxEvent(ctx, msg.ID, time.Now().Add(delay), deliveryErr.Error(), delivered)
+}
@@ -0,0 +1,198 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type fakeSink struct {
+	name string
+	err  error
+	// delay is how long a delivery takes, it gives up with its context
+	delay     time.Duration
+	delivered []domain.Event
+}
+
+func (s *fakeSink) Name() string {
+	if s.name == "" {
+		return "fake"
+	}
+	return s.name
+}
+
+func (s *fakeSink) Deliver(ctx context.Context, event domain.Event) error {
+	s.delivered = append(s.delivered, event)
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return s.err
+}
+
+func outboxMessage(attempts int) domain.OutboxMessage {
+	return domain.OutboxMessage{
+		Event: domain.Event{
+			ID:          "0b7d3f7e-1c4a-4f0e-9a55-3c2f9d8e7a61",
+			Type:        domain.EventNewsPublished,
+			AggregateID: "news-1",
+		},
+		Attempts: attempts,
+	}
+}
+
+func TestBackoff_Delay(t *testing.T) {
+	backoff := service.Backoff{Base: time.Second, Max: 10 * time.Second}
+
+	assert.Equal(t, time.Second, backoff.Delay(1))
+	assert.Equal(t, 2*time.Second, backoff.Delay(2))
+	assert.Equal(t, 8*time.Second, backoff.Delay(4))
+	assert.Equal(t, 10*time.Second, backoff.Delay(5))
+	assert.Equal(t, 10*time.Second, backoff.Delay(50))
+}
+
+func TestOutboxRelay_DispatchPending(t *testing.T) {
+	config := service.DefaultOutboxRelayConfig
+
+	t.Run("Marks events delivered to every sink as dispatched", func(t *testing.T) {
+		mockOutboxRepo := new(mocks.OutboxRepository)
+		logSink, busSink := &fakeSink{name: "log"}, &fakeSink{name: "bus"}
+		relay := service.NewOutboxRelay(mockOutboxRepo, config, logSink, busSink)
+
+		msg := outboxMessage(1)
+		mockOutboxRepo.On("ClaimOutboxEvents", mock.Anything, config.BatchSize, config.Lease).
+			Return([]domain.OutboxMessage{msg}, nil).Once()
+		mockOutboxRepo.On("MarkOutboxDispatched", mock.Anything, msg.ID).Return(nil).Once()
+
+		dispatched, err := relay.DispatchPending(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, dispatched)
+		assert.Equal(t, []domain.Event{msg.Event}, logSink.delivered)
+		assert.Equal(t, []domain.Event{msg.Event}, busSink.delivered)
+		mockOutboxRepo.AssertExpectations(t)
+	})
+
+	t.Run("Schedules a retry with backoff when a sink fails", func(t *testing.T) {
+		mockOutboxRepo := new(mocks.OutboxRepository)
+		relay := service.NewOutboxRelay(mockOutboxRepo, config, &fakeSink{name: "log"}, &fakeSink{err: errors.New("receiver down")})
+
+		msg := outboxMessage(3)
+		mockOutboxRepo.On("ClaimOutboxEvents", mock.Anything, config.BatchSize, config.Lease).
+			Return([]domain.OutboxMessage{msg}, nil).Once()
+		mockOutboxRepo.On("RetryOutboxEvent", mock.Anything, msg.ID,
+			mock.MatchedBy(func(next time.Time) bool {
+				wait := time.Until(next)
+				return wait > 3*time.Second && wait <= 4*time.Second
+			}),
+			"fake: receiver down",
+			[]string{"log"},
+		).Return(nil).Once()
+
+		_, err := relay.DispatchPending(context.Background())
+
+		assert.NoError(t, err)
+		mockOutboxRepo.AssertExpectations(t)
+		mockOutboxRepo.AssertNotCalled(t, "MarkOutboxDispatched", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Skips the sinks which accepted the event before", func(t *testing.T) {
+		mockOutboxRepo := new(mocks.OutboxRepository)
+		logSink, httpSink := &fakeSink{name: "log"}, &fakeSink{name: "http"}
+		relay := service.NewOutboxRelay(mockOutboxRepo, config, logSink, httpSink)
+
+		msg := outboxMessage(2)
+		msg.DeliveredSinks = []string{"log"}
+		mockOutboxRepo.On("ClaimOutboxEvents", mock.Anything, config.BatchSize, config.Lease).
+			Return([]domain.OutboxMessage{msg}, nil).Once()
+		mockOutboxRepo.On("MarkOutboxDispatched", mock.Anything, msg.ID).Return(nil).Once()
+
+		_, err := relay.DispatchPending(context.Background())
+
+		assert.NoError(t, err)
+		assert.Empty(t, logSink.delivered, "the log sink does not get the event again")
+		assert.Equal(t, []domain.Event{msg.Event}, httpSink.delivered)
+		mockOutboxRepo.AssertExpectations(t)
+	})
+
+	t.Run("Gives up after the last attempt", func(t *testing.T) {
+		mockOutboxRepo := new(mocks.OutboxRepository)
+		relay := service.NewOutboxRelay(mockOutboxRepo, config, &fakeSink{err: errors.New("receiver down")})
+
+		msg := outboxMessage(config.MaxAttempts)
+		mockOutboxRepo.On("ClaimOutboxEvents", mock.Anything, config.BatchSize, config.Lease).
+			Return([]domain.OutboxMessage{msg}, nil).Once()
+		mockOutboxRepo.On("FailOutboxEvent", mock.Anything, msg.ID, "fake: receiver down").Return(nil).Once()
+
+		_, err := relay.DispatchPending(context.Background())
+
+		assert.NoError(t, err)
+		mockOutboxRepo.AssertExpectations(t)
+	})
+
+	t.Run("Dispatches the rest of the batch when recording an event fails", func(t *testing.T) {
+		mockOutboxRepo := new(mocks.OutboxRepository)
+		sink := &fakeSink{}
+		relay := service.NewOutboxRelay(mockOutboxRepo, config, sink)
+
+		first, second := outboxMessage(1), outboxMessage(1)
+		second.ID = "5a0c1a8e-2d7f-4c5b-8e3a-9f6b1d2c3e4f"
+		mockOutboxRepo.On("ClaimOutboxEvents", mock.Anything, config.BatchSize, config.Lease).
+			Return([]domain.OutboxMessage{first, second}, nil).Once()
+		mockOutboxRepo.On("MarkOutboxDispatched", mock.Anything, first.ID).Return(errors.New("db down")).Once()
+		mockOutboxRepo.On("MarkOutboxDispatched", mock.Anything, second.ID).Return(nil).Once()
+
+		dispatched, err := relay.DispatchPending(context.Background())
+
+		assert.ErrorContains(t, err, "db down")
+		assert.Equal(t, 2, dispatched)
+		assert.Len(t, sink.delivered, 2)
+		mockOutboxRepo.AssertExpectations(t)
+	})
+
+	t.Run("Releases the events left when the lease runs out", func(t *testing.T) {
+		config := config
+		config.Lease = 50 * time.Millisecond
+		mockOutboxRepo := new(mocks.OutboxRepository)
+		sink := &fakeSink{delay: time.Minute}
+		relay := service.NewOutboxRelay(mockOutboxRepo, config, sink)
+
+		first, second := outboxMessage(1), outboxMessage(1)
+		second.ID = "5a0c1a8e-2d7f-4c5b-8e3a-9f6b1d2c3e4f"
+		mockOutboxRepo.On("ClaimOutboxEvents", mock.Anything, config.BatchSize, config.Lease).
+			Return([]domain.OutboxMessage{first, second}, nil).Once()
+		mockOutboxRepo.On("RetryOutboxEvent", mock.Anything, first.ID, mock.Anything,
+			"fake: "+context.DeadlineExceeded.Error(), []string(nil)).Return(nil).Once()
+		mockOutboxRepo.On("ReleaseOutboxEvents", mock.Anything, []string{second.ID}).Return(nil).Once()
+
+		start := time.Now()
+		_, err := relay.DispatchPending(context.Background())
+
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(start), config.Lease, "the batch ends before its lease")
+		assert.Equal(t, []domain.Event{first.Event}, sink.delivered)
+		mockOutboxRepo.AssertExpectations(t)
+	})
+
+	t.Run("Returns the claim error", func(t *testing.T) {
+		mockOutboxRepo := new(mocks.OutboxRepository)
+		relay := service.NewOutboxRelay(mockOutboxRepo, config, &fakeSink{})
+
+		mockOutboxRepo.On("ClaimOutboxEvents", mock.Anything, config.BatchSize, config.Lease).
+			Return(nil, errors.New("db down")).Once()
+
+		dispatched, err := relay.DispatchPending(context.Background())
+
+		assert.Error(t, err)
+		assert.Zero(t, dispatched)
+		mockOutboxRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/google/uuid"
+)
+
+type PartnerClientRepository interface {
+	GetPartnerClient(ctx context.Context, id uuid.UUID) (*domain.PartnerClient, error)
+}
+
+type PartnerService struct {
+	partnerRepo PartnerClientRepository
+}
+
+func NewPartnerService(p PartnerClientRepository) *PartnerService {
+	return &PartnerService{
+		partnerRepo: p,
+	}
+}
+
+// GetPartnerClient looks up an active partner client, malformed ids are
+// reported as not found so callers cannot tell them apart from unknown ones.
+func (ps *PartnerService) GetPartnerClient(ctx context.Context, clientID string) (*domain.PartnerClient, error) {
+	id, err := uuid.Parse(clientID)
+	if err != nil {
+		return nil, domain.ErrNotFound
+	}
+	return ps.partnerRepo.GetPartnerClient(ctx, id)
+}
@@ -0,0 +1,41 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPartnerService_GetPartnerClient(t *testing.T) {
+	t.Run("Returns the active client", func(t *testing.T) {
+		mockPartnerRepo := new(mocks.PartnerClientRepository)
+		partnerService := service.NewPartnerService(mockPartnerRepo)
+
+		id := uuid.New()
+		client := &domain.PartnerClient{ID: id.String(), Name: "Wire Agency", Secret: "s3cret"}
+		mockPartnerRepo.On("GetPartnerClient", mock.Anything, id).Return(client, nil).Once()
+
+		result, err := partnerService.GetPartnerClient(context.Background(), id.String())
+
+		assert.NoError(t, err)
+		assert.Equal(t, client, result)
+		mockPartnerRepo.AssertExpectations(t)
+	})
+
+	t.Run("Reports malformed ids as not found", func(t *testing.T) {
+		mockPartnerRepo := new(mocks.PartnerClientRepository)
+		partnerService := service.NewPartnerService(mockPartnerRepo)
+
+		result, err := partnerService.GetPartnerClient(context.Background(), "not-a-uuid")
+
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.Nil(t, result)
+		mockPartnerRepo.AssertNotCalled(t, "GetPartnerClient", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+)
+
+// maxPasswordBytes is the longest password bcrypt accepts
+const maxPasswordBytes = 72
+
+// commonPasswords are refused by the default policy even though some of them
+// mix enough character classes
+var commonPasswords = []string{
+	"password", "password1", "password12", "password123", "password1234", "passw0rd", "p@ssw0rd", "p@ssword1",
+	"123456789", "1234567890", "qwerty123", "qwertyuiop", "1q2w3e4r5t", "iloveyou", "admin123", "welcome1",
+	"welcome123", "letmein123", "changeme", "abc123456",
+}
+
+type PasswordPolicy struct {
+	MinLength int
+	// MinClasses is the number of character classes, among lower case, upper
+	// case, digits and symbols, a password has to mix
+	MinClasses int
+	// Banned passwords are refused whatever their strength, they are compared
+	// case insensitively
+	Banned []string
+}
+
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:  10,
+	MinClasses: 3,
+	Banned:     commonPasswords,
+}
+
+// Validate returns an ErrBadParamInput error describing the first rule the
+// password breaks.
+func (p PasswordPolicy) Validate(password string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("%w: password must be at least %d characters long", domain.ErrBadParamInput, p.MinLength)
+	}
+	if len(password) > maxPasswordBytes {
+		return fmt.Errorf("%w: password must be at most %d bytes long", domain.ErrBadParamInput, maxPasswordBytes)
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	classes := 0
+	for _, has := range []bool{lower, upper, digit, symbol} {
+		if has {
+			classes++
+		}
+	}
+	if classes < p.MinClasses {
+		return fmt.Errorf("%w: password must mix at least %d of lower case letters, upper case letters, digits and symbols",
+			domain.ErrBadParamInput, p.MinClasses)
+	}
+
+	for _, banned := range p.Banned {
+		if strings.EqualFold(password, banned) {
+			return fmt.Errorf("%w: password is too common", domain.ErrBadParamInput)
+		}
+	}
+	return nil
+}
+
+type PasswordResetConfig struct {
+	// TTL is how long a reset token stays valid
+	TTL time.Duration
+	// URL is the reset page of the client application, the token is added
+	// as the token query parameter
+	URL string
+}
+
+var DefaultPasswordResetConfig = PasswordResetConfig{
+	TTL: time.Hour,
+	URL: "http://localhost:3000/reset-password",
+}
+
+// HashToken returns the stored form of API keys and one-time tokens. They are
+// long random strings, a plain SHA-256 is enough to make a leaked table useless.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
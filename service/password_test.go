@@ -0,0 +1,306 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/mail"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service/mocks"
+	"github.com/edwinjordan/ZOGTest-Golang.git/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := service.DefaultPasswordPolicy
+
+	tests := []struct {
+		name     string
+		password string
+		valid    bool
+	}{
+		{"Long password mixing three classes", "Correct-horse-battery", true},
+		{"Too short", "Ab1!", false},
+		{"Single class", "correcthorsebattery", false},
+		{"Two classes", "correcthorse42", false},
+		{"Banned whatever the case", "PASSword1234", false},
+		{"Longer than bcrypt accepts", "Aa1" + strings.Repeat("x", 80), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, domain.ErrBadParamInput)
+			}
+		})
+	}
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	userID := uuid.New()
+	hash, err := utils.HashPassword("Current-Pass-1")
+	assert.NoError(t, err)
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{
+		UserID: userID.String(), Name: "Jane", Email: "jane@example.com", Method: auth.MethodBasic,
+	})
+
+	t.Run("Stores the new hash and notifies the user", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mailer := mail.NewMemoryMailer()
+		userService := service.NewUserService(mockUserRepo, service.WithMailer(mailer))
+
+		mockUserRepo.On("GetPasswordHash", mock.Anything, userID).Return(hash, nil).Once()
+		mockUserRepo.On("UpdatePassword", mock.Anything, userID, mock.MatchedBy(func(h string) bool {
+			return utils.ComparePassword("Brand-New-Pass-2", h)
+		})).Return(nil).Once()
+
+		err := userService.ChangePassword(ctx, &domain.ChangePasswordRequest{
+			CurrentPassword: "Current-Pass-1",
+			NewPassword:     "Brand-New-Pass-2",
+		})
+
+		assert.NoError(t, err)
+		if sent := mailer.Sent(); assert.Len(t, sent, 1) {
+			assert.Equal(t, "jane@example.com", sent[0].To)
+		}
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects a wrong current password", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo)
+
+		mockUserRepo.On("GetPasswordHash", mock.Anything, userID).Return(hash, nil).Once()
+
+		err := userService.ChangePassword(ctx, &domain.ChangePasswordRequest{
+			CurrentPassword: "Wrong-Pass-1",
+			NewPassword:     "Brand-New-Pass-2",
+		})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+		mockUserRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects a new password breaking the policy", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo)
+
+		mockUserRepo.On("GetPasswordHash", mock.Anything, userID).Return(hash, nil).Once()
+
+		err := userService.ChangePassword(ctx, &domain.ChangePasswordRequest{
+			CurrentPassword: "Current-Pass-1",
+			NewPassword:     "short",
+		})
+
+		assert.ErrorIs(t, err, domain.ErrBadParamInput)
+		mockUserRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Revokes the other sessions of the user", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockSessionRepo := new(mocks.SessionRepository)
+		userService := service.NewUserService(mockUserRepo, service.WithSessions(mockSessionRepo, service.DefaultSessionConfig))
+		sessionID := uuid.NewString()
+		ctx := auth.WithPrincipal(context.Background(), &auth.Principal{
+			UserID: userID.String(), Method: auth.MethodSession, SessionID: sessionID,
+		})
+
+		mockUserRepo.On("GetPasswordHash", mock.Anything, userID).Return(hash, nil).Once()
+		mockUserRepo.On("UpdatePassword", mock.Anything, userID, mock.Anything).Return(nil).Once()
+		mockSessionRepo.On("RevokeOtherSessions", mock.Anything, userID.String(), sessionID).Return(int64(2), nil).Once()
+
+		err := userService.ChangePassword(ctx, &domain.ChangePasswordRequest{
+			CurrentPassword: "Current-Pass-1",
+			NewPassword:     "Brand-New-Pass-2",
+		})
+
+		assert.NoError(t, err)
+		mockSessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Revokes every session of a caller without one", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockSessionRepo := new(mocks.SessionRepository)
+		userService := service.NewUserService(mockUserRepo, service.WithSessions(mockSessionRepo, service.DefaultSessionConfig))
+
+		mockUserRepo.On("GetPasswordHash", mock.Anything, userID).Return(hash, nil).Once()
+		mockUserRepo.On("UpdatePassword", mock.Anything, userID, mock.Anything).Return(nil).Once()
+		mockSessionRepo.On("RevokeSessions", mock.Anything, userID.String()).Return(int64(1), nil).Once()
+
+		err := userService.ChangePassword(ctx, &domain.ChangePasswordRequest{
+			CurrentPassword: "Current-Pass-1",
+			NewPassword:     "Brand-New-Pass-2",
+		})
+
+		assert.NoError(t, err)
+		mockSessionRepo.AssertExpectations(t)
+	})
+}
+
+func TestUserService_ForgotPassword(t *testing.T) {
+	t.Run("Stores a hashed token and emails the plain one", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mailer := mail.NewMemoryMailer()
+		userService := service.NewUserService(mockUserRepo, service.WithMailer(mailer),
+			service.WithPasswordReset(service.PasswordResetConfig{TTL: 30 * time.Minute, URL: "https://app.example.com/reset?lang=id"}))
+
+		creds := &domain.UserCredentials{User: domain.User{ID: uuid.NewString(), Name: "Jane", Email: "jane@example.com", Status: domain.UserStatusActive}, PasswordHash: "hash"}
+		mockUserRepo.On("GetUserCredentials", mock.Anything, "jane@example.com").Return(creds, nil).Once()
+		var storedHash string
+		mockUserRepo.On("CreatePasswordResetToken", mock.Anything, creds.User.ID, mock.Anything, mock.MatchedBy(func(at time.Time) bool {
+			return time.Until(at) > 29*time.Minute && time.Until(at) <= 30*time.Minute
+		})).Run(func(args mock.Arguments) { storedHash = args.String(2) }).Return(nil).Once()
+
+		err := userService.ForgotPassword(context.Background(), &domain.ForgotPasswordRequest{Email: "jane@example.com"})
+		userService.Wait()
+
+		assert.NoError(t, err)
+		sent := mailer.Sent()
+		if assert.Len(t, sent, 1) {
+			link := sent[0].Body[strings.Index(sent[0].Body, "https://"):]
+			parsed, err := url.Parse(strings.Fields(link)[0])
+			assert.NoError(t, err)
+			token := parsed.Query().Get("token")
+			assert.Equal(t, "id", parsed.Query().Get("lang"))
+			assert.NotEmpty(t, token)
+			assert.NotEqual(t, token, storedHash)
+			assert.Equal(t, service.HashToken(token), storedHash)
+		}
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("Does not reveal unknown emails", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mailer := mail.NewMemoryMailer()
+		userService := service.NewUserService(mockUserRepo, service.WithMailer(mailer))
+
+		mockUserRepo.On("GetUserCredentials", mock.Anything, "nobody@example.com").Return(nil, domain.ErrUserNotFound).Once()
+
+		err := userService.ForgotPassword(context.Background(), &domain.ForgotPasswordRequest{Email: "nobody@example.com"})
+		userService.Wait()
+
+		assert.NoError(t, err)
+		assert.Empty(t, mailer.Sent())
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("Gives no local password to users of the OpenID provider", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mailer := mail.NewMemoryMailer()
+		userService := service.NewUserService(mockUserRepo, service.WithMailer(mailer))
+
+		creds := &domain.UserCredentials{User: domain.User{ID: uuid.NewString(), Email: "staff@example.com", Status: domain.UserStatusActive}}
+		mockUserRepo.On("GetUserCredentials", mock.Anything, "staff@example.com").Return(creds, nil).Once()
+
+		err := userService.ForgotPassword(context.Background(), &domain.ForgotPasswordRequest{Email: "staff@example.com"})
+		userService.Wait()
+
+		assert.NoError(t, err)
+		assert.Empty(t, mailer.Sent())
+		mockUserRepo.AssertNotCalled(t, "CreatePasswordResetToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Sends nothing to an unverified email", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mailer := mail.NewMemoryMailer()
+		userService := service.NewUserService(mockUserRepo, service.WithMailer(mailer))
+
+		creds := &domain.UserCredentials{User: domain.User{ID: uuid.NewString(), Email: "changed@example.com", Status: domain.UserStatusPending}, PasswordHash: "hash"}
+		mockUserRepo.On("GetUserCredentials", mock.Anything, "changed@example.com").Return(creds, nil).Once()
+
+		err := userService.ForgotPassword(context.Background(), &domain.ForgotPasswordRequest{Email: "changed@example.com"})
+		userService.Wait()
+
+		assert.NoError(t, err)
+		assert.Empty(t, mailer.Sent())
+		mockUserRepo.AssertNotCalled(t, "CreatePasswordResetToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestUserService_ForgotPassword_Background(t *testing.T) {
+	mockUserRepo := new(mocks.UserRepository)
+	userService := service.NewUserService(mockUserRepo, service.WithMailer(mail.NewMemoryMailer()))
+
+	release := make(chan struct{})
+	mockUserRepo.On("GetUserCredentials", mock.Anything, "jane@example.com").
+		Run(func(args mock.Arguments) {
+			<-release
+			assert.NoError(t, args.Get(0).(context.Context).Err(), "the request context is not used once done")
+		}).Return(nil, errors.New("db down")).Once()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	err := userService.ForgotPassword(ctx, &domain.ForgotPasswordRequest{Email: "jane@example.com"})
+
+	assert.NoError(t, err, "the request does not wait for the lookup nor report its errors")
+	cancel()
+	close(release)
+	userService.Wait()
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestUserService_ResetPassword(t *testing.T) {
+	t.Run("Consumes the token with the new hash", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo)
+
+		mockUserRepo.On("ResetPassword", mock.Anything, service.HashToken("reset-token"), mock.MatchedBy(func(h string) bool {
+			return utils.ComparePassword("Brand-New-Pass-2", h)
+		})).Return(uuid.NewString(), nil).Once()
+
+		err := userService.ResetPassword(context.Background(), &domain.ResetPasswordRequest{Token: "reset-token", NewPassword: "Brand-New-Pass-2"})
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("Reports used or expired tokens", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo)
+
+		mockUserRepo.On("ResetPassword", mock.Anything, mock.Anything, mock.Anything).Return("", domain.ErrInvalidToken).Once()
+
+		err := userService.ResetPassword(context.Background(), &domain.ResetPasswordRequest{Token: "used-token", NewPassword: "Brand-New-Pass-2"})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+	})
+
+	t.Run("Revokes every session of the user", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockSessionRepo := new(mocks.SessionRepository)
+		userService := service.NewUserService(mockUserRepo, service.WithSessions(mockSessionRepo, service.DefaultSessionConfig))
+		userID := uuid.NewString()
+
+		mockUserRepo.On("ResetPassword", mock.Anything, service.HashToken("reset-token"), mock.Anything).Return(userID, nil).Once()
+		mockSessionRepo.On("RevokeSessions", mock.Anything, userID).Return(int64(3), nil).Once()
+
+		err := userService.ResetPassword(context.Background(), &domain.ResetPasswordRequest{Token: "reset-token", NewPassword: "Brand-New-Pass-2"})
+
+		assert.NoError(t, err)
+		mockSessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Reports a failure to revoke the sessions", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockSessionRepo := new(mocks.SessionRepository)
+		userService := service.NewUserService(mockUserRepo, service.WithSessions(mockSessionRepo, service.DefaultSessionConfig))
+		failure := errors.New("connection lost")
+
+		mockUserRepo.On("ResetPassword", mock.Anything, mock.Anything, mock.Anything).Return(uuid.NewString(), nil).Once()
+		mockSessionRepo.On("RevokeSessions", mock.Anything, mock.Anything).Return(int64(0), failure).Once()
+
+		err := userService.ResetPassword(context.Background(), &domain.ResetPasswordRequest{Token: "reset-token", NewPassword: "Brand-New-Pass-2"})
+
+		assert.ErrorIs(t, err, failure)
+	})
+}
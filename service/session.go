@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/edwinjordan/ZOGTest-Golang.git/utils"
+	"github.com/google/uuid"
+)
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *domain.Session) error
+	GetSessionByHash(ctx context.Context, tokenHash string) (*domain.SessionCredentials, error)
+	TouchSession(ctx context.Context, id, ip string) error
+	GetSessions(ctx context.Context, userID string) ([]domain.Session, error)
+	RevokeSession(ctx context.Context, userID, id string) error
+	RevokeSessions(ctx context.Context, userID string) (int64, error)
+	RevokeOtherSessions(ctx context.Context, userID, keepID string) (int64, error)
+}
+
+type SessionConfig struct {
+	// TTL is how long a session stays valid after login
+	TTL time.Duration
+}
+
+var DefaultSessionConfig = SessionConfig{
+	TTL: 12 * time.Hour,
+}
+
+// sessionTouchInterval bounds how often a session's last-seen data is
+// written, so busy clients don't cost a write per request
+const sessionTouchInterval = time.Minute
+
+var errSessionsDisabled = errors.New("sessions are not configured")
+
+// Login checks an email and password pair and starts a session. Users with
+// two-factor authentication get a challenge instead, to answer through
+// LoginTwoFactor.
+func (us *UserService) Login(ctx context.Context, req *domain.LoginRequest) (_ *domain.LoginResult, err error) {
+	defer us.countOperation(ctx, domain.AuditEntityUser, "login", &err)
+	creds, err := us.checkPassword(ctx, req.Email, req.Password)
+	if err != nil {
+		return nil, err
+	}
+	return us.completeLogin(ctx, creds)
+}
+
+// completeLogin starts a session for an authenticated user, or returns the
+// two-factor challenge they must answer first.
+func (us *UserService) completeLogin(ctx context.Context, creds *domain.UserCredentials) (*domain.LoginResult, error) {
+	if creds.TwoFactorEnabled {
+		id, err := uuid.Parse(creds.User.ID)
+		if err != nil {
+			return nil, err
+		}
+		state, err := us.userRepo.GetTwoFactor(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		expiresAt := time.Now().Add(us.twoFactor.ChallengeTTL)
+		return &domain.LoginResult{Challenge: &domain.LoginChallenge{
+			Token:     signLoginChallenge(state.Secret, creds.User.ID, expiresAt),
+			ExpiresAt: expiresAt,
+		}}, nil
+	}
+
+	session, err := us.startSession(ctx, &creds.User)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.LoginResult{Session: session}, nil
+}
+
+func (us *UserService) startSession(ctx context.Context, user *domain.User) (*domain.SessionToken, error) {
+	if us.sessions == nil {
+		return nil, errSessionsDisabled
+	}
+
+	token, err := utils.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+	plain := domain.SessionTokenPrefix + token
+	session := &domain.Session{
+		UserID:    user.ID,
+		IP:        auth.RequestFromContext(ctx).ClientIP,
+		UserAgent: auth.RequestFromContext(ctx).UserAgent,
+		ExpiresAt: time.Now().Add(us.sessionConfig.TTL),
+		TokenHash: HashToken(plain),
+	}
+	if err := us.sessions.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+	return &domain.SessionToken{Token: plain, ExpiresAt: session.ExpiresAt}, nil
+}
+
+// AuthenticateSession returns the caller of a session token. Unknown, expired
+// and revoked sessions yield ErrInvalidToken.
+func (us *UserService) AuthenticateSession(ctx context.Context, token string) (*auth.Principal, error) {
+	if us.sessions == nil {
+		return nil, domain.ErrInvalidToken
+	}
+	creds, err := us.sessions.GetSessionByHash(ctx, HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	us.touchSession(ctx, &creds.Session)
+
+	return &auth.Principal{
+		UserID:                 creds.User.ID,
+		Name:                   creds.User.Name,
+		Email:                  creds.User.Email,
+		Role:                   creds.User.Role,
+		Method:                 auth.MethodSession,
+		SessionID:              creds.Session.ID,
+		TwoFactorSetupRequired: creds.TwoFactorRequired && !creds.TwoFactorEnabled,
+	}, nil
+}
+
+// touchSession updates the last-seen data of a session. Failures are only
+// logged, they must not fail the request.
+func (us *UserService) touchSession(ctx context.Context, session *domain.Session) {
+	ip := auth.RequestFromContext(ctx).ClientIP
+	if time.Since(session.LastSeenAt) < sessionTouchInterval && ip == session.LastSeenIP {
+		return
+	}
+	if err := us.sessions.TouchSession(ctx, session.ID, ip); err != nil {
+		logging.LogError(ctx, err, "touch_session", slog.String("session_id", session.ID))
+	}
+}
+
+// GetSessions lists the live sessions of the caller, marking the one the
+// request was made with.
+func (us *UserService) GetSessions(ctx context.Context) ([]domain.Session, error) {
+	caller, id, err := callerUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if us.sessions == nil {
+		return []domain.Session{}, nil
+	}
+
+	sessions, err := us.sessions.GetSessions(ctx, id.String())
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == caller.SessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession logs the caller out of one of their sessions.
+func (us *UserService) RevokeSession(ctx context.Context, sessionID uuid.UUID) error {
+	_, id, err := callerUserID(ctx)
+	if err != nil {
+		return err
+	}
+	if us.sessions == nil {
+		return domain.ErrNotFound
+	}
+
+	if err := us.sessions.RevokeSession(ctx, id.String(), sessionID.String()); err != nil {
+		return err
+	}
+	logging.LogSecurityEvent(ctx, "session_revoked",
+		slog.String("user_id", id.String()),
+		slog.String("session_id", sessionID.String()),
+	)
+	return nil
+}
+
+// RevokeAllSessions logs the caller out everywhere, including the session
+// of the request itself.
+func (us *UserService) RevokeAllSessions(ctx context.Context) (*domain.RevokedSessions, error) {
+	_, id, err := callerUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return us.revokeSessions(ctx, id.String())
+}
+
+// RevokeUserSessions lets an admin log a user out everywhere.
+func (us *UserService) RevokeUserSessions(ctx context.Context, userID uuid.UUID) (*domain.RevokedSessions, error) {
+	user, err := us.userRepo.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := us.revokeSessions(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	us.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionRevoke,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   user.ID,
+		After:      map[string]any{"sessions_revoked": revoked.Revoked},
+	})
+	return revoked, nil
+}
+
+func (us *UserService) revokeSessions(ctx context.Context, userID string) (*domain.RevokedSessions, error) {
+	if us.sessions == nil {
+		return &domain.RevokedSessions{}, nil
+	}
+
+	n, err := us.sessions.RevokeSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	logging.LogSecurityEvent(ctx, "sessions_revoked",
+		slog.String("user_id", userID),
+		slog.Int64("count", n),
+	)
+	return &domain.RevokedSessions{Revoked: n}, nil
+}
+
+// revokeOtherSessions ends the sessions of the caller except the one of the
+// request, callers without a session are logged out everywhere.
+func (us *UserService) revokeOtherSessions(ctx context.Context, caller *auth.Principal) (*domain.RevokedSessions, error) {
+	if us.sessions == nil || caller.SessionID == "" {
+		return us.revokeSessions(ctx, caller.UserID)
+	}
+
+	n, err := us.sessions.RevokeOtherSessions(ctx, caller.UserID, caller.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	logging.LogSecurityEvent(ctx, "sessions_revoked",
+		slog.String("user_id", caller.UserID),
+		slog.Int64("count", n),
+	)
+	return &domain.RevokedSessions{Revoked: n}, nil
+}
@@ -0,0 +1,140 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUserService_TouchSession(t *testing.T) {
+	ctx := context.Background()
+	user := domain.User{ID: uuid.NewString(), Role: domain.RoleUser, Status: domain.UserStatusActive}
+	token := domain.SessionTokenPrefix + "abc"
+
+	t.Run("Skips sessions seen recently from the same IP", func(t *testing.T) {
+		mockSessionRepo := new(mocks.SessionRepository)
+		userService := service.NewUserService(new(mocks.UserRepository), service.WithSessions(mockSessionRepo, service.DefaultSessionConfig))
+
+		mockSessionRepo.On("GetSessionByHash", mock.Anything, service.HashToken(token)).Return(&domain.SessionCredentials{
+			Session: domain.Session{ID: "s-1", UserID: user.ID, LastSeenAt: time.Now()},
+			User:    user,
+		}, nil).Once()
+
+		_, err := userService.AuthenticateSession(ctx, token)
+
+		assert.NoError(t, err)
+		mockSessionRepo.AssertNotCalled(t, "TouchSession", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Keeps authenticating when the touch fails", func(t *testing.T) {
+		mockSessionRepo := new(mocks.SessionRepository)
+		userService := service.NewUserService(new(mocks.UserRepository), service.WithSessions(mockSessionRepo, service.DefaultSessionConfig))
+
+		mockSessionRepo.On("GetSessionByHash", mock.Anything, service.HashToken(token)).Return(&domain.SessionCredentials{
+			Session: domain.Session{ID: "s-1", UserID: user.ID, LastSeenAt: time.Now().Add(-time.Hour)},
+			User:    user,
+		}, nil).Once()
+		mockSessionRepo.On("TouchSession", mock.Anything, "s-1", "").Return(errors.New("db down")).Once()
+
+		principal, err := userService.AuthenticateSession(ctx, token)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "s-1", principal.SessionID)
+		mockSessionRepo.AssertExpectations(t)
+	})
+}
+
+func TestUserService_GetSessions(t *testing.T) {
+	id := uuid.New()
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{UserID: id.String(), Method: auth.MethodSession, SessionID: "s-2"})
+
+	t.Run("Marks the session of the request", func(t *testing.T) {
+		mockSessionRepo := new(mocks.SessionRepository)
+		userService := service.NewUserService(new(mocks.UserRepository), service.WithSessions(mockSessionRepo, service.DefaultSessionConfig))
+
+		mockSessionRepo.On("GetSessions", mock.Anything, id.String()).
+			Return([]domain.Session{{ID: "s-1"}, {ID: "s-2"}}, nil).Once()
+
+		sessions, err := userService.GetSessions(ctx)
+
+		assert.NoError(t, err)
+		assert.False(t, sessions[0].Current)
+		assert.True(t, sessions[1].Current)
+	})
+
+	t.Run("Refuses API key callers", func(t *testing.T) {
+		userService := service.NewUserService(new(mocks.UserRepository), service.WithSessions(new(mocks.SessionRepository), service.DefaultSessionConfig))
+		keyCtx := auth.WithPrincipal(context.Background(), &auth.Principal{UserID: id.String(), KeyID: "k-1", Method: auth.MethodAPIKey})
+
+		_, err := userService.GetSessions(keyCtx)
+
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+}
+
+func TestUserService_RevokeSessions(t *testing.T) {
+	id := uuid.New()
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{UserID: id.String(), Method: auth.MethodSession, SessionID: "s-1"})
+
+	t.Run("Revokes one session of the caller", func(t *testing.T) {
+		mockSessionRepo := new(mocks.SessionRepository)
+		userService := service.NewUserService(new(mocks.UserRepository), service.WithSessions(mockSessionRepo, service.DefaultSessionConfig))
+		sessionID := uuid.New()
+
+		mockSessionRepo.On("RevokeSession", mock.Anything, id.String(), sessionID.String()).Return(domain.ErrNotFound).Once()
+
+		err := userService.RevokeSession(ctx, sessionID)
+
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		mockSessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Logs the caller out everywhere", func(t *testing.T) {
+		mockSessionRepo := new(mocks.SessionRepository)
+		userService := service.NewUserService(new(mocks.UserRepository), service.WithSessions(mockSessionRepo, service.DefaultSessionConfig))
+
+		mockSessionRepo.On("RevokeSessions", mock.Anything, id.String()).Return(int64(3), nil).Once()
+
+		revoked, err := userService.RevokeAllSessions(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), revoked.Revoked)
+	})
+
+	t.Run("Lets admins log a user out everywhere", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockSessionRepo := new(mocks.SessionRepository)
+		userService := service.NewUserService(mockUserRepo, service.WithSessions(mockSessionRepo, service.DefaultSessionConfig))
+		target := uuid.New()
+
+		mockUserRepo.On("GetUser", mock.Anything, target).Return(&domain.User{ID: target.String()}, nil).Once()
+		mockSessionRepo.On("RevokeSessions", mock.Anything, target.String()).Return(int64(2), nil).Once()
+
+		revoked, err := userService.RevokeUserSessions(context.Background(), target)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), revoked.Revoked)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("Reports unknown users", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo, service.WithSessions(new(mocks.SessionRepository), service.DefaultSessionConfig))
+		target := uuid.New()
+
+		mockUserRepo.On("GetUser", mock.Anything, target).Return(nil, domain.ErrNotFound).Once()
+
+		_, err := userService.RevokeUserSessions(context.Background(), target)
+
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+}
@@ -2,11 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
 	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
 	"github.com/google/uuid"
-	"go.opentelemetry.io/otel"
 )
 
 type TopicRepository interface {
@@ -15,15 +16,19 @@ type TopicRepository interface {
 	GetTopic(ctx context.Context, id uuid.UUID) (*domain.Topic, error)
 	UpdateTopic(ctx context.Context, id uuid.UUID, topic *domain.Topic) (*domain.Topic, error)
 	DeleteTopic(ctx context.Context, id uuid.UUID) error
+	BulkSaveTopics(ctx context.Context, items []domain.BulkTopicItem, allOrNothing bool) ([]domain.BulkItemResult, error)
+	MergeTopics(ctx context.Context, sourceID, targetID uuid.UUID) (int64, error)
 }
 
 type TopicService struct {
 	topicRepo TopicRepository
+	serviceOptions
 }
 
-func NewTopicService(u TopicRepository) *TopicService {
+func NewTopicService(u TopicRepository, opts ...Option) *TopicService {
 	return &TopicService{
-		topicRepo: u,
+		topicRepo:      u,
+		serviceOptions: newServiceOptions(opts),
 	}
 }
 
@@ -31,11 +36,19 @@ func NewTopicService(u TopicRepository) *TopicService {
 func (us *TopicService) CreateTopic(
 	ctx context.Context,
 	u *domain.CreateTopicRequest,
-) (*domain.Topic, error) {
+) (_ *domain.Topic, err error) {
+	defer us.countOperation(ctx, domain.AuditEntityTopic, "create", &err)
 	createdTopic, err := us.topicRepo.CreateTopic(ctx, u)
 	if err != nil {
 		return nil, err
 	}
+
+	us.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionCreate,
+		EntityType: domain.AuditEntityTopic,
+		EntityID:   createdTopic.ID,
+		After:      createdTopic,
+	})
 	return createdTopic, nil
 }
 
@@ -44,11 +57,7 @@ func (us *TopicService) GetTopic(
 	ctx context.Context,
 	id uuid.UUID,
 ) (*domain.Topic, error) {
-	tracer := otel.Tracer("service.topic")
-	ctxTrace, span := tracer.Start(ctx, "TopicService.GetTopic")
-	defer span.End()
-
-	topic, err := us.topicRepo.GetTopic(ctxTrace, id)
+	topic, err := us.topicRepo.GetTopic(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -60,8 +69,8 @@ func (us *TopicService) UpdateTopic(
 	ctx context.Context,
 	id uuid.UUID,
 	u *domain.Topic,
-) (*domain.Topic, error) {
-
+) (_ *domain.Topic, err error) {
+	defer us.countOperation(ctx, domain.AuditEntityTopic, "update", &err)
 	existing, err := us.topicRepo.GetTopic(ctx, id)
 	if err != nil {
 		return nil, err
@@ -69,6 +78,7 @@ func (us *TopicService) UpdateTopic(
 	if existing == nil {
 		return nil, domain.ErrUserNotFound
 	}
+	before := *existing
 
 	existing.Name = u.Name
 	existing.Slug = u.Slug
@@ -78,6 +88,14 @@ func (us *TopicService) UpdateTopic(
 		return nil, err
 	}
 
+	us.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityTopic,
+		EntityID:   id.String(),
+		Before:     before,
+		After:      existing,
+	})
+
 	return existing, nil
 }
 
@@ -85,8 +103,8 @@ func (us *TopicService) UpdateTopic(
 func (us *TopicService) DeleteTopic(
 	ctx context.Context,
 	id uuid.UUID,
-) error {
-
+) (err error) {
+	defer us.countOperation(ctx, domain.AuditEntityTopic, "delete", &err)
 	topic, err := us.topicRepo.GetTopic(ctx, id)
 	if err != nil {
 		return err
@@ -100,6 +118,13 @@ func (us *TopicService) DeleteTopic(
 		return err
 	}
 
+	us.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionDelete,
+		EntityType: domain.AuditEntityTopic,
+		EntityID:   id.String(),
+		Before:     topic,
+	})
+
 	return nil
 }
 
@@ -112,3 +137,48 @@ func (us *TopicService) GetTopicList(ctx context.Context, filter *domain.TopicFi
 
 	return topics, nil
 }
+
+// BulkSaveTopics creates the entries without an ID and updates the others.
+func (us *TopicService) BulkSaveTopics(ctx context.Context, req *domain.BulkTopicRequest) (_ *domain.BulkResult, err error) {
+	defer us.countOperation(ctx, domain.AuditEntityTopic, "bulk_save", &err)
+	result, err := runBulk(ctx, req.Items, req.AllOrNothing, validateBulkTopicItem, us.topicRepo.BulkSaveTopics)
+	us.auditor.Record(ctx, bulkAuditEntries(domain.AuditEntityTopic, result, req.Items)...)
+	return result, err
+}
+
+// MergeTopics folds the source topic into the target one, the news of the
+// source are moved to the target and the source is deleted. Returns the number
+// of news moved.
+func (us *TopicService) MergeTopics(ctx context.Context, sourceID uuid.UUID, req *domain.MergeTopicRequest) (_ int64, err error) {
+	defer us.countOperation(ctx, domain.AuditEntityTopic, "merge", &err)
+	targetID, err := uuid.Parse(req.TargetID)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid target id", domain.ErrBadParamInput)
+	}
+	if targetID == sourceID {
+		return 0, fmt.Errorf("%w: a topic cannot be merged into itself", domain.ErrBadParamInput)
+	}
+
+	moved, err := us.topicRepo.MergeTopics(ctx, sourceID, targetID)
+	if err != nil {
+		return 0, err
+	}
+
+	us.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionMerge,
+		EntityType: domain.AuditEntityTopic,
+		EntityID:   sourceID.String(),
+		After:      domain.TopicMergedPayload{SourceID: sourceID.String(), TargetID: targetID.String(), MovedNews: moved},
+	})
+	return moved, nil
+}
+
+func validateBulkTopicItem(item domain.BulkTopicItem) error {
+	if err := validateOptionalID(item.ID); err != nil {
+		return err
+	}
+	if item.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
@@ -327,3 +327,89 @@ func TestTopicService_GetTopicList(t *testing.T) {
 		mockTopicRepo.AssertExpectations(t)
 	})
 }
+
+func TestTopicService_BulkSaveTopics(t *testing.T) {
+	ctx := context.Background()
+	existingID := uuid.New().String()
+
+	t.Run("Saves valid items and reports invalid ones", func(t *testing.T) {
+		mockTopicRepo := new(mocks.TopicRepository)
+		topicService := service.NewTopicService(mockTopicRepo)
+
+		items := []domain.BulkTopicItem{{Name: "Politics"}, {ID: existingID, Name: "Economy"}}
+		createdID := uuid.New().String()
+		mockTopicRepo.On("BulkSaveTopics", mock.Anything, items, false).
+			Return([]domain.BulkItemResult{
+				{Index: 0, ID: createdID, Action: domain.BulkActionCreated},
+				{Index: 1, ID: existingID, Action: domain.BulkActionUpdated},
+			}, nil).Once()
+
+		result, err := topicService.BulkSaveTopics(ctx, &domain.BulkTopicRequest{
+			Items: []domain.BulkTopicItem{items[0], {ID: "bad-id", Name: "Sport"}, items[1]},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, result.Succeeded)
+		assert.Equal(t, 1, result.Failed)
+		assert.Equal(t, createdID, result.Items[0].ID)
+		assert.NotEmpty(t, result.Items[1].Error)
+		assert.Equal(t, existingID, result.Items[2].ID)
+
+		mockTopicRepo.AssertExpectations(t)
+	})
+
+	t.Run("All or nothing aborts on invalid items", func(t *testing.T) {
+		mockTopicRepo := new(mocks.TopicRepository)
+		topicService := service.NewTopicService(mockTopicRepo)
+
+		result, err := topicService.BulkSaveTopics(ctx, &domain.BulkTopicRequest{
+			AllOrNothing: true,
+			Items:        []domain.BulkTopicItem{{Name: "Politics"}, {}},
+		})
+
+		assert.ErrorIs(t, err, domain.ErrBulkAborted)
+		assert.Equal(t, 2, result.Failed)
+
+		mockTopicRepo.AssertNotCalled(t, "BulkSaveTopics", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestTopicService_MergeTopics(t *testing.T) {
+	sourceID := uuid.New()
+	targetID := uuid.New()
+
+	t.Run("Merges the topic into the target", func(t *testing.T) {
+		mockTopicRepo := new(mocks.TopicRepository)
+		topicService := service.NewTopicService(mockTopicRepo)
+
+		mockTopicRepo.On("MergeTopics", mock.Anything, sourceID, targetID).Return(int64(4), nil).Once()
+
+		moved, err := topicService.MergeTopics(context.Background(), sourceID, &domain.MergeTopicRequest{TargetID: targetID.String()})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), moved)
+		mockTopicRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects merging a topic into itself", func(t *testing.T) {
+		mockTopicRepo := new(mocks.TopicRepository)
+		topicService := service.NewTopicService(mockTopicRepo)
+
+		_, err := topicService.MergeTopics(context.Background(), sourceID, &domain.MergeTopicRequest{TargetID: sourceID.String()})
+
+		assert.ErrorIs(t, err, domain.ErrBadParamInput)
+		mockTopicRepo.AssertNotCalled(t, "MergeTopics", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Returns not found from the repository", func(t *testing.T) {
+		mockTopicRepo := new(mocks.TopicRepository)
+		topicService := service.NewTopicService(mockTopicRepo)
+
+		mockTopicRepo.On("MergeTopics", mock.Anything, sourceID, targetID).Return(int64(0), domain.ErrNotFound).Once()
+
+		_, err := topicService.MergeTopics(context.Background(), sourceID, &domain.MergeTopicRequest{TargetID: targetID.String()})
+
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		mockTopicRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/totp"
+	"github.com/edwinjordan/ZOGTest-Golang.git/utils"
+	"github.com/google/uuid"
+)
+
+type TwoFactorConfig struct {
+	// Issuer names the account in authenticator apps
+	Issuer string
+	// ChallengeTTL is how long a user has to enter the second factor after
+	// the password was accepted
+	ChallengeTTL time.Duration
+	// RecoveryCodes is the number of recovery codes handed out
+	RecoveryCodes int
+	// Skew is the number of 30 second steps a code may be early or late
+	Skew int
+}
+
+var DefaultTwoFactorConfig = TwoFactorConfig{
+	Issuer:        "ZOGTest",
+	ChallengeTTL:  5 * time.Minute,
+	RecoveryCodes: 10,
+	Skew:          1,
+}
+
+// signLoginChallenge returns a token proving the password of a user was
+// accepted until expiresAt. It is signed with the TOTP secret of the user so
+// resetting two-factor authentication voids pending challenges.
+func signLoginChallenge(secret, userID string, expiresAt time.Time) string {
+	payload := userID + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(loginChallengeMAC(secret, payload))
+}
+
+func loginChallengeMAC(secret, payload string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("login-challenge\n" + payload))
+	return mac.Sum(nil)
+}
+
+// normalizeRecoveryCode drops the separators and case users may type a
+// recovery code with
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+func generateRecoveryCodes(n int) ([]string, []string, error) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+	for range n {
+		token, err := utils.GenerateToken(5)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, token[:5]+"-"+token[5:])
+		hashes = append(hashes, HashToken(normalizeRecoveryCode(token)))
+	}
+	return codes, hashes, nil
+}
+
+// callerUserID returns the signed in user calling the service
+func callerUserID(ctx context.Context) (*auth.Principal, uuid.UUID, error) {
+	caller := auth.FromContext(ctx)
+	if caller == nil || caller.UserID == "" || caller.Method == auth.MethodAPIKey {
+		return nil, uuid.Nil, domain.ErrForbidden
+	}
+	id, err := uuid.Parse(caller.UserID)
+	if err != nil {
+		return nil, uuid.Nil, domain.ErrForbidden
+	}
+	return caller, id, nil
+}
+
+// SetupTwoFactor starts the enrollment of an authenticator app for the
+// caller. The secret only takes effect once VerifyTwoFactor confirmed it.
+func (us *UserService) SetupTwoFactor(ctx context.Context) (*domain.TwoFactorSetup, error) {
+	caller, id, err := callerUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := us.userRepo.SetTwoFactorSecret(ctx, id, secret); err != nil {
+		return nil, err
+	}
+
+	logging.LogSecurityEvent(ctx, "two_factor_setup_started", slog.String("user_id", caller.UserID))
+	return &domain.TwoFactorSetup{
+		Secret: secret,
+		URI:    totp.URI(us.twoFactor.Issuer, caller.Email, secret),
+	}, nil
+}
+
+// VerifyTwoFactor enables two-factor authentication once the caller proved
+// the enrolled app works, returning the recovery codes. They are only
+// stored hashed so this is the one time they can be shown.
+func (us *UserService) VerifyTwoFactor(ctx context.Context, req *domain.VerifyTwoFactorRequest) (*domain.RecoveryCodes, error) {
+	caller, id, err := callerUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := us.userRepo.GetTwoFactor(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if state.Enabled() {
+		return nil, domain.ErrConflict
+	}
+	if state.Secret == "" {
+		return nil, fmt.Errorf("%w: set up two-factor authentication first", domain.ErrBadParamInput)
+	}
+	step, ok := totp.Validate(state.Secret, strings.TrimSpace(req.Code), time.Now(), us.twoFactor.Skew)
+	if !ok {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	codes, hashes, err := generateRecoveryCodes(us.twoFactor.RecoveryCodes)
+	if err != nil {
+		return nil, err
+	}
+	if err := us.userRepo.EnableTwoFactor(ctx, id, step, hashes); err != nil {
+		return nil, err
+	}
+
+	logging.LogSecurityEvent(ctx, "two_factor_enabled", slog.String("user_id", caller.UserID))
+	us.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   caller.UserID,
+		After:      map[string]any{"two_factor": "enabled"},
+	})
+	sendEmail(ctx, us.mailer, domain.Email{
+		To:      state.User.Email,
+		Subject: "Two-factor authentication was enabled",
+		Body:    "Hi " + state.User.Name + ",\n\nTwo-factor authentication was just enabled on your account. If you did not do this, contact an administrator right away.\n",
+	})
+	return &domain.RecoveryCodes{Codes: codes}, nil
+}
+
+// LoginTwoFactor completes a login challenged for a second factor with a
+// TOTP code or a recovery code. Wrong codes count as failed logins of the
+// account.
+func (us *UserService) LoginTwoFactor(ctx context.Context, req *domain.TwoFactorLoginRequest) (_ *domain.LoginResult, err error) {
+	defer us.countOperation(ctx, domain.AuditEntityUser, "login_two_factor", &err)
+	id, payload, mac, err := parseSignedToken(req.ChallengeToken, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	state, err := us.userRepo.GetTwoFactor(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, err
+	}
+	if !state.Enabled() || !state.User.Active() || !hmac.Equal(mac, loginChallengeMAC(state.Secret, payload)) {
+		return nil, domain.ErrInvalidToken
+	}
+
+	accountKey, ipKey := us.throttleKeys(ctx, state.User.Email)
+	failures := 0
+	if us.loginThrottle != nil {
+		if failures, err = us.loginThrottle.before(ctx, accountKey, ipKey); err != nil {
+			return nil, err
+		}
+	}
+
+	ok, err := us.checkSecondFactor(ctx, id, state, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		logging.LogAuthAttempt(ctx, state.User.Email, false, "invalid second factor")
+		if us.loginThrottle != nil {
+			us.loginThrottle.failed(ctx, accountKey, ipKey)
+		}
+		return nil, domain.ErrInvalidCredentials
+	}
+	if failures > 0 {
+		us.loginThrottle.succeeded(ctx, accountKey)
+	}
+	logging.LogAuthAttempt(ctx, state.User.Email, true, "second factor")
+
+	session, err := us.startSession(ctx, &state.User)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.LoginResult{Session: session}, nil
+}
+
+// ResetTwoFactor turns two-factor authentication off for a user who lost
+// their authenticator app and recovery codes, so they can log in with their
+// password and set it up again. Pending setups are dropped as well, and
+// challenges issued before are void since their secret is gone.
+func (us *UserService) ResetTwoFactor(ctx context.Context, id uuid.UUID) (err error) {
+	defer us.countOperation(ctx, domain.AuditEntityUser, "reset_two_factor", &err)
+	state, err := us.userRepo.GetTwoFactor(ctx, id)
+	if err != nil {
+		return err
+	}
+	if state.Secret == "" {
+		return nil
+	}
+	if err := us.userRepo.DisableTwoFactor(ctx, id); err != nil {
+		return err
+	}
+
+	before := "pending"
+	if state.Enabled() {
+		before = "enabled"
+	}
+	logging.LogSecurityEvent(ctx, "two_factor_reset", slog.String("user_id", state.User.ID))
+	us.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   state.User.ID,
+		Before:     map[string]any{"two_factor": before},
+		After:      map[string]any{"two_factor": "disabled"},
+	})
+	if state.Enabled() {
+		sendEmail(ctx, us.mailer, domain.Email{
+			To:      state.User.Email,
+			Subject: "Two-factor authentication was turned off",
+			Body:    "Hi " + state.User.Name + ",\n\nAn administrator turned off two-factor authentication on your account. Set it up again after your next login. If you did not ask for this, contact an administrator right away.\n",
+		})
+	}
+	return nil
+}
+
+// checkSecondFactor accepts each TOTP time step and each recovery code once
+func (us *UserService) checkSecondFactor(ctx context.Context, id uuid.UUID, state *domain.TwoFactor, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if _, err := strconv.Atoi(code); err == nil && len(code) == totp.Digits {
+		step, ok := totp.Validate(state.Secret, code, time.Now(), us.twoFactor.Skew)
+		if !ok || step <= state.LastStep {
+			return false, nil
+		}
+		return us.userRepo.UseTOTPStep(ctx, id, step)
+	}
+
+	used, err := us.userRepo.UseRecoveryCode(ctx, id, HashToken(normalizeRecoveryCode(code)))
+	if err != nil || !used {
+		return false, err
+	}
+	logging.LogSecurityEvent(ctx, "recovery_code_used", slog.String("user_id", state.User.ID))
+	return true, nil
+}
+
+// GetTwoFactorPolicies lists the policy of every role, roles without a
+// stored policy do not require two-factor authentication.
+func (us *UserService) GetTwoFactorPolicies(ctx context.Context) ([]domain.TwoFactorPolicy, error) {
+	stored, err := us.userRepo.GetTwoFactorPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]domain.TwoFactorPolicy, 0, len(domain.Roles))
+	for _, role := range domain.Roles {
+		policy := domain.TwoFactorPolicy{Role: role}
+		if i := slices.IndexFunc(stored, func(p domain.TwoFactorPolicy) bool { return p.Role == role }); i >= 0 {
+			policy = stored[i]
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// SetTwoFactorPolicy requires two-factor authentication for the users of a
+// role, or stops requiring it. Users of the role who did not set it up yet
+// can only set it up until they do.
+func (us *UserService) SetTwoFactorPolicy(ctx context.Context, role string, req *domain.UpdateTwoFactorPolicyRequest) (*domain.TwoFactorPolicy, error) {
+	if !slices.Contains(domain.Roles, role) {
+		return nil, fmt.Errorf("%w: unknown role %q", domain.ErrBadParamInput, role)
+	}
+
+	policy, err := us.userRepo.SetTwoFactorPolicy(ctx, role, req.Required)
+	if err != nil {
+		return nil, err
+	}
+
+	logging.LogSecurityEvent(ctx, "two_factor_policy_changed", slog.String("role", role), slog.Bool("required", req.Required))
+	us.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityTwoFactorPolicy,
+		EntityID:   role,
+		After:      map[string]any{"required": req.Required},
+	})
+	return policy, nil
+}
@@ -0,0 +1,355 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/totp"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service/mocks"
+	"github.com/edwinjordan/ZOGTest-Golang.git/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUserService_SetupTwoFactor(t *testing.T) {
+	id := uuid.New()
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{UserID: id.String(), Email: "jane@example.com", Role: domain.RoleUser, Method: auth.MethodBasic})
+
+	t.Run("Stores a new secret and returns its URI", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo, service.WithTwoFactor(service.TwoFactorConfig{Issuer: "ZOG"}))
+
+		mockUserRepo.On("SetTwoFactorSecret", mock.Anything, id, mock.AnythingOfType("string")).Return(nil).Once()
+
+		setup, err := userService.SetupTwoFactor(ctx)
+
+		assert.NoError(t, err)
+		assert.Len(t, setup.Secret, 32)
+		assert.Contains(t, setup.URI, "otpauth://totp/ZOG:jane@example.com?")
+		assert.Contains(t, setup.URI, "secret="+setup.Secret)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("Refuses API key callers", func(t *testing.T) {
+		userService := service.NewUserService(new(mocks.UserRepository))
+		keyCtx := auth.WithPrincipal(context.Background(), &auth.Principal{UserID: id.String(), KeyID: "k-1", Method: auth.MethodAPIKey})
+
+		_, err := userService.SetupTwoFactor(keyCtx)
+
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+}
+
+func TestUserService_VerifyTwoFactor(t *testing.T) {
+	id := uuid.New()
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{UserID: id.String(), Role: domain.RoleUser, Method: auth.MethodBasic})
+	secret, err := totp.GenerateSecret()
+	assert.NoError(t, err)
+	pending := &domain.TwoFactor{User: domain.User{ID: id.String(), Email: "jane@example.com"}, Secret: secret}
+
+	t.Run("Enables two-factor authentication and returns recovery codes", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo)
+
+		step := totp.Step(time.Now())
+		code, err := totp.Code(secret, step)
+		assert.NoError(t, err)
+		mockUserRepo.On("GetTwoFactor", mock.Anything, id).Return(pending, nil).Once()
+		mockUserRepo.On("EnableTwoFactor", mock.Anything, id, step, mock.MatchedBy(func(hashes []string) bool {
+			return len(hashes) == service.DefaultTwoFactorConfig.RecoveryCodes
+		})).Return(nil).Once()
+
+		codes, err := userService.VerifyTwoFactor(ctx, &domain.VerifyTwoFactorRequest{Code: code})
+
+		assert.NoError(t, err)
+		assert.Len(t, codes.Codes, service.DefaultTwoFactorConfig.RecoveryCodes)
+		assert.Regexp(t, `^[0-9a-f]{5}-[0-9a-f]{5}$`, codes.Codes[0])
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects a wrong code", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo)
+
+		mockUserRepo.On("GetTwoFactor", mock.Anything, id).Return(pending, nil).Once()
+
+		_, err := userService.VerifyTwoFactor(ctx, &domain.VerifyTwoFactorRequest{Code: "000000x"})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+		mockUserRepo.AssertNotCalled(t, "EnableTwoFactor", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Needs a setup first", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo)
+
+		mockUserRepo.On("GetTwoFactor", mock.Anything, id).Return(&domain.TwoFactor{User: pending.User}, nil).Once()
+
+		_, err := userService.VerifyTwoFactor(ctx, &domain.VerifyTwoFactorRequest{Code: "123456"})
+
+		assert.ErrorIs(t, err, domain.ErrBadParamInput)
+	})
+}
+
+func TestUserService_LoginTwoFactor(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+	secret, err := totp.GenerateSecret()
+	assert.NoError(t, err)
+	hash, err := utils.HashPassword("Password1234")
+	assert.NoError(t, err)
+
+	user := domain.User{ID: id.String(), Name: "Jane", Email: "jane@example.com", Role: domain.RoleAdmin, Status: domain.UserStatusActive}
+	creds := &domain.UserCredentials{User: user, PasswordHash: hash, TwoFactorEnabled: true}
+	enabledAt := time.Now().Add(-time.Hour)
+	state := &domain.TwoFactor{User: user, Secret: secret, EnabledAt: &enabledAt}
+
+	// challenge logs in with the password and returns the challenge token
+	challenge := func(t *testing.T) (*mocks.UserRepository, *mocks.SessionRepository, *service.UserService, string) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockSessionRepo := new(mocks.SessionRepository)
+		userService := service.NewUserService(mockUserRepo, service.WithSessions(mockSessionRepo, service.DefaultSessionConfig))
+
+		mockUserRepo.On("GetUserCredentials", mock.Anything, "jane@example.com").Return(creds, nil).Once()
+		mockUserRepo.On("GetTwoFactor", mock.Anything, id).Return(state, nil).Once()
+
+		result, err := userService.Login(ctx, &domain.LoginRequest{Email: "jane@example.com", Password: "Password1234"})
+		assert.NoError(t, err)
+		assert.Nil(t, result.Session)
+		if !assert.NotNil(t, result.Challenge) {
+			t.FailNow()
+		}
+		return mockUserRepo, mockSessionRepo, userService, result.Challenge.Token
+	}
+
+	t.Run("Exchanges a challenge and a TOTP code for a session", func(t *testing.T) {
+		mockUserRepo, mockSessionRepo, userService, token := challenge(t)
+
+		step := totp.Step(time.Now())
+		code, err := totp.Code(secret, step)
+		assert.NoError(t, err)
+		mockUserRepo.On("GetTwoFactor", mock.Anything, id).Return(state, nil).Once()
+		mockUserRepo.On("UseTOTPStep", mock.Anything, id, step).Return(true, nil).Once()
+		mockSessionRepo.On("CreateSession", mock.Anything, mock.MatchedBy(func(s *domain.Session) bool {
+			return s.UserID == id.String() && s.TokenHash != ""
+		})).Return(nil).Once()
+
+		result, err := userService.LoginTwoFactor(ctx, &domain.TwoFactorLoginRequest{ChallengeToken: token, Code: code})
+
+		assert.NoError(t, err)
+		assert.True(t, len(result.Session.Token) > len(domain.SessionTokenPrefix))
+		assert.Contains(t, result.Session.Token, domain.SessionTokenPrefix)
+		mockSessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Refuses a replayed TOTP code", func(t *testing.T) {
+		mockUserRepo, _, userService, token := challenge(t)
+
+		step := totp.Step(time.Now())
+		code, err := totp.Code(secret, step)
+		assert.NoError(t, err)
+		mockUserRepo.On("GetTwoFactor", mock.Anything, id).Return(state, nil).Once()
+		mockUserRepo.On("UseTOTPStep", mock.Anything, id, step).Return(false, nil).Once()
+
+		_, err = userService.LoginTwoFactor(ctx, &domain.TwoFactorLoginRequest{ChallengeToken: token, Code: code})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+	})
+
+	t.Run("Accepts a recovery code once", func(t *testing.T) {
+		mockUserRepo, mockSessionRepo, userService, token := challenge(t)
+
+		mockUserRepo.On("GetTwoFactor", mock.Anything, id).Return(state, nil).Once()
+		mockUserRepo.On("UseRecoveryCode", mock.Anything, id, service.HashToken("abcde12345")).Return(true, nil).Once()
+		mockSessionRepo.On("CreateSession", mock.Anything, mock.Anything).Return(nil).Once()
+
+		result, err := userService.LoginTwoFactor(ctx, &domain.TwoFactorLoginRequest{ChallengeToken: token, Code: "ABCDE-12345"})
+
+		assert.NoError(t, err)
+		assert.NotNil(t, result.Session)
+	})
+
+	t.Run("Rejects challenges issued before two-factor was set up again", func(t *testing.T) {
+		mockUserRepo, _, userService, token := challenge(t)
+
+		other, err := totp.GenerateSecret()
+		assert.NoError(t, err)
+		mockUserRepo.On("GetTwoFactor", mock.Anything, id).Return(&domain.TwoFactor{User: user, Secret: other, EnabledAt: &enabledAt}, nil).Once()
+
+		_, err = userService.LoginTwoFactor(ctx, &domain.TwoFactorLoginRequest{ChallengeToken: token, Code: "123456"})
+
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+	})
+
+	t.Run("Basic auth is refused once two-factor is enabled", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo)
+
+		mockUserRepo.On("GetUserCredentials", mock.Anything, "jane@example.com").Return(creds, nil).Once()
+
+		principal, err := userService.Authenticate(ctx, "jane@example.com", "Password1234")
+
+		assert.ErrorIs(t, err, domain.ErrTwoFactorRequired)
+		assert.Nil(t, principal)
+	})
+}
+
+func TestUserService_Sessions(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+	hash, err := utils.HashPassword("Password1234")
+	assert.NoError(t, err)
+	user := domain.User{ID: id.String(), Name: "Jane", Email: "jane@example.com", Role: domain.RoleAdmin, Status: domain.UserStatusActive}
+
+	t.Run("Starts a session straight away without two-factor", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockSessionRepo := new(mocks.SessionRepository)
+		userService := service.NewUserService(mockUserRepo, service.WithSessions(mockSessionRepo, service.SessionConfig{TTL: time.Hour}))
+
+		mockUserRepo.On("GetUserCredentials", mock.Anything, "jane@example.com").
+			Return(&domain.UserCredentials{User: user, PasswordHash: hash}, nil).Once()
+		var stored *domain.Session
+		mockSessionRepo.On("CreateSession", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*domain.Session)
+		}).Return(nil).Once()
+
+		result, err := userService.Login(ctx, &domain.LoginRequest{Email: "jane@example.com", Password: "Password1234"})
+
+		assert.NoError(t, err)
+		assert.Nil(t, result.Challenge)
+		assert.Equal(t, service.HashToken(result.Session.Token), stored.TokenHash)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), result.Session.ExpiresAt, time.Minute)
+	})
+
+	t.Run("Flags users whose role requires two-factor setup", func(t *testing.T) {
+		mockSessionRepo := new(mocks.SessionRepository)
+		userService := service.NewUserService(new(mocks.UserRepository), service.WithSessions(mockSessionRepo, service.DefaultSessionConfig))
+
+		token := domain.SessionTokenPrefix + "abc"
+		mockSessionRepo.On("GetSessionByHash", mock.Anything, service.HashToken(token)).Return(&domain.SessionCredentials{
+			Session:           domain.Session{ID: "s-1", UserID: user.ID},
+			User:              user,
+			TwoFactorRequired: true,
+		}, nil).Once()
+		mockSessionRepo.On("TouchSession", mock.Anything, "s-1", "").Return(nil).Once()
+
+		principal, err := userService.AuthenticateSession(ctx, token)
+
+		assert.NoError(t, err)
+		assert.Equal(t, auth.MethodSession, principal.Method)
+		assert.Equal(t, "s-1", principal.SessionID)
+		assert.True(t, principal.TwoFactorSetupRequired)
+	})
+
+	t.Run("Rejects unknown sessions", func(t *testing.T) {
+		mockSessionRepo := new(mocks.SessionRepository)
+		userService := service.NewUserService(new(mocks.UserRepository), service.WithSessions(mockSessionRepo, service.DefaultSessionConfig))
+
+		mockSessionRepo.On("GetSessionByHash", mock.Anything, mock.Anything).Return(nil, domain.ErrInvalidToken).Once()
+
+		_, err := userService.AuthenticateSession(ctx, domain.SessionTokenPrefix+"nope")
+
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+	})
+}
+
+func TestUserService_ResetTwoFactor(t *testing.T) {
+	id := uuid.New()
+	enabledAt := time.Now().Add(-time.Hour)
+	enabled := &domain.TwoFactor{User: domain.User{ID: id.String(), Email: "jane@example.com"}, Secret: "secret", EnabledAt: &enabledAt}
+
+	t.Run("Turns two-factor authentication off and records it", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockAuditRepo := new(mocks.AuditRepository)
+		userService := service.NewUserService(mockUserRepo,
+			service.WithAuditor(service.NewAuditService(mockAuditRepo, 0)))
+
+		mockUserRepo.On("GetTwoFactor", mock.Anything, id).Return(enabled, nil).Once()
+		mockUserRepo.On("DisableTwoFactor", mock.Anything, id).Return(nil).Once()
+		mockAuditRepo.On("CreateAuditEvents", mock.Anything, mock.MatchedBy(func(events []domain.AuditEvent) bool {
+			if len(events) != 1 {
+				return false
+			}
+			var before, after map[string]any
+			_ = json.Unmarshal(events[0].Before, &before)
+			_ = json.Unmarshal(events[0].After, &after)
+			return events[0].Action == domain.AuditActionUpdate &&
+				events[0].EntityType == domain.AuditEntityUser &&
+				events[0].EntityID == id.String() &&
+				before["two_factor"] == "enabled" && after["two_factor"] == "disabled"
+		})).Return(nil).Once()
+
+		err := userService.ResetTwoFactor(auditContext(), id)
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertExpectations(t)
+		mockAuditRepo.AssertExpectations(t)
+	})
+
+	t.Run("Does nothing for users without two-factor authentication", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo)
+
+		mockUserRepo.On("GetTwoFactor", mock.Anything, id).Return(&domain.TwoFactor{User: enabled.User}, nil).Once()
+
+		err := userService.ResetTwoFactor(auditContext(), id)
+
+		assert.NoError(t, err)
+		mockUserRepo.AssertNotCalled(t, "DisableTwoFactor", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Returns ErrUserNotFound for an unknown user", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo)
+
+		mockUserRepo.On("GetTwoFactor", mock.Anything, id).Return(nil, domain.ErrUserNotFound).Once()
+
+		err := userService.ResetTwoFactor(auditContext(), id)
+
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	})
+}
+
+func TestUserService_SetTwoFactorPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Stores the policy of a known role", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo)
+
+		mockUserRepo.On("SetTwoFactorPolicy", mock.Anything, domain.RoleAdmin, true).
+			Return(&domain.TwoFactorPolicy{Role: domain.RoleAdmin, Required: true}, nil).Once()
+
+		policy, err := userService.SetTwoFactorPolicy(ctx, domain.RoleAdmin, &domain.UpdateTwoFactorPolicyRequest{Required: true})
+
+		assert.NoError(t, err)
+		assert.True(t, policy.Required)
+	})
+
+	t.Run("Rejects unknown roles", func(t *testing.T) {
+		userService := service.NewUserService(new(mocks.UserRepository))
+
+		_, err := userService.SetTwoFactorPolicy(ctx, "owner", &domain.UpdateTwoFactorPolicyRequest{Required: true})
+
+		assert.ErrorIs(t, err, domain.ErrBadParamInput)
+	})
+
+	t.Run("Lists every role", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo)
+
+		mockUserRepo.On("GetTwoFactorPolicies", mock.Anything).
+			Return([]domain.TwoFactorPolicy{{Role: domain.RoleAdmin, Required: true}}, nil).Once()
+
+		policies, err := userService.GetTwoFactorPolicies(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.TwoFactorPolicy{{Role: domain.RoleUser}, {Role: domain.RoleEditor}, {Role: domain.RoleAdmin, Required: true}}, policies)
+	})
+}
@@ -2,68 +2,153 @@ package service
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 	//"{{ package_name }}/domain"
 	//"{{ package_name }}/internal/logging"
 
 	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
 	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/edwinjordan/ZOGTest-Golang.git/utils"
 	"github.com/google/uuid"
-	"go.opentelemetry.io/otel"
 )
 
 type UserRepository interface {
-	CreateUser(ctx context.Context, user *domain.CreateUserRequest) (*domain.User, error)
+	CreateUser(ctx context.Context, user *domain.CreateUserRequest, passwordHash string) (*domain.User, error)
 	GetUserList(ctx context.Context, filter *domain.UserFilter) ([]domain.User, error)
 	GetUser(ctx context.Context, id uuid.UUID) (*domain.User, error)
 	UpdateUser(ctx context.Context, id uuid.UUID, user *domain.User) (*domain.User, error)
 	DeleteUser(ctx context.Context, id uuid.UUID) error
+	GetUserCredentials(ctx context.Context, email string) (*domain.UserCredentials, error)
+	GetPasswordHash(ctx context.Context, id uuid.UUID) (string, error)
+	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error
+	RehashPassword(ctx context.Context, id uuid.UUID, oldHash, newHash string) error
+	CreatePasswordResetToken(ctx context.Context, userID, tokenHash string, expiresAt time.Time) error
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) (string, error)
+	ActivateUser(ctx context.Context, id uuid.UUID) (*domain.User, error)
+	ClaimVerificationResend(ctx context.Context, email string, cooldown time.Duration) (*domain.User, error)
+	GetTwoFactor(ctx context.Context, id uuid.UUID) (*domain.TwoFactor, error)
+	SetTwoFactorSecret(ctx context.Context, id uuid.UUID, secret string) error
+	EnableTwoFactor(ctx context.Context, id uuid.UUID, step int64, recoveryCodeHashes []string) error
+	DisableTwoFactor(ctx context.Context, id uuid.UUID) error
+	UseTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, id uuid.UUID, codeHash string) (bool, error)
+	GetTwoFactorPolicies(ctx context.Context) ([]domain.TwoFactorPolicy, error)
+	SetTwoFactorPolicy(ctx context.Context, role string, required bool) (*domain.TwoFactorPolicy, error)
+	UpsertExternalUser(ctx context.Context, identity *domain.ExternalIdentity) (*domain.UserCredentials, bool, error)
 }
 
 type UserService struct {
 	userRepo UserRepository
+	serviceOptions
+	// background runs the work which must not delay the response
+	background sync.WaitGroup
+	// unknownUserHash is verified when no password hash is known for an
+	// email, so a login takes as long whether it is registered or not
+	unknownUserHash func() string
 }
 
-func NewUserService(u UserRepository) *UserService {
+func NewUserService(u UserRepository, opts ...Option) *UserService {
+	options := newServiceOptions(opts)
+	if len(options.verification.Secret) == 0 {
+		options.verification.Secret = make([]byte, 32)
+		rand.Read(options.verification.Secret)
+	}
+	if len(options.oidc.Secret) == 0 {
+		options.oidc.Secret = make([]byte, 32)
+		rand.Read(options.oidc.Secret)
+	}
 	return &UserService{
-		userRepo: u,
+		userRepo:       u,
+		serviceOptions: options,
+		unknownUserHash: sync.OnceValue(func() string {
+			// A random password nobody can know, hashed by the configured hasher
+			hash, _ := options.passwordHasher.Hash(rand.Text())
+			return hash
+		}),
 	}
 }
 
-// CreateUser adds a new user.
+// CreateUser adds a new pending user and emails them a verification link,
+// they cannot log in before following it.
 func (us *UserService) CreateUser(
 	ctx context.Context,
 	u *domain.CreateUserRequest,
-) (*domain.User, error) {
-	createdUser, err := us.userRepo.CreateUser(ctx, u)
+) (_ *domain.User, err error) {
+	defer us.countOperation(ctx, domain.AuditEntityUser, "create", &err)
+	if err := us.passwordPolicy.Validate(u.Password); err != nil {
+		return nil, err
+	}
+
+	hash, err := us.passwordHasher.Hash(u.Password)
 	if err != nil {
 		return nil, err
 	}
+	createdUser, err := us.userRepo.CreateUser(ctx, u, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	us.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionCreate,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   createdUser.ID,
+		After:      createdUser,
+	})
+	us.sendVerification(ctx, createdUser)
 	return createdUser, nil
 }
 
-// GetUser fetches a user by ID.
+// authorizeUser only lets the user themselves and admins through
+func authorizeUser(ctx context.Context, id uuid.UUID) error {
+	caller := auth.FromContext(ctx)
+	switch {
+	case caller == nil || caller.Method == auth.MethodAPIKey:
+		return domain.ErrForbidden
+	case caller.UserID == id.String():
+		return nil
+	case caller.HasRole(domain.RoleAdmin) && !caller.TwoFactorSetupRequired:
+		return nil
+	}
+	return domain.ErrForbidden
+}
+
+// GetUser fetches a user by ID, for the user themselves or an admin.
 func (us *UserService) GetUser(
 	ctx context.Context,
 	id uuid.UUID,
 ) (*domain.User, error) {
-	tracer := otel.Tracer("service.user")
-	ctxTrace, span := tracer.Start(ctx, "UserService.GetUser")
-	defer span.End()
-
-	user, err := us.userRepo.GetUser(ctxTrace, id)
+	if err := authorizeUser(ctx, id); err != nil {
+		return nil, err
+	}
+	user, err := us.userRepo.GetUser(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
-// UpdateUser updates name/email of an existing user.
+// UpdateUser updates name/email of an existing user, for the user
+// themselves or an admin. A new email makes the user pending again until
+// they verify it, and the previous address is told about the change.
 func (us *UserService) UpdateUser(
 	ctx context.Context,
 	id uuid.UUID,
 	u *domain.User,
-) (*domain.User, error) {
-
+) (_ *domain.User, err error) {
+	defer us.countOperation(ctx, domain.AuditEntityUser, "update", &err)
+	if err := authorizeUser(ctx, id); err != nil {
+		return nil, err
+	}
 	existing, err := us.userRepo.GetUser(ctx, id)
 	if err != nil {
 		return nil, err
@@ -71,24 +156,43 @@ func (us *UserService) UpdateUser(
 	if existing == nil {
 		return nil, domain.ErrUserNotFound
 	}
+	before := *existing
 
 	existing.Name = u.Name
 	existing.Email = u.Email
 
-	_, err = us.userRepo.UpdateUser(ctx, id, existing)
+	updated, err := us.userRepo.UpdateUser(ctx, id, existing)
 	if err != nil {
 		return nil, err
 	}
 
-	return existing, nil
+	us.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   id.String(),
+		Before:     before,
+		After:      updated,
+	})
+	if !strings.EqualFold(before.Email, updated.Email) {
+		logging.LogSecurityEvent(ctx, "email_changed", slog.String("user_id", updated.ID))
+		sendEmail(ctx, us.mailer, domain.Email{
+			To:      before.Email,
+			Subject: "Your email address was changed",
+			Body: "Hi " + before.Name + ",\n\nThe email address of your account was changed to another one. " +
+				"If you did not do this, contact an administrator right away.\n",
+		})
+		us.sendVerification(ctx, updated)
+	}
+
+	return updated, nil
 }
 
 // DeleteUser removes a user by ID.
 func (us *UserService) DeleteUser(
 	ctx context.Context,
 	id uuid.UUID,
-) error {
-
+) (err error) {
+	defer us.countOperation(ctx, domain.AuditEntityUser, "delete", &err)
 	user, err := us.userRepo.GetUser(ctx, id)
 	if err != nil {
 		return err
@@ -102,6 +206,13 @@ func (us *UserService) DeleteUser(
 		return err
 	}
 
+	us.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionDelete,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   id.String(),
+		Before:     user,
+	})
+
 	return nil
 }
 
@@ -114,3 +225,365 @@ func (us *UserService) GetUserList(ctx context.Context, filter *domain.UserFilte
 
 	return users, nil
 }
+
+// Authenticate checks an email and password pair and returns the matching
+// caller. Unknown emails and wrong passwords both yield ErrInvalidCredentials,
+// users who did not verify their email yet get ErrUnverifiedAccount and users
+// with two-factor authentication ErrTwoFactorRequired, they have to log in
+// through Login. With a login throttle, locked accounts and client IPs get an
+// AccountLockedError.
+func (us *UserService) Authenticate(ctx context.Context, email, password string) (*auth.Principal, error) {
+	creds, err := us.checkPassword(ctx, email, password)
+	if err != nil {
+		return nil, err
+	}
+	if creds.TwoFactorEnabled {
+		return nil, domain.ErrTwoFactorRequired
+	}
+
+	return &auth.Principal{
+		UserID:                 creds.User.ID,
+		Name:                   creds.User.Name,
+		Email:                  creds.User.Email,
+		Role:                   creds.User.Role,
+		Method:                 auth.MethodBasic,
+		TwoFactorSetupRequired: creds.TwoFactorRequired,
+	}, nil
+}
+
+func (us *UserService) throttleKeys(ctx context.Context, email string) (string, string) {
+	if ip := auth.RequestFromContext(ctx).ClientIP; ip != "" {
+		return accountThrottleKey(email), ipThrottleKey(ip)
+	}
+	return accountThrottleKey(email), ""
+}
+
+// checkPassword returns the credentials of an active user whose password
+// matches, counting failures against the login throttle.
+func (us *UserService) checkPassword(ctx context.Context, email, password string) (*domain.UserCredentials, error) {
+	accountKey, ipKey := us.throttleKeys(ctx, email)
+	failures := 0
+	if us.loginThrottle != nil {
+		var err error
+		if failures, err = us.loginThrottle.before(ctx, accountKey, ipKey); err != nil {
+			return nil, err
+		}
+	}
+
+	creds, err := us.userRepo.GetUserCredentials(ctx, email)
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		return nil, err
+	}
+	known := creds != nil && creds.PasswordHash != ""
+	hash := us.unknownUserHash()
+	if known {
+		hash = creds.PasswordHash
+	}
+	match, rehash := us.passwordHasher.Verify(password, hash)
+	match = match && known
+	if !match {
+		logging.LogAuthAttempt(ctx, email, false, "invalid credentials")
+		if us.loginThrottle != nil {
+			us.loginThrottle.failed(ctx, accountKey, ipKey)
+		}
+		return nil, domain.ErrInvalidCredentials
+	}
+	if rehash {
+		us.rehashPassword(ctx, creds, password)
+	}
+	if failures > 0 {
+		logging.LogAuthAttempt(ctx, email, true, "after failed attempts")
+		us.loginThrottle.succeeded(ctx, accountKey)
+	}
+	// Only tell about the pending state once the password proved ownership
+	if !creds.User.Active() {
+		return nil, domain.ErrUnverifiedAccount
+	}
+	return creds, nil
+}
+
+// rehashPassword upgrades an outdated password hash while the password is at
+// hand. Failures are only logged, the login goes on with the old hash.
+func (us *UserService) rehashPassword(ctx context.Context, creds *domain.UserCredentials, password string) {
+	id, err := uuid.Parse(creds.User.ID)
+	if err != nil {
+		return
+	}
+	hash, err := us.passwordHasher.Hash(password)
+	if err == nil {
+		err = us.userRepo.RehashPassword(ctx, id, creds.PasswordHash, hash)
+	}
+	if err != nil {
+		logging.LogError(ctx, err, "rehash_password", slog.String("user_id", creds.User.ID))
+		return
+	}
+	logging.LogSecurityEvent(ctx, "password_rehashed", slog.String("user_id", creds.User.ID))
+}
+
+// ChangePassword replaces the password of the caller after checking the
+// current one, then tells the user by email.
+func (us *UserService) ChangePassword(ctx context.Context, req *domain.ChangePasswordRequest) (err error) {
+	defer us.countOperation(ctx, domain.AuditEntityUser, "change_password", &err)
+	caller, id, err := callerUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	hash, err := us.userRepo.GetPasswordHash(ctx, id)
+	if err != nil {
+		return err
+	}
+	if match, _ := us.passwordHasher.Verify(req.CurrentPassword, hash); !match {
+		logging.LogSecurityEvent(ctx, "password_change_rejected", slog.String("user_id", caller.UserID))
+		return domain.ErrInvalidCredentials
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return fmt.Errorf("%w: new password must differ from the current one", domain.ErrBadParamInput)
+	}
+	if err := us.passwordPolicy.Validate(req.NewPassword); err != nil {
+		return err
+	}
+
+	newHash, err := us.passwordHasher.Hash(req.NewPassword)
+	if err != nil {
+		return err
+	}
+	if err := us.userRepo.UpdatePassword(ctx, id, newHash); err != nil {
+		return err
+	}
+	// Whoever knew the old password is logged out, the caller stays logged in
+	revoked, err := us.revokeOtherSessions(ctx, caller)
+	if err != nil {
+		return err
+	}
+
+	logging.LogSecurityEvent(ctx, "password_changed", slog.String("user_id", caller.UserID))
+	us.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   caller.UserID,
+		After:      map[string]any{"password": "changed", "sessions_revoked": revoked.Revoked},
+	})
+	if caller.Email != "" {
+		sendEmail(ctx, us.mailer, domain.Email{
+			To:      caller.Email,
+			Subject: "Your password was changed",
+			Body:    "Hi " + caller.Name + ",\n\nThe password of your account was just changed. If you did not do this, reset your password right away.\n",
+		})
+	}
+	return nil
+}
+
+// ForgotPassword emails a single-use reset link to the user. Unknown emails
+// are not reported so the endpoint cannot be used to find accounts.
+func (us *UserService) ForgotPassword(ctx context.Context, req *domain.ForgotPasswordRequest) (err error) {
+	defer us.countOperation(ctx, domain.AuditEntityUser, "forgot_password", &err)
+	// The token is created and emailed in the background so the response
+	// takes as long whether the email is registered or not
+	ctx = context.WithoutCancel(ctx)
+	us.background.Go(func() {
+		if err := us.sendPasswordReset(ctx, req.Email); err != nil {
+			logging.LogError(ctx, err, "forgot_password")
+		}
+	})
+	return nil
+}
+
+// sendPasswordReset emails a password reset token to the user with email,
+// if any
+func (us *UserService) sendPasswordReset(ctx context.Context, email string) error {
+	creds, err := us.userRepo.GetUserCredentials(ctx, email)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			logging.LogSecurityEvent(ctx, "password_reset_unknown_email")
+			return nil
+		}
+		return err
+	}
+	// Only verified addresses get a reset link, an email changed by someone
+	// else must not receive one before its owner confirmed it
+	if !creds.User.Active() {
+		logging.LogSecurityEvent(ctx, "password_reset_unverified_email", slog.String("user_id", creds.User.ID))
+		return nil
+	}
+	// Users of the OpenID provider have no local password to reset, giving
+	// them one would outlive their account at the provider
+	if creds.PasswordHash == "" {
+		logging.LogSecurityEvent(ctx, "password_reset_external_user", slog.String("user_id", creds.User.ID))
+		return nil
+	}
+
+	token, err := utils.GenerateToken(32)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(us.passwordReset.TTL)
+	if err := us.userRepo.CreatePasswordResetToken(ctx, creds.User.ID, HashToken(token), expiresAt); err != nil {
+		return err
+	}
+
+	logging.LogSecurityEvent(ctx, "password_reset_requested", slog.String("user_id", creds.User.ID))
+	sendEmail(ctx, us.mailer, domain.Email{
+		To:      creds.User.Email,
+		Subject: "Reset your password",
+		Body: "Hi " + creds.User.Name + ",\n\nOpen the link below to choose a new password. It expires in " +
+			us.passwordReset.TTL.String() + " and works only once.\n\n" + tokenLink(us.passwordReset.URL, token) +
+			"\n\nIf you did not ask for a reset you can ignore this email.\n",
+	})
+	return nil
+}
+
+// Wait blocks until the work started in the background, like the password
+// reset emails, is done
+func (us *UserService) Wait() {
+	us.background.Wait()
+}
+
+// ResetPassword sets a new password with a token sent by ForgotPassword.
+// The token and every other pending token of the user are used up.
+func (us *UserService) ResetPassword(ctx context.Context, req *domain.ResetPasswordRequest) (err error) {
+	defer us.countOperation(ctx, domain.AuditEntityUser, "reset_password", &err)
+	if req.Token == "" {
+		return domain.ErrInvalidToken
+	}
+	if err := us.passwordPolicy.Validate(req.NewPassword); err != nil {
+		return err
+	}
+
+	hash, err := us.passwordHasher.Hash(req.NewPassword)
+	if err != nil {
+		return err
+	}
+	userID, err := us.userRepo.ResetPassword(ctx, HashToken(req.Token), hash)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidToken) {
+			logging.LogSecurityEvent(ctx, "password_reset_invalid_token")
+		}
+		return err
+	}
+	// The reset may be recovering a stolen account, so every session ends
+	revoked, err := us.revokeSessions(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	logging.LogSecurityEvent(ctx, "password_reset", slog.String("user_id", userID))
+	us.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   userID,
+		After:      map[string]any{"password": "reset", "sessions_revoked": revoked.Revoked},
+	})
+	return nil
+}
+
+// VerifyEmail activates the user a verification token was issued for.
+// Verifying an active user again succeeds without changing anything.
+func (us *UserService) VerifyEmail(ctx context.Context, token string) (_ *domain.User, err error) {
+	defer us.countOperation(ctx, domain.AuditEntityUser, "verify_email", &err)
+	id, payload, mac, err := parseSignedToken(token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := us.userRepo.GetUser(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, err
+	}
+	if !hmac.Equal(mac, verificationMAC(us.verification.Secret, payload, user.Email)) {
+		logging.LogSecurityEvent(ctx, "email_verification_invalid_token", slog.String("user_id", user.ID))
+		return nil, domain.ErrInvalidToken
+	}
+	if user.Active() {
+		return user, nil
+	}
+
+	activated, err := us.userRepo.ActivateUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	us.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   activated.ID,
+		Before:     user,
+		After:      activated,
+	})
+	return activated, nil
+}
+
+// ResendVerification emails a new verification link to a pending user, at
+// most once per cooldown. Unknown, active and rate limited emails are not
+// reported so the endpoint cannot be used to find accounts.
+func (us *UserService) ResendVerification(ctx context.Context, req *domain.ResendVerificationRequest) error {
+	user, err := us.userRepo.ClaimVerificationResend(ctx, req.Email, us.verification.ResendCooldown)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			logging.LogSecurityEvent(ctx, "email_verification_resend_skipped")
+			return nil
+		}
+		return err
+	}
+
+	us.sendVerification(ctx, user)
+	return nil
+}
+
+func (us *UserService) sendVerification(ctx context.Context, user *domain.User) {
+	token := signVerificationToken(us.verification.Secret, user.ID, user.Email, time.Now().Add(us.verification.TTL))
+	sendEmail(ctx, us.mailer, domain.Email{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: "Hi " + user.Name + ",\n\nOpen the link below to activate your account. It expires in " +
+			us.verification.TTL.String() + ".\n\n" + tokenLink(us.verification.URL, token) + "\n",
+	})
+}
+
+// tokenLink adds a token to a page URL as the token query parameter
+func tokenLink(base, token string) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return base + "?token=" + url.QueryEscape(token)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// UnlockUser lifts the lockout of an account, and of a client IP when one is
+// given, and forgets their failed logins.
+func (us *UserService) UnlockUser(ctx context.Context, id uuid.UUID, req *domain.UnlockUserRequest) (err error) {
+	defer us.countOperation(ctx, domain.AuditEntityUser, "unlock", &err)
+	user, err := us.userRepo.GetUser(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	keys := []string{accountThrottleKey(user.Email)}
+	if req.IP != "" {
+		if net.ParseIP(req.IP) == nil {
+			return fmt.Errorf("%w: invalid ip", domain.ErrBadParamInput)
+		}
+		keys = append(keys, ipThrottleKey(req.IP))
+	}
+	if us.loginThrottle == nil {
+		return nil
+	}
+	if err := us.loginThrottle.repo.ClearLoginFailures(ctx, keys...); err != nil {
+		return err
+	}
+
+	logging.LogSecurityEvent(ctx, "login_unlocked", slog.String("user_id", user.ID), slog.String("ip", req.IP))
+	us.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityUser,
+		EntityID:   user.ID,
+		After:      map[string]any{"lockout": "cleared", "ip": req.IP},
+	})
+	return nil
+}
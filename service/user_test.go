@@ -5,14 +5,18 @@ import (
 	"errors"
 
 	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/mail"
 	"github.com/edwinjordan/ZOGTest-Golang.git/service"
 	"github.com/edwinjordan/ZOGTest-Golang.git/service/mocks"
+	"github.com/edwinjordan/ZOGTest-Golang.git/utils"
 
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestUserService_CreateUser(t *testing.T) {
@@ -22,8 +26,9 @@ func TestUserService_CreateUser(t *testing.T) {
 
 	ctx := context.Background()
 	req := &domain.CreateUserRequest{
-		Name:  "Test User",
-		Email: "test@example.com",
+		Name:     "Test User",
+		Email:    "test@example.com",
+		Password: "Correct-Horse-42",
 	}
 	expectedUser := &domain.User{
 		ID:    uuid.New().String(),
@@ -32,7 +37,10 @@ func TestUserService_CreateUser(t *testing.T) {
 	}
 
 	t.Run("Successfully creates a user", func(t *testing.T) {
-		mockUserRepo.On("CreateUser", mock.Anything, req).Return(expectedUser, nil).Once()
+		hashesPassword := mock.MatchedBy(func(hash string) bool {
+			return hash != req.Password && utils.ComparePassword(req.Password, hash)
+		})
+		mockUserRepo.On("CreateUser", mock.Anything, req, hashesPassword).Return(expectedUser, nil).Once()
 
 		user, err := userService.CreateUser(ctx, req)
 
@@ -45,12 +53,23 @@ func TestUserService_CreateUser(t *testing.T) {
 		mockUserRepo.AssertExpectations(t)
 	})
 
+	t.Run("Rejects a password breaking the policy", func(t *testing.T) {
+		mockUserRepo = new(mocks.UserRepository)
+		userService = service.NewUserService(mockUserRepo)
+
+		user, err := userService.CreateUser(ctx, &domain.CreateUserRequest{Name: "Weak", Email: "weak@example.com", Password: "password"})
+
+		assert.ErrorIs(t, err, domain.ErrBadParamInput)
+		assert.Nil(t, user)
+		mockUserRepo.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything, mock.Anything)
+	})
+
 	t.Run("Returns error when repository fails", func(t *testing.T) {
 		mockUserRepo = new(mocks.UserRepository)
 		userService = service.NewUserService(mockUserRepo)
 
 		repoErr := errors.New("database error")
-		mockUserRepo.On("CreateUser", mock.Anything, req).Return(nil, repoErr).Once()
+		mockUserRepo.On("CreateUser", mock.Anything, req, mock.AnythingOfType("string")).Return(nil, repoErr).Once()
 
 		user, err := userService.CreateUser(ctx, req)
 
@@ -66,7 +85,7 @@ func TestUserService_GetUser(t *testing.T) {
 	mockUserRepo := new(mocks.UserRepository)
 	userService := service.NewUserService(mockUserRepo)
 
-	ctx := context.Background()
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{UserID: uuid.NewString(), Role: domain.RoleAdmin, Method: auth.MethodBasic})
 	userID := uuid.New()
 	expectedUser := &domain.User{
 		ID:    userID.String(),
@@ -122,7 +141,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 	mockUserRepo := new(mocks.UserRepository)
 	userService := service.NewUserService(mockUserRepo)
 
-	ctx := context.Background()
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{UserID: uuid.NewString(), Role: domain.RoleAdmin, Method: auth.MethodBasic})
 	userID := uuid.New()
 	existingUser := &domain.User{
 		ID:    userID.String(),
@@ -208,6 +227,60 @@ func TestUserService_UpdateUser(t *testing.T) {
 	})
 }
 
+func TestUserService_UserAccess(t *testing.T) {
+	userID := uuid.New()
+	self := auth.WithPrincipal(context.Background(), &auth.Principal{UserID: userID.String(), Role: domain.RoleUser, Method: auth.MethodSession})
+	other := auth.WithPrincipal(context.Background(), &auth.Principal{UserID: uuid.NewString(), Role: domain.RoleEditor, Method: auth.MethodSession})
+	apiKey := auth.WithPrincipal(context.Background(), &auth.Principal{UserID: userID.String(), KeyID: "key", Method: auth.MethodAPIKey})
+	user := &domain.User{ID: userID.String(), Name: "Jane", Email: "jane@example.com", Status: domain.UserStatusActive}
+
+	t.Run("Lets users read themselves", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo)
+		mockUserRepo.On("GetUser", mock.Anything, userID).Return(user, nil).Once()
+
+		got, err := userService.GetUser(self, userID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, user, got)
+	})
+
+	for name, ctx := range map[string]context.Context{"anonymous": context.Background(), "other user": other, "API key": apiKey} {
+		t.Run("Rejects "+name, func(t *testing.T) {
+			mockUserRepo := new(mocks.UserRepository)
+			userService := service.NewUserService(mockUserRepo)
+
+			_, err := userService.GetUser(ctx, userID)
+			assert.ErrorIs(t, err, domain.ErrForbidden)
+			_, err = userService.UpdateUser(ctx, userID, &domain.User{Name: "Mallory", Email: "mallory@example.com"})
+			assert.ErrorIs(t, err, domain.ErrForbidden)
+			mockUserRepo.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("Verifies a new email and tells the previous one", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mailer := mail.NewMemoryMailer()
+		userService := service.NewUserService(mockUserRepo, service.WithMailer(mailer))
+		existing := *user
+		pending := domain.User{ID: user.ID, Name: "Jane", Email: "jane@new.example.com", Status: domain.UserStatusPending}
+		mockUserRepo.On("GetUser", mock.Anything, userID).Return(&existing, nil).Once()
+		mockUserRepo.On("UpdateUser", mock.Anything, userID, mock.Anything).Return(&pending, nil).Once()
+
+		updated, err := userService.UpdateUser(self, userID, &domain.User{Name: "Jane", Email: "jane@new.example.com"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.UserStatusPending, updated.Status)
+		sent := mailer.Sent()
+		if assert.Len(t, sent, 2) {
+			assert.Equal(t, "jane@example.com", sent[0].To)
+			assert.Equal(t, "Your email address was changed", sent[0].Subject)
+			assert.Equal(t, "jane@new.example.com", sent[1].To)
+			assert.Equal(t, "Verify your email address", sent[1].Subject)
+		}
+	})
+}
+
 func TestUserService_DeleteUser(t *testing.T) {
 	mockUserRepo := new(mocks.UserRepository)
 	userService := service.NewUserService(mockUserRepo)
@@ -329,3 +402,147 @@ func TestUserService_GetUserList(t *testing.T) {
 		mockUserRepo.AssertExpectations(t)
 	})
 }
+
+func TestUserService_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	hash, err := utils.HashPassword("Password1234")
+	assert.NoError(t, err)
+
+	creds := &domain.UserCredentials{
+		User:         domain.User{ID: uuid.New().String(), Name: "Jane", Email: "jane@example.com", Status: domain.UserStatusActive},
+		PasswordHash: hash,
+	}
+
+	t.Run("Returns the caller for valid credentials", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo)
+
+		mockUserRepo.On("GetUserCredentials", mock.Anything, "jane@example.com").Return(creds, nil).Once()
+
+		principal, err := userService.Authenticate(ctx, "jane@example.com", "Password1234")
+
+		assert.NoError(t, err)
+		assert.Equal(t, creds.User.ID, principal.UserID)
+		assert.Equal(t, auth.MethodBasic, principal.Method)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects a wrong password", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo)
+
+		mockUserRepo.On("GetUserCredentials", mock.Anything, "jane@example.com").Return(creds, nil).Once()
+
+		principal, err := userService.Authenticate(ctx, "jane@example.com", "wrong")
+
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+		assert.Nil(t, principal)
+	})
+
+	t.Run("Refuses users who did not verify their email", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo)
+
+		pending := *creds
+		pending.User.Status = domain.UserStatusPending
+		mockUserRepo.On("GetUserCredentials", mock.Anything, "jane@example.com").Return(&pending, nil).Once()
+
+		principal, err := userService.Authenticate(ctx, "jane@example.com", "Password1234")
+
+		assert.ErrorIs(t, err, domain.ErrUnverifiedAccount)
+		assert.Nil(t, principal)
+	})
+
+	t.Run("Rehashes an outdated hash after a successful login", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		argon := utils.Argon2idHasher{Params: utils.Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}}
+		userService := service.NewUserService(mockUserRepo, service.WithPasswordHasher(argon))
+
+		bcryptHash, err := utils.BcryptHasher{Cost: bcrypt.MinCost}.Hash("Password1234")
+		assert.NoError(t, err)
+		outdated := *creds
+		outdated.PasswordHash = bcryptHash
+		mockUserRepo.On("GetUserCredentials", mock.Anything, "jane@example.com").Return(&outdated, nil).Once()
+		mockUserRepo.On("RehashPassword", mock.Anything, uuid.MustParse(creds.User.ID), bcryptHash, mock.MatchedBy(func(hash string) bool {
+			match, rehash := argon.Verify("Password1234", hash)
+			return match && !rehash
+		})).Return(nil).Once()
+
+		principal, err := userService.Authenticate(ctx, "jane@example.com", "Password1234")
+
+		assert.NoError(t, err)
+		assert.Equal(t, creds.User.ID, principal.UserID)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("Keeps logging in when the rehash fails", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo, service.WithPasswordHasher(utils.BcryptHasher{Cost: bcrypt.MinCost}))
+
+		mockUserRepo.On("GetUserCredentials", mock.Anything, "jane@example.com").Return(creds, nil).Once()
+		mockUserRepo.On("RehashPassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("db down")).Once()
+
+		principal, err := userService.Authenticate(ctx, "jane@example.com", "Password1234")
+
+		assert.NoError(t, err)
+		assert.NotNil(t, principal)
+	})
+
+	t.Run("Flags users whose role requires two-factor setup", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo)
+
+		required := *creds
+		required.TwoFactorRequired = true
+		mockUserRepo.On("GetUserCredentials", mock.Anything, "jane@example.com").Return(&required, nil).Once()
+
+		principal, err := userService.Authenticate(ctx, "jane@example.com", "Password1234")
+
+		assert.NoError(t, err)
+		assert.True(t, principal.TwoFactorSetupRequired)
+	})
+
+	t.Run("Rejects an unknown email", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo)
+
+		mockUserRepo.On("GetUserCredentials", mock.Anything, "nobody@example.com").Return(nil, domain.ErrUserNotFound).Once()
+
+		principal, err := userService.Authenticate(ctx, "nobody@example.com", "Password1234")
+
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+		assert.Nil(t, principal)
+	})
+
+	t.Run("Verifies a hash for unknown emails and users without password", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		hasher := &recordingHasher{PasswordHasher: utils.BcryptHasher{Cost: bcrypt.MinCost}}
+		userService := service.NewUserService(mockUserRepo, service.WithPasswordHasher(hasher))
+
+		external := *creds
+		external.PasswordHash = ""
+		mockUserRepo.On("GetUserCredentials", mock.Anything, "nobody@example.com").Return(nil, domain.ErrUserNotFound).Once()
+		mockUserRepo.On("GetUserCredentials", mock.Anything, "jane@example.com").Return(&external, nil).Once()
+
+		_, err := userService.Authenticate(ctx, "nobody@example.com", "Password1234")
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+		_, err = userService.Authenticate(ctx, "jane@example.com", "")
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+
+		if assert.Len(t, hasher.verified, 2) {
+			assert.NotEmpty(t, hasher.verified[0])
+			assert.Equal(t, hasher.verified[0], hasher.verified[1], "the same dummy hash is verified")
+		}
+	})
+}
+
+// recordingHasher records the hashes it verifies
+type recordingHasher struct {
+	utils.PasswordHasher
+	verified []string
+}
+
+func (h *recordingHasher) Verify(password, hash string) (bool, bool) {
+	h.verified = append(h.verified, hash)
+	return h.PasswordHasher.Verify(password, hash)
+}
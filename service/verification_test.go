@@ -0,0 +1,140 @@
+package service_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/mail"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// emailedToken extracts the token of the link in the last email sent
+func emailedToken(t *testing.T, mailer *mail.MemoryMailer) string {
+	sent := mailer.Sent()
+	if !assert.NotEmpty(t, sent) {
+		return ""
+	}
+	body := sent[len(sent)-1].Body
+	link := strings.Fields(body[strings.Index(body, "http"):])[0]
+	parsed, err := url.Parse(link)
+	assert.NoError(t, err)
+	return parsed.Query().Get("token")
+}
+
+func TestUserService_VerifyEmail(t *testing.T) {
+	verification := service.EmailVerificationConfig{
+		Secret:         []byte("verification-secret"),
+		TTL:            time.Hour,
+		URL:            "https://api.example.com/api/v1/auth/verify",
+		ResendCooldown: time.Minute,
+	}
+	id := uuid.New()
+	pending := &domain.User{ID: id.String(), Name: "Jane", Email: "jane@example.com", Status: domain.UserStatusPending}
+
+	// signup creates the pending user and returns the emailed token
+	signup := func(t *testing.T, config service.EmailVerificationConfig) (*mocks.UserRepository, *service.UserService, string) {
+		mockUserRepo := new(mocks.UserRepository)
+		mailer := mail.NewMemoryMailer()
+		userService := service.NewUserService(mockUserRepo, service.WithMailer(mailer), service.WithEmailVerification(config))
+
+		req := &domain.CreateUserRequest{Name: "Jane", Email: "jane@example.com", Password: "Correct-Horse-42"}
+		mockUserRepo.On("CreateUser", mock.Anything, req, mock.AnythingOfType("string")).Return(pending, nil).Once()
+		_, err := userService.CreateUser(context.Background(), req)
+		assert.NoError(t, err)
+
+		return mockUserRepo, userService, emailedToken(t, mailer)
+	}
+
+	t.Run("Activates the user of an emailed token", func(t *testing.T) {
+		mockUserRepo, userService, token := signup(t, verification)
+
+		activated := *pending
+		activated.Status = domain.UserStatusActive
+		mockUserRepo.On("GetUser", mock.Anything, id).Return(pending, nil).Once()
+		mockUserRepo.On("ActivateUser", mock.Anything, id).Return(&activated, nil).Once()
+
+		user, err := userService.VerifyEmail(context.Background(), token)
+
+		assert.NoError(t, err)
+		assert.True(t, user.Active())
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects a token once the email changed", func(t *testing.T) {
+		mockUserRepo, userService, token := signup(t, verification)
+
+		changed := *pending
+		changed.Email = "other@example.com"
+		mockUserRepo.On("GetUser", mock.Anything, id).Return(&changed, nil).Once()
+
+		user, err := userService.VerifyEmail(context.Background(), token)
+
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+		assert.Nil(t, user)
+		mockUserRepo.AssertNotCalled(t, "ActivateUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects a token signed with another secret", func(t *testing.T) {
+		_, _, token := signup(t, verification)
+
+		other := verification
+		other.Secret = []byte("another-secret")
+		mockUserRepo := new(mocks.UserRepository)
+		userService := service.NewUserService(mockUserRepo, service.WithEmailVerification(other))
+		mockUserRepo.On("GetUser", mock.Anything, id).Return(pending, nil).Once()
+
+		_, err := userService.VerifyEmail(context.Background(), token)
+
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+	})
+
+	t.Run("Rejects expired and malformed tokens", func(t *testing.T) {
+		expired := verification
+		expired.TTL = -time.Minute
+		mockUserRepo, userService, token := signup(t, expired)
+
+		for _, token := range []string{token, "", "garbage", "Z2FyYmFnZQ.Z2FyYmFnZQ"} {
+			_, err := userService.VerifyEmail(context.Background(), token)
+			assert.ErrorIs(t, err, domain.ErrInvalidToken)
+		}
+		mockUserRepo.AssertNotCalled(t, "GetUser", mock.Anything, mock.Anything)
+	})
+}
+
+func TestUserService_ResendVerification(t *testing.T) {
+	t.Run("Emails a new link when the cooldown passed", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mailer := mail.NewMemoryMailer()
+		userService := service.NewUserService(mockUserRepo, service.WithMailer(mailer))
+
+		pending := &domain.User{ID: uuid.NewString(), Name: "Jane", Email: "jane@example.com", Status: domain.UserStatusPending}
+		mockUserRepo.On("ClaimVerificationResend", mock.Anything, "jane@example.com", service.DefaultEmailVerificationConfig.ResendCooldown).
+			Return(pending, nil).Once()
+
+		err := userService.ResendVerification(context.Background(), &domain.ResendVerificationRequest{Email: "jane@example.com"})
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, emailedToken(t, mailer))
+	})
+
+	t.Run("Stays silent for rate limited, active and unknown emails", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mailer := mail.NewMemoryMailer()
+		userService := service.NewUserService(mockUserRepo, service.WithMailer(mailer))
+
+		mockUserRepo.On("ClaimVerificationResend", mock.Anything, "jane@example.com", mock.Anything).Return(nil, domain.ErrNotFound).Once()
+
+		err := userService.ResendVerification(context.Background(), &domain.ResendVerificationRequest{Email: "jane@example.com"})
+
+		assert.NoError(t, err)
+		assert.Empty(t, mailer.Sent())
+	})
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/auth"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/logging"
+	"github.com/edwinjordan/ZOGTest-Golang.git/internal/netguard"
+	"github.com/edwinjordan/ZOGTest-Golang.git/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	WebhookSignatureHeader = "X-Signature"
+	WebhookTimestampHeader = "X-Signature-Timestamp"
+)
+
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook *domain.Webhook) (*domain.Webhook, error)
+	GetWebhookList(ctx context.Context) ([]domain.Webhook, error)
+	GetWebhook(ctx context.Context, id uuid.UUID) (*domain.Webhook, error)
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+	EnqueueWebhookDeliveries(ctx context.Context, eventID, eventType string, payload []byte) (int64, error)
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+	GetWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, filter *domain.WebhookDeliveryFilter) ([]domain.WebhookDelivery, int, error)
+	RedeliverWebhookDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID) (*domain.WebhookDelivery, error)
+}
+
+type WebhookDispatchConfig struct {
+	// BatchSize is the number of deliveries claimed at once
+	BatchSize int
+	// PollInterval is the wait between polls when nothing is due
+	PollInterval time.Duration
+	// Lease is how long a claimed delivery is hidden from other senders
+	Lease time.Duration
+	// MaxAttempts is the number of tries before a delivery is dead lettered
+	MaxAttempts int
+	Backoff     Backoff
+	// Timeout bounds a single HTTP request to a receiver
+	Timeout time.Duration
+	// AllowedNetworks are the networks receivers may be in besides the
+	// public internet, their receivers may also use plain http
+	AllowedNetworks []*net.IPNet
+}
+
+var DefaultWebhookDispatchConfig = WebhookDispatchConfig{
+	BatchSize:    50,
+	PollInterval: time.Second,
+	Lease:        time.Minute,
+	MaxAttempts:  8,
+	Backoff:      Backoff{Base: 10 * time.Second, Max: time.Hour},
+	Timeout:      10 * time.Second,
+}
+
+type WebhookService struct {
+	webhookRepo WebhookRepository
+	config      WebhookDispatchConfig
+	guard       netguard.Guard
+	client      *http.Client
+	serviceOptions
+}
+
+func NewWebhookService(w WebhookRepository, config WebhookDispatchConfig, opts ...Option) *WebhookService {
+	guard := netguard.Guard{AllowedNetworks: config.AllowedNetworks}
+
+	// The receivers are only reached on public or allowed addresses, the
+	// check happens when connecting so that DNS cannot be used to get
+	// around it. Proxies would connect on our behalf and redirects lead
+	// anywhere, neither is followed.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = guard.Dialer(config.Timeout).DialContext
+	client := &http.Client{
+		Timeout:   config.Timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return &WebhookService{
+		webhookRepo:    w,
+		config:         config,
+		guard:          guard,
+		client:         client,
+		serviceOptions: newServiceOptions(opts),
+	}
+}
+
+// SignWebhookPayload computes the X-Signature value of a delivery, an HMAC
+// SHA-256 over the timestamp and the body joined by a dot.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// CreateWebhook subscribes a URL to the given event types. The returned
+// webhook carries its secret, it is never shown again.
+func (ws *WebhookService) CreateWebhook(ctx context.Context, req *domain.CreateWebhookRequest) (*domain.Webhook, error) {
+	if err := ws.checkURL(ctx, req.URL); err != nil {
+		return nil, err
+	}
+	if len(req.EventTypes) == 0 {
+		return nil, fmt.Errorf("%w: at least one event type is required", domain.ErrBadParamInput)
+	}
+	for _, eventType := range req.EventTypes {
+		if eventType != domain.WebhookAllEvents && !slices.Contains(domain.EventTypes, eventType) {
+			return nil, fmt.Errorf("%w: unknown event type %q", domain.ErrBadParamInput, eventType)
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = utils.GenerateToken(32); err != nil {
+			return nil, err
+		}
+	}
+
+	webhook := &domain.Webhook{
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Secret:     secret,
+	}
+	if caller := auth.FromContext(ctx); caller != nil {
+		webhook.CreatedBy = caller.UserID
+	}
+
+	created, err := ws.webhookRepo.CreateWebhook(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	ws.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionCreate,
+		EntityType: domain.AuditEntityWebhook,
+		EntityID:   created.ID,
+		After:      domain.Webhook{ID: created.ID, URL: created.URL, EventTypes: created.EventTypes},
+	})
+	return created, nil
+}
+
+// checkURL only accepts https URLs of hosts resolving to public addresses,
+// so webhooks cannot be used to reach the internal network. Hosts in the
+// allowed networks may use plain http.
+func (ws *WebhookService) checkURL(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return fmt.Errorf("%w: url must be an absolute https URL", domain.ErrBadParamInput)
+	}
+
+	ips, err := ws.guard.LookupHost(ctx, parsed.Hostname())
+	if errors.Is(err, netguard.ErrForbiddenAddress) {
+		return fmt.Errorf("%w: url must point to a public address", domain.ErrBadParamInput)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: url host cannot be resolved", domain.ErrBadParamInput)
+	}
+	if parsed.Scheme == "http" && !slices.ContainsFunc(ips, ws.guard.Allowed) {
+		return fmt.Errorf("%w: url must be an absolute https URL", domain.ErrBadParamInput)
+	}
+	return nil
+}
+
+func (ws *WebhookService) GetWebhookList(ctx context.Context) ([]domain.Webhook, error) {
+	return ws.webhookRepo.GetWebhookList(ctx)
+}
+
+func (ws *WebhookService) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	if err := ws.webhookRepo.DeleteWebhook(ctx, id); err != nil {
+		return err
+	}
+
+	ws.auditor.Record(ctx, AuditEntry{
+		Action:     domain.AuditActionDelete,
+		EntityType: domain.AuditEntityWebhook,
+		EntityID:   id.String(),
+	})
+	return nil
+}
+
+// GetWebhookDeliveries lists the delivery history of a webhook.
+func (ws *WebhookService) GetWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, filter *domain.WebhookDeliveryFilter) ([]domain.WebhookDelivery, int, error) {
+	filter.Normalize()
+	if _, err := ws.webhookRepo.GetWebhook(ctx, webhookID); err != nil {
+		return nil, 0, err
+	}
+	return ws.webhookRepo.GetWebhookDeliveries(ctx, webhookID, filter)
+}
+
+// Redeliver queues a delivery again, dead lettered ones included.
+func (ws *WebhookService) Redeliver(ctx context.Context, webhookID, deliveryID uuid.UUID) (*domain.WebhookDelivery, error) {
+	delivery, err := ws.webhookRepo.RedeliverWebhookDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	logging.LogBusinessEvent(ctx, "webhook_redelivery", domain.AuditEntityWebhook, webhookID.String(),
+		slog.String("delivery_id", deliveryID.String()),
+	)
+	return delivery, nil
+}
+
+// EnqueueDeliveries queues the event for every webhook subscribed to it. It is
+// meant to be subscribed to the event bus fed by the outbox relay.
+func (ws *WebhookService) EnqueueDeliveries(ctx context.Context, event domain.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = ws.webhookRepo.EnqueueWebhookDeliveries(ctx, event.ID, event.Type, payload)
+	return err
+}
+
+// RunDispatcher sends the due deliveries until the context is cancelled.
+func (ws *WebhookService) RunDispatcher(ctx context.Context) {
+	for {
+		sent, err := ws.DispatchPending(ctx)
+		if err != nil && ctx.Err() == nil {
+			logging.LogError(ctx, err, "webhook_dispatcher")
+		}
+		if err == nil && sent == ws.config.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(ws.config.PollInterval):
+		}
+	}
+}
+
+// DispatchPending claims one batch of due deliveries and sends them,
+// returning the number of deliveries claimed.
+func (ws *WebhookService) DispatchPending(ctx context.Context) (int, error) {
+	deliveries, err := ws.webhookRepo.ClaimWebhookDeliveries(ctx, ws.config.BatchSize, ws.config.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		ws.send(ctx, delivery)
+		if err := ws.webhookRepo.UpdateWebhookDelivery(ctx, delivery); err != nil {
+			return len(deliveries), err
+		}
+	}
+	return len(deliveries), nil
+}
+
+// send posts the signed delivery and records the outcome on it. Failures are
+// retried with backoff until MaxAttempts, then the delivery is dead lettered.
+func (ws *WebhookService) send(ctx context.Context, delivery *domain.WebhookDelivery) {
+	statusCode, err := ws.post(ctx, delivery)
+	now := time.Now()
+	delivery.LastStatusCode = statusCode
+
+	if err == nil {
+		delivery.Status = domain.WebhookDeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= ws.config.MaxAttempts {
+		delivery.Status = domain.WebhookDeliveryDead
+		logging.LogErrorMessage(ctx, "Webhook delivery dead lettered",
+			slog.String("delivery_id", delivery.ID),
+			slog.String("webhook_id", delivery.WebhookID),
+			slog.Int("attempts", delivery.Attempts),
+			slog.String("error", delivery.LastError),
+		)
+		return
+	}
+
+	delivery.Status = domain.WebhookDeliveryPending
+	delivery.NextAttemptAt = now.Add(ws.config.Backoff.Delay(delivery.Attempts))
+}
+
+func (ws *WebhookService) post(ctx context.Context, delivery *domain.WebhookDelivery) (int, error) {
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-ID", delivery.WebhookID)
+	req.Header.Set("X-Delivery-ID", delivery.ID)
+	req.Header.Set("X-Event-ID", delivery.EventID)
+	req.Header.Set("X-Event-Type", delivery.EventType)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := ws.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
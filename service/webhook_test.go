@@ -0,0 +1,242 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/domain"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service"
+	"github.com/edwinjordan/ZOGTest-Golang.git/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func webhookDelivery(url string, attempts int) domain.WebhookDelivery {
+	return domain.WebhookDelivery{
+		ID:        "5a0c1a8e-2d7f-4c5b-8e3a-9f6b1d2c3e4f",
+		WebhookID: "8e2b7c4d-1a3f-4e6b-9c8d-7f5e4d3c2b1a",
+		EventID:   "0b7d3f7e-1c4a-4f0e-9a55-3c2f9d8e7a61",
+		EventType: domain.EventNewsPublished,
+		Payload:   json.RawMessage(`{"id":"0b7d3f7e-1c4a-4f0e-9a55-3c2f9d8e7a61","type":"news.published"}`),
+		Status:    domain.WebhookDeliveryPending,
+		Attempts:  attempts,
+		URL:       url,
+		Secret:    "s3cret",
+	}
+}
+
+func TestWebhookService_DispatchPending(t *testing.T) {
+	config := service.DefaultWebhookDispatchConfig
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+	config.AllowedNetworks = []*net.IPNet{loopback}
+
+	t.Run("Sends a signed delivery and marks it succeeded", func(t *testing.T) {
+		var signatureValid bool
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			timestamp, _ := strconv.ParseInt(r.Header.Get(service.WebhookTimestampHeader), 10, 64)
+			signatureValid = r.Header.Get(service.WebhookSignatureHeader) == service.SignWebhookPayload("s3cret", timestamp, body) &&
+				r.Header.Get("X-Event-Type") == domain.EventNewsPublished
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer receiver.Close()
+
+		mockWebhookRepo := new(mocks.WebhookRepository)
+		webhookService := service.NewWebhookService(mockWebhookRepo, config)
+
+		delivery := webhookDelivery(receiver.URL, 1)
+		mockWebhookRepo.On("ClaimWebhookDeliveries", mock.Anything, config.BatchSize, config.Lease).
+			Return([]domain.WebhookDelivery{delivery}, nil).Once()
+		mockWebhookRepo.On("UpdateWebhookDelivery", mock.Anything, mock.MatchedBy(func(d *domain.WebhookDelivery) bool {
+			return d.Status == domain.WebhookDeliverySucceeded && d.LastStatusCode == http.StatusNoContent && d.DeliveredAt != nil
+		})).Return(nil).Once()
+
+		sent, err := webhookService.DispatchPending(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, sent)
+		assert.True(t, signatureValid)
+		mockWebhookRepo.AssertExpectations(t)
+	})
+
+	t.Run("Schedules a retry with backoff when the receiver fails", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer receiver.Close()
+
+		mockWebhookRepo := new(mocks.WebhookRepository)
+		webhookService := service.NewWebhookService(mockWebhookRepo, config)
+
+		delivery := webhookDelivery(receiver.URL, 2)
+		mockWebhookRepo.On("ClaimWebhookDeliveries", mock.Anything, config.BatchSize, config.Lease).
+			Return([]domain.WebhookDelivery{delivery}, nil).Once()
+		mockWebhookRepo.On("UpdateWebhookDelivery", mock.Anything, mock.MatchedBy(func(d *domain.WebhookDelivery) bool {
+			wait := time.Until(d.NextAttemptAt)
+			return d.Status == domain.WebhookDeliveryPending &&
+				d.LastStatusCode == http.StatusInternalServerError &&
+				d.LastError != "" &&
+				wait > config.Backoff.Delay(2)-time.Second && wait <= config.Backoff.Delay(2)
+		})).Return(nil).Once()
+
+		_, err := webhookService.DispatchPending(context.Background())
+
+		assert.NoError(t, err)
+		mockWebhookRepo.AssertExpectations(t)
+	})
+
+	t.Run("Dead letters the delivery after the last attempt", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer receiver.Close()
+
+		mockWebhookRepo := new(mocks.WebhookRepository)
+		webhookService := service.NewWebhookService(mockWebhookRepo, config)
+
+		delivery := webhookDelivery(receiver.URL, config.MaxAttempts)
+		mockWebhookRepo.On("ClaimWebhookDeliveries", mock.Anything, config.BatchSize, config.Lease).
+			Return([]domain.WebhookDelivery{delivery}, nil).Once()
+		mockWebhookRepo.On("UpdateWebhookDelivery", mock.Anything, mock.MatchedBy(func(d *domain.WebhookDelivery) bool {
+			return d.Status == domain.WebhookDeliveryDead && d.LastStatusCode == http.StatusBadGateway
+		})).Return(nil).Once()
+
+		_, err := webhookService.DispatchPending(context.Background())
+
+		assert.NoError(t, err)
+		mockWebhookRepo.AssertExpectations(t)
+	})
+
+	t.Run("Does not connect to internal addresses outside the allowed networks", func(t *testing.T) {
+		var called bool
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}))
+		defer receiver.Close()
+
+		mockWebhookRepo := new(mocks.WebhookRepository)
+		webhookService := service.NewWebhookService(mockWebhookRepo, service.DefaultWebhookDispatchConfig)
+
+		delivery := webhookDelivery(receiver.URL, 1)
+		mockWebhookRepo.On("ClaimWebhookDeliveries", mock.Anything, config.BatchSize, config.Lease).
+			Return([]domain.WebhookDelivery{delivery}, nil).Once()
+		mockWebhookRepo.On("UpdateWebhookDelivery", mock.Anything, mock.MatchedBy(func(d *domain.WebhookDelivery) bool {
+			return d.Status == domain.WebhookDeliveryPending && d.LastStatusCode == 0 && d.LastError != ""
+		})).Return(nil).Once()
+
+		_, err := webhookService.DispatchPending(context.Background())
+
+		assert.NoError(t, err)
+		assert.False(t, called)
+		mockWebhookRepo.AssertExpectations(t)
+	})
+}
+
+func TestWebhookService_CreateWebhook(t *testing.T) {
+	t.Run("Generates a secret when none is given", func(t *testing.T) {
+		mockWebhookRepo := new(mocks.WebhookRepository)
+		webhookService := service.NewWebhookService(mockWebhookRepo, service.DefaultWebhookDispatchConfig)
+
+		mockWebhookRepo.On("CreateWebhook", mock.Anything, mock.MatchedBy(func(w *domain.Webhook) bool {
+			return len(w.Secret) == 64
+		})).Return(func(_ context.Context, w *domain.Webhook) (*domain.Webhook, error) {
+			created := *w
+			created.ID = "8e2b7c4d-1a3f-4e6b-9c8d-7f5e4d3c2b1a"
+			return &created, nil
+		}).Once()
+
+		webhook, err := webhookService.CreateWebhook(context.Background(), &domain.CreateWebhookRequest{
+			URL:        "https://203.0.113.10/hooks",
+			EventTypes: []string{domain.EventNewsPublished},
+		})
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, webhook.Secret)
+		mockWebhookRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects invalid subscriptions", func(t *testing.T) {
+		mockWebhookRepo := new(mocks.WebhookRepository)
+		webhookService := service.NewWebhookService(mockWebhookRepo, service.DefaultWebhookDispatchConfig)
+
+		for _, req := range []domain.CreateWebhookRequest{
+			{URL: "ftp://example.com", EventTypes: []string{domain.EventNewsCreated}},
+			{URL: "/relative", EventTypes: []string{domain.EventNewsCreated}},
+			{URL: "https://203.0.113.10", EventTypes: nil},
+			{URL: "https://203.0.113.10", EventTypes: []string{"news.exploded"}},
+		} {
+			_, err := webhookService.CreateWebhook(context.Background(), &req)
+			assert.ErrorIs(t, err, domain.ErrBadParamInput, req.URL)
+		}
+		mockWebhookRepo.AssertNotCalled(t, "CreateWebhook", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects plain http and internal addresses", func(t *testing.T) {
+		mockWebhookRepo := new(mocks.WebhookRepository)
+		webhookService := service.NewWebhookService(mockWebhookRepo, service.DefaultWebhookDispatchConfig)
+
+		for _, url := range []string{
+			"http://203.0.113.10/hooks",
+			"https://127.0.0.1/hooks",
+			"https://localhost/hooks",
+			"https://10.0.0.1/hooks",
+			"https://169.254.169.254/latest/meta-data",
+			"https://[::1]/hooks",
+		} {
+			_, err := webhookService.CreateWebhook(context.Background(), &domain.CreateWebhookRequest{
+				URL:        url,
+				EventTypes: []string{domain.EventNewsCreated},
+			})
+			assert.ErrorIs(t, err, domain.ErrBadParamInput, url)
+		}
+		mockWebhookRepo.AssertNotCalled(t, "CreateWebhook", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Accepts internal addresses of the allowed networks", func(t *testing.T) {
+		config := service.DefaultWebhookDispatchConfig
+		_, internal, _ := net.ParseCIDR("10.1.0.0/16")
+		config.AllowedNetworks = []*net.IPNet{internal}
+		mockWebhookRepo := new(mocks.WebhookRepository)
+		webhookService := service.NewWebhookService(mockWebhookRepo, config)
+
+		mockWebhookRepo.On("CreateWebhook", mock.Anything, mock.Anything).Return(&domain.Webhook{}, nil).Once()
+
+		_, err := webhookService.CreateWebhook(context.Background(), &domain.CreateWebhookRequest{
+			URL:        "http://10.1.2.3:8080/hooks",
+			EventTypes: []string{domain.EventNewsCreated},
+		})
+		assert.NoError(t, err)
+
+		_, err = webhookService.CreateWebhook(context.Background(), &domain.CreateWebhookRequest{
+			URL:        "https://10.2.0.1/hooks",
+			EventTypes: []string{domain.EventNewsCreated},
+		})
+		assert.ErrorIs(t, err, domain.ErrBadParamInput)
+		mockWebhookRepo.AssertExpectations(t)
+	})
+}
+
+func TestWebhookService_EnqueueDeliveries(t *testing.T) {
+	mockWebhookRepo := new(mocks.WebhookRepository)
+	webhookService := service.NewWebhookService(mockWebhookRepo, service.DefaultWebhookDispatchConfig)
+
+	event, err := domain.NewEvent(domain.EventTopicMerged, domain.AuditEntityTopic, "topic-1", domain.TopicMergedPayload{SourceID: "a", TargetID: "b"})
+	assert.NoError(t, err)
+
+	mockWebhookRepo.On("EnqueueWebhookDeliveries", mock.Anything, event.ID, domain.EventTopicMerged,
+		mock.MatchedBy(func(payload []byte) bool {
+			var decoded domain.Event
+			return json.Unmarshal(payload, &decoded) == nil && decoded.ID == event.ID
+		}),
+	).Return(int64(2), nil).Once()
+
+	assert.NoError(t, webhookService.EnqueueDeliveries(context.Background(), event))
+	mockWebhookRepo.AssertExpectations(t)
+}
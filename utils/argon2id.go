@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+type Argon2idParams struct {
+	// Memory is in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams are close to the second recommended option of
+// RFC 9106, 64 MiB of memory and 3 passes
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Argon2idHasher hashes passwords with Argon2id, encoding the parameters in
+// the PHC string format so they can change without breaking stored hashes:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+type Argon2idHasher struct {
+	Params Argon2idParams
+}
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	p := h.Params
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h Argon2idHasher) Verify(password, hash string) (bool, bool) {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		// Hashes of another algorithm are always outdated
+		match := ComparePassword(password, hash)
+		return match, match
+	}
+	if !argon2idMatches(password, params, salt, key) {
+		return false, false
+	}
+	return true, params != h.Params
+}
+
+func compareArgon2id(password, hash string) bool {
+	params, salt, key, err := decodeArgon2id(hash)
+	return err == nil && argon2idMatches(password, params, salt, key)
+}
+
+func argon2idMatches(password string, p Argon2idParams, salt, key []byte) bool {
+	candidate := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return subtle.ConstantTimeCompare(candidate, key) == 1
+}
+
+var errInvalidArgon2idHash = errors.New("invalid argon2id hash")
+
+func decodeArgon2id(hash string) (Argon2idParams, []byte, []byte, error) {
+	var p Argon2idParams
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, errInvalidArgon2idHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, errInvalidArgon2idHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, errInvalidArgon2idHash
+	}
+	if p.Memory == 0 || p.Iterations == 0 || p.Parallelism == 0 {
+		return p, nil, nil, errInvalidArgon2idHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, errInvalidArgon2idHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, errInvalidArgon2idHash
+	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+	return p, salt, key, nil
+}
@@ -1,27 +1,43 @@
 package utils
 
 import (
-	"fmt"
-
 	"golang.org/x/crypto/bcrypt"
 )
 
-func HashPassword(password string) (string, error) {
-	hashedBytes, err := bcrypt.GenerateFromPassword(
-		[]byte(password), bcrypt.DefaultCost)
+// BcryptHasher hashes passwords with bcrypt, a zero Cost means
+// bcrypt.DefaultCost
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) cost() int {
+	if h.Cost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return h.Cost
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), h.cost())
 	if err != nil {
-		fmt.Printf("error hashing: %v\n", err)
 		return "", err
 	}
-	hash := string(hashedBytes)
-	return hash, nil
+	return string(hashedBytes), nil
 }
 
-func ComparePassword(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+func (h BcryptHasher) Verify(password, hash string) (bool, bool) {
+	cost, err := bcrypt.Cost([]byte(hash))
 	if err != nil {
-		fmt.Printf("Password is invalid: %v\n", password)
-		return false
+		// Hashes of another algorithm are always outdated
+		match := ComparePassword(password, hash)
+		return match, match
+	}
+	if !compareBcrypt(password, hash) {
+		return false, false
 	}
-	return true
+	return true, cost != h.cost()
+}
+
+func compareBcrypt(password, hash string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
 }
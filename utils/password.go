@@ -0,0 +1,30 @@
+package utils
+
+import "strings"
+
+// PasswordHasher hashes passwords for storage. Verify accepts the hashes of
+// every supported algorithm and reports whether a matching hash is outdated,
+// made with another algorithm or other parameters, so callers can store a
+// fresh one while they have the password at hand.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password, hash string) (match bool, rehash bool)
+}
+
+// DefaultPasswordHasher is used by HashPassword and by the services unless
+// configured otherwise
+var DefaultPasswordHasher PasswordHasher = Argon2idHasher{Params: DefaultArgon2idParams}
+
+// HashPassword hashes a password with DefaultPasswordHasher
+func HashPassword(password string) (string, error) {
+	return DefaultPasswordHasher.Hash(password)
+}
+
+// ComparePassword reports whether the password matches a hash of any
+// supported algorithm
+func ComparePassword(password, hash string) bool {
+	if strings.HasPrefix(hash, argon2idPrefix) {
+		return compareArgon2id(password, hash)
+	}
+	return compareBcrypt(password, hash)
+}
@@ -0,0 +1,83 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/edwinjordan/ZOGTest-Golang.git/utils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fastArgon2id keeps the tests quick, production uses DefaultArgon2idParams
+var fastArgon2id = utils.Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2idHasher(t *testing.T) {
+	hasher := utils.Argon2idHasher{Params: fastArgon2id}
+	hash, err := hasher.Hash("Correct-Horse-42")
+	assert.NoError(t, err)
+
+	t.Run("Encodes the parameters", func(t *testing.T) {
+		assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"), hash)
+	})
+
+	t.Run("Verifies the password", func(t *testing.T) {
+		match, rehash := hasher.Verify("Correct-Horse-42", hash)
+		assert.True(t, match)
+		assert.False(t, rehash)
+
+		match, _ = hasher.Verify("wrong", hash)
+		assert.False(t, match)
+		assert.True(t, utils.ComparePassword("Correct-Horse-42", hash))
+	})
+
+	t.Run("Salts every hash", func(t *testing.T) {
+		other, err := hasher.Hash("Correct-Horse-42")
+		assert.NoError(t, err)
+		assert.NotEqual(t, hash, other)
+	})
+
+	t.Run("Asks to rehash when the parameters changed", func(t *testing.T) {
+		stronger := fastArgon2id
+		stronger.Iterations = 2
+
+		match, rehash := utils.Argon2idHasher{Params: stronger}.Verify("Correct-Horse-42", hash)
+		assert.True(t, match)
+		assert.True(t, rehash)
+	})
+
+	t.Run("Rejects malformed hashes", func(t *testing.T) {
+		match, _ := hasher.Verify("Correct-Horse-42", "$argon2id$v=19$m=0,t=1,p=1$c2FsdA$a2V5")
+		assert.False(t, match)
+	})
+}
+
+func TestBcryptHasher(t *testing.T) {
+	hasher := utils.BcryptHasher{Cost: bcrypt.MinCost}
+	hash, err := hasher.Hash("Correct-Horse-42")
+	assert.NoError(t, err)
+
+	t.Run("Verifies the password", func(t *testing.T) {
+		match, rehash := hasher.Verify("Correct-Horse-42", hash)
+		assert.True(t, match)
+		assert.False(t, rehash)
+	})
+
+	t.Run("Asks to rehash when the cost changed", func(t *testing.T) {
+		match, rehash := utils.BcryptHasher{Cost: bcrypt.MinCost + 1}.Verify("Correct-Horse-42", hash)
+		assert.True(t, match)
+		assert.True(t, rehash)
+	})
+
+	t.Run("Upgrades to argon2id", func(t *testing.T) {
+		argon := utils.Argon2idHasher{Params: fastArgon2id}
+
+		match, rehash := argon.Verify("Correct-Horse-42", hash)
+		assert.True(t, match)
+		assert.True(t, rehash)
+
+		match, rehash = argon.Verify("wrong", hash)
+		assert.False(t, match)
+		assert.False(t, rehash)
+	})
+}